@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"go.mau.fi/whatsmeow/proto/waCompanionReg"
 )
 
@@ -11,26 +13,107 @@ var (
 	AppOs                    = "AldinoKemal"
 	AppPlatform              = waCompanionReg.DeviceProps_PlatformType(1)
 	AppBasicAuthCredential   []string
-	AppChatFlushIntervalDays = 7 // Number of days before flushing chat.csv
+	AppChatFlushIntervalDays = 7                // Number of days before flushing chat.csv
+	AppBaseURL               string             // Public url of this app, used to build fetchable links such as webhook media
+	AppLogLevel              = "info"           // Level of the application logs: debug, info, warn or error
+	AppLogFormat             = "text"           // Format of the application logs: text or json
+	AppLogRedact             = true             // Mask phone numbers and url secrets in the logs
+	AppShutdownTimeout       = 30 * time.Second // Longest a graceful shutdown may take before the app exits anyway
+
+	PathQrCode         = "statics/qrcode"
+	PathSendItems      = "statics/senditems"
+	PathMedia          = "statics/media"
+	PathStorages       = "storages"
+	PathChatStorage    = "storages/chat.csv"
+	PathWebhookStore   = "storages/webhooks.json"
+	PathSessionStore   = "storages/sessions.json"
+	PathAutoReplyStore = "storages/auto_replies.json"
 
-	PathQrCode      = "statics/qrcode"
-	PathSendItems   = "statics/senditems"
-	PathMedia       = "statics/media"
-	PathStorages    = "storages"
-	PathChatStorage = "storages/chat.csv"
+	MediaJanitorEnabled    = true
+	MediaRetentionDuration = 7 * 24 * time.Hour // Downloaded media older than this is deleted by the media janitor
+	MediaJanitorInterval   = 1 * time.Hour
 
-	DBURI = "file:storages/whatsapp.db?_foreign_keys=on"
+	DBURI             = "file:storages/whatsapp.db?_foreign_keys=on"
+	DBMaxOpenConns    = 10 // Connections of the postgres pool, sqlite keeps the driver defaults
+	DBMaxIdleConns    = 5
+	DBConnMaxLifetime = 30 * time.Minute // Connections are recycled so a failover of the postgres server is picked up
 
+	WhatsappSessions               []string // Ids of the sessions started next to the default session, one number each
 	WhatsappAutoReplyMessage       string
+	WhatsappAutoReplyRules         string // JSON file of keyword auto reply rules loaded at startup
 	WhatsappWebhook                []string
-	WhatsappWebhookSecret                = "secret"
-	WhatsappLogLevel                     = "ERROR"
-	WhatsappSettingMaxImageSize    int64 = 20000000  // 20MB
-	WhatsappSettingMaxFileSize     int64 = 50000000  // 50MB
-	WhatsappSettingMaxVideoSize    int64 = 100000000 // 100MB
-	WhatsappSettingMaxDownloadSize int64 = 500000000 // 500MB
-	WhatsappTypeUser                     = "@s.whatsapp.net"
-	WhatsappTypeGroup                    = "@g.us"
-	WhatsappAccountValidation            = true
-	WhatsappChatStorage                  = true
+	WhatsappWebhookSecret                 = "secret"
+	WhatsappLogLevel                      = "ERROR"
+	WhatsappSettingMaxImageSize    int64  = 20000000  // 20MB
+	WhatsappSettingMaxFileSize     int64  = 50000000  // 50MB
+	WhatsappSettingMaxVideoSize    int64  = 100000000 // 100MB
+	WhatsappSettingMaxDownloadSize int64  = 500000000 // 500MB, received audios and stickers bigger than it aren't downloaded
+	WhatsappMaxImageDownloadSize   int64  = 500000000 // 500MB, received images bigger than it aren't downloaded
+	WhatsappMaxVideoDownloadSize   int64  = 500000000 // 500MB, received videos bigger than it aren't downloaded
+	WhatsappMaxFileDownloadSize    int64  = 500000000 // 500MB, received documents bigger than it aren't downloaded
+	WhatsappTypeUser                      = "@s.whatsapp.net"
+	WhatsappTypeGroup                     = "@g.us"
+	WhatsappAccountValidation             = true
+	WhatsappDefaultCountryCode     string // Calling code replacing the leading 0 of a national phone number
+	WhatsappProxyURL               string // http, https or socks5 proxy of the whatsapp websocket and media transfers
+	WhatsappChatStorage            = true
+	WhatsappLinkPreviewTimeout     = 5 * time.Second  // Timeout to fetch the OpenGraph tags of a link preview
+	WhatsappLinkPreviewCacheTTL    = 10 * time.Minute // How long a fetched link preview is reused
+	WhatsappProfilePictureCacheTTL = 1 * time.Hour    // How long a fetched profile picture of a contact is reused
+	WhatsappContactCheckMaxPhones  = 100              // Phones of a single /contacts/check request
+	WhatsappContactCheckCacheTTL   = 10 * time.Minute // How long the whatsapp registration of a phone is reused
+	WhatsappBatchConcurrency       = 3                // Recipients of a batch sent at the same time
+	WhatsappBatchDelay             = 1 * time.Second  // Pause of each batch worker between two recipients
+	WhatsappBatchRateLimit         = 30               // Messages per minute over all batches, 0 means unlimited
+	WhatsappBatchMaxRecipients     = 1000
+	WhatsappSendRateLimit          = 0.0             // Outbound messages per second of every session, 0 means unlimited
+	WhatsappSendRateBurst          = 5               // Messages a session can send at once before the rate applies
+	WhatsappSendRateLimitMode      = "block"         // block waits for the limiter, reject answers 429
+	WhatsappLiveLocationInterval   = 1 * time.Minute // Interval of the updates of a shared live location
+	WhatsappScheduleMaxDelay       = 1 * time.Hour   // How late a scheduled message may still be sent, 0 sends it however late
+
+	WhatsappWebhookHeaders            map[string]string                  // Static headers added to every webhook request
+	WhatsappWebhookSecrets            []string                           // Per url secrets, each entry is url=secret, other urls use WhatsappWebhookSecret
+	WhatsappWebhookSignatureMode      = "body"                           // body signs the raw body, timestamp signs timestamp + "." + body
+	WhatsappWebhookSignatureAlgo      = "sha256"                         // sha1, sha256 or sha512, the hmac of the webhook signature
+	WhatsappWebhookCompress           = false                            // Gzip the webhook body, the signature is computed over the uncompressed json
+	WhatsappWebhookClientCert         string                             // PEM client certificate presented to webhook endpoints requiring mutual tls
+	WhatsappWebhookClientKey          string                             // PEM private key of WhatsappWebhookClientCert
+	WhatsappWebhookCACert             string                             // PEM ca bundle trusted for webhook endpoints on top of the system roots
+	WhatsappWebhookProxyURL           string                             // http, https or socks5 proxy of the webhook requests
+	WhatsappWebhookRoutes             []string                           // Route event types to dedicated urls, each entry is event_type=url
+	WhatsappWebhookEvents             []string                           // Allowlist of event types forwarded to the webhook, empty means all events
+	WhatsappWebhookRawEvents                            = false          // Forward the events without a payload of their own as raw json
+	WhatsappWebhookSchemaVersion                        = -1             // Schema version of the webhook payloads, -1 is the latest one
+	WhatsappWebhookMediaMode                            = "path"         // path, base64 or url
+	WhatsappWebhookMediaMaxBase64Size int64             = 5000000        // 5MB, bigger media falls back to path
+	WhatsappWebhookMediaURLExpiry                       = 24 * time.Hour // Lifetime of signed media urls in url media mode
+	WhatsappWebhookMediaURLSecret                       = ""             // Key of the signed media urls, required in url media mode
+	WhatsappWebhookMediaConcurrency                     = 4              // Media of a single message downloaded at once
+	WhatsappWebhookPartialMedia                         = false          // Forward the event with the error of a failed media download instead of dropping it
+	WhatsappAutoDownloadAudio                           = true           // Download received audios for the webhook, otherwise they are fetched on demand
+	WhatsappAutoDownloadDocument                        = true
+	WhatsappAutoDownloadImage                           = true
+	WhatsappAutoDownloadSticker                         = true
+	WhatsappAutoDownloadVideo                           = true
+	WhatsappMediaCacheTTL                               = 24 * time.Hour // How long received media can be downloaded on demand
+	WhatsappMediaCacheSize                              = 10000          // Max received messages kept for on demand media download
+	WhatsappMessageCacheTTL                             = 24 * time.Hour // How long a message can be forwarded or quoted
+	WhatsappMessageCacheSize                            = 10000          // Max messages kept for forward and reply_to, 0 disables both
+	WhatsappDedupCacheSize                              = 10000          // Recently seen message ids kept to skip redelivered messages, 0 disables it
+	WhatsappDedupTTL                                    = 1 * time.Hour  // How long a seen message id is remembered
+	WhatsappWebhookQueueSize                            = 1000
+	WhatsappWebhookWorkers                              = 4
+	WhatsappWebhookTimeout                              = 10 * time.Second
+	WhatsappWebhookMaxRetries                           = 4 // Number of retries after the first attempt, 0 means try once
+	WhatsappWebhookRetryBaseDelay                       = 1 * time.Second
+	WhatsappWebhookMaxDelay                             = 30 * time.Second
+	WhatsappWebhookRetryJitter                          = "full"                 // none, full or equal, randomizes the retry delays so failed webhooks don't retry at once
+	WhatsappWebhookRetryOnStatus                        = []string{"5xx", "429"} // Status codes (or classes like 5xx) that will be retried
+	WhatsappWebhookCircuitThreshold                     = 5                      // Consecutive failed deliveries that open the circuit of a url, 0 disables it
+	WhatsappWebhookCircuitCooldown                      = time.Minute            // Time an open circuit skips its url before a probe delivery
+	WhatsappWebhookDeadLetterPath     string                                     // Permanently failed webhooks are appended here as JSON lines, empty means disabled
+	WhatsappWebhookDeadLetterMaxSize  int64             = 10000000               // 10MB, the file is rotated to <path>.1 when exceeded
+	WhatsappSSEBufferSize                               = 100                    // Recent events kept to replay to sse clients reconnecting with Last-Event-ID
+	WhatsappSSEHeartbeat                                = 15 * time.Second       // Interval of the comments keeping idle sse streams open
 )
@@ -2,16 +2,28 @@ package app
 
 import (
 	"context"
+	"mime/multipart"
 	"time"
 )
 
+// SessionBackupMinPassphrase is the shortest passphrase accepted to encrypt a session backup
+const SessionBackupMinPassphrase = 8
+
 type IAppService interface {
 	Login(ctx context.Context) (response LoginResponse, err error)
-	LoginWithCode(ctx context.Context, phoneNumber string) (loginCode string, err error)
+	LoginWithCode(ctx context.Context, phoneNumber string) (response LoginWithCodeResponse, err error)
+	Status(ctx context.Context) (response StatusResponse, err error)
+	QR(ctx context.Context, request QRRequest) (response QRResponse, err error)
+	// QRStream returns the login events of the session until ctx is done, starting with the current QR code, or
+	// with a success event when the session is already logged in
+	QRStream(ctx context.Context) (events <-chan QREvent, err error)
 	Logout(ctx context.Context) (err error)
 	Reconnect(ctx context.Context) (err error)
 	FirstDevice(ctx context.Context) (response DevicesResponse, err error)
 	FetchDevices(ctx context.Context) (response []DevicesResponse, err error)
+	DeviceInfo(ctx context.Context) (response DeviceInfoResponse, err error)
+	ExportSession(ctx context.Context, request ExportSessionRequest) (response ExportSessionResponse, err error)
+	ImportSession(ctx context.Context, request ImportSessionRequest) (response ImportSessionResponse, err error)
 }
 
 type DevicesResponse struct {
@@ -19,8 +31,80 @@ type DevicesResponse struct {
 	Device string `json:"device"`
 }
 
+type DeviceInfoResponse struct {
+	JID          string `json:"jid"`
+	LID          string `json:"lid,omitempty"`
+	Platform     string `json:"platform"`
+	PushName     string `json:"push_name"`
+	IsBusiness   bool   `json:"is_business"`
+	BusinessName string `json:"business_name,omitempty"`
+	IsConnected  bool   `json:"is_connected"`
+	IsLoggedIn   bool   `json:"is_logged_in"`
+	// ConnectedAt and UptimeSeconds are only set while the session is connected
+	ConnectedAt      *time.Time `json:"connected_at,omitempty"`
+	UptimeSeconds    int64      `json:"uptime_seconds,omitempty"`
+	WhatsmeowVersion string     `json:"whatsmeow_version"`
+}
+
 type LoginResponse struct {
 	ImagePath string        `json:"image_path"`
 	Duration  time.Duration `json:"duration"`
 	Code      string        `json:"code"`
 }
+
+type LoginWithCodeResponse struct {
+	PairCode  string    `json:"pair_code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type StatusResponse struct {
+	IsConnected bool `json:"is_connected"`
+	IsLoggedIn  bool `json:"is_logged_in"`
+	// LoginState is logged_in, logged_out, waiting_qr or waiting_pair_code
+	LoginState string     `json:"login_state"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+const (
+	QRFormatPNG  = "png"
+	QRFormatJSON = "json"
+)
+
+type QRRequest struct {
+	// Format is png for the QR code image, or json for the raw QR code string
+	Format string `json:"format" query:"format"`
+}
+
+type QRResponse struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Image     []byte    `json:"-"`
+}
+
+// QREvent is a new QR code to scan, the pairing success, or the QR codes running out. Event is one of the
+// whatsapp.LoginEvent names
+type QREvent struct {
+	Event     string     `json:"-"`
+	Code      string     `json:"code,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ExportSessionRequest struct {
+	Passphrase string `json:"passphrase" form:"passphrase"`
+}
+
+type ExportSessionResponse struct {
+	FileName string
+	Data     []byte
+}
+
+type ImportSessionRequest struct {
+	Backup     *multipart.FileHeader `json:"backup" form:"backup"`
+	Passphrase string                `json:"passphrase" form:"passphrase"`
+	// Force replaces a logged in session, it stays linked on the phone until removed there
+	Force bool `json:"force" form:"force"`
+}
+
+type ImportSessionResponse struct {
+	Device string `json:"device"`
+}
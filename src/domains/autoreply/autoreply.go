@@ -0,0 +1,42 @@
+package autoreply
+
+import "context"
+
+type IAutoReplyService interface {
+	List(ctx context.Context) (response AutoReplyRulesResponse, err error)
+	Add(ctx context.Context, request AddAutoReplyRequest) (response AutoReplyRuleResponse, err error)
+	Delete(ctx context.Context, request DeleteAutoReplyRequest) (err error)
+	SetEnabled(ctx context.Context, request SetAutoReplyEnabledRequest) (err error)
+}
+
+type AutoReplyRuleResponse struct {
+	ID            string `json:"id"`
+	Match         string `json:"match"`
+	Pattern       string `json:"pattern"`
+	CaseSensitive bool   `json:"case_sensitive"`
+	Chat          string `json:"chat"`
+	Reply         string `json:"reply"`
+	Source        string `json:"source"`
+}
+
+type AutoReplyRulesResponse struct {
+	Enabled bool                    `json:"enabled"`
+	Rules   []AutoReplyRuleResponse `json:"rules"`
+}
+
+type AddAutoReplyRequest struct {
+	ID            string `json:"id" form:"id"`
+	Match         string `json:"match" form:"match"`
+	Pattern       string `json:"pattern" form:"pattern"`
+	CaseSensitive bool   `json:"case_sensitive" form:"case_sensitive"`
+	Chat          string `json:"chat" form:"chat"`
+	Reply         string `json:"reply" form:"reply"`
+}
+
+type DeleteAutoReplyRequest struct {
+	ID string `json:"id" uri:"id"`
+}
+
+type SetAutoReplyEnabledRequest struct {
+	Enabled bool `json:"enabled" form:"enabled"`
+}
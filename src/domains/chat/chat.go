@@ -0,0 +1,148 @@
+package chat
+
+import "context"
+
+type IChatService interface {
+	ListChats(ctx context.Context, request ListChatsRequest) (response ListChatsResponse, err error)
+	ListMessages(ctx context.Context, request ListMessagesRequest) (response ListMessagesResponse, err error)
+	SetEphemeral(ctx context.Context, request SetEphemeralRequest) (response SetEphemeralResponse, err error)
+	MarkAsRead(ctx context.Context, request MarkAsReadRequest) (response MarkAsReadResponse, err error)
+	MarkAsUnread(ctx context.Context, request MarkAsUnreadRequest) (response MarkAsUnreadResponse, err error)
+	ArchiveChat(ctx context.Context, request ArchiveChatRequest) (response ChatSettingsResponse, err error)
+	PinChat(ctx context.Context, request PinChatRequest) (response ChatSettingsResponse, err error)
+	MuteChat(ctx context.Context, request MuteChatRequest) (response ChatSettingsResponse, err error)
+}
+
+const (
+	ChatListDefaultLimit = 25
+	ChatListMaxLimit     = 100
+
+	// ChatSortRecent lists the chats with the latest activity first
+	ChatSortRecent = "recent"
+	// ChatSortOldest lists the chats with the oldest activity first
+	ChatSortOldest = "oldest"
+
+	MessageListDefaultLimit = 50
+	MessageListMaxLimit     = 200
+)
+
+type ListChatsRequest struct {
+	Limit  int    `json:"limit" query:"limit"`
+	Offset int    `json:"offset" query:"offset"`
+	Sort   string `json:"sort" query:"sort"`
+}
+
+type ChatData struct {
+	JID  string `json:"jid"`
+	Name string `json:"name"`
+	// LastMessageAt is empty for a chat without a known message, like a group only its subject is known of
+	LastMessageID string `json:"last_message_id,omitempty"`
+	LastMessageAt string `json:"last_message_at,omitempty"`
+	UnreadCount   int    `json:"unread_count"`
+}
+
+type ListChatsResponse struct {
+	Data   []ChatData `json:"data"`
+	Total  int        `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+type ListMessagesRequest struct {
+	ChatJID string `json:"chat_jid" uri:"jid"`
+	// Before and After are a message id or an RFC3339 timestamp, a message id must be stored for the chat
+	Before string `json:"before" query:"before"`
+	After  string `json:"after" query:"after"`
+	Limit  int    `json:"limit" query:"limit"`
+}
+
+type MessageData struct {
+	ID        string `json:"id"`
+	ChatJID   string `json:"chat_jid"`
+	SenderJID string `json:"sender_jid"`
+	IsFromMe  bool   `json:"is_from_me"`
+	PushName  string `json:"push_name,omitempty"`
+	// Type is one of text, image, video, audio, document, sticker, contact, location, live_location, poll,
+	// other or revoked for a message deleted for everyone
+	Type      string        `json:"type"`
+	Text      string        `json:"text,omitempty"`
+	Media     *MessageMedia `json:"media,omitempty"`
+	Timestamp string        `json:"timestamp"`
+}
+
+type MessageMedia struct {
+	MimeType string `json:"mime_type"`
+	FileName string `json:"file_name,omitempty"`
+	// DownloadPath downloads the media while its keys are still cached
+	DownloadPath string `json:"download_path"`
+}
+
+type ListMessagesResponse struct {
+	// Data is ordered oldest first
+	Data []MessageData `json:"data"`
+	// HasMore tells more messages are stored past the page, before it or after it when only after was given
+	HasMore bool `json:"has_more"`
+}
+
+type SetEphemeralRequest struct {
+	ChatJID string `json:"chat_jid" uri:"jid"`
+	// Duration is one of off, 24h, 7d or 90d, the duration in seconds is accepted too
+	Duration string `json:"duration" form:"duration"`
+}
+
+type SetEphemeralResponse struct {
+	ChatJID  string `json:"chat_jid"`
+	Duration uint32 `json:"duration"`
+}
+
+type MarkAsReadRequest struct {
+	ChatJID    string   `json:"chat_jid" uri:"jid"`
+	MessageIDs []string `json:"message_ids" form:"message_ids"`
+	// Sender of the messages, required in group chats
+	Sender string `json:"sender" form:"sender"`
+	// UpToMessageID marks the whole chat as read up to this message
+	UpToMessageID string `json:"up_to_message_id" form:"up_to_message_id"`
+}
+
+type MarkAsReadResponse struct {
+	ChatJID    string   `json:"chat_jid"`
+	MessageIDs []string `json:"message_ids"`
+	// ReadReceipts is false when the account disabled read receipts, the sender won't see blue ticks
+	ReadReceipts bool `json:"read_receipts"`
+}
+
+type MarkAsUnreadRequest struct {
+	ChatJID string `json:"chat_jid" uri:"jid"`
+	// Unread sets the unread marker, false clears it like marking the chat read does
+	Unread bool `json:"unread"`
+}
+
+type MarkAsUnreadResponse struct {
+	ChatJID string `json:"chat_jid"`
+	Unread  bool   `json:"unread"`
+}
+
+type ArchiveChatRequest struct {
+	ChatJID  string `json:"chat_jid" uri:"jid"`
+	Archived bool   `json:"archived"`
+}
+
+type PinChatRequest struct {
+	ChatJID string `json:"chat_jid" uri:"jid"`
+	Pinned  bool   `json:"pinned"`
+}
+
+type MuteChatRequest struct {
+	ChatJID string `json:"chat_jid" uri:"jid"`
+	// Duration is one of 8h, 1w or always, off unmutes the chat
+	Duration string `json:"duration" form:"duration"`
+}
+
+type ChatSettingsResponse struct {
+	ChatJID  string `json:"chat_jid"`
+	Archived bool   `json:"archived"`
+	Pinned   bool   `json:"pinned"`
+	Muted    bool   `json:"muted"`
+	// MutedUntil is empty when the chat is muted forever
+	MutedUntil string `json:"muted_until,omitempty"`
+}
@@ -0,0 +1,38 @@
+package contact
+
+import "context"
+
+type IContactService interface {
+	Check(ctx context.Context, request CheckRequest) (response CheckResponse, err error)
+	Block(ctx context.Context, request BlockRequest) (response BlockResponse, err error)
+	Unblock(ctx context.Context, request BlockRequest) (response BlockResponse, err error)
+	Blocklist(ctx context.Context) (response BlocklistResponse, err error)
+}
+
+type CheckRequest struct {
+	Phones []string `json:"phones" form:"phones"`
+}
+
+type CheckResponseData struct {
+	Phone        string `json:"phone"`
+	IsOnWhatsapp bool   `json:"is_on_whatsapp"`
+	JID          string `json:"jid,omitempty"`
+	VerifiedName string `json:"verified_name,omitempty"`
+}
+
+type CheckResponse struct {
+	Data []CheckResponseData `json:"data"`
+}
+
+type BlockRequest struct {
+	Phone string `json:"phone" form:"phone"`
+}
+
+type BlockResponse struct {
+	JID       string `json:"jid"`
+	IsBlocked bool   `json:"is_blocked"`
+}
+
+type BlocklistResponse struct {
+	Data []string `json:"data"`
+}
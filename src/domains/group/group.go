@@ -10,7 +10,11 @@ import (
 type IGroupService interface {
 	JoinGroupWithLink(ctx context.Context, request JoinGroupWithLinkRequest) (groupID string, err error)
 	LeaveGroup(ctx context.Context, request LeaveGroupRequest) (err error)
-	CreateGroup(ctx context.Context, request CreateGroupRequest) (groupID string, err error)
+	SetGroupInfo(ctx context.Context, request GroupInfoRequest) (response GroupInfoResponse, err error)
+	SetGroupSetting(ctx context.Context, request GroupSettingRequest) (response GroupSettingResponse, err error)
+	GetInviteLink(ctx context.Context, request GroupInviteLinkRequest) (response GroupInviteLinkResponse, err error)
+	GetInviteInfo(ctx context.Context, request GroupInviteInfoRequest) (response GroupInviteInfoResponse, err error)
+	CreateGroup(ctx context.Context, request CreateGroupRequest) (response CreateGroupResponse, err error)
 	ManageParticipant(ctx context.Context, request ParticipantRequest) (result []ParticipantStatus, err error)
 	GetGroupRequestParticipants(ctx context.Context, request GetGroupRequestParticipantsRequest) (result []GetGroupRequestParticipantsResponse, err error)
 	ManageGroupRequestParticipants(ctx context.Context, request GroupRequestParticipantsRequest) (result []ParticipantStatus, err error)
@@ -20,6 +24,73 @@ type JoinGroupWithLinkRequest struct {
 	Link string `json:"link" form:"link"`
 }
 
+const (
+	GroupSubjectMaxLength     = 100
+	GroupDescriptionMaxLength = 2048
+)
+
+// GroupInfoRequest changes the fields that are set, an empty description removes it
+type GroupInfoRequest struct {
+	GroupID     string  `json:"group_id" form:"group_id"`
+	Subject     *string `json:"subject" form:"subject"`
+	Description *string `json:"description" form:"description"`
+}
+
+type GroupInfoResponse struct {
+	GroupID     string  `json:"group_id"`
+	Subject     *string `json:"subject,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+const (
+	// GroupSettingAnnounce allows only admins to send messages
+	GroupSettingAnnounce = "announce"
+	// GroupSettingLocked allows only admins to edit the group info
+	GroupSettingLocked = "locked"
+)
+
+type GroupSettingRequest struct {
+	GroupID string `json:"group_id" form:"group_id"`
+	Setting string `json:"-"`
+	Enabled *bool  `json:"enabled" form:"enabled"`
+}
+
+type GroupSettingResponse struct {
+	GroupID string `json:"group_id"`
+	Setting string `json:"setting"`
+	Enabled bool   `json:"enabled"`
+}
+
+type GroupInviteLinkRequest struct {
+	GroupID string `json:"group_id" form:"group_id" query:"group_id"`
+	// Reset revokes the current link and creates a new one
+	Reset bool `json:"-"`
+}
+
+type GroupInviteLinkResponse struct {
+	GroupID    string `json:"group_id"`
+	InviteLink string `json:"invite_link"`
+	InviteCode string `json:"invite_code"`
+}
+
+// GroupInviteInfoRequest takes a full invite url or a bare invite code
+type GroupInviteInfoRequest struct {
+	Code string `json:"code" query:"code"`
+}
+
+type GroupInviteInfoResponse struct {
+	GroupID     string `json:"group_id"`
+	InviteCode  string `json:"invite_code"`
+	Subject     string `json:"subject"`
+	Description string `json:"description,omitempty"`
+	// Size is the number of participants whatsapp lists for the invite
+	Size       int    `json:"size"`
+	CreatorJID string `json:"creator_jid,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	// IsJoinApprovalRequired tells joining creates a request an admin has to approve
+	IsJoinApprovalRequired bool `json:"is_join_approval_required"`
+}
+
 type LeaveGroupRequest struct {
 	GroupID string `json:"group_id" form:"group_id"`
 }
@@ -29,6 +100,12 @@ type CreateGroupRequest struct {
 	Participants []string `json:"participants" form:"participants"`
 }
 
+type CreateGroupResponse struct {
+	GroupID      string              `json:"group_id"`
+	InviteLink   string              `json:"invite_link,omitempty"`
+	Participants []ParticipantStatus `json:"participants"`
+}
+
 type ParticipantRequest struct {
 	GroupID      string                      `json:"group_id" form:"group_id"`
 	Participants []string                    `json:"participants" form:"participants"`
@@ -39,6 +116,9 @@ type ParticipantStatus struct {
 	Participant string `json:"participant"`
 	Status      string `json:"status"`
 	Message     string `json:"message"`
+	// Set when the participant can only join through an invite, because of their privacy settings
+	InviteCode       string    `json:"invite_code,omitempty"`
+	InviteExpiration time.Time `json:"invite_expiration,omitzero"`
 }
 
 type GetGroupRequestParticipantsRequest struct {
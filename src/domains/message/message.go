@@ -9,6 +9,10 @@ type IMessageService interface {
 	UpdateMessage(ctx context.Context, request UpdateMessageRequest) (response GenericResponse, err error)
 	DeleteMessage(ctx context.Context, request DeleteRequest) (err error)
 	StarMessage(ctx context.Context, request StarRequest) (err error)
+	DownloadMedia(ctx context.Context, request DownloadMediaRequest) (response DownloadMediaResponse, err error)
+	GetReactions(ctx context.Context, request ReactionsRequest) (response ReactionsResponse, err error)
+	ForwardMessage(ctx context.Context, request ForwardRequest) (response ForwardResponse, err error)
+	GetMessageStatus(ctx context.Context, request StatusRequest) (response StatusResponse, err error)
 }
 
 type GenericResponse struct {
@@ -24,6 +28,8 @@ type RevokeRequest struct {
 type DeleteRequest struct {
 	MessageID string `json:"message_id" uri:"message_id"`
 	Phone     string `json:"phone" form:"phone"`
+	// ForEveryone revokes the message for every participant instead of deleting it only on this account
+	ForEveryone bool `json:"for_everyone" form:"for_everyone"`
 }
 
 type ReactionRequest struct {
@@ -48,3 +54,67 @@ type StarRequest struct {
 	Phone     string `json:"phone" form:"phone"`
 	IsStarred bool   `json:"is_starred"`
 }
+
+type DownloadMediaRequest struct {
+	MessageID string `json:"message_id" uri:"message_id"`
+}
+
+type DownloadMediaResponse struct {
+	Data     []byte
+	MimeType string
+	FileName string
+}
+
+type ReactionsRequest struct {
+	MessageID string `json:"message_id" uri:"message_id"`
+	Phone     string `json:"phone" query:"phone"`
+}
+
+type ReactionData struct {
+	SenderJID string `json:"sender_jid"`
+	Emoji     string `json:"emoji"`
+	Timestamp string `json:"timestamp"`
+}
+
+type ReactionsResponse struct {
+	MessageID string         `json:"message_id"`
+	Data      []ReactionData `json:"data"`
+}
+
+type StatusRequest struct {
+	MessageID string `json:"message_id" uri:"message_id"`
+	Phone     string `json:"phone" query:"phone"`
+}
+
+type StatusResponse struct {
+	MessageID string `json:"message_id"`
+	ChatJID   string `json:"chat_jid"`
+	// Status is one of sent, delivered or read, in a group the first participant moves it forward
+	Status string `json:"status"`
+	SentAt string `json:"sent_at"`
+	// DeliveredAt and ReadAt are empty until the status is reached
+	DeliveredAt string `json:"delivered_at,omitempty"`
+	ReadAt      string `json:"read_at,omitempty"`
+}
+
+type ForwardRequest struct {
+	MessageID string `json:"message_id" uri:"message_id"`
+	// Phone is the chat the message was received in
+	Phone string `json:"phone" form:"phone"`
+	// Phones are the chats the message is forwarded to
+	Phones []string `json:"phones" form:"phones"`
+}
+
+type ForwardResponse struct {
+	Status  string          `json:"status"`
+	Sent    int             `json:"sent"`
+	Failed  int             `json:"failed"`
+	Results []ForwardResult `json:"results"`
+}
+
+type ForwardResult struct {
+	Phone     string `json:"phone"`
+	Success   bool   `json:"success"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
@@ -3,9 +3,32 @@ package newsletter
 import "context"
 
 type INewsletterService interface {
+	Follow(ctx context.Context, request FollowRequest) (response Newsletter, err error)
 	Unfollow(ctx context.Context, request UnfollowRequest) (err error)
+	List(ctx context.Context) (response ListResponse, err error)
+}
+
+// FollowRequest picks the channel by its jid or, when there's no jid, by its invite link
+type FollowRequest struct {
+	NewsletterID string `json:"newsletter_id" form:"newsletter_id"`
+	InviteLink   string `json:"invite_link" form:"invite_link"`
 }
 
 type UnfollowRequest struct {
 	NewsletterID string `json:"newsletter_id" form:"newsletter_id"`
+	InviteLink   string `json:"invite_link" form:"invite_link"`
+}
+
+type Newsletter struct {
+	JID         string `json:"jid"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	InviteCode  string `json:"invite_code"`
+	Subscribers int    `json:"subscribers"`
+	Role        string `json:"role,omitempty"`
+	Muted       bool   `json:"muted"`
+}
+
+type ListResponse struct {
+	Data []Newsletter `json:"data"`
 }
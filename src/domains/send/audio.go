@@ -3,7 +3,11 @@ package send
 import "mime/multipart"
 
 type AudioRequest struct {
-	Phone       string                `json:"phone" form:"phone"`
-	Audio       *multipart.FileHeader `json:"audio" form:"audio"`
-	IsForwarded bool                  `json:"is_forwarded" form:"is_forwarded"`
+	Phone    string                `json:"phone" form:"phone"`
+	Audio    *multipart.FileHeader `json:"audio" form:"audio"`
+	ViewOnce bool                  `json:"view_once" form:"view_once"`
+	// PTT sends the audio as a voice note, it is transcoded to opus and gets a waveform
+	PTT         bool     `json:"ptt" form:"ptt"`
+	IsForwarded bool     `json:"is_forwarded" form:"is_forwarded"`
+	ReplyTo     *ReplyTo `json:"reply_to" form:"reply_to"`
 }
@@ -0,0 +1,21 @@
+package send
+
+type BatchRequest struct {
+	Phones      []string `json:"phones" form:"phones"`
+	Message     string   `json:"message" form:"message"`
+	IsForwarded bool     `json:"is_forwarded" form:"is_forwarded"`
+}
+
+type BatchResponse struct {
+	Status  string        `json:"status"`
+	Sent    int           `json:"sent"`
+	Failed  int           `json:"failed"`
+	Results []BatchResult `json:"results"`
+}
+
+type BatchResult struct {
+	Phone     string `json:"phone"`
+	Success   bool   `json:"success"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
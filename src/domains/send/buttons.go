@@ -0,0 +1,16 @@
+package send
+
+// ButtonsMaxButtons is the max quick reply buttons WhatsApp renders in a message
+const ButtonsMaxButtons = 3
+
+type ButtonsRequest struct {
+	Phone   string   `json:"phone" form:"phone"`
+	Body    string   `json:"body" form:"body"`
+	Footer  string   `json:"footer" form:"footer"`
+	Buttons []Button `json:"buttons" form:"buttons"`
+}
+
+type Button struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
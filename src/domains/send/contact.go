@@ -4,5 +4,15 @@ type ContactRequest struct {
 	Phone        string `json:"phone" form:"phone"`
 	ContactName  string `json:"contact_name" form:"contact_name"`
 	ContactPhone string `json:"contact_phone" form:"contact_phone"`
-	IsForwarded  bool   `json:"is_forwarded" form:"is_forwarded"`
+	// Vcard is sent as is instead of the card built from contact_name and contact_phone
+	Vcard string `json:"vcard" form:"vcard"`
+	// Contacts sends several cards in a single message, the fields above are ignored then
+	Contacts    []ContactCard `json:"contacts" form:"contacts"`
+	IsForwarded bool          `json:"is_forwarded" form:"is_forwarded"`
+}
+
+type ContactCard struct {
+	Name  string `json:"name" form:"name"`
+	Phone string `json:"phone" form:"phone"`
+	Vcard string `json:"vcard" form:"vcard"`
 }
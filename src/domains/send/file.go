@@ -3,8 +3,14 @@ package send
 import "mime/multipart"
 
 type FileRequest struct {
-	Phone       string                `json:"phone" form:"phone"`
-	File        *multipart.FileHeader `json:"file" form:"file"`
-	Caption     string                `json:"caption" form:"caption"`
-	IsForwarded bool                  `json:"is_forwarded" form:"is_forwarded"`
+	Phone   string                `json:"phone" form:"phone"`
+	File    *multipart.FileHeader `json:"file" form:"file"`
+	Caption string                `json:"caption" form:"caption"`
+	// FileName is the name the recipient sees, the name of the uploaded file when empty
+	FileName string `json:"filename" form:"filename"`
+	// MimeType is inferred from the file name and the content when empty
+	MimeType    string   `json:"mimetype" form:"mimetype"`
+	ViewOnce    bool     `json:"view_once" form:"view_once"`
+	IsForwarded bool     `json:"is_forwarded" form:"is_forwarded"`
+	ReplyTo     *ReplyTo `json:"reply_to" form:"reply_to"`
 }
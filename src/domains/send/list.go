@@ -0,0 +1,27 @@
+package send
+
+// ListMaxSections and ListMaxRows are the limits WhatsApp renders in a list message, rows are counted over all sections
+const (
+	ListMaxSections = 10
+	ListMaxRows     = 10
+)
+
+type ListRequest struct {
+	Phone       string        `json:"phone" form:"phone"`
+	Title       string        `json:"title" form:"title"`
+	Description string        `json:"description" form:"description"`
+	Footer      string        `json:"footer" form:"footer"`
+	ButtonText  string        `json:"button_text" form:"button_text"`
+	Sections    []ListSection `json:"sections" form:"sections"`
+}
+
+type ListSection struct {
+	Title string    `json:"title"`
+	Rows  []ListRow `json:"rows"`
+}
+
+type ListRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
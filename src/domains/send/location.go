@@ -4,5 +4,27 @@ type LocationRequest struct {
 	Phone       string `json:"phone" form:"phone"`
 	Latitude    string `json:"latitude" form:"latitude"`
 	Longitude   string `json:"longitude" form:"longitude"`
+	Name        string `json:"name" form:"name"`
+	Address     string `json:"address" form:"address"`
 	IsForwarded bool   `json:"is_forwarded" form:"is_forwarded"`
 }
+
+type LiveLocationRequest struct {
+	Phone     string `json:"phone" form:"phone"`
+	Latitude  string `json:"latitude" form:"latitude"`
+	Longitude string `json:"longitude" form:"longitude"`
+	Caption   string `json:"caption" form:"caption"`
+	// Duration of the share in seconds
+	Duration int `json:"duration" form:"duration"`
+}
+
+type UpdateLiveLocationRequest struct {
+	MessageID string `json:"message_id" uri:"message_id"`
+	Latitude  string `json:"latitude" form:"latitude"`
+	Longitude string `json:"longitude" form:"longitude"`
+	Caption   string `json:"caption" form:"caption"`
+}
+
+type StopLiveLocationRequest struct {
+	MessageID string `json:"message_id" uri:"message_id"`
+}
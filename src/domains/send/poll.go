@@ -5,4 +5,9 @@ type PollRequest struct {
 	Question  string   `json:"question" form:"question"`
 	Options   []string `json:"options" form:"options"`
 	MaxAnswer int      `json:"max_answer" form:"max_answer"`
+	// MultipleAnswers lets voters select any number of options, up to MaxAnswer when it is set
+	MultipleAnswers bool `json:"multiple_answers" form:"multiple_answers"`
 }
+
+// PollMaxOptions is the max options WhatsApp accepts in a poll
+const PollMaxOptions = 12
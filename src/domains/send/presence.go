@@ -4,3 +4,11 @@ type PresenceRequest struct {
 	Type        string `json:"type" form:"type"`
 	IsForwarded bool   `json:"is_forwarded" form:"is_forwarded"`
 }
+
+type ChatPresenceRequest struct {
+	Phone string `json:"phone" form:"phone"`
+	// State is composing, recording or paused
+	State string `json:"state" form:"state"`
+	// Duration in seconds before the state is cleared back to paused, 0 keeps it until WhatsApp expires it
+	Duration int `json:"duration" form:"duration"`
+}
@@ -0,0 +1,8 @@
+package send
+
+// ReplyTo quotes a message of the chat the new message is sent to
+type ReplyTo struct {
+	MessageID string `json:"message_id" form:"message_id"`
+	// Participant is the sender of the quoted message, it is checked against the known sender when set
+	Participant string `json:"participant" form:"participant"`
+}
@@ -0,0 +1,39 @@
+package send
+
+import "time"
+
+// ScheduleTypeText is the only type of message that can be scheduled, the media of the other types would have to be
+// kept until the message is due
+const ScheduleTypeText = "text"
+
+// ScheduleRequest is a text message to send at SendAt, an RFC3339 timestamp in the future
+type ScheduleRequest struct {
+	MessageRequest
+	SendAt string `json:"send_at" form:"send_at"`
+	// Type of the message, empty or ScheduleTypeText
+	Type string `json:"type" form:"type"`
+}
+
+type ScheduledMessage struct {
+	ID        string     `json:"id"`
+	Phone     string     `json:"phone"`
+	Message   string     `json:"message"`
+	SendAt    time.Time  `json:"send_at"`
+	Status    string     `json:"status"`
+	MessageID string     `json:"message_id,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+}
+
+type ListScheduledRequest struct {
+	Status string `json:"status" query:"status"`
+}
+
+type ListScheduledResponse struct {
+	Data []ScheduledMessage `json:"data"`
+}
+
+type CancelScheduledRequest struct {
+	ID string `json:"id" uri:"id"`
+}
@@ -12,9 +12,23 @@ type ISendService interface {
 	SendContact(ctx context.Context, request ContactRequest) (response GenericResponse, err error)
 	SendLink(ctx context.Context, request LinkRequest) (response GenericResponse, err error)
 	SendLocation(ctx context.Context, request LocationRequest) (response GenericResponse, err error)
+	SendLiveLocation(ctx context.Context, request LiveLocationRequest) (response GenericResponse, err error)
+	UpdateLiveLocation(ctx context.Context, request UpdateLiveLocationRequest) (response GenericResponse, err error)
+	StopLiveLocation(ctx context.Context, request StopLiveLocationRequest) (response GenericResponse, err error)
 	SendAudio(ctx context.Context, request AudioRequest) (response GenericResponse, err error)
+	SendSticker(ctx context.Context, request StickerRequest) (response GenericResponse, err error)
 	SendPoll(ctx context.Context, request PollRequest) (response GenericResponse, err error)
+	SendButtons(ctx context.Context, request ButtonsRequest) (response GenericResponse, err error)
+	SendTemplateButtons(ctx context.Context, request TemplateButtonsRequest) (response GenericResponse, err error)
+	SendList(ctx context.Context, request ListRequest) (response GenericResponse, err error)
 	SendPresence(ctx context.Context, request PresenceRequest) (response GenericResponse, err error)
+	SendBatch(ctx context.Context, request BatchRequest) (response BatchResponse, err error)
+	SendChatPresence(ctx context.Context, request ChatPresenceRequest) (response GenericResponse, err error)
+	ScheduleMessage(ctx context.Context, request ScheduleRequest) (response ScheduledMessage, err error)
+	ListScheduledMessages(ctx context.Context, request ListScheduledRequest) (response ListScheduledResponse, err error)
+	CancelScheduledMessage(ctx context.Context, request CancelScheduledRequest) (response ScheduledMessage, err error)
+	// SendScheduledMessage sends the stored request of a scheduled message once it's due
+	SendScheduledMessage(ctx context.Context, payload string) (response GenericResponse, err error)
 }
 
 type GenericResponse struct {
@@ -0,0 +1,9 @@
+package send
+
+import "mime/multipart"
+
+type StickerRequest struct {
+	Phone       string                `json:"phone" form:"phone"`
+	Sticker     *multipart.FileHeader `json:"sticker" form:"sticker"`
+	IsForwarded bool                  `json:"is_forwarded" form:"is_forwarded"`
+}
@@ -0,0 +1,33 @@
+package send
+
+// A template message mixes quick reply buttons with call to action buttons opening a url or calling a number.
+// WhatsApp renders at most TemplateMaxButtons of them, TemplateMaxURLButtons urls and TemplateMaxCallButtons calls
+const (
+	TemplateMaxButtons     = 3
+	TemplateMaxURLButtons  = 2
+	TemplateMaxCallButtons = 1
+
+	TemplateButtonQuickReply = "quick_reply"
+	TemplateButtonURL        = "url"
+	TemplateButtonCall       = "call"
+)
+
+type TemplateButtonsRequest struct {
+	Phone   string           `json:"phone" form:"phone"`
+	Title   string           `json:"title" form:"title"`
+	Body    string           `json:"body" form:"body"`
+	Footer  string           `json:"footer" form:"footer"`
+	Buttons []TemplateButton `json:"buttons" form:"buttons"`
+	// Fallback sends the body and the buttons as plain text when WhatsApp rejects the template message, not when it
+	// accepts the template and silently drops it
+	Fallback bool `json:"fallback" form:"fallback"`
+}
+
+// TemplateButton is a quick_reply button with an ID, an url button with an URL or a call button with a PhoneNumber
+type TemplateButton struct {
+	Type        string `json:"type"`
+	Text        string `json:"text"`
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	PhoneNumber string `json:"phone_number"`
+}
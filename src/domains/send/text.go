@@ -1,8 +1,16 @@
 package send
 
 type MessageRequest struct {
-	Phone          string  `json:"phone" form:"phone"`
-	Message        string  `json:"message" form:"message"`
-	IsForwarded    bool    `json:"is_forwarded" form:"is_forwarded"`
-	ReplyMessageID *string `json:"reply_message_id" form:"reply_message_id"`
+	Phone          string   `json:"phone" form:"phone"`
+	Message        string   `json:"message" form:"message"`
+	IsForwarded    bool     `json:"is_forwarded" form:"is_forwarded"`
+	ReplyTo        *ReplyTo `json:"reply_to" form:"reply_to"`
+	ReplyMessageID *string  `json:"reply_message_id" form:"reply_message_id"`
+	// Mentions are the numbers to mention besides the @number of Message, each one needs a @number placeholder in
+	// Message that is rewritten to the form whatsapp renders. In a group every mentioned number must be a member
+	Mentions []string `json:"mentions" form:"mentions"`
+	// LinkPreview fetches the first link of the message to attach its title, description and thumbnail
+	LinkPreview bool `json:"link_preview" form:"link_preview"`
+	// DisappearingDuration in seconds (0, 86400, 604800 or 7776000), the chat setting is used when omitted
+	DisappearingDuration *uint32 `json:"disappearing_duration" form:"disappearing_duration"`
 }
@@ -0,0 +1,56 @@
+package status
+
+import (
+	"context"
+	"mime/multipart"
+)
+
+type IStatusService interface {
+	PostStatus(ctx context.Context, request PostStatusRequest) (response PostStatusResponse, err error)
+	ListStatuses(ctx context.Context, request ListStatusesRequest) (response ListStatusesResponse, err error)
+}
+
+const (
+	StatusTypeText  = "text"
+	StatusTypeImage = "image"
+	StatusTypeVideo = "video"
+)
+
+var StatusTypes = []string{StatusTypeText, StatusTypeImage, StatusTypeVideo}
+
+// PostStatusRequest is a text status with an optional background color, or an image or video status with Text as
+// its caption. The status goes to whoever the status privacy setting of the account allows, Recipients are the
+// numbers that must be among them
+type PostStatusRequest struct {
+	Type            string                `json:"type" form:"type"`
+	Text            string                `json:"text" form:"text"`
+	BackgroundColor string                `json:"background_color" form:"background_color"`
+	Media           *multipart.FileHeader `json:"media" form:"media"`
+	Recipients      []string              `json:"recipients" form:"recipients"`
+}
+
+type PostStatusResponse struct {
+	MessageID string `json:"message_id"`
+	Status    string `json:"status"`
+}
+
+// ListStatusesRequest lists the statuses of every contact, or only the ones of Phone
+type ListStatusesRequest struct {
+	Phone string `json:"phone" query:"phone"`
+}
+
+type StatusData struct {
+	ID              string `json:"id"`
+	SenderJID       string `json:"sender_jid"`
+	PushName        string `json:"pushname,omitempty"`
+	Type            string `json:"type"`
+	Text            string `json:"text,omitempty"`
+	BackgroundColor string `json:"background_color,omitempty"`
+	MimeType        string `json:"mime_type,omitempty"`
+	PostedAt        string `json:"posted_at"`
+	ExpiresAt       string `json:"expires_at"`
+}
+
+type ListStatusesResponse struct {
+	Data []StatusData `json:"data"`
+}
@@ -2,6 +2,7 @@ package user
 
 import (
 	"mime/multipart"
+	"time"
 
 	"go.mau.fi/whatsmeow/types"
 )
@@ -57,10 +58,21 @@ type MyListNewsletterResponse struct {
 	Data []types.NewsletterMetadata `json:"data"`
 }
 
+// A profile picture is a square jpeg, larger uploads are scaled down to AvatarMaxSize
+const (
+	AvatarMinSize       = 192
+	AvatarMaxSize       = 640
+	AvatarMaxUploadSize = 10000
+)
+
 type ChangeAvatarRequest struct {
 	Avatar *multipart.FileHeader `json:"avatar" form:"avatar"`
 }
 
+type ChangeAvatarResponse struct {
+	PictureID string `json:"picture_id"`
+}
+
 type MyListContactsResponse struct {
 	Data []MyListContactsResponseData `json:"data"`
 }
@@ -70,6 +82,113 @@ type MyListContactsResponseData struct {
 	Name string    `json:"name"`
 }
 
+type ContactInfoRequest struct {
+	Phone string `json:"phone" query:"phone"`
+}
+
+type ContactProfilePicture struct {
+	Status string `json:"status"` // available, not_set or restricted
+	URL    string `json:"url,omitempty"`
+	ID     string `json:"id,omitempty"`
+}
+
+type ContactInfoResponse struct {
+	JID            string                `json:"jid"`
+	PushName       string                `json:"push_name"`
+	FullName       string                `json:"full_name"`
+	BusinessName   string                `json:"business_name"`
+	VerifiedName   string                `json:"verified_name"`
+	IsVerified     bool                  `json:"is_verified"`
+	ProfilePicture ContactProfilePicture `json:"profile_picture"`
+}
+
+// whatsapp caps the profile name and the about text, both are counted in characters
+const (
+	PushNameMaxLength = 25
+	StatusMaxLength   = 139
+)
+
 type ChangePushNameRequest struct {
 	PushName string `json:"push_name" form:"push_name"`
 }
+
+type ChangePushNameResponse struct {
+	PushName string `json:"push_name"`
+}
+
+type ChangeStatusRequest struct {
+	Status string `json:"status" form:"status"`
+}
+
+type ChangeStatusResponse struct {
+	Status string `json:"status"`
+}
+
+type PresenceSubscriptionRequest struct {
+	Phone string `json:"phone" form:"phone"`
+}
+
+type PresenceSubscriptionData struct {
+	JID          string     `json:"jid"`
+	Available    bool       `json:"available"`
+	LastSeen     *time.Time `json:"last_seen,omitempty"`
+	SubscribedAt time.Time  `json:"subscribed_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+type PresenceSubscriptionsResponse struct {
+	// OwnPresence is the presence last sent, others are only received while it's available
+	OwnPresence   string                     `json:"own_presence"`
+	Subscriptions []PresenceSubscriptionData `json:"subscriptions"`
+}
+
+// A catalog is read by pages, whatsapp returns at most BusinessCatalogMaxLimit products per query
+const (
+	BusinessCatalogDefaultLimit = 10
+	BusinessCatalogMaxLimit     = 100
+)
+
+type BusinessCatalogRequest struct {
+	Phone string `json:"phone" query:"phone"`
+	Limit int    `json:"limit" query:"limit"`
+	After string `json:"after" query:"after"`
+}
+
+type BusinessProductRequest struct {
+	Phone     string `json:"phone" query:"phone"`
+	ProductID string `json:"product_id" query:"product_id"`
+}
+
+type BusinessProduct struct {
+	ID          string `json:"id"`
+	RetailerID  string `json:"retailer_id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	// Price is in the currency unit, PriceAmount1000 is the amount in thousandths as sent by whatsapp
+	Price            float64 `json:"price"`
+	PriceAmount1000  int64   `json:"price_amount_1000"`
+	Currency         string  `json:"currency"`
+	ImageURL         string  `json:"image_url,omitempty"`
+	OriginalImageURL string  `json:"original_image_url,omitempty"`
+	Hidden           bool    `json:"hidden"`
+}
+
+type BusinessCatalogResponse struct {
+	JID      string            `json:"jid"`
+	Products []BusinessProduct `json:"products"`
+	// NextCursor is passed as after to read the next page, empty on the last one
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type JIDInfoRequest struct {
+	JID string `json:"jid" query:"jid"`
+}
+
+type JIDInfoResponse struct {
+	JID  string `json:"jid"`
+	Type string `json:"type"`
+	// IsMember and IsAdmin are only set for groups
+	IsMember *bool `json:"is_member,omitempty"`
+	IsAdmin  *bool `json:"is_admin,omitempty"`
+}
@@ -7,10 +7,19 @@ import (
 type IUserService interface {
 	Info(ctx context.Context, request InfoRequest) (response InfoResponse, err error)
 	Avatar(ctx context.Context, request AvatarRequest) (response AvatarResponse, err error)
-	ChangeAvatar(ctx context.Context, request ChangeAvatarRequest) (err error)
-	ChangePushName(ctx context.Context, request ChangePushNameRequest) (err error)
+	ContactInfo(ctx context.Context, request ContactInfoRequest) (response ContactInfoResponse, err error)
+	ChangeAvatar(ctx context.Context, request ChangeAvatarRequest) (response ChangeAvatarResponse, err error)
+	RemoveAvatar(ctx context.Context) (response ChangeAvatarResponse, err error)
+	ChangePushName(ctx context.Context, request ChangePushNameRequest) (response ChangePushNameResponse, err error)
+	ChangeStatus(ctx context.Context, request ChangeStatusRequest) (response ChangeStatusResponse, err error)
 	MyListGroups(ctx context.Context) (response MyListGroupsResponse, err error)
 	MyListNewsletter(ctx context.Context) (response MyListNewsletterResponse, err error)
 	MyPrivacySetting(ctx context.Context) (response MyPrivacySettingResponse, err error)
+	SubscribePresence(ctx context.Context, request PresenceSubscriptionRequest) (response PresenceSubscriptionData, err error)
+	UnsubscribePresence(ctx context.Context, request PresenceSubscriptionRequest) (err error)
+	PresenceSubscriptions(ctx context.Context) (response PresenceSubscriptionsResponse, err error)
 	MyListContacts(ctx context.Context) (response MyListContactsResponse, err error)
+	BusinessCatalog(ctx context.Context, request BusinessCatalogRequest) (response BusinessCatalogResponse, err error)
+	BusinessProduct(ctx context.Context, request BusinessProductRequest) (response BusinessProduct, err error)
+	JIDInfo(ctx context.Context, request JIDInfoRequest) (response JIDInfoResponse, err error)
 }
@@ -0,0 +1,36 @@
+package webhook
+
+import "context"
+
+type IWebhookService interface {
+	List(ctx context.Context) (response []WebhookResponse, err error)
+	Add(ctx context.Context, request AddWebhookRequest) (response WebhookResponse, err error)
+	Delete(ctx context.Context, request DeleteWebhookRequest) (err error)
+	Test(ctx context.Context, request TestWebhookRequest) (response TestWebhookResponse, err error)
+}
+
+type WebhookResponse struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Source string   `json:"source"`
+}
+
+type AddWebhookRequest struct {
+	URL    string   `json:"url" form:"url"`
+	Events []string `json:"events" form:"events"`
+}
+
+type DeleteWebhookRequest struct {
+	URL string `json:"url" query:"url"`
+}
+
+type TestWebhookRequest struct {
+	URL string `json:"url" form:"url"`
+}
+
+type TestWebhookResponse struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Body       string `json:"body"`
+}
@@ -1,9 +1,14 @@
 package rest
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+
 	domainApp "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/app"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/internal/sse"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -11,13 +16,19 @@ type App struct {
 	Service domainApp.IAppService
 }
 
-func InitRestApp(app *fiber.App, service domainApp.IAppService) App {
+func InitRestApp(app fiber.Router, service domainApp.IAppService) App {
 	rest := App{Service: service}
 	app.Get("/app/login", rest.Login)
 	app.Get("/app/login-with-code", rest.LoginWithCode)
 	app.Get("/app/logout", rest.Logout)
 	app.Get("/app/reconnect", rest.Reconnect)
 	app.Get("/app/devices", rest.Devices)
+	app.Get("/device", rest.DeviceInfo)
+	app.Get("/app/status", rest.Status)
+	app.Get("/qr", rest.QR)
+	app.Get("/qr/stream", rest.QRStream)
+	app.Post("/app/session/export", rest.ExportSession)
+	app.Post("/app/session/import", rest.ImportSession)
 
 	return App{Service: service}
 }
@@ -38,19 +49,78 @@ func (handler *App) Login(c *fiber.Ctx) error {
 }
 
 func (handler *App) LoginWithCode(c *fiber.Ctx) error {
-	pairCode, err := handler.Service.LoginWithCode(c.UserContext(), c.Query("phone"))
+	response, err := handler.Service.LoginWithCode(c.UserContext(), c.Query("phone"))
 	utils.PanicIfNeeded(err)
 
 	return c.JSON(utils.ResponseData{
 		Status:  200,
 		Code:    "SUCCESS",
 		Message: "Login with code success",
-		Results: map[string]any{
-			"pair_code": pairCode,
-		},
+		Results: response,
 	})
 }
 
+func (handler *App) Status(c *fiber.Ctx) error {
+	response, err := handler.Service.Status(c.UserContext())
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Fetch status success",
+		Results: response,
+	})
+}
+
+func (handler *App) QR(c *fiber.Ctx) error {
+	var request domainApp.QRRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := handler.Service.QR(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	// The QR code rotates, it must not be cached by the login page
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	if response.Code == "" {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	if request.Format == domainApp.QRFormatJSON {
+		return c.JSON(utils.ResponseData{
+			Status:  200,
+			Code:    "SUCCESS",
+			Message: "Fetch qr code success",
+			Results: response,
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "image/png")
+	return c.Send(response.Image)
+}
+
+// QRStream streams the login events as server-sent events named qr, success and timeout. The stream ends after the
+// success event
+func (handler *App) QRStream(c *fiber.Ctx) error {
+	// The login listener lives as long as the stream
+	ctx, cancel := context.WithCancel(c.UserContext())
+	events, err := handler.Service.QRStream(ctx)
+	if err != nil {
+		cancel()
+	}
+	utils.PanicIfNeeded(err)
+
+	sse.Stream(c, nil, events, qrFrame, cancel)
+	return nil
+}
+
+func qrFrame(evt domainApp.QREvent) (sse.Frame, error) {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return sse.Frame{}, err
+	}
+	return sse.Frame{Event: evt.Event, Data: data, Last: evt.Event == whatsapp.LoginEventSuccess}, nil
+}
+
 func (handler *App) Logout(c *fiber.Ctx) error {
 	err := handler.Service.Logout(c.UserContext())
 	utils.PanicIfNeeded(err)
@@ -86,3 +156,47 @@ func (handler *App) Devices(c *fiber.Ctx) error {
 		Results: devices,
 	})
 }
+
+func (handler *App) DeviceInfo(c *fiber.Ctx) error {
+	response, err := handler.Service.DeviceInfo(c.UserContext())
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Fetch device info success",
+		Results: response,
+	})
+}
+
+func (handler *App) ExportSession(c *fiber.Ctx) error {
+	var request domainApp.ExportSessionRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := handler.Service.ExportSession(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	c.Attachment(response.FileName)
+	return c.Send(response.Data)
+}
+
+func (handler *App) ImportSession(c *fiber.Ctx) error {
+	var request domainApp.ImportSessionRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	request.Backup, err = c.FormFile("backup")
+	utils.PanicIfNeeded(err)
+
+	response, err := handler.Service.ImportSession(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success import session",
+		Results: response,
+	})
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	domainAutoReply "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/autoreply"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AutoReply struct {
+	Service domainAutoReply.IAutoReplyService
+}
+
+func InitRestAutoReply(app *fiber.App, service domainAutoReply.IAutoReplyService) AutoReply {
+	rest := AutoReply{Service: service}
+	app.Get("/auto-replies", rest.List)
+	app.Post("/auto-replies", rest.Add)
+	app.Post("/auto-replies/enabled", rest.SetEnabled)
+	app.Delete("/auto-replies/:id", rest.Delete)
+	return rest
+}
+
+func (controller *AutoReply) List(c *fiber.Ctx) error {
+	response, err := controller.Service.List(c.UserContext())
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get list auto reply rules",
+		Results: response,
+	})
+}
+
+func (controller *AutoReply) Add(c *fiber.Ctx) error {
+	var request domainAutoReply.AddAutoReplyRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.Add(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success add auto reply rule",
+		Results: response,
+	})
+}
+
+func (controller *AutoReply) SetEnabled(c *fiber.Ctx) error {
+	var request domainAutoReply.SetAutoReplyEnabledRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	err = controller.Service.SetEnabled(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	message := "Success disable auto reply rules"
+	if request.Enabled {
+		message = "Success enable auto reply rules"
+	}
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: message,
+	})
+}
+
+func (controller *AutoReply) Delete(c *fiber.Ctx) error {
+	request := domainAutoReply.DeleteAutoReplyRequest{ID: c.Params("id")}
+
+	err := controller.Service.Delete(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success delete auto reply rule",
+	})
+}
@@ -0,0 +1,232 @@
+package rest
+
+import (
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Chat struct {
+	Service domainChat.IChatService
+}
+
+func InitRestChat(app fiber.Router, service domainChat.IChatService) Chat {
+	rest := Chat{Service: service}
+	app.Get("/chats", rest.ListChats)
+	app.Get("/chat/:jid/messages", rest.ListMessages)
+	app.Post("/chat/:jid/ephemeral", rest.SetEphemeral)
+	app.Post("/chat/:jid/read", rest.MarkAsRead)
+	app.Post("/chat/:jid/unread", rest.MarkAsUnread)
+	app.Delete("/chat/:jid/unread", rest.ClearUnread)
+	app.Post("/chat/:jid/archive", rest.ArchiveChat)
+	app.Post("/chat/:jid/unarchive", rest.UnarchiveChat)
+	app.Post("/chat/:jid/pin", rest.PinChat)
+	app.Post("/chat/:jid/unpin", rest.UnpinChat)
+	app.Post("/chat/:jid/mute", rest.MuteChat)
+	app.Post("/chat/:jid/unmute", rest.UnmuteChat)
+	return rest
+}
+
+func (controller *Chat) ListChats(c *fiber.Ctx) error {
+	var request domainChat.ListChatsRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.ListChats(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get chat list",
+		Results: response,
+	})
+}
+
+func (controller *Chat) ListMessages(c *fiber.Ctx) error {
+	var request domainChat.ListMessagesRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	request.ChatJID = c.Params("jid")
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.ListMessages(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get chat messages",
+		Results: response,
+	})
+}
+
+func (controller *Chat) SetEphemeral(c *fiber.Ctx) error {
+	var request domainChat.SetEphemeralRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	request.ChatJID = c.Params("jid")
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.SetEphemeral(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success set disappearing messages",
+		Results: response,
+	})
+}
+
+func (controller *Chat) MarkAsRead(c *fiber.Ctx) error {
+	var request domainChat.MarkAsReadRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	request.ChatJID = c.Params("jid")
+	whatsapp.SanitizePhone(&request.ChatJID)
+	whatsapp.SanitizePhone(&request.Sender)
+
+	response, err := controller.Service.MarkAsRead(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	message := "Success mark as read"
+	if !response.ReadReceipts {
+		message = "Success mark as read, read receipts are disabled so the sender won't see blue ticks"
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: message,
+		Results: response,
+	})
+}
+
+func (controller *Chat) MarkAsUnread(c *fiber.Ctx) error {
+	request := domainChat.MarkAsUnreadRequest{ChatJID: c.Params("jid"), Unread: true}
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.MarkAsUnread(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success mark chat as unread",
+		Results: response,
+	})
+}
+
+func (controller *Chat) ClearUnread(c *fiber.Ctx) error {
+	request := domainChat.MarkAsUnreadRequest{ChatJID: c.Params("jid"), Unread: false}
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.MarkAsUnread(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success clear chat unread marker",
+		Results: response,
+	})
+}
+
+func (controller *Chat) ArchiveChat(c *fiber.Ctx) error {
+	request := domainChat.ArchiveChatRequest{ChatJID: c.Params("jid"), Archived: true}
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.ArchiveChat(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success archive chat",
+		Results: response,
+	})
+}
+
+func (controller *Chat) UnarchiveChat(c *fiber.Ctx) error {
+	request := domainChat.ArchiveChatRequest{ChatJID: c.Params("jid"), Archived: false}
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.ArchiveChat(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success unarchive chat",
+		Results: response,
+	})
+}
+
+func (controller *Chat) PinChat(c *fiber.Ctx) error {
+	request := domainChat.PinChatRequest{ChatJID: c.Params("jid"), Pinned: true}
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.PinChat(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success pin chat",
+		Results: response,
+	})
+}
+
+func (controller *Chat) UnpinChat(c *fiber.Ctx) error {
+	request := domainChat.PinChatRequest{ChatJID: c.Params("jid"), Pinned: false}
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.PinChat(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success unpin chat",
+		Results: response,
+	})
+}
+
+func (controller *Chat) MuteChat(c *fiber.Ctx) error {
+	var request domainChat.MuteChatRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	request.ChatJID = c.Params("jid")
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.MuteChat(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success mute chat",
+		Results: response,
+	})
+}
+
+func (controller *Chat) UnmuteChat(c *fiber.Ctx) error {
+	request := domainChat.MuteChatRequest{ChatJID: c.Params("jid"), Duration: "off"}
+	whatsapp.SanitizePhone(&request.ChatJID)
+
+	response, err := controller.Service.MuteChat(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success unmute chat",
+		Results: response,
+	})
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	domainContact "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/contact"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Contact struct {
+	Service domainContact.IContactService
+}
+
+func InitRestContact(app fiber.Router, service domainContact.IContactService) Contact {
+	rest := Contact{Service: service}
+	app.Post("/contacts/check", rest.CheckContacts)
+	app.Post("/contacts/block", rest.BlockContact)
+	app.Post("/contacts/unblock", rest.UnblockContact)
+	app.Get("/contacts/blocked", rest.BlockedContacts)
+	return rest
+}
+
+func (controller *Contact) CheckContacts(c *fiber.Ctx) error {
+	var request domainContact.CheckRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.Check(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success check contacts on whatsapp",
+		Results: response,
+	})
+}
+
+func (controller *Contact) BlockContact(c *fiber.Ctx) error {
+	var request domainContact.BlockRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.Block(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success block contact",
+		Results: response,
+	})
+}
+
+func (controller *Contact) UnblockContact(c *fiber.Ctx) error {
+	var request domainContact.BlockRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.Unblock(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success unblock contact",
+		Results: response,
+	})
+}
+
+func (controller *Contact) BlockedContacts(c *fiber.Ctx) error {
+	response, err := controller.Service.Blocklist(c.UserContext())
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get blocked contacts",
+		Results: response,
+	})
+}
@@ -14,11 +14,18 @@ type Group struct {
 	Service domainGroup.IGroupService
 }
 
-func InitRestGroup(app *fiber.App, service domainGroup.IGroupService) Group {
+func InitRestGroup(app fiber.Router, service domainGroup.IGroupService) Group {
 	rest := Group{Service: service}
 	app.Post("/group", rest.CreateGroup)
 	app.Post("/group/join-with-link", rest.JoinGroupWithLink)
 	app.Post("/group/leave", rest.LeaveGroup)
+	app.Post("/group/:jid/leave", rest.LeaveGroup)
+	app.Post("/group/info", rest.SetGroupInfo)
+	app.Post("/group/settings/announce", rest.SetGroupAnnounce)
+	app.Post("/group/settings/locked", rest.SetGroupLocked)
+	app.Get("/group/invite-link", rest.GetInviteLink)
+	app.Get("/group/invite-info", rest.GetInviteInfo)
+	app.Post("/group/invite-link/reset", rest.ResetInviteLink)
 	app.Post("/group/participants", rest.AddParticipants)
 	app.Post("/group/participants/remove", rest.DeleteParticipants)
 	app.Post("/group/participants/promote", rest.PromoteParticipants)
@@ -47,14 +54,97 @@ func (controller *Group) JoinGroupWithLink(c *fiber.Ctx) error {
 	})
 }
 
-func (controller *Group) LeaveGroup(c *fiber.Ctx) error {
-	var request domainGroup.LeaveGroupRequest
+func (controller *Group) SetGroupInfo(c *fiber.Ctx) error {
+	var request domainGroup.GroupInfoRequest
 	err := c.BodyParser(&request)
 	utils.PanicIfNeeded(err)
 
 	whatsapp.SanitizePhone(&request.GroupID)
 
-	err = controller.Service.LeaveGroup(c.UserContext(), request)
+	response, err := controller.Service.SetGroupInfo(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success update group info",
+		Results: response,
+	})
+}
+
+func (controller *Group) SetGroupAnnounce(c *fiber.Ctx) error {
+	return controller.setGroupSetting(c, domainGroup.GroupSettingAnnounce)
+}
+
+func (controller *Group) SetGroupLocked(c *fiber.Ctx) error {
+	return controller.setGroupSetting(c, domainGroup.GroupSettingLocked)
+}
+
+func (controller *Group) GetInviteLink(c *fiber.Ctx) error {
+	var request domainGroup.GroupInviteLinkRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.GroupID)
+
+	response, err := controller.Service.GetInviteLink(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get group invite link",
+		Results: response,
+	})
+}
+
+func (controller *Group) GetInviteInfo(c *fiber.Ctx) error {
+	var request domainGroup.GroupInviteInfoRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.GetInviteInfo(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get group invite info",
+		Results: response,
+	})
+}
+
+func (controller *Group) ResetInviteLink(c *fiber.Ctx) error {
+	var request domainGroup.GroupInviteLinkRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.GroupID)
+	request.Reset = true
+
+	response, err := controller.Service.GetInviteLink(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success reset group invite link, the previous link no longer works",
+		Results: response,
+	})
+}
+
+func (controller *Group) LeaveGroup(c *fiber.Ctx) error {
+	var request domainGroup.LeaveGroupRequest
+	if jid := c.Params("jid"); jid != "" {
+		request.GroupID = jid
+	} else {
+		err := c.BodyParser(&request)
+		utils.PanicIfNeeded(err)
+	}
+
+	whatsapp.SanitizePhone(&request.GroupID)
+
+	err := controller.Service.LeaveGroup(c.UserContext(), request)
 	utils.PanicIfNeeded(err)
 
 	return c.JSON(utils.ResponseData{
@@ -69,16 +159,14 @@ func (controller *Group) CreateGroup(c *fiber.Ctx) error {
 	err := c.BodyParser(&request)
 	utils.PanicIfNeeded(err)
 
-	groupID, err := controller.Service.CreateGroup(c.UserContext(), request)
+	response, err := controller.Service.CreateGroup(c.UserContext(), request)
 	utils.PanicIfNeeded(err)
 
 	return c.JSON(utils.ResponseData{
 		Status:  200,
 		Code:    "SUCCESS",
-		Message: fmt.Sprintf("Success created group with id %s", groupID),
-		Results: map[string]string{
-			"group_id": groupID,
-		},
+		Message: fmt.Sprintf("Success created group with id %s", response.GroupID),
+		Results: response,
 	})
 }
 func (controller *Group) AddParticipants(c *fiber.Ctx) error {
@@ -148,6 +236,23 @@ func (controller *Group) manageParticipants(c *fiber.Ctx, action whatsmeow.Parti
 	})
 }
 
+// Generalized group setting toggle handler
+func (controller *Group) setGroupSetting(c *fiber.Ctx, setting string) error {
+	var request domainGroup.GroupSettingRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+	whatsapp.SanitizePhone(&request.GroupID)
+	request.Setting = setting
+	response, err := controller.Service.SetGroupSetting(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: fmt.Sprintf("Success set group %s to %t", setting, response.Enabled),
+		Results: response,
+	})
+}
+
 // Generalized requested participants handler
 func (controller *Group) handleRequestedParticipants(c *fiber.Ctx, action whatsmeow.ParticipantRequestChange, successMsg string) error {
 	var request domainGroup.GroupRequestParticipantsRequest
@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Health struct{}
+
+// InitRestHealth serves the health check for load balancers, it is registered before basic auth
+// so orchestrators can call it without credentials
+func InitRestHealth(app *fiber.App) Health {
+	rest := Health{}
+	app.Get("/health", rest.Check)
+	return rest
+}
+
+// Check answers 503 when a paired session is not connected and 200 otherwise, sessions waiting to be paired
+// don't make the instance unhealthy
+func (controller *Health) Check(c *fiber.Ctx) error {
+	sessions := whatsapp.GetSessionsHealth()
+
+	results := fiber.Map{"sessions": sessions}
+	for _, session := range sessions {
+		if session.SessionID == whatsapp.DefaultSessionID {
+			results["state"] = session.State
+			results["last_event_at"] = session.LastEventAt
+		}
+	}
+
+	for _, session := range sessions {
+		if session.IsExpectedConnected() && session.State != whatsapp.ConnectionStateConnected {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(utils.ResponseData{
+				Status:  fiber.StatusServiceUnavailable,
+				Code:    "UNHEALTHY",
+				Message: "Session " + session.SessionID + " is " + session.State,
+				Results: results,
+			})
+		}
+	}
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Healthy",
+		Results: results,
+	})
+}
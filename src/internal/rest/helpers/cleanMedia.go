@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/dustin/go-humanize"
+	"github.com/sirupsen/logrus"
+)
+
+// CleanMedia deletes downloaded media older than config.MediaRetentionDuration and returns the reclaimed bytes.
+// Media is written to a temporary file and renamed once complete, so files still being written are skipped.
+func CleanMedia(now time.Time) (deleted int, reclaimed int64, err error) {
+	entries, err := os.ReadDir(config.PathMedia)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasSuffix(entry.Name(), ".part") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// The file may have been removed in the meantime
+			continue
+		}
+		if now.Sub(info.ModTime()) < config.MediaRetentionDuration {
+			continue
+		}
+
+		if err = os.Remove(filepath.Join(config.PathMedia, entry.Name())); err != nil {
+			logrus.Warnf("Failed to delete media %s: %v", entry.Name(), err)
+			continue
+		}
+		deleted++
+		reclaimed += info.Size()
+	}
+	return deleted, reclaimed, nil
+}
+
+// StartMediaJanitor starts a goroutine that periodically deletes old downloaded media
+func StartMediaJanitor() {
+	go func() {
+		ticker := time.NewTicker(config.MediaJanitorInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			deleted, reclaimed, err := CleanMedia(time.Now())
+			if err != nil {
+				logrus.Errorf("Error cleaning media: %v", err)
+			} else if deleted > 0 {
+				logrus.Infof("Deleted %d media files, reclaimed %s", deleted, humanize.Bytes(uint64(reclaimed)))
+			}
+		}
+	}()
+
+	logrus.Infof("Media janitor started. Will delete media older than %s every %s", config.MediaRetentionDuration, config.MediaJanitorInterval)
+}
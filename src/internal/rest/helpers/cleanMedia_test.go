@@ -0,0 +1,43 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanMedia(t *testing.T) {
+	origPathMedia := config.PathMedia
+	origRetention := config.MediaRetentionDuration
+	defer func() {
+		config.PathMedia = origPathMedia
+		config.MediaRetentionDuration = origRetention
+	}()
+	config.PathMedia = t.TempDir()
+	config.MediaRetentionDuration = time.Hour
+
+	now := time.Now()
+	writeMedia := func(name string, age time.Duration) string {
+		path := filepath.Join(config.PathMedia, name)
+		assert.NoError(t, os.WriteFile(path, []byte("12345"), 0600))
+		assert.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+		return path
+	}
+
+	oldMedia := writeMedia("old.jpg", 2*time.Hour)
+	newMedia := writeMedia("new.jpg", time.Minute)
+	partialMedia := writeMedia("writing.jpg.part", 2*time.Hour)
+
+	deleted, reclaimed, err := CleanMedia(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Equal(t, int64(5), reclaimed)
+
+	assert.NoFileExists(t, oldMedia)
+	assert.FileExists(t, newMedia)
+	assert.FileExists(t, partialMedia)
+}
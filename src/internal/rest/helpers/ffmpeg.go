@@ -0,0 +1,48 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// FFmpegTimeout bounds a single ffmpeg run, a stuck conversion is killed instead of holding the request forever
+const FFmpegTimeout = 5 * time.Minute
+
+// ErrFFmpegNotInstalled is returned when ffmpeg isn't on the path
+var ErrFFmpegNotInstalled = errors.New("ffmpeg not installed")
+
+// RunFFmpeg runs ffmpeg with args until it exits, the context is done or FFmpegTimeout passed. The error carries the
+// last line ffmpeg wrote to stderr. stdout may be nil
+func RunFFmpeg(ctx context.Context, stdout io.Writer, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, FFmpegTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, exec.ErrNotFound):
+		return ErrFFmpegNotInstalled
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("ffmpeg didn't finish within %s", FFmpegTimeout)
+	case ctx.Err() != nil:
+		return ctx.Err()
+	}
+	return fmt.Errorf("%v: %s", err, lastLine(stderr.String()))
+}
+
+// lastLine returns the last non empty line of the ffmpeg output, which holds the reason of the failure
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,18 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunFFmpegNotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	assert.ErrorIs(t, RunFFmpeg(context.Background(), nil, "-version"), ErrFFmpegNotInstalled)
+}
+
+func TestLastLine(t *testing.T) {
+	assert.Equal(t, "input.wav: No such file or directory", lastLine("ffmpeg version 6\n  built with gcc\ninput.wav: No such file or directory\n\n"))
+}
@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+)
+
+// StickerSize is the width and height WhatsApp expects for stickers
+const StickerSize = 512
+
+// stickerFilter fits the image in a transparent 512x512 square, keeping the alpha channel of png input
+var stickerFilter = fmt.Sprintf(
+	"scale=%[1]d:%[1]d:force_original_aspect_ratio=decrease,format=rgba,pad=%[1]d:%[1]d:(ow-iw)/2:(oh-ih)/2:color=0x00000000",
+	StickerSize,
+)
+
+// ConvertToWebpSticker converts an image to the webp sticker format with ffmpeg.
+// Animated input is converted to an animated webp limited to 10 seconds.
+func ConvertToWebpSticker(ctx context.Context, inputPath, outputPath string, animated bool) error {
+	args := []string{"-y", "-i", inputPath}
+	if animated {
+		args = append(args, "-vf", "fps=15,"+stickerFilter, "-loop", "0", "-t", "10", "-an", "-vsync", "0")
+	} else {
+		args = append(args, "-vf", stickerFilter, "-frames:v", "1")
+	}
+	args = append(args, "-c:v", "libwebp", "-lossless", "0", "-q:v", "75", outputPath)
+	return RunFFmpeg(ctx, nil, args...)
+}
@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/webp"
+)
+
+func TestConvertToWebpSticker(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.png")
+	output := filepath.Join(dir, "output.webp")
+
+	// A wide transparent png with an opaque pixel, the sticker must keep the alpha channel
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	img.Set(100, 50, color.NRGBA{R: 255, A: 255})
+	file, err := os.Create(input)
+	assert.NoError(t, err)
+	assert.NoError(t, png.Encode(file, img))
+	assert.NoError(t, file.Close())
+
+	assert.NoError(t, ConvertToWebpSticker(context.Background(), input, output, false))
+
+	converted, err := os.Open(output)
+	assert.NoError(t, err)
+	defer converted.Close()
+	sticker, err := webp.Decode(converted)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, StickerSize, StickerSize), sticker.Bounds())
+	_, _, _, alpha := sticker.At(0, 0).RGBA()
+	assert.Zero(t, alpha)
+}
+
+func TestConvertToWebpStickerInvalidInput(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+
+	input := filepath.Join(t.TempDir(), "input.png")
+	assert.NoError(t, os.WriteFile(input, []byte("not an image"), 0600))
+
+	assert.Error(t, ConvertToWebpSticker(context.Background(), input, filepath.Join(t.TempDir(), "output.webp"), false))
+}
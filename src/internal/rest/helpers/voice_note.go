@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	// VoiceNoteMimeType is the format WhatsApp plays as a voice note bubble
+	VoiceNoteMimeType = "audio/ogg; codecs=opus"
+	// VoiceNoteWaveformSize is the number of bars of the waveform drawn in the bubble
+	VoiceNoteWaveformSize = 64
+
+	// waveformSampleRate is the rate the audio is decoded at to draw the waveform, plenty for 64 bars
+	waveformSampleRate = 8000
+)
+
+// ConvertToVoiceNote transcodes any audio ffmpeg can decode to mono opus in an ogg container, as recorded by the
+// WhatsApp apps
+func ConvertToVoiceNote(ctx context.Context, inputPath, outputPath string) error {
+	return RunFFmpeg(ctx, nil, "-y", "-i", inputPath, "-vn", "-ac", "1", "-ar", "48000", "-c:a", "libopus", "-b:a", "32k",
+		"-application", "voip", "-f", "ogg", outputPath)
+}
+
+// VoiceNoteWaveform decodes the audio and returns the waveform of the bubble with the duration in seconds
+func VoiceNoteWaveform(ctx context.Context, inputPath string) (waveform []byte, seconds uint32, err error) {
+	var stdout bytes.Buffer
+	if err = RunFFmpeg(ctx, &stdout, "-i", inputPath, "-vn", "-ac", "1", "-ar", fmt.Sprint(waveformSampleRate), "-f", "s16le", "-"); err != nil {
+		return nil, 0, err
+	}
+
+	samples := make([]int16, stdout.Len()/2)
+	if err = binary.Read(bytes.NewReader(stdout.Bytes()[:len(samples)*2]), binary.LittleEndian, samples); err != nil {
+		return nil, 0, err
+	}
+	seconds = uint32(math.Round(float64(len(samples)) / waveformSampleRate))
+	return Waveform(samples, VoiceNoteWaveformSize), max(seconds, 1), nil
+}
+
+// Waveform splits the samples into bars and scales the loudness of every bar to 0-100, the loudest bar is 100
+func Waveform(samples []int16, bars int) []byte {
+	waveform := make([]byte, bars)
+	if len(samples) == 0 {
+		return waveform
+	}
+
+	levels := make([]float64, bars)
+	var loudest float64
+	for i := range levels {
+		start, end := i*len(samples)/bars, (i+1)*len(samples)/bars
+		if end <= start {
+			continue
+		}
+		var sum float64
+		for _, sample := range samples[start:end] {
+			sum += float64(sample) * float64(sample)
+		}
+		levels[i] = math.Sqrt(sum / float64(end-start))
+		loudest = max(loudest, levels[i])
+	}
+	if loudest == 0 {
+		return waveform
+	}
+	for i, level := range levels {
+		waveform[i] = byte(math.Round(level / loudest * 100))
+	}
+	return waveform
+}
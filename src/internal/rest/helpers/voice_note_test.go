@@ -0,0 +1,54 @@
+package helpers
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaveform(t *testing.T) {
+	// A silent first half and a loud second half
+	samples := make([]int16, 800)
+	for i := 400; i < 800; i++ {
+		samples[i] = 10000
+		if i%2 == 0 {
+			samples[i] = -10000
+		}
+	}
+	waveform := Waveform(samples, 4)
+	assert.Equal(t, []byte{0, 0, 100, 100}, waveform)
+
+	assert.Equal(t, make([]byte, 64), Waveform(nil, 64))
+	assert.Equal(t, make([]byte, 4), Waveform(make([]int16, 10), 4))
+	// More bars than samples leaves the empty bars at 0
+	assert.Len(t, Waveform([]int16{100, 200}, 4), 4)
+}
+
+func TestConvertToVoiceNote(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.wav")
+	output := filepath.Join(dir, "output.ogg")
+	require.NoError(t, exec.Command("ffmpeg", "-f", "lavfi", "-i", "sine=frequency=440:duration=2", input).Run())
+
+	assert.NoError(t, ConvertToVoiceNote(context.Background(), input, output))
+	converted, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "OggS", string(converted[:4]))
+	assert.Contains(t, string(converted[:64]), "OpusHead")
+
+	waveform, seconds, err := VoiceNoteWaveform(context.Background(), output)
+	assert.NoError(t, err)
+	assert.Len(t, waveform, VoiceNoteWaveformSize)
+	assert.Equal(t, uint32(2), seconds)
+
+	assert.Error(t, ConvertToVoiceNote(context.Background(), filepath.Join(dir, "missing.wav"), output))
+}
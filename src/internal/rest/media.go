@@ -0,0 +1,29 @@
+package rest
+
+import (
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Media struct{}
+
+// InitRestMedia serves downloaded media through signed urls, it is registered before basic auth
+// because the signature is the authentication. Only registered in url media mode
+func InitRestMedia(app *fiber.App) Media {
+	rest := Media{}
+	app.Get("/media/:id", rest.Download)
+	return rest
+}
+
+func (controller *Media) Download(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	err := whatsapp.VerifyMediaSignature(id, c.Query("expires"), c.Query("signature"))
+	utils.PanicIfNeeded(err)
+
+	path, err := whatsapp.MediaFilePath(id)
+	utils.PanicIfNeeded(err)
+
+	return c.SendFile(path)
+}
@@ -11,7 +11,7 @@ type Message struct {
 	Service domainMessage.IMessageService
 }
 
-func InitRestMessage(app *fiber.App, service domainMessage.IMessageService) Message {
+func InitRestMessage(app fiber.Router, service domainMessage.IMessageService) Message {
 	rest := Message{Service: service}
 	app.Post("/message/:message_id/reaction", rest.ReactMessage)
 	app.Post("/message/:message_id/revoke", rest.RevokeMessage)
@@ -20,6 +20,10 @@ func InitRestMessage(app *fiber.App, service domainMessage.IMessageService) Mess
 	app.Post("/message/:message_id/read", rest.MarkAsRead)
 	app.Post("/message/:message_id/star", rest.StarMessage)
 	app.Post("/message/:message_id/unstar", rest.UnstarMessage)
+	app.Get("/message/:message_id/media", rest.DownloadMedia)
+	app.Get("/message/:message_id/reactions", rest.MessageReactions)
+	app.Post("/message/:message_id/forward", rest.ForwardMessage)
+	app.Get("/message/:message_id/status", rest.MessageStatus)
 	return rest
 }
 
@@ -156,3 +160,77 @@ func (controller *Message) UnstarMessage(c *fiber.Ctx) error {
 		Results: nil,
 	})
 }
+
+func (controller *Message) DownloadMedia(c *fiber.Ctx) error {
+	var request domainMessage.DownloadMediaRequest
+	request.MessageID = c.Params("message_id")
+
+	response, err := controller.Service.DownloadMedia(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	c.Set(fiber.HeaderContentType, response.MimeType)
+	if response.FileName != "" {
+		c.Attachment(response.FileName)
+	}
+	return c.Send(response.Data)
+}
+
+func (controller *Message) MessageReactions(c *fiber.Ctx) error {
+	var request domainMessage.ReactionsRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	request.MessageID = c.Params("message_id")
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.GetReactions(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get message reactions",
+		Results: response,
+	})
+}
+
+func (controller *Message) ForwardMessage(c *fiber.Ctx) error {
+	var request domainMessage.ForwardRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	request.MessageID = c.Params("message_id")
+	whatsapp.SanitizePhone(&request.Phone)
+	for i := range request.Phones {
+		whatsapp.SanitizePhone(&request.Phones[i])
+	}
+
+	response, err := controller.Service.ForwardMessage(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Message) MessageStatus(c *fiber.Ctx) error {
+	var request domainMessage.StatusRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	request.MessageID = c.Params("message_id")
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.GetMessageStatus(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get message status",
+		Results: response,
+	})
+}
@@ -0,0 +1,16 @@
+package rest
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Metrics struct{}
+
+// InitRestMetrics exposes the prometheus metrics
+func InitRestMetrics(app fiber.Router) Metrics {
+	rest := Metrics{}
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+	return rest
+}
@@ -10,12 +10,42 @@ type Newsletter struct {
 	Service domainNewsletter.INewsletterService
 }
 
-func InitRestNewsletter(app *fiber.App, service domainNewsletter.INewsletterService) Newsletter {
+func InitRestNewsletter(app fiber.Router, service domainNewsletter.INewsletterService) Newsletter {
 	rest := Newsletter{Service: service}
+	app.Get("/newsletter", rest.List)
+	app.Post("/newsletter/follow", rest.Follow)
 	app.Post("/newsletter/unfollow", rest.Unfollow)
 	return rest
 }
 
+func (controller *Newsletter) List(c *fiber.Ctx) error {
+	response, err := controller.Service.List(c.UserContext())
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get list newsletter",
+		Results: response,
+	})
+}
+
+func (controller *Newsletter) Follow(c *fiber.Ctx) error {
+	var request domainNewsletter.FollowRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.Follow(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success follow newsletter",
+		Results: response,
+	})
+}
+
 func (controller *Newsletter) Unfollow(c *fiber.Ctx) error {
 	var request domainNewsletter.UnfollowRequest
 	err := c.BodyParser(&request)
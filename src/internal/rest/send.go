@@ -1,7 +1,11 @@
 package rest
 
 import (
+	"fmt"
+	"time"
+
 	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
 	"github.com/gofiber/fiber/v2"
@@ -11,7 +15,7 @@ type Send struct {
 	Service domainSend.ISendService
 }
 
-func InitRestSend(app *fiber.App, service domainSend.ISendService) Send {
+func InitRestSend(app fiber.Router, service domainSend.ISendService) Send {
 	rest := Send{Service: service}
 	app.Post("/send/message", rest.SendText)
 	app.Post("/send/image", rest.SendImage)
@@ -20,9 +24,21 @@ func InitRestSend(app *fiber.App, service domainSend.ISendService) Send {
 	app.Post("/send/contact", rest.SendContact)
 	app.Post("/send/link", rest.SendLink)
 	app.Post("/send/location", rest.SendLocation)
+	app.Post("/send/live-location", rest.SendLiveLocation)
+	app.Post("/send/live-location/:message_id/update", rest.UpdateLiveLocation)
+	app.Post("/send/live-location/:message_id/stop", rest.StopLiveLocation)
 	app.Post("/send/audio", rest.SendAudio)
+	app.Post("/send/sticker", rest.SendSticker)
 	app.Post("/send/poll", rest.SendPoll)
+	app.Post("/send/buttons", rest.SendButtons)
+	app.Post("/send/template-buttons", rest.SendTemplateButtons)
+	app.Post("/send/list", rest.SendList)
 	app.Post("/send/presence", rest.SendPresence)
+	app.Post("/send/batch", rest.SendBatch)
+	app.Post("/send/chat-presence", rest.SendChatPresence)
+	app.Post("/send/schedule", rest.ScheduleMessage)
+	app.Get("/send/schedule", rest.ListScheduledMessages)
+	app.Delete("/send/schedule/:id", rest.CancelScheduledMessage)
 	return rest
 }
 
@@ -113,6 +129,28 @@ func (controller *Send) SendVideo(c *fiber.Ctx) error {
 	})
 }
 
+func (controller *Send) SendSticker(c *fiber.Ctx) error {
+	var request domainSend.StickerRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	sticker, err := c.FormFile("sticker")
+	utils.PanicIfNeeded(err)
+
+	request.Sticker = sticker
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.SendSticker(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
 func (controller *Send) SendContact(c *fiber.Ctx) error {
 	var request domainSend.ContactRequest
 	err := c.BodyParser(&request)
@@ -167,6 +205,56 @@ func (controller *Send) SendLocation(c *fiber.Ctx) error {
 	})
 }
 
+func (controller *Send) SendLiveLocation(c *fiber.Ctx) error {
+	var request domainSend.LiveLocationRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.SendLiveLocation(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Send) UpdateLiveLocation(c *fiber.Ctx) error {
+	var request domainSend.UpdateLiveLocationRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+	request.MessageID = c.Params("message_id")
+
+	response, err := controller.Service.UpdateLiveLocation(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Send) StopLiveLocation(c *fiber.Ctx) error {
+	var request domainSend.StopLiveLocationRequest
+	request.MessageID = c.Params("message_id")
+
+	response, err := controller.Service.StopLiveLocation(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
 func (controller *Send) SendAudio(c *fiber.Ctx) error {
 	var request domainSend.AudioRequest
 	err := c.BodyParser(&request)
@@ -207,6 +295,60 @@ func (controller *Send) SendPoll(c *fiber.Ctx) error {
 	})
 }
 
+func (controller *Send) SendButtons(c *fiber.Ctx) error {
+	var request domainSend.ButtonsRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.SendButtons(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Send) SendTemplateButtons(c *fiber.Ctx) error {
+	var request domainSend.TemplateButtonsRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.SendTemplateButtons(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Send) SendList(c *fiber.Ctx) error {
+	var request domainSend.ListRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.SendList(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
 func (controller *Send) SendPresence(c *fiber.Ctx) error {
 	var request domainSend.PresenceRequest
 	err := c.BodyParser(&request)
@@ -222,3 +364,95 @@ func (controller *Send) SendPresence(c *fiber.Ctx) error {
 		Results: response,
 	})
 }
+
+func (controller *Send) SendBatch(c *fiber.Ctx) error {
+	var request domainSend.BatchRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	for i := range request.Phones {
+		whatsapp.SanitizePhone(&request.Phones[i])
+	}
+
+	response, err := controller.Service.SendBatch(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Send) SendChatPresence(c *fiber.Ctx) error {
+	var request domainSend.ChatPresenceRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.SendChatPresence(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Send) ScheduleMessage(c *fiber.Ctx) error {
+	var request domainSend.ScheduleRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	// The uploads of the media messages are not kept until the message is due
+	if form, err := c.MultipartForm(); err == nil && len(form.File) > 0 {
+		utils.PanicIfNeeded(pkgError.ValidationError("only text messages can be scheduled, files are not accepted"))
+	}
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.ScheduleMessage(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: fmt.Sprintf("Message scheduled at %s", response.SendAt.Format(time.RFC3339)),
+		Results: response,
+	})
+}
+
+func (controller *Send) ListScheduledMessages(c *fiber.Ctx) error {
+	var request domainSend.ListScheduledRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.ListScheduledMessages(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get scheduled messages",
+		Results: response,
+	})
+}
+
+func (controller *Send) CancelScheduledMessage(c *fiber.Ctx) error {
+	var request domainSend.CancelScheduledRequest
+	request.ID = c.Params("id")
+
+	response, err := controller.Service.CancelScheduledMessage(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Scheduled message cancelled",
+		Results: response,
+	})
+}
@@ -0,0 +1,62 @@
+package rest
+
+import (
+	domainStatus "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/status"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Status struct {
+	Service domainStatus.IStatusService
+}
+
+func InitRestStatus(app fiber.Router, service domainStatus.IStatusService) Status {
+	rest := Status{Service: service}
+	app.Get("/status", rest.ListStatuses)
+	app.Post("/status", rest.PostStatus)
+	return rest
+}
+
+func (controller *Status) PostStatus(c *fiber.Ctx) error {
+	var request domainStatus.PostStatusRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	file, err := c.FormFile("media")
+	if err == nil {
+		request.Media = file
+	}
+
+	for i := range request.Recipients {
+		whatsapp.SanitizePhone(&request.Recipients[i])
+	}
+
+	response, err := controller.Service.PostStatus(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: response.Status,
+		Results: response,
+	})
+}
+
+func (controller *Status) ListStatuses(c *fiber.Ctx) error {
+	var request domainStatus.ListStatusesRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.ListStatuses(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get statuses",
+		Results: response,
+	})
+}
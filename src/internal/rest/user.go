@@ -11,16 +11,25 @@ type User struct {
 	Service domainUser.IUserService
 }
 
-func InitRestUser(app *fiber.App, service domainUser.IUserService) User {
+func InitRestUser(app fiber.Router, service domainUser.IUserService) User {
 	rest := User{Service: service}
 	app.Get("/user/info", rest.UserInfo)
 	app.Get("/user/avatar", rest.UserAvatar)
+	app.Get("/user/contact", rest.UserContactInfo)
 	app.Post("/user/avatar", rest.UserChangeAvatar)
+	app.Delete("/user/avatar", rest.UserRemoveAvatar)
 	app.Post("/user/pushname", rest.UserChangePushName)
+	app.Post("/user/status", rest.UserChangeStatus)
+	app.Get("/user/presence/subscriptions", rest.UserPresenceSubscriptions)
+	app.Post("/user/presence/subscribe", rest.UserSubscribePresence)
+	app.Post("/user/presence/unsubscribe", rest.UserUnsubscribePresence)
 	app.Get("/user/my/privacy", rest.UserMyPrivacySetting)
 	app.Get("/user/my/groups", rest.UserMyListGroups)
 	app.Get("/user/my/newsletters", rest.UserMyListNewsletter)
 	app.Get("/user/my/contacts", rest.UserMyListContacts)
+	app.Get("/user/business/catalog", rest.UserBusinessCatalog)
+	app.Get("/user/business/product", rest.UserBusinessProduct)
+	app.Get("/user/jid", rest.UserJIDInfo)
 
 	return rest
 }
@@ -61,6 +70,24 @@ func (controller *User) UserAvatar(c *fiber.Ctx) error {
 	})
 }
 
+func (controller *User) UserContactInfo(c *fiber.Ctx) error {
+	var request domainUser.ContactInfoRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.ContactInfo(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get contact info",
+		Results: response,
+	})
+}
+
 func (controller *User) UserChangeAvatar(c *fiber.Ctx) error {
 	var request domainUser.ChangeAvatarRequest
 	err := c.BodyParser(&request)
@@ -69,13 +96,26 @@ func (controller *User) UserChangeAvatar(c *fiber.Ctx) error {
 	request.Avatar, err = c.FormFile("avatar")
 	utils.PanicIfNeeded(err)
 
-	err = controller.Service.ChangeAvatar(c.UserContext(), request)
+	response, err := controller.Service.ChangeAvatar(c.UserContext(), request)
 	utils.PanicIfNeeded(err)
 
 	return c.JSON(utils.ResponseData{
 		Status:  200,
 		Code:    "SUCCESS",
 		Message: "Success change avatar",
+		Results: response,
+	})
+}
+
+func (controller *User) UserRemoveAvatar(c *fiber.Ctx) error {
+	response, err := controller.Service.RemoveAvatar(c.UserContext())
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success remove avatar",
+		Results: response,
 	})
 }
 
@@ -132,12 +172,128 @@ func (controller *User) UserChangePushName(c *fiber.Ctx) error {
 	err := c.BodyParser(&request)
 	utils.PanicIfNeeded(err)
 
-	err = controller.Service.ChangePushName(c.UserContext(), request)
+	response, err := controller.Service.ChangePushName(c.UserContext(), request)
 	utils.PanicIfNeeded(err)
 
 	return c.JSON(utils.ResponseData{
 		Status:  200,
 		Code:    "SUCCESS",
 		Message: "Success change push name",
+		Results: response,
+	})
+}
+
+func (controller *User) UserChangeStatus(c *fiber.Ctx) error {
+	var request domainUser.ChangeStatusRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.ChangeStatus(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success change status",
+		Results: response,
+	})
+}
+
+func (controller *User) UserSubscribePresence(c *fiber.Ctx) error {
+	var request domainUser.PresenceSubscriptionRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.SubscribePresence(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success subscribe presence",
+		Results: response,
+	})
+}
+
+func (controller *User) UserUnsubscribePresence(c *fiber.Ctx) error {
+	var request domainUser.PresenceSubscriptionRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	err = controller.Service.UnsubscribePresence(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success unsubscribe presence",
+	})
+}
+
+func (controller *User) UserPresenceSubscriptions(c *fiber.Ctx) error {
+	response, err := controller.Service.PresenceSubscriptions(c.UserContext())
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get presence subscriptions",
+		Results: response,
+	})
+}
+
+func (controller *User) UserBusinessCatalog(c *fiber.Ctx) error {
+	var request domainUser.BusinessCatalogRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.BusinessCatalog(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get business catalog",
+		Results: response,
+	})
+}
+
+func (controller *User) UserBusinessProduct(c *fiber.Ctx) error {
+	var request domainUser.BusinessProductRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	whatsapp.SanitizePhone(&request.Phone)
+
+	response, err := controller.Service.BusinessProduct(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get business product",
+		Results: response,
+	})
+}
+
+func (controller *User) UserJIDInfo(c *fiber.Ctx) error {
+	var request domainUser.JIDInfoRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.JIDInfo(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get jid info",
+		Results: response,
 	})
 }
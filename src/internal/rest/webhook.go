@@ -0,0 +1,79 @@
+package rest
+
+import (
+	domainWebhook "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/webhook"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type Webhook struct {
+	Service domainWebhook.IWebhookService
+}
+
+func InitRestWebhook(app *fiber.App, service domainWebhook.IWebhookService) Webhook {
+	rest := Webhook{Service: service}
+	app.Get("/webhooks", rest.List)
+	app.Post("/webhooks", rest.Add)
+	app.Delete("/webhooks", rest.Delete)
+	app.Post("/webhook/test", rest.Test)
+	return rest
+}
+
+func (controller *Webhook) List(c *fiber.Ctx) error {
+	response, err := controller.Service.List(c.UserContext())
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success get list webhooks",
+		Results: response,
+	})
+}
+
+func (controller *Webhook) Add(c *fiber.Ctx) error {
+	var request domainWebhook.AddWebhookRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.Add(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success add webhook",
+		Results: response,
+	})
+}
+
+func (controller *Webhook) Delete(c *fiber.Ctx) error {
+	var request domainWebhook.DeleteWebhookRequest
+	err := c.QueryParser(&request)
+	utils.PanicIfNeeded(err)
+
+	err = controller.Service.Delete(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success delete webhook",
+	})
+}
+
+func (controller *Webhook) Test(c *fiber.Ctx) error {
+	var request domainWebhook.TestWebhookRequest
+	err := c.BodyParser(&request)
+	utils.PanicIfNeeded(err)
+
+	response, err := controller.Service.Test(c.UserContext(), request)
+	utils.PanicIfNeeded(err)
+
+	return c.JSON(utils.ResponseData{
+		Status:  200,
+		Code:    "SUCCESS",
+		Message: "Success test webhook",
+		Results: response,
+	})
+}
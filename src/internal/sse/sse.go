@@ -0,0 +1,170 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	subscriberBuffer = 256 // Events buffered for a client before new ones are dropped
+	// Events are still published this long after the last client left, so a client reconnecting with Last-Event-ID
+	// gets the events it missed meanwhile
+	reconnectGrace = time.Minute
+)
+
+// event is a published payload, kept in the replay buffer
+type event struct {
+	id        uint64
+	eventType string
+	sessionID string
+	data      []byte
+}
+
+// frame is the event as it's written to the stream
+func (evt event) frame() (Frame, error) {
+	return Frame{ID: strconv.FormatUint(evt.id, 10), Data: evt.data}, nil
+}
+
+type subscriber struct {
+	send       chan event
+	eventTypes []string // empty means every event type
+	sessionID  string   // empty means every session
+	dropped    atomic.Uint64
+}
+
+func (sub *subscriber) wants(evt event) bool {
+	if sub.sessionID != "" && sub.sessionID != evt.sessionID {
+		return false
+	}
+	return len(sub.eventTypes) == 0 || slices.Contains(sub.eventTypes, evt.eventType)
+}
+
+var (
+	mu          sync.Mutex
+	subscribers = make(map[*subscriber]struct{})
+	replay      []event // oldest first, at most config.WhatsappSSEBufferSize events
+	// Ids start at the boot time so the ids of a restarted server are higher than the ones a client saw before
+	nextID = uint64(time.Now().UnixMicro())
+	// connected counts the streams, lastLeft is the unix nano time the last one closed
+	connected atomic.Int64
+	lastLeft  atomic.Int64
+)
+
+// IsActive reports whether the events have to be published, which is the case while a client is connected
+// and for reconnectGrace after the last one left
+func IsActive() bool {
+	if connected.Load() > 0 {
+		return true
+	}
+	left := lastLeft.Load()
+	return left > 0 && time.Since(time.Unix(0, left)) < reconnectGrace
+}
+
+// PublishEvent assigns the next id to the payload, keeps it for replay and sends it to the connected clients.
+// It never blocks: a client with a full buffer misses the event
+func PublishEvent(eventType, sessionID string, payload any) {
+	if !IsActive() {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logrus.Errorf("Failed to marshal %s event for the sse stream: %v", eventType, err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	nextID++
+	evt := event{id: nextID, eventType: eventType, sessionID: sessionID, data: data}
+	if config.WhatsappSSEBufferSize > 0 {
+		replay = append(replay, evt)
+		if overflow := len(replay) - config.WhatsappSSEBufferSize; overflow > 0 {
+			replay = slices.Delete(replay, 0, overflow)
+		}
+	}
+
+	for sub := range subscribers {
+		if !sub.wants(evt) {
+			continue
+		}
+		select {
+		case sub.send <- evt:
+		default:
+			dropped := sub.dropped.Add(1)
+			logrus.Warnf("SSE client is too slow, dropping %s event (total dropped: %d)", eventType, dropped)
+		}
+	}
+}
+
+// subscribe registers the client and returns the buffered events after lastEventID it is interested in,
+// both under the same lock so no event is missed or sent twice
+func subscribe(sub *subscriber, lastEventID uint64, hasLastEventID bool) []event {
+	mu.Lock()
+	defer mu.Unlock()
+
+	connected.Add(1)
+	subscribers[sub] = struct{}{}
+	if !hasLastEventID {
+		return nil
+	}
+
+	var missed []event
+	for _, evt := range replay {
+		if evt.id > lastEventID && sub.wants(evt) {
+			missed = append(missed, evt)
+		}
+	}
+	return missed
+}
+
+func unsubscribe(sub *subscriber) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := subscribers[sub]; !ok {
+		return
+	}
+	delete(subscribers, sub)
+	lastLeft.Store(time.Now().UnixNano())
+	connected.Add(-1)
+}
+
+// RegisterRoutes serves /events/sse, which streams the webhook payloads of the given event types as server-sent events.
+// Clients pick the events with ?events=message,receipt and a session with ?session_id=sales, the Last-Event-ID header
+// (or ?last_event_id= for the first connection) replays the buffered events that came after it
+func RegisterRoutes(app *fiber.App, eventTypes []string) {
+	app.Get("/events/sse", func(c *fiber.Ctx) error {
+		selected, err := utils.ParseEventTypes(c.Query("events"), eventTypes)
+		if err != nil {
+			utils.PanicIfNeeded(pkgError.ValidationError(err.Error()))
+		}
+
+		lastEventIDValue := c.Get("Last-Event-ID", c.Query("last_event_id"))
+		var lastEventID uint64
+		if lastEventIDValue != "" {
+			if lastEventID, err = strconv.ParseUint(lastEventIDValue, 10, 64); err != nil {
+				utils.PanicIfNeeded(pkgError.ValidationError(fmt.Sprintf("last event id %q must be a number", lastEventIDValue)))
+			}
+		}
+
+		sub := &subscriber{
+			send:       make(chan event, subscriberBuffer),
+			eventTypes: selected,
+			sessionID:  c.Query("session_id"),
+		}
+		missed := subscribe(sub, lastEventID, lastEventIDValue != "")
+		Stream(c, missed, sub.send, event.frame, func() { unsubscribe(sub) })
+		return nil
+	})
+}
@@ -0,0 +1,191 @@
+package sse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetBroker(t *testing.T, bufferSize int) {
+	oldBufferSize := config.WhatsappSSEBufferSize
+	config.WhatsappSSEBufferSize = bufferSize
+	mu.Lock()
+	replay = nil
+	subscribers = make(map[*subscriber]struct{})
+	mu.Unlock()
+	// Publishing as if a client just left
+	connected.Store(0)
+	lastLeft.Store(time.Now().UnixNano())
+	t.Cleanup(func() {
+		config.WhatsappSSEBufferSize = oldBufferSize
+		lastLeft.Store(0)
+	})
+}
+
+func TestPublishEventKeepsReplayBuffer(t *testing.T) {
+	resetBroker(t, 2)
+
+	for i := 0; i < 3; i++ {
+		PublishEvent("message", "default", map[string]any{"n": i})
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, replay, 2)
+	assert.JSONEq(t, `{"n":1}`, string(replay[0].data))
+	assert.Equal(t, replay[0].id+1, replay[1].id)
+}
+
+func TestSubscribeReplaysMissedEvents(t *testing.T) {
+	resetBroker(t, 10)
+
+	PublishEvent("message", "default", map[string]any{"n": 1})
+	mu.Lock()
+	firstID := replay[0].id
+	mu.Unlock()
+	PublishEvent("receipt", "default", map[string]any{"n": 2})
+	PublishEvent("message", "sales", map[string]any{"n": 3})
+	PublishEvent("message", "default", map[string]any{"n": 4})
+
+	sub := &subscriber{send: make(chan event, 1), eventTypes: []string{"message"}, sessionID: "default"}
+	missed := subscribe(sub, firstID, true)
+	defer unsubscribe(sub)
+	assert.Len(t, missed, 1)
+	assert.JSONEq(t, `{"n":4}`, string(missed[0].data))
+
+	// Without Last-Event-ID only live events are sent, and a full buffer drops instead of blocking
+	fresh := &subscriber{send: make(chan event, 1)}
+	assert.Empty(t, subscribe(fresh, 0, false))
+	defer unsubscribe(fresh)
+	PublishEvent("message", "default", map[string]any{"n": 5})
+	PublishEvent("message", "default", map[string]any{"n": 6})
+	assert.Len(t, fresh.send, 1)
+	assert.Equal(t, uint64(1), fresh.dropped.Load())
+}
+
+func TestIsActiveCountsSubscribers(t *testing.T) {
+	resetBroker(t, 10)
+	lastLeft.Store(0)
+	assert.False(t, IsActive())
+
+	first := &subscriber{send: make(chan event, 1)}
+	second := &subscriber{send: make(chan event, 1)}
+	subscribe(first, 0, false)
+	subscribe(second, 0, false)
+	assert.True(t, IsActive())
+
+	unsubscribe(first)
+	unsubscribe(first)
+	assert.Equal(t, int64(1), connected.Load())
+	unsubscribe(second)
+	assert.Equal(t, int64(0), connected.Load())
+
+	// The events are kept a while for the clients reconnecting, then no longer
+	assert.True(t, IsActive())
+	lastLeft.Store(time.Now().Add(-reconnectGrace).UnixNano())
+	assert.False(t, IsActive())
+}
+
+func TestPublishEventInactive(t *testing.T) {
+	resetBroker(t, 10)
+	lastLeft.Store(0)
+
+	PublishEvent("message", "default", map[string]any{"n": 1})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Empty(t, replay)
+}
+
+func TestStreamEndpoint(t *testing.T) {
+	resetBroker(t, 10)
+	PublishEvent("message", "default", map[string]any{"event_type": "message", "n": 1})
+	PublishEvent("message", "default", map[string]any{"event_type": "message", "n": 2})
+	mu.Lock()
+	firstID := replay[0].id
+	mu.Unlock()
+
+	// A short heartbeat lets the stream notice the closed client, so the shutdown doesn't wait for it
+	oldHeartbeat := config.WhatsappSSEHeartbeat
+	config.WhatsappSSEHeartbeat = 50 * time.Millisecond
+	defer func() { config.WhatsappSSEHeartbeat = oldHeartbeat }()
+
+	app := fiber.New()
+	RegisterRoutes(app, []string{"message", "receipt"})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(listener) }()
+	defer func() { _ = app.Shutdown() }()
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/events/sse?events=message", listener.Addr()), nil)
+	req.Header.Set("Last-Event-ID", fmt.Sprint(firstID))
+	resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readFrame := func() string {
+		var frame strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if line == "\n" {
+				if frame.String() == ": heartbeat\n" {
+					frame.Reset()
+					continue
+				}
+				return frame.String()
+			}
+			frame.WriteString(line)
+		}
+	}
+
+	assert.Equal(t, ": connected\n", readFrame())
+	assert.Equal(t, fmt.Sprintf("id: %d\ndata: {\"event_type\":\"message\",\"n\":2}\n", firstID+1), readFrame())
+
+	PublishEvent("receipt", "default", map[string]any{"event_type": "receipt"})
+	PublishEvent("message", "default", map[string]any{"event_type": "message", "n": 3})
+	assert.Equal(t, fmt.Sprintf("id: %d\ndata: {\"event_type\":\"message\",\"n\":3}\n", firstID+3), readFrame())
+}
+
+func TestStreamEndsAfterLastFrame(t *testing.T) {
+	events := make(chan string, 2)
+	done := make(chan struct{})
+	app := fiber.New()
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		frame := func(evt string) (Frame, error) {
+			return Frame{Event: evt, Data: []byte(`{}`), Last: evt == "success"}, nil
+		}
+		Stream(c, []string{"qr"}, events, frame, func() { close(done) })
+		return nil
+	})
+	events <- "success"
+	events <- "qr"
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/stream", nil), 5000)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, ": connected\n\nevent: qr\ndata: {}\n\nevent: success\ndata: {}\n\n", string(body))
+	assert.Len(t, events, 1)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("done was not called once the stream ended")
+	}
+}
@@ -0,0 +1,89 @@
+package sse
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// Frame is an event of a stream, ID lets the client resume with Last-Event-ID and Event names it for
+// EventSource.addEventListener, both are left out when empty
+type Frame struct {
+	ID    string
+	Event string
+	Data  []byte
+	// Last ends the stream once the frame is written
+	Last bool
+}
+
+// Stream answers c with an event stream: the backlog first, then the events until the client is gone, a Last frame
+// was written or events is closed. frame turns an event into what's written, an error ends the stream.
+// done runs once the stream ended, the caller releases its subscription there
+func Stream[T any](c *fiber.Ctx, backlog []T, events <-chan T, frame func(T) (Frame, error), done func()) {
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // nginx would buffer the stream otherwise
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer done()
+
+		// Flushes the headers right away, so the client knows it is connected before the first event
+		if writeComment(w, "connected") != nil {
+			return
+		}
+		write := func(evt T) bool {
+			f, err := frame(evt)
+			return err == nil && writeFrame(w, f) == nil && !f.Last
+		}
+		for _, evt := range backlog {
+			if !write(evt) {
+				return
+			}
+		}
+
+		// Comments keep proxies from closing an idle stream, a failed write means the client is gone
+		heartbeat := time.NewTicker(config.WhatsappSSEHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case evt, ok := <-events:
+				if !ok || !write(evt) {
+					return
+				}
+			case <-heartbeat.C:
+				if writeComment(w, "heartbeat") != nil {
+					return
+				}
+			}
+		}
+	}))
+}
+
+func writeFrame(w *bufio.Writer, frame Frame) error {
+	if frame.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", frame.ID); err != nil {
+			return err
+		}
+	}
+	if frame.Event != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", frame.Event); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", frame.Data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func writeComment(w *bufio.Writer, comment string) error {
+	if _, err := fmt.Fprintf(w, ": %s\n\n", comment); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,137 @@
+package websocket
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	eventSubscriberBuffer = 256              // Events buffered for a client before new ones are dropped
+	eventWriteTimeout     = 10 * time.Second // A client that can't take a message in time is disconnected
+)
+
+// eventSubscriber is a client of /ws/events, it has its own buffer so a slow client never holds the others
+type eventSubscriber struct {
+	send       chan []byte
+	eventTypes []string // empty means every event type
+	sessionID  string   // empty means every session
+	dropped    atomic.Uint64
+}
+
+var (
+	eventSubscribers   = make(map[*eventSubscriber]struct{})
+	eventSubscribersMu sync.RWMutex
+)
+
+func (sub *eventSubscriber) wants(eventType, sessionID string) bool {
+	if sub.sessionID != "" && sub.sessionID != sessionID {
+		return false
+	}
+	return len(sub.eventTypes) == 0 || slices.Contains(sub.eventTypes, eventType)
+}
+
+func addEventSubscriber(sub *eventSubscriber) {
+	eventSubscribersMu.Lock()
+	defer eventSubscribersMu.Unlock()
+	eventSubscribers[sub] = struct{}{}
+}
+
+func removeEventSubscriber(sub *eventSubscriber) {
+	eventSubscribersMu.Lock()
+	defer eventSubscribersMu.Unlock()
+	delete(eventSubscribers, sub)
+}
+
+// HasEventSubscribers reports whether a client is connected to /ws/events
+func HasEventSubscribers() bool {
+	eventSubscribersMu.RLock()
+	defer eventSubscribersMu.RUnlock()
+	return len(eventSubscribers) > 0
+}
+
+// PublishEvent streams the payload to the /ws/events clients that subscribed to the event type and session,
+// it never blocks: a client with a full buffer misses the event
+func PublishEvent(eventType, sessionID string, payload any) {
+	eventSubscribersMu.RLock()
+	defer eventSubscribersMu.RUnlock()
+	if len(eventSubscribers) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logrus.Errorf("Failed to marshal %s event for the websocket stream: %v", eventType, err)
+		return
+	}
+
+	for sub := range eventSubscribers {
+		if !sub.wants(eventType, sessionID) {
+			continue
+		}
+		select {
+		case sub.send <- data:
+		default:
+			dropped := sub.dropped.Add(1)
+			logrus.Warnf("Websocket event client is too slow, dropping %s event (total dropped: %d)", eventType, dropped)
+		}
+	}
+}
+
+// RegisterEventRoutes serves /ws/events, which streams the webhook payloads of the given event types.
+// Clients pick the events with ?events=message,receipt and a session with ?session_id=sales
+func RegisterEventRoutes(app *fiber.App, eventTypes []string) {
+	handler := websocket.New(func(conn *websocket.Conn) {
+		sub := conn.Locals("subscriber").(*eventSubscriber)
+		addEventSubscriber(sub)
+		defer func() {
+			removeEventSubscriber(sub)
+			_ = conn.Close()
+		}()
+
+		// The stream is one way, reading only notices the client going away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case data := <-sub.send:
+				_ = conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+				if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+					logrus.Warnf("Websocket event client disconnected: %v", err)
+					return
+				}
+			}
+		}
+	})
+
+	app.Get("/ws/events", func(c *fiber.Ctx) error {
+		selected, err := utils.ParseEventTypes(c.Query("events"), eventTypes)
+		if err != nil {
+			utils.PanicIfNeeded(pkgError.ValidationError(err.Error()))
+		}
+		c.Locals("subscriber", &eventSubscriber{
+			send:       make(chan []byte, eventSubscriberBuffer),
+			eventTypes: selected,
+			sessionID:  c.Query("session_id"),
+		})
+		return handler(c)
+	})
+}
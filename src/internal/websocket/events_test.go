@@ -0,0 +1,43 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func subscribe(t *testing.T, sub *eventSubscriber) *eventSubscriber {
+	if sub.send == nil {
+		sub.send = make(chan []byte, eventSubscriberBuffer)
+	}
+	addEventSubscriber(sub)
+	t.Cleanup(func() { removeEventSubscriber(sub) })
+	return sub
+}
+
+func TestPublishEventFilters(t *testing.T) {
+	all := subscribe(t, &eventSubscriber{})
+	messages := subscribe(t, &eventSubscriber{eventTypes: []string{"message"}})
+	sales := subscribe(t, &eventSubscriber{sessionID: "sales"})
+	assert.True(t, HasEventSubscribers())
+
+	PublishEvent("receipt", "default", map[string]any{"event_type": "receipt"})
+
+	assert.Len(t, all.send, 1)
+	assert.Len(t, messages.send, 0)
+	assert.Len(t, sales.send, 0)
+	assert.JSONEq(t, `{"event_type":"receipt"}`, string(<-all.send))
+}
+
+func TestPublishEventDoesNotBlockOnSlowClient(t *testing.T) {
+	slow := subscribe(t, &eventSubscriber{send: make(chan []byte, 1)})
+	fast := subscribe(t, &eventSubscriber{})
+
+	for i := 0; i < 3; i++ {
+		PublishEvent("message", "default", map[string]any{"event_type": "message"})
+	}
+
+	assert.Len(t, slow.send, 1)
+	assert.Equal(t, uint64(2), slow.dropped.Load())
+	assert.Len(t, fast.send, 3)
+}
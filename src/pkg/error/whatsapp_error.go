@@ -74,4 +74,22 @@ const (
 	ErrInvalidJID        = InvalidJID("your JID is invalid")
 	ErrUserNotRegistered = InvalidJID("user is not registered")
 	ErrWaCLI             = WaCliError("your WhatsApp CLI is invalid or empty")
+	ErrAppStateNotSynced = AppStateNotSyncedError("the chat settings of this account are not synced yet, retry once the initial sync after login completed")
 )
+
+type AppStateNotSyncedError string
+
+// Error for complying the error interface
+func (e AppStateNotSyncedError) Error() string {
+	return string(e)
+}
+
+// ErrCode will return the error code based on the error data type
+func (e AppStateNotSyncedError) ErrCode() string {
+	return "APP_STATE_NOT_SYNCED"
+}
+
+// StatusCode will return the HTTP status code based on the error data type
+func (e AppStateNotSyncedError) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "whatsapp"
+
+var (
+	// WebhooksSent counts the webhook requests accepted by the consumer
+	WebhooksSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_sent_total",
+		Help:      "Webhook requests answered with a 2xx status.",
+	}, []string{"event_type"})
+
+	// WebhooksFailed counts the webhooks given up on, after the retries or because the payload couldn't be built
+	WebhooksFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_failed_total",
+		Help:      "Webhooks that could not be delivered.",
+	}, []string{"event_type"})
+
+	// WebhooksRetried counts the attempts after the first one
+	WebhooksRetried = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_retried_total",
+		Help:      "Webhook delivery attempts after the first one.",
+	}, []string{"event_type"})
+
+	// WebhookDuration observes the response time of every attempt, status_code is "error" when no response came back
+	WebhookDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "webhook_response_seconds",
+		Help:      "Response time of webhook requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"status_code"})
+
+	// WebhookCircuitState is the circuit of every webhook url that failed: 0 closed, 1 open, 2 half open (probing)
+	WebhookCircuitState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "webhook_circuit_state",
+		Help:      "Circuit breaker state of webhook urls, 0 closed, 1 open, 2 half open.",
+	}, []string{"url"})
+
+	// WebhookCircuitSkipped counts the deliveries skipped because the circuit of the url was open
+	WebhookCircuitSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_circuit_skipped_total",
+		Help:      "Webhook deliveries skipped while the circuit of the url was open.",
+	}, []string{"url"})
+
+	// MessagesReceived counts the received messages by type (text, image, video, reaction, ...)
+	MessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_received_total",
+		Help:      "Messages received from whatsapp.",
+	}, []string{"type"})
+
+	// MessagesDeduplicated counts the redelivered messages that were not forwarded again
+	MessagesDeduplicated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_deduplicated_total",
+		Help:      "Redelivered messages skipped by the deduplication.",
+	}, []string{"session_id"})
+
+	// MediaDownloadedBytes counts the bytes of media downloaded from whatsapp
+	MediaDownloadedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "media_downloaded_bytes_total",
+		Help:      "Bytes of media downloaded from whatsapp.",
+	})
+
+	// SendLimiterRejected counts the sends rejected by the send limiter in reject mode
+	SendLimiterRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "send_limiter_rejected_total",
+		Help:      "Outbound messages rejected by the send rate limiter.",
+	}, []string{"session_id"})
+
+	// SendLimiterWaitSeconds observes how long the sends waited for the send limiter in block mode
+	SendLimiterWaitSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "send_limiter_wait_seconds",
+		Help:      "Time outbound messages waited for the send rate limiter.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"session_id"})
+)
+
+// SendLimiterState is the state of the send limiter of one session at scrape time
+type SendLimiterState struct {
+	SessionID string
+	Tokens    float64
+	Rate      float64
+	Burst     float64
+}
+
+var (
+	sendLimiterTokensDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "send_limiter_tokens"),
+		"Tokens left in the send rate limiter, negative when sends are waiting.", []string{"session_id"}, nil)
+	sendLimiterRateDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "send_limiter_rate"),
+		"Messages per second allowed by the send rate limiter.", []string{"session_id"}, nil)
+	sendLimiterBurstDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "send_limiter_burst"),
+		"Burst size of the send rate limiter.", []string{"session_id"}, nil)
+)
+
+// sendLimiterCollector reads the limiter state on every scrape, so the tokens refilled while idle are reported too
+type sendLimiterCollector struct {
+	states func() []SendLimiterState
+}
+
+func (c sendLimiterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sendLimiterTokensDesc
+	ch <- sendLimiterRateDesc
+	ch <- sendLimiterBurstDesc
+}
+
+func (c sendLimiterCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, state := range c.states() {
+		ch <- prometheus.MustNewConstMetric(sendLimiterTokensDesc, prometheus.GaugeValue, state.Tokens, state.SessionID)
+		ch <- prometheus.MustNewConstMetric(sendLimiterRateDesc, prometheus.GaugeValue, state.Rate, state.SessionID)
+		ch <- prometheus.MustNewConstMetric(sendLimiterBurstDesc, prometheus.GaugeValue, state.Burst, state.SessionID)
+	}
+}
+
+// RegisterSendLimiter exposes the state of the send limiters returned by states
+func RegisterSendLimiter(states func() []SendLimiterState) {
+	prometheus.MustRegister(sendLimiterCollector{states: states})
+}
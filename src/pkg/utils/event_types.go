@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ParseEventTypes reads the comma separated events query param of the event streams, every entry must be one of eventTypes
+func ParseEventTypes(query string, eventTypes []string) ([]string, error) {
+	var selected []string
+	for _, eventType := range strings.Split(query, ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType == "" {
+			continue
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			return nil, fmt.Errorf("event %q is not supported, available events: %s", eventType, strings.Join(eventTypes, ","))
+		}
+		selected = append(selected, eventType)
+	}
+	return selected, nil
+}
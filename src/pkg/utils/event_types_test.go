@@ -0,0 +1,23 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEventTypes(t *testing.T) {
+	eventTypes := []string{"message", "receipt", "presence"}
+
+	selected, err := utils.ParseEventTypes("", eventTypes)
+	assert.NoError(t, err)
+	assert.Empty(t, selected)
+
+	selected, err = utils.ParseEventTypes(" message, receipt ,", eventTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"message", "receipt"}, selected)
+
+	_, err = utils.ParseEventTypes("message,call", eventTypes)
+	assert.EqualError(t, err, `event "call" is not supported, available events: message,receipt,presence`)
+}
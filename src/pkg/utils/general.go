@@ -81,9 +81,14 @@ type Metadata struct {
 }
 
 func GetMetaDataFromURL(urlStr string) (meta Metadata, err error) {
+	return GetMetaDataFromURLWithTimeout(urlStr, 15*time.Second)
+}
+
+// GetMetaDataFromURLWithTimeout is GetMetaDataFromURL where the page and image download share the given timeout
+func GetMetaDataFromURLWithTimeout(urlStr string, timeout time.Duration) (meta Metadata, err error) {
 	// Create HTTP client with timeout
 	client := &http.Client{
-		Timeout: 15 * time.Second,
+		Timeout: timeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			if len(via) >= 10 {
 				return fmt.Errorf("too many redirects")
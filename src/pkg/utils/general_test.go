@@ -100,6 +100,27 @@ func (suite *UtilsTestSuite) TestGetMetaDataFromURL() {
 	assert.Equal(suite.T(), "http://example.com/image.jpg", meta.Image)
 }
 
+func (suite *UtilsTestSuite) TestGetLinkPreviewIsCached() {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write([]byte(`<html><head><meta property="og:title" content="Cached Title"></head></html>`))
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		meta, err := utils.GetLinkPreview(server.URL)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), "Cached Title", meta.Title)
+	}
+	assert.Equal(suite.T(), 1, hits)
+}
+
+func (suite *UtilsTestSuite) TestExtractFirstURL() {
+	assert.Equal(suite.T(), "https://example.com/a?b=1", utils.ExtractFirstURL("look https://example.com/a?b=1 and http://other.com"))
+	assert.Equal(suite.T(), "", utils.ExtractFirstURL("no link here"))
+}
+
 func (suite *UtilsTestSuite) TestDownloadImageFromURL() {
 	// Use httptest.NewServer to mock HTTP server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
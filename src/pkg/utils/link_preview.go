@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+)
+
+var linkPattern = regexp.MustCompile(`https?://[^\s]+`)
+
+type cachedLinkPreview struct {
+	meta      Metadata
+	expiresAt time.Time
+}
+
+var (
+	linkPreviewCache   = make(map[string]cachedLinkPreview)
+	linkPreviewCacheMu sync.Mutex
+)
+
+// ExtractFirstURL returns the first http(s) url found in the text
+func ExtractFirstURL(text string) string {
+	return linkPattern.FindString(text)
+}
+
+// GetLinkPreview fetches the OpenGraph metadata of the url with config.WhatsappLinkPreviewTimeout.
+// Previews are cached for config.WhatsappLinkPreviewCacheTTL so the same url is not fetched on every message.
+func GetLinkPreview(url string) (Metadata, error) {
+	now := time.Now()
+
+	linkPreviewCacheMu.Lock()
+	cached, ok := linkPreviewCache[url]
+	linkPreviewCacheMu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.meta, nil
+	}
+
+	meta, err := GetMetaDataFromURLWithTimeout(url, config.WhatsappLinkPreviewTimeout)
+	if err != nil {
+		return meta, err
+	}
+
+	linkPreviewCacheMu.Lock()
+	defer linkPreviewCacheMu.Unlock()
+	for key, preview := range linkPreviewCache {
+		if now.After(preview.expiresAt) {
+			delete(linkPreviewCache, key)
+		}
+	}
+	linkPreviewCache[url] = cachedLinkPreview{meta: meta, expiresAt: now.Add(config.WhatsappLinkPreviewCacheTTL)}
+	return meta, nil
+}
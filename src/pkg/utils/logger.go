@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// InitLogger sets the level and the formatter of the application logs, json writes one object per line
+// for log pipelines. With redact the phone numbers and the url secrets are masked before an entry is written
+func InitLogger(level string, format string, redact bool) error {
+	logLevel, err := log.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	var formatter log.Formatter
+	switch strings.ToLower(format) {
+	case LogFormatText:
+		formatter = &log.TextFormatter{FullTimestamp: true}
+	case LogFormatJSON:
+		formatter = &log.JSONFormatter{}
+	default:
+		return fmt.Errorf("invalid log format %q, expected text or json", format)
+	}
+	if redact {
+		formatter = redactingFormatter{Formatter: formatter}
+	}
+	log.SetFormatter(formatter)
+	log.SetLevel(logLevel)
+	return nil
+}
@@ -0,0 +1,27 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitLogger(t *testing.T) {
+	t.Cleanup(func() {
+		logrus.SetLevel(logrus.InfoLevel)
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	})
+
+	assert.NoError(t, utils.InitLogger("warn", "json", false))
+	assert.Equal(t, logrus.WarnLevel, logrus.GetLevel())
+	assert.IsType(t, &logrus.JSONFormatter{}, logrus.StandardLogger().Formatter)
+
+	assert.NoError(t, utils.InitLogger("DEBUG", "text", false))
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	assert.IsType(t, &logrus.TextFormatter{}, logrus.StandardLogger().Formatter)
+
+	assert.Error(t, utils.InitLogger("verbose", "text", false))
+	assert.Error(t, utils.InitLogger("info", "xml", false))
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces calls evenly so no more than the configured rate happen, allowing bursts of up to burst calls
+// after a quiet period
+type RateLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    int
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRateLimiter allows perMinute calls per minute, 0 or less means unlimited
+func NewRateLimiter(perMinute int) *RateLimiter {
+	return NewBurstRateLimiter(float64(perMinute)/60, 1)
+}
+
+// NewBurstRateLimiter allows perSecond calls per second in bursts of up to burst calls, a rate of 0 or less
+// means unlimited
+func NewBurstRateLimiter(perSecond float64, burst int) *RateLimiter {
+	limiter := &RateLimiter{rate: perSecond, burst: max(burst, 1)}
+	if perSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return limiter
+}
+
+// reserve takes the next slot and returns how long the caller has to wait for it, the caller must hold the lock
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	if l.next.Before(now) {
+		l.next = now
+	}
+	// A full burst may run ahead of the evenly spaced slots
+	wait := l.next.Sub(now) - time.Duration(l.burst-1)*l.interval
+	l.next = l.next.Add(l.interval)
+	return wait
+}
+
+// Allow takes a slot if the caller may proceed right away, without waiting
+func (l *RateLimiter) Allow() bool {
+	if l.interval == 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	next := l.next
+	if l.reserve(time.Now()) > 0 {
+		l.next = next
+		return false
+	}
+	return true
+}
+
+// Wait blocks until the caller is allowed to proceed or the context is done
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l.interval == 0 {
+		return ctx.Err()
+	}
+
+	// The slot is reserved up front so concurrent callers queue behind each other
+	l.mu.Lock()
+	wait := l.reserve(time.Now())
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the slot back so the callers behind don't wait for a call that never happens
+		l.mu.Lock()
+		l.next = l.next.Add(-l.interval)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Tokens returns the calls that may proceed right away, negative when callers are queued
+func (l *RateLimiter) Tokens() float64 {
+	if l.interval == 0 {
+		return float64(l.burst)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ahead := max(l.next.Sub(time.Now()), 0)
+	return float64(l.burst) - float64(ahead)/float64(l.interval)
+}
+
+// Rate returns the allowed calls per second
+func (l *RateLimiter) Rate() float64 {
+	return l.rate
+}
+
+// Burst returns the calls allowed in a burst
+func (l *RateLimiter) Burst() float64 {
+	return float64(l.burst)
+}
@@ -0,0 +1,87 @@
+package utils_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterSpacesCalls(t *testing.T) {
+	limiter := utils.NewRateLimiter(600) // one call every 100ms
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	limiter := utils.NewRateLimiter(0)
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestRateLimiterStopsOnCancel(t *testing.T) {
+	limiter := utils.NewRateLimiter(1)
+	assert.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestRateLimiterAllowsBurst(t *testing.T) {
+	limiter := utils.NewBurstRateLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow())
+	}
+	assert.False(t, limiter.Allow())
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	limiter := utils.NewBurstRateLimiter(20, 1) // one token every 50ms
+	assert.True(t, limiter.Allow())
+	assert.False(t, limiter.Allow())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, limiter.Allow())
+}
+
+func TestRateLimiterWaitBlocksAfterBurst(t *testing.T) {
+	limiter := utils.NewBurstRateLimiter(10, 2) // one token every 100ms after the burst
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestRateLimiterGivesBackCancelledWait(t *testing.T) {
+	limiter := utils.NewBurstRateLimiter(1, 1)
+	assert.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+	// The cancelled reservation is given back
+	assert.InDelta(t, 0, limiter.Tokens(), 0.1)
+}
+
+func TestRateLimiterBurstUnlimited(t *testing.T) {
+	limiter := utils.NewBurstRateLimiter(0, 1)
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, limiter.Allow())
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	redactURLPattern   = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+	// A phone number is told apart from the other numbers like timestamps by its leading + or by its jid server
+	redactPhonePattern = regexp.MustCompile(`\+\d{8,15}\b|\b\d{8,15}(?::\d+)?@s\.whatsapp\.net\b`)
+)
+
+// Calling codes are prefix free, the ones not listed here have 3 digits
+var (
+	oneDigitCallingCodes = []string{"1", "7"}
+	twoDigitCallingCodes = []string{
+		"20", "27", "30", "31", "32", "33", "34", "36", "39", "40", "41", "43", "44", "45", "46", "47", "48", "49",
+		"51", "52", "53", "54", "55", "56", "57", "58", "60", "61", "62", "63", "64", "65", "66", "81", "82", "84",
+		"86", "90", "91", "92", "93", "94", "95", "98",
+	}
+)
+
+func callingCodeLength(digits string) int {
+	for _, code := range oneDigitCallingCodes {
+		if strings.HasPrefix(digits, code) {
+			return 1
+		}
+	}
+	for _, code := range twoDigitCallingCodes {
+		if strings.HasPrefix(digits, code) {
+			return 2
+		}
+	}
+	return 3
+}
+
+// RedactPhone masks a phone number, only the calling code and the last 2 digits are kept
+func RedactPhone(phone string) string {
+	digits := strings.TrimPrefix(phone, "+")
+	keep := callingCodeLength(digits)
+	if len(digits) <= keep+2 {
+		return phone
+	}
+	return phone[:len(phone)-len(digits)] + digits[:keep] + strings.Repeat("*", len(digits)-keep-2) + digits[len(digits)-2:]
+}
+
+// RedactURL drops the credentials, the query string and the fragment of a url, they often carry tokens
+func RedactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return raw
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.ForceQuery = false
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
+
+// RedactText masks the phone numbers and the urls found in a text. A phone number is a run of 8 to 15 digits
+// after a + or the user part of a phone jid, other digit runs are left alone
+func RedactText(text string) string {
+	text = redactURLPattern.ReplaceAllStringFunc(text, RedactURL)
+	return redactPhonePattern.ReplaceAllStringFunc(text, func(match string) string {
+		user, server, _ := strings.Cut(match, "@")
+		user, device, hasDevice := strings.Cut(user, ":")
+		if server == "" {
+			return RedactPhone(match)
+		}
+		if hasDevice {
+			return RedactPhone(user) + ":" + device + "@" + server
+		}
+		return RedactPhone(user) + "@" + server
+	})
+}
+
+// redactValue redacts a field of a log entry. Errors and stringers like jids are logged as their text, the other
+// structured values go through their json so they keep their shape, the numbers and booleans are left as they are
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value
+	case string:
+		return RedactText(v)
+	case error:
+		return RedactText(v.Error())
+	case fmt.Stringer:
+		return RedactText(v.String())
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return RedactText(fmt.Sprintf("%v", value))
+	}
+	var redacted any
+	if err = json.Unmarshal([]byte(RedactText(string(data))), &redacted); err != nil {
+		return RedactText(string(data))
+	}
+	return redacted
+}
+
+// redactingFormatter redacts the message and the fields of an entry before the wrapped formatter writes it
+type redactingFormatter struct {
+	log.Formatter
+}
+
+func (formatter redactingFormatter) Format(entry *log.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Message = RedactText(entry.Message)
+	redacted.Data = make(log.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		redacted.Data[key] = redactValue(value)
+	}
+	return formatter.Formatter.Format(&redacted)
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// TTLCache keeps values for a while and evicts them in the order they were set. Its entries are expected to share
+// the same ttl, so the oldest one is always the first to expire
+type TTLCache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List // oldest at the front
+}
+
+type ttlCacheEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// NewTTLCache returns an empty cache
+func NewTTLCache[K comparable, V any]() *TTLCache[K, V] {
+	return &TTLCache[K, V]{entries: make(map[K]*list.Element), order: list.New()}
+}
+
+// Get returns the value of the key while it hasn't expired
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok || time.Now().After(element.Value.(*ttlCacheEntry[K, V]).expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return element.Value.(*ttlCacheEntry[K, V]).value, true
+}
+
+// Set caches the value for ttl and evicts the expired entries, then the oldest ones while the cache holds more
+// than maxSize. maxSize 0 or less means unbounded
+func (c *TTLCache[K, V]) Set(key K, value V, ttl time.Duration, maxSize int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+	}
+	c.entries[key] = c.order.PushBack(&ttlCacheEntry[K, V]{key: key, value: value, expiresAt: now.Add(ttl)})
+
+	for element := c.order.Front(); element != nil; element = c.order.Front() {
+		entry := element.Value.(*ttlCacheEntry[K, V])
+		if now.Before(entry.expiresAt) && (maxSize <= 0 || len(c.entries) <= maxSize) {
+			break
+		}
+		c.order.Remove(element)
+		delete(c.entries, entry.key)
+	}
+}
+
+// Delete forgets the key
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// DeleteFunc forgets the entries del returns true for
+func (c *TTLCache[K, V]) DeleteFunc(del func(key K, value V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if entry := element.Value.(*ttlCacheEntry[K, V]); del(entry.key, entry.value) {
+			c.order.Remove(element)
+			delete(c.entries, entry.key)
+		}
+		element = next
+	}
+}
+
+// Len returns the number of cached entries, expired ones included until they are evicted
+func (c *TTLCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,55 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLCacheEvictsOldestOverSize(t *testing.T) {
+	cache := utils.NewTTLCache[string, int]()
+	cache.Set("a", 1, time.Minute, 2)
+	cache.Set("b", 2, time.Minute, 2)
+	cache.Set("a", 10, time.Minute, 2) // set again, b is now the oldest
+	cache.Set("c", 3, time.Minute, 2)
+
+	_, ok := cache.Get("b")
+	assert.False(t, ok)
+	value, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 10, value)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestTTLCacheExpires(t *testing.T) {
+	cache := utils.NewTTLCache[string, int]()
+	cache.Set("a", 1, 50*time.Millisecond, 0)
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	time.Sleep(60 * time.Millisecond)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+
+	// The expired entry is evicted by the next set
+	cache.Set("b", 2, 50*time.Millisecond, 0)
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestTTLCacheDeleteFunc(t *testing.T) {
+	cache := utils.NewTTLCache[string, int]()
+	for i, key := range []string{"a", "b", "c", "d"} {
+		cache.Set(key, i, time.Minute, 0)
+	}
+	cache.DeleteFunc(func(_ string, value int) bool { return value%2 == 0 })
+	cache.Delete("d")
+
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	value, ok := cache.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 1, cache.Len())
+}
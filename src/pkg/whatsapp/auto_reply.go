@@ -0,0 +1,287 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/google/uuid"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	AutoReplyMatchExact    = "exact"
+	AutoReplyMatchContains = "contains"
+	AutoReplyMatchRegex    = "regex"
+
+	AutoReplySourceConfig = "config"
+	AutoReplySourceAPI    = "api"
+)
+
+var AutoReplyMatchTypes = []string{AutoReplyMatchExact, AutoReplyMatchContains, AutoReplyMatchRegex}
+
+// AutoReplyRule sends Reply when an inbound text matches Pattern. A rule with a Chat only applies to that chat,
+// groups included, a rule without one applies to every private chat
+type AutoReplyRule struct {
+	ID            string `json:"id"`
+	Match         string `json:"match"`
+	Pattern       string `json:"pattern"`
+	CaseSensitive bool   `json:"case_sensitive,omitempty"`
+	Chat          string `json:"chat,omitempty"`
+	Reply         string `json:"reply"`
+	Source        string `json:"source"`
+
+	regex *regexp.Regexp
+}
+
+// autoReplyStoreData is the content of config.PathAutoReplyStore
+type autoReplyStoreData struct {
+	Enabled bool            `json:"enabled"`
+	Rules   []AutoReplyRule `json:"rules"`
+}
+
+// autoReplyStore keeps the rules of config.WhatsappAutoReplyRules and the ones added at runtime, with the switch
+// turning all of them on or off. The runtime rules and the switch are persisted to config.PathAutoReplyStore
+type autoReplyStore struct {
+	mu          sync.RWMutex
+	enabled     bool
+	configRules []AutoReplyRule
+	rules       []AutoReplyRule
+}
+
+var autoReplies = &autoReplyStore{enabled: true}
+
+// LoadAutoReplyRules reads the rules file of config.WhatsappAutoReplyRules and the runtime rules of
+// config.PathAutoReplyStore
+func LoadAutoReplyRules() error {
+	var configRules []AutoReplyRule
+	if config.WhatsappAutoReplyRules != "" {
+		data, err := os.ReadFile(config.WhatsappAutoReplyRules)
+		if err != nil {
+			return fmt.Errorf("failed to read auto reply rules: %w", err)
+		}
+		if err = json.Unmarshal(data, &configRules); err != nil {
+			return fmt.Errorf("failed to parse auto reply rules %s: %w", config.WhatsappAutoReplyRules, err)
+		}
+		for i := range configRules {
+			if configRules[i].ID == "" {
+				configRules[i].ID = fmt.Sprintf("config-%d", i+1)
+			}
+			configRules[i].Source = AutoReplySourceConfig
+			if err = prepareAutoReplyRule(&configRules[i]); err != nil {
+				return fmt.Errorf("auto reply rule %s of %s: %w", configRules[i].ID, config.WhatsappAutoReplyRules, err)
+			}
+		}
+	}
+
+	store := autoReplyStoreData{Enabled: true}
+	data, err := os.ReadFile(config.PathAutoReplyStore)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read auto reply store: %w", err)
+	}
+	if err == nil {
+		if err = json.Unmarshal(data, &store); err != nil {
+			return fmt.Errorf("failed to parse auto reply store %s: %w", config.PathAutoReplyStore, err)
+		}
+		for i := range store.Rules {
+			store.Rules[i].Source = AutoReplySourceAPI
+			if err = prepareAutoReplyRule(&store.Rules[i]); err != nil {
+				return fmt.Errorf("auto reply rule %s of %s: %w", store.Rules[i].ID, config.PathAutoReplyStore, err)
+			}
+		}
+	}
+
+	autoReplies.mu.Lock()
+	defer autoReplies.mu.Unlock()
+	autoReplies.enabled = store.Enabled
+	autoReplies.configRules = configRules
+	autoReplies.rules = store.Rules
+	return nil
+}
+
+// ListAutoReplyRules returns whether the rules are enabled, and the rules from the startup configuration followed
+// by the ones added at runtime
+func ListAutoReplyRules() (bool, []AutoReplyRule) {
+	autoReplies.mu.RLock()
+	defer autoReplies.mu.RUnlock()
+	return autoReplies.enabled, slices.Concat(autoReplies.configRules, autoReplies.rules)
+}
+
+// AddAutoReplyRule adds a runtime rule, or replaces the runtime rule with the same id, and persists the store
+func AddAutoReplyRule(rule AutoReplyRule) (AutoReplyRule, error) {
+	if rule.ID == "" {
+		rule.ID = uuid.NewString()
+	}
+	rule.Source = AutoReplySourceAPI
+	if err := prepareAutoReplyRule(&rule); err != nil {
+		return AutoReplyRule{}, err
+	}
+
+	autoReplies.mu.Lock()
+	defer autoReplies.mu.Unlock()
+
+	if slices.ContainsFunc(autoReplies.configRules, func(r AutoReplyRule) bool { return r.ID == rule.ID }) {
+		return AutoReplyRule{}, pkgError.ValidationError("auto reply rule is configured at startup")
+	}
+
+	rules := slices.Clone(autoReplies.rules)
+	if index := slices.IndexFunc(rules, func(r AutoReplyRule) bool { return r.ID == rule.ID }); index >= 0 {
+		rules[index] = rule
+	} else {
+		rules = append(rules, rule)
+	}
+
+	if err := saveAutoReplyStore(autoReplies.enabled, rules); err != nil {
+		return AutoReplyRule{}, err
+	}
+	autoReplies.rules = rules
+	return rule, nil
+}
+
+// RemoveAutoReplyRule removes a runtime rule and persists the store.
+// Rules from the startup configuration can only be removed from that configuration.
+func RemoveAutoReplyRule(id string) error {
+	autoReplies.mu.Lock()
+	defer autoReplies.mu.Unlock()
+
+	if slices.ContainsFunc(autoReplies.configRules, func(r AutoReplyRule) bool { return r.ID == id }) {
+		return pkgError.ValidationError("auto reply rule is configured at startup, remove it from --autoreply-rules instead")
+	}
+
+	index := slices.IndexFunc(autoReplies.rules, func(r AutoReplyRule) bool { return r.ID == id })
+	if index < 0 {
+		return pkgError.NotFoundError("auto reply rule not found")
+	}
+
+	rules := slices.Delete(slices.Clone(autoReplies.rules), index, index+1)
+	if err := saveAutoReplyStore(autoReplies.enabled, rules); err != nil {
+		return err
+	}
+	autoReplies.rules = rules
+	return nil
+}
+
+// SetAutoReplyEnabled turns every rule on or off and persists the switch
+func SetAutoReplyEnabled(enabled bool) error {
+	autoReplies.mu.Lock()
+	defer autoReplies.mu.Unlock()
+
+	if err := saveAutoReplyStore(enabled, autoReplies.rules); err != nil {
+		return err
+	}
+	autoReplies.enabled = enabled
+	return nil
+}
+
+// prepareAutoReplyRule validates the rule, normalizes its chat and compiles its pattern
+func prepareAutoReplyRule(rule *AutoReplyRule) error {
+	if !slices.Contains(AutoReplyMatchTypes, rule.Match) {
+		return pkgError.ValidationError(fmt.Sprintf("match must be one of %s", strings.Join(AutoReplyMatchTypes, ", ")))
+	}
+	if rule.Pattern == "" || strings.TrimSpace(rule.Reply) == "" {
+		return pkgError.ValidationError("pattern and reply can't be empty")
+	}
+
+	if rule.Chat != "" {
+		chat, err := NormalizeJID(rule.Chat)
+		if err != nil {
+			return err
+		}
+		rule.Chat = chat.String()
+	}
+
+	rule.regex = nil
+	if rule.Match == AutoReplyMatchRegex {
+		pattern := rule.Pattern
+		if !rule.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return pkgError.ValidationError(fmt.Sprintf("pattern is not a valid regex: %v", err))
+		}
+		rule.regex = regex
+	}
+	return nil
+}
+
+// matches tells whether the inbound text matches the rule, exact and contains ignore the surrounding spaces
+func (rule AutoReplyRule) matches(text string) bool {
+	switch rule.Match {
+	case AutoReplyMatchRegex:
+		return rule.regex != nil && rule.regex.MatchString(text)
+	case AutoReplyMatchExact:
+		text = strings.TrimSpace(text)
+		if rule.CaseSensitive {
+			return text == rule.Pattern
+		}
+		return strings.EqualFold(text, rule.Pattern)
+	case AutoReplyMatchContains:
+		if rule.CaseSensitive {
+			return strings.Contains(text, rule.Pattern)
+		}
+		return strings.Contains(strings.ToLower(text), strings.ToLower(rule.Pattern))
+	}
+	return false
+}
+
+// matchAutoReply returns the first rule of the chat matching the text, the rules of the chat itself win over the
+// global ones. Global rules never answer in groups
+func matchAutoReply(chat types.JID, text string) (AutoReplyRule, bool) {
+	autoReplies.mu.RLock()
+	defer autoReplies.mu.RUnlock()
+
+	if !autoReplies.enabled || text == "" {
+		return AutoReplyRule{}, false
+	}
+
+	rules := slices.Concat(autoReplies.configRules, autoReplies.rules)
+	chatJID := chat.ToNonAD().String()
+	for _, rule := range rules {
+		if rule.Chat == chatJID && rule.matches(text) {
+			return rule, true
+		}
+	}
+	if chat.Server == types.GroupServer {
+		return AutoReplyRule{}, false
+	}
+	for _, rule := range rules {
+		if rule.Chat == "" && rule.matches(text) {
+			return rule, true
+		}
+	}
+	return AutoReplyRule{}, false
+}
+
+// saveAutoReplyStore writes the store to a temporary file and renames it, so a crash never leaves a partial store
+func saveAutoReplyStore(enabled bool, rules []AutoReplyRule) error {
+	if rules == nil {
+		rules = []AutoReplyRule{}
+	}
+	data, err := json.MarshalIndent(autoReplyStoreData{Enabled: enabled, Rules: rules}, "", "  ")
+	if err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to encode auto reply store: %v", err))
+	}
+
+	if err = os.MkdirAll(filepath.Dir(config.PathAutoReplyStore), 0700); err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to create auto reply store folder: %v", err))
+	}
+
+	tmpPath := config.PathAutoReplyStore + ".tmp"
+	if err = os.WriteFile(tmpPath, data, 0600); err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to write auto reply store: %v", err))
+	}
+	if err = os.Rename(tmpPath, config.PathAutoReplyStore); err != nil {
+		_ = os.Remove(tmpPath)
+		return pkgError.InternalServerError(fmt.Sprintf("failed to write auto reply store: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package whatsapp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func setAutoReplyConfig(t *testing.T, configRules string) {
+	origStore, origRules := config.PathAutoReplyStore, config.WhatsappAutoReplyRules
+	t.Cleanup(func() {
+		config.PathAutoReplyStore, config.WhatsappAutoReplyRules = origStore, origRules
+		autoReplies.enabled, autoReplies.configRules, autoReplies.rules = true, nil, nil
+	})
+
+	config.PathAutoReplyStore = filepath.Join(t.TempDir(), "auto_replies.json")
+	config.WhatsappAutoReplyRules = ""
+	if configRules != "" {
+		config.WhatsappAutoReplyRules = filepath.Join(t.TempDir(), "rules.json")
+		require.NoError(t, os.WriteFile(config.WhatsappAutoReplyRules, []byte(configRules), 0600))
+	}
+	require.NoError(t, LoadAutoReplyRules())
+}
+
+func TestAutoReplyRuleMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		rule AutoReplyRule
+		text string
+		want bool
+	}{
+		{"exact ignores case and spaces", AutoReplyRule{Match: AutoReplyMatchExact, Pattern: "Price"}, "  price ", true},
+		{"exact needs the whole text", AutoReplyRule{Match: AutoReplyMatchExact, Pattern: "price"}, "price list", false},
+		{"exact case sensitive", AutoReplyRule{Match: AutoReplyMatchExact, Pattern: "Price", CaseSensitive: true}, "price", false},
+		{"contains", AutoReplyRule{Match: AutoReplyMatchContains, Pattern: "opening hours"}, "What are your Opening Hours?", true},
+		{"contains case sensitive", AutoReplyRule{Match: AutoReplyMatchContains, Pattern: "Hours", CaseSensitive: true}, "opening hours", false},
+		{"regex", AutoReplyRule{Match: AutoReplyMatchRegex, Pattern: `^order #\d+$`}, "ORDER #42", true},
+		{"regex no match", AutoReplyRule{Match: AutoReplyMatchRegex, Pattern: `^order #\d+$`}, "order #abc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Reply = "reply"
+			require.NoError(t, prepareAutoReplyRule(&tt.rule))
+			assert.Equal(t, tt.want, tt.rule.matches(tt.text))
+		})
+	}
+}
+
+func TestMatchAutoReply(t *testing.T) {
+	setAutoReplyConfig(t, `[{"match":"contains","pattern":"hello","reply":"Hi from config"}]`)
+
+	_, err := AddAutoReplyRule(AutoReplyRule{ID: "vip", Match: AutoReplyMatchContains, Pattern: "hello", Chat: "+62 812-3456-789", Reply: "Hi VIP"})
+	require.NoError(t, err)
+	_, err = AddAutoReplyRule(AutoReplyRule{ID: "group", Match: AutoReplyMatchExact, Pattern: "menu", Chat: "120363025246125486@g.us", Reply: "Menu"})
+	require.NoError(t, err)
+
+	vip := types.NewJID("628123456789", types.DefaultUserServer)
+	other := types.NewJID("628111111111", types.DefaultUserServer)
+	group := types.NewJID("120363025246125486", types.GroupServer)
+
+	// The rule of the chat wins over the global one
+	rule, ok := matchAutoReply(vip, "hello there")
+	assert.True(t, ok)
+	assert.Equal(t, "Hi VIP", rule.Reply)
+
+	rule, ok = matchAutoReply(other, "hello there")
+	assert.True(t, ok)
+	assert.Equal(t, "config-1", rule.ID)
+
+	// Global rules never answer in groups
+	_, ok = matchAutoReply(group, "hello")
+	assert.False(t, ok)
+	rule, ok = matchAutoReply(group, "menu")
+	assert.True(t, ok)
+	assert.Equal(t, "group", rule.ID)
+
+	require.NoError(t, SetAutoReplyEnabled(false))
+	_, ok = matchAutoReply(other, "hello")
+	assert.False(t, ok)
+}
+
+func TestAutoReplyStorePersistence(t *testing.T) {
+	setAutoReplyConfig(t, `[{"id":"greeting","match":"exact","pattern":"hi","reply":"Hello"}]`)
+
+	added, err := AddAutoReplyRule(AutoReplyRule{Match: AutoReplyMatchRegex, Pattern: `\bprice\b`, Reply: "See our catalog"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, added.ID)
+	require.NoError(t, SetAutoReplyEnabled(false))
+
+	// Reload from disk as it happens on reboot
+	require.NoError(t, LoadAutoReplyRules())
+	enabled, rules := ListAutoReplyRules()
+	assert.False(t, enabled)
+	require.Len(t, rules, 2)
+	assert.Equal(t, AutoReplySourceConfig, rules[0].Source)
+	assert.Equal(t, added.ID, rules[1].ID)
+	assert.Equal(t, AutoReplySourceAPI, rules[1].Source)
+	assert.True(t, rules[1].matches("what is the PRICE?"))
+
+	_, err = AddAutoReplyRule(AutoReplyRule{ID: "greeting", Match: AutoReplyMatchExact, Pattern: "hi", Reply: "Hey"})
+	assert.Error(t, err)
+	assert.Error(t, RemoveAutoReplyRule("greeting"))
+	assert.Error(t, RemoveAutoReplyRule("unknown"))
+
+	require.NoError(t, RemoveAutoReplyRule(added.ID))
+	require.NoError(t, LoadAutoReplyRules())
+	_, rules = ListAutoReplyRules()
+	assert.Len(t, rules, 1)
+}
+
+func TestLoadAutoReplyRulesInvalid(t *testing.T) {
+	origStore, origRules := config.PathAutoReplyStore, config.WhatsappAutoReplyRules
+	t.Cleanup(func() { config.PathAutoReplyStore, config.WhatsappAutoReplyRules = origStore, origRules })
+
+	config.PathAutoReplyStore = filepath.Join(t.TempDir(), "auto_replies.json")
+	config.WhatsappAutoReplyRules = filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(config.WhatsappAutoReplyRules, []byte(`[{"match":"regex","pattern":"(","reply":"x"}]`), 0600))
+	assert.ErrorContains(t, LoadAutoReplyRules(), "config-1")
+}
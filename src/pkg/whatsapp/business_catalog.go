@@ -0,0 +1,225 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"go.mau.fi/whatsmeow"
+	waBinary "go.mau.fi/whatsmeow/binary"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	catalogNamespace = "w:biz:catalog"
+	// catalogImageSize is the width and height of the product images whatsapp returns, in pixels
+	catalogImageSize = "100"
+	catalogTimeout   = 30 * time.Second
+)
+
+// BusinessProduct is a product of a business catalog. Prices are sent by whatsapp in thousandths of the currency
+type BusinessProduct struct {
+	ID               string
+	RetailerID       string
+	Name             string
+	Description      string
+	URL              string
+	Currency         string
+	PriceAmount1000  int64
+	ImageURL         string
+	OriginalImageURL string
+	Hidden           bool
+}
+
+// BusinessCatalog is a page of the catalog of a business, NextCursor is empty on the last page
+type BusinessCatalog struct {
+	Products   []BusinessProduct
+	NextCursor string
+}
+
+// GetBusinessCatalog returns up to limit products of the catalog of jid, starting after the cursor of a previous page
+func GetBusinessCatalog(ctx context.Context, client *whatsmeow.Client, jid types.JID, limit int, after string) (BusinessCatalog, error) {
+	if err := requireBusinessAccount(client, jid); err != nil {
+		return BusinessCatalog{}, err
+	}
+
+	content := []waBinary.Node{
+		{Tag: "limit", Content: []byte(strconv.Itoa(limit))},
+		{Tag: "width", Content: []byte(catalogImageSize)},
+		{Tag: "height", Content: []byte(catalogImageSize)},
+	}
+	if after != "" {
+		content = append(content, waBinary.Node{Tag: "after", Content: []byte(after)})
+	}
+	resp, err := sendCatalogQuery(ctx, client, waBinary.Node{
+		Tag:     "product_catalog",
+		Attrs:   waBinary.Attrs{"jid": jid.ToNonAD(), "allow_shop_source": "true"},
+		Content: content,
+	})
+	if err != nil {
+		return BusinessCatalog{}, catalogError(jid, err)
+	}
+	return parseBusinessCatalog(resp)
+}
+
+// GetBusinessProduct returns a single product of the catalog of jid
+func GetBusinessProduct(ctx context.Context, client *whatsmeow.Client, jid types.JID, productID string) (BusinessProduct, error) {
+	if err := requireBusinessAccount(client, jid); err != nil {
+		return BusinessProduct{}, err
+	}
+
+	resp, err := sendCatalogQuery(ctx, client, waBinary.Node{
+		Tag:   "product",
+		Attrs: waBinary.Attrs{"jid": jid.ToNonAD()},
+		Content: []waBinary.Node{
+			{Tag: "id", Content: []byte(productID)},
+			{Tag: "width", Content: []byte(catalogImageSize)},
+			{Tag: "height", Content: []byte(catalogImageSize)},
+		},
+	})
+	if err != nil {
+		return BusinessProduct{}, catalogError(jid, err)
+	}
+	return parseSingleProduct(resp, productID)
+}
+
+// requireBusinessAccount rejects the accounts that aren't business ones, they can't have a catalog
+func requireBusinessAccount(client *whatsmeow.Client, jid types.JID) error {
+	infos, err := client.GetUserInfo([]types.JID{jid.ToNonAD()})
+	if err != nil {
+		return err
+	}
+	if info, ok := infos[jid.ToNonAD()]; !ok || info.VerifiedName == nil {
+		return pkgError.ValidationError(fmt.Sprintf("%s is not a business account", jid.ToNonAD()))
+	}
+	return nil
+}
+
+// sendCatalogQuery sends an info query to the catalog namespace and waits for its result. whatsmeow has no call for
+// the catalog, the query is built the same way as its own ones
+func sendCatalogQuery(ctx context.Context, client *whatsmeow.Client, content waBinary.Node) (*waBinary.Node, error) {
+	internals := client.DangerousInternals()
+	id := internals.GenerateRequestID()
+	waiter := internals.WaitResponse(id)
+	err := internals.SendNode(waBinary.Node{
+		Tag: "iq",
+		Attrs: waBinary.Attrs{
+			"id":    id,
+			"xmlns": catalogNamespace,
+			"type":  "get",
+			"to":    types.ServerJID,
+		},
+		Content: []waBinary.Node{content},
+	})
+	if err != nil {
+		internals.CancelResponse(id, waiter)
+		return nil, err
+	}
+
+	select {
+	case resp := <-waiter:
+		return catalogResult(resp)
+	case <-ctx.Done():
+		internals.CancelResponse(id, waiter)
+		return nil, ctx.Err()
+	case <-time.After(catalogTimeout):
+		internals.CancelResponse(id, waiter)
+		return nil, whatsmeow.ErrIQTimedOut
+	}
+}
+
+// catalogResult turns the error responses of whatsapp into an *whatsmeow.IQError
+func catalogResult(resp *waBinary.Node) (*waBinary.Node, error) {
+	if resp.Tag != "iq" {
+		return nil, &whatsmeow.IQError{RawNode: resp}
+	}
+	switch resp.AttrGetter().OptionalString("type") {
+	case "result":
+		return resp, nil
+	case "error":
+		iqErr := &whatsmeow.IQError{RawNode: resp}
+		if errNode, ok := resp.GetOptionalChildByTag("error"); ok {
+			iqErr.ErrorNode = &errNode
+			iqErr.Code = errNode.AttrGetter().OptionalInt("code")
+			iqErr.Text = errNode.AttrGetter().OptionalString("text")
+		}
+		return nil, iqErr
+	}
+	return nil, &whatsmeow.IQError{RawNode: resp}
+}
+
+// catalogError tells apart a missing catalog or product from the other failures
+func catalogError(jid types.JID, err error) error {
+	if iqErr, ok := err.(*whatsmeow.IQError); ok && iqErr.Code == 404 {
+		return pkgError.NotFoundError(fmt.Sprintf("catalog or product of %s not found", jid.ToNonAD()))
+	}
+	return err
+}
+
+func parseBusinessCatalog(resp *waBinary.Node) (BusinessCatalog, error) {
+	catalogNode, ok := resp.GetOptionalChildByTag("product_catalog")
+	if !ok {
+		return BusinessCatalog{}, &whatsmeow.ElementMissingError{Tag: "product_catalog", In: "response to catalog query"}
+	}
+
+	catalog := BusinessCatalog{Products: make([]BusinessProduct, 0)}
+	for _, node := range catalogNode.GetChildrenByTag("product") {
+		catalog.Products = append(catalog.Products, parseBusinessProduct(node))
+	}
+	if paging, ok := catalogNode.GetOptionalChildByTag("paging"); ok {
+		catalog.NextCursor = nodeText(paging, "after")
+	}
+	return catalog, nil
+}
+
+// parseSingleProduct reads the product of a product query, whatsapp wraps it in a product_catalog node or not
+func parseSingleProduct(resp *waBinary.Node, productID string) (BusinessProduct, error) {
+	parent := *resp
+	if catalogNode, ok := resp.GetOptionalChildByTag("product_catalog"); ok {
+		parent = catalogNode
+	}
+	for _, node := range parent.GetChildrenByTag("product") {
+		if product := parseBusinessProduct(node); product.ID == productID {
+			return product, nil
+		}
+	}
+	return BusinessProduct{}, pkgError.NotFoundError(fmt.Sprintf("product %s not found", productID))
+}
+
+func parseBusinessProduct(node waBinary.Node) BusinessProduct {
+	product := BusinessProduct{
+		ID:          nodeText(node, "id"),
+		RetailerID:  nodeText(node, "retailer_id"),
+		Name:        nodeText(node, "name"),
+		Description: nodeText(node, "description"),
+		URL:         nodeText(node, "url"),
+		Currency:    nodeText(node, "currency"),
+		Hidden:      node.AttrGetter().OptionalString("is_hidden") == "true",
+	}
+	product.PriceAmount1000, _ = strconv.ParseInt(nodeText(node, "price"), 10, 64)
+
+	if media, ok := node.GetOptionalChildByTag("media"); ok {
+		if image, ok := media.GetOptionalChildByTag("image"); ok {
+			product.ImageURL = nodeText(image, "request_image_url")
+			product.OriginalImageURL = nodeText(image, "original_image_url")
+		}
+	}
+	return product
+}
+
+// nodeText returns the text content of the child tag of node, empty when there's none
+func nodeText(node waBinary.Node, tag string) string {
+	child, ok := node.GetOptionalChildByTag(tag)
+	if !ok {
+		return ""
+	}
+	switch content := child.Content.(type) {
+	case []byte:
+		return string(content)
+	case string:
+		return content
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package whatsapp
+
+import (
+	"testing"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow"
+	waBinary "go.mau.fi/whatsmeow/binary"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func textNode(tag, text string) waBinary.Node {
+	return waBinary.Node{Tag: tag, Content: []byte(text)}
+}
+
+func productNode(id, name string) waBinary.Node {
+	return waBinary.Node{
+		Tag:   "product",
+		Attrs: waBinary.Attrs{"is_hidden": "false"},
+		Content: []waBinary.Node{
+			textNode("id", id),
+			textNode("retailer_id", "SKU-"+id),
+			textNode("name", name),
+			textNode("description", "A "+name),
+			textNode("url", "https://example.com/"+id),
+			textNode("price", "150000000"),
+			textNode("currency", "IDR"),
+			{Tag: "media", Content: []waBinary.Node{{Tag: "image", Content: []waBinary.Node{
+				textNode("request_image_url", "https://example.com/"+id+"-100.jpg"),
+				textNode("original_image_url", "https://example.com/"+id+".jpg"),
+			}}}},
+		},
+	}
+}
+
+func TestParseBusinessCatalog(t *testing.T) {
+	resp := &waBinary.Node{Tag: "iq", Content: []waBinary.Node{{
+		Tag: "product_catalog",
+		Content: []waBinary.Node{
+			productNode("1", "Coffee"),
+			productNode("2", "Tea"),
+			{Tag: "paging", Content: []waBinary.Node{textNode("after", "cursor-2")}},
+		},
+	}}}
+
+	catalog, err := parseBusinessCatalog(resp)
+	require.NoError(t, err)
+	require.Len(t, catalog.Products, 2)
+	assert.Equal(t, "cursor-2", catalog.NextCursor)
+	assert.Equal(t, BusinessProduct{
+		ID:               "1",
+		RetailerID:       "SKU-1",
+		Name:             "Coffee",
+		Description:      "A Coffee",
+		URL:              "https://example.com/1",
+		Currency:         "IDR",
+		PriceAmount1000:  150000000,
+		ImageURL:         "https://example.com/1-100.jpg",
+		OriginalImageURL: "https://example.com/1.jpg",
+	}, catalog.Products[0])
+	assert.Equal(t, "Tea", catalog.Products[1].Name)
+}
+
+func TestParseBusinessCatalogEmpty(t *testing.T) {
+	catalog, err := parseBusinessCatalog(&waBinary.Node{Tag: "iq", Content: []waBinary.Node{{Tag: "product_catalog"}}})
+	require.NoError(t, err)
+	assert.Empty(t, catalog.Products)
+	assert.NotNil(t, catalog.Products)
+	assert.Empty(t, catalog.NextCursor)
+
+	_, err = parseBusinessCatalog(&waBinary.Node{Tag: "iq"})
+	assert.Error(t, err)
+}
+
+func TestParseSingleProduct(t *testing.T) {
+	bare := &waBinary.Node{Tag: "iq", Content: []waBinary.Node{productNode("1", "Coffee")}}
+	product, err := parseSingleProduct(bare, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Coffee", product.Name)
+
+	wrapped := &waBinary.Node{Tag: "iq", Content: []waBinary.Node{{
+		Tag:     "product_catalog",
+		Content: []waBinary.Node{productNode("1", "Coffee"), productNode("2", "Tea")},
+	}}}
+	product, err = parseSingleProduct(wrapped, "2")
+	require.NoError(t, err)
+	assert.Equal(t, "Tea", product.Name)
+
+	_, err = parseSingleProduct(wrapped, "3")
+	assert.IsType(t, pkgError.NotFoundError(""), err)
+}
+
+func TestCatalogResult(t *testing.T) {
+	ok := &waBinary.Node{Tag: "iq", Attrs: waBinary.Attrs{"type": "result"}}
+	resp, err := catalogResult(ok)
+	require.NoError(t, err)
+	assert.Same(t, ok, resp)
+
+	_, err = catalogResult(&waBinary.Node{
+		Tag:     "iq",
+		Attrs:   waBinary.Attrs{"type": "error"},
+		Content: []waBinary.Node{{Tag: "error", Attrs: waBinary.Attrs{"code": "404", "text": "item-not-found"}}},
+	})
+	var iqErr *whatsmeow.IQError
+	require.ErrorAs(t, err, &iqErr)
+	assert.Equal(t, 404, iqErr.Code)
+	assert.Equal(t, "item-not-found", iqErr.Text)
+
+	jid := types.NewJID("6281234567890", types.DefaultUserServer)
+	assert.IsType(t, pkgError.NotFoundError(""), catalogError(jid, err))
+	other := &whatsmeow.IQError{Code: 500}
+	assert.Same(t, other, catalogError(jid, other))
+
+	_, err = catalogResult(&waBinary.Node{Tag: "stream:error"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package whatsapp
+
+import (
+	"fmt"
+	"time"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ChatSettings is the archive, pin and mute state of a chat, it's part of the app state shared by every device
+// of the account. MutedUntil is zero when the chat is muted forever or not muted
+type ChatSettings struct {
+	Archived   bool
+	Pinned     bool
+	Muted      bool
+	MutedUntil time.Time
+}
+
+// SendChatSettings applies a patch of the chat settings. The app state keys and the current version of the patch
+// come with the initial sync after login, until then whatsapp can't take the patch and ErrAppStateNotSynced is returned
+func SendChatSettings(client *whatsmeow.Client, patch appstate.PatchInfo) error {
+	ready, err := isAppStateSynced(client, patch.Type)
+	if err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to read the app state: %v", err))
+	}
+	if !ready {
+		return pkgError.ErrAppStateNotSynced
+	}
+	if err = client.SendAppState(patch); err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to update the chat settings: %v", err))
+	}
+	return nil
+}
+
+func isAppStateSynced(client *whatsmeow.Client, name appstate.WAPatchName) (bool, error) {
+	keyID, err := client.Store.AppStateKeys.GetLatestAppStateSyncKeyID()
+	if err != nil || keyID == nil {
+		return false, err
+	}
+	version, _, err := client.Store.AppState.GetAppStateVersion(string(name))
+	if err != nil {
+		return false, err
+	}
+	return version > 0, nil
+}
+
+// GetChatSettings returns the chat settings stored by whatsmeow, they are updated by every app state patch
+// including the ones sent by SendChatSettings
+func GetChatSettings(client *whatsmeow.Client, chat types.JID) (ChatSettings, error) {
+	local, err := client.Store.ChatSettings.GetChatSettings(chat)
+	if err != nil {
+		return ChatSettings{}, pkgError.InternalServerError(fmt.Sprintf("failed to read the chat settings: %v", err))
+	}
+	return newChatSettings(local, time.Now()), nil
+}
+
+func newChatSettings(local types.LocalChatSettings, now time.Time) ChatSettings {
+	settings := ChatSettings{Archived: local.Archived, Pinned: local.Pinned}
+	switch {
+	case local.MutedUntil.IsZero():
+	case local.MutedUntil.UnixMilli() <= 0:
+		// A chat muted forever has no mute end, whatsapp stores it as 0 or -1
+		settings.Muted = true
+	case local.MutedUntil.After(now):
+		settings.Muted = true
+		settings.MutedUntil = local.MutedUntil
+	}
+	return settings
+}
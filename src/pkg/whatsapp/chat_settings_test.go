@@ -0,0 +1,31 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestNewChatSettings(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		local    types.LocalChatSettings
+		settings ChatSettings
+	}{
+		{"not muted", types.LocalChatSettings{Archived: true}, ChatSettings{Archived: true}},
+		{"muted for a while", types.LocalChatSettings{MutedUntil: now.Add(time.Hour)}, ChatSettings{Muted: true, MutedUntil: now.Add(time.Hour)}},
+		{"mute ended", types.LocalChatSettings{Pinned: true, MutedUntil: now.Add(-time.Hour)}, ChatSettings{Pinned: true}},
+		{"muted forever", types.LocalChatSettings{MutedUntil: time.UnixMilli(0)}, ChatSettings{Muted: true}},
+		{"muted forever as -1", types.LocalChatSettings{MutedUntil: time.UnixMilli(-1)}, ChatSettings{Muted: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.settings, newChatSettings(tt.local, now))
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Chat is a chat of the session as seen from the events it received, whatsapp has no query for the chat list
+type Chat struct {
+	JID           string
+	Name          string
+	LastMessageID string
+	LastMessageAt time.Time
+	UnreadCount   int
+}
+
+// A message only moves the last message forward, so a late delivered older message can't replace it.
+// An unread count of 0 in a message upsert means our account wrote in the chat, which reads it
+const (
+	createChatTable = `CREATE TABLE IF NOT EXISTS whatsapp_chats (
+	session_id      TEXT NOT NULL,
+	chat_jid        TEXT NOT NULL,
+	name            TEXT NOT NULL DEFAULT '',
+	last_message_id TEXT NOT NULL DEFAULT '',
+	last_message_at BIGINT NOT NULL DEFAULT 0,
+	unread_count    INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (session_id, chat_jid)
+)`
+	upsertChatMessage = `INSERT INTO whatsapp_chats (session_id, chat_jid, name, last_message_id, last_message_at, unread_count)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (session_id, chat_jid) DO UPDATE SET
+	name = CASE WHEN excluded.name <> '' THEN excluded.name ELSE whatsapp_chats.name END,
+	last_message_id = CASE WHEN excluded.last_message_at >= whatsapp_chats.last_message_at THEN excluded.last_message_id ELSE whatsapp_chats.last_message_id END,
+	last_message_at = CASE WHEN excluded.last_message_at >= whatsapp_chats.last_message_at THEN excluded.last_message_at ELSE whatsapp_chats.last_message_at END,
+	unread_count = CASE WHEN excluded.unread_count = 0 THEN 0 ELSE whatsapp_chats.unread_count + excluded.unread_count END`
+	// upsertChat takes the unread count as is, it comes from a history sync that is newer than what is known
+	upsertChat = `INSERT INTO whatsapp_chats (session_id, chat_jid, name, last_message_id, last_message_at, unread_count)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (session_id, chat_jid) DO UPDATE SET
+	name = CASE WHEN excluded.name <> '' THEN excluded.name ELSE whatsapp_chats.name END,
+	last_message_id = CASE WHEN excluded.last_message_at > whatsapp_chats.last_message_at THEN excluded.last_message_id ELSE whatsapp_chats.last_message_id END,
+	last_message_at = CASE WHEN excluded.last_message_at > whatsapp_chats.last_message_at THEN excluded.last_message_at ELSE whatsapp_chats.last_message_at END,
+	unread_count = CASE WHEN excluded.last_message_at > 0 AND excluded.last_message_at >= whatsapp_chats.last_message_at THEN excluded.unread_count ELSE whatsapp_chats.unread_count END`
+	updateChatRead    = `UPDATE whatsapp_chats SET unread_count = 0 WHERE session_id = $1 AND chat_jid = $2`
+	updateChatUnread  = `UPDATE whatsapp_chats SET unread_count = 1 WHERE session_id = $1 AND chat_jid = $2 AND unread_count = 0`
+	selectChatsRecent = `SELECT chat_jid, name, last_message_id, last_message_at, unread_count FROM whatsapp_chats
+WHERE session_id = $1 ORDER BY last_message_at DESC, chat_jid LIMIT $2 OFFSET $3`
+	selectChatsOldest = `SELECT chat_jid, name, last_message_id, last_message_at, unread_count FROM whatsapp_chats
+WHERE session_id = $1 ORDER BY last_message_at ASC, chat_jid LIMIT $2 OFFSET $3`
+	countChats         = `SELECT COUNT(*) FROM whatsapp_chats WHERE session_id = $1`
+	deleteSessionChats = `DELETE FROM whatsapp_chats WHERE session_id = $1`
+)
+
+// initChatStore creates the chat table in the database of the whatsmeow store
+func initChatStore(db *sql.DB) error {
+	if _, err := db.Exec(createChatTable); err != nil {
+		return fmt.Errorf("failed to create chat table: %w", err)
+	}
+	return nil
+}
+
+// recordChatMessage moves the chat of a message to it, the push name of the sender names a private chat.
+// It runs before recordMessage, so a message already in the history is a redelivery
+func recordChatMessage(sessionID string, evt *events.Message) {
+	if storeDB == nil || !isContentMessage(evt.Message) || evt.Info.Chat == types.StatusBroadcastJID {
+		return
+	}
+
+	name := ""
+	if !evt.Info.IsGroup && !evt.Info.IsFromMe {
+		name = evt.Info.PushName
+	}
+	unread := 1
+	if evt.Info.IsFromMe {
+		unread = 0
+	}
+	err := saveChatMessage(storeDB, sessionID, evt.Info.Chat, name, evt.Info.ID, evt.Info.Timestamp, unread)
+	if err != nil {
+		log.Errorf("Failed to record chat %s: %v", evt.Info.Chat, err)
+	}
+}
+
+func saveChatMessage(db *sql.DB, sessionID string, chat types.JID, name string, messageID string, sentAt time.Time, unread int) error {
+	// whatsmeow delivers a message again after a reconnect or a retry, it must not be counted as unread twice
+	stored, err := isMessageStored(db, sessionID, chat, messageID)
+	if err != nil || stored {
+		return err
+	}
+	_, err = db.Exec(upsertChatMessage, sessionID, chat.ToNonAD().String(), name, messageID, sentAt.UnixMilli(), unread)
+	return err
+}
+
+// recordHistoryChats stores the chats of a history sync, it's the only source of the chats that had no activity
+// since the session was paired
+func recordHistoryChats(sessionID string, evt *events.HistorySync) {
+	if storeDB == nil {
+		return
+	}
+	for _, conversation := range evt.Data.GetConversations() {
+		chat, err := types.ParseJID(conversation.GetID())
+		if err != nil || chat == types.StatusBroadcastJID {
+			continue
+		}
+
+		name := conversation.GetName()
+		if name == "" {
+			name = conversation.GetDisplayName()
+		}
+		lastMessageAt := conversation.GetLastMsgTimestamp()
+		if lastMessageAt == 0 {
+			lastMessageAt = conversation.GetConversationTimestamp()
+		}
+		lastMessageID := ""
+		var lastMessageTimestamp uint64
+		for _, msg := range conversation.GetMessages() {
+			if ts := msg.GetMessage().GetMessageTimestamp(); ts >= lastMessageTimestamp {
+				lastMessageTimestamp = ts
+				lastMessageID = msg.GetMessage().GetKey().GetID()
+			}
+		}
+		unread := int(conversation.GetUnreadCount())
+		if unread == 0 && conversation.GetMarkedAsUnread() {
+			unread = 1
+		}
+
+		err = saveChat(storeDB, sessionID, chat, name, lastMessageID, time.Unix(int64(lastMessageAt), 0), unread)
+		if err != nil {
+			log.Errorf("Failed to record chat %s of the history sync: %v", chat, err)
+		}
+	}
+}
+
+// recordGroupName keeps the subject of a group as the name of its chat
+func recordGroupName(sessionID string, evt *events.GroupInfo) {
+	if storeDB == nil || evt.Name == nil || evt.Name.Name == "" {
+		return
+	}
+	if err := saveChat(storeDB, sessionID, evt.JID, evt.Name.Name, "", time.UnixMilli(0), 0); err != nil {
+		log.Errorf("Failed to record the name of group %s: %v", evt.JID, err)
+	}
+}
+
+func saveChat(db *sql.DB, sessionID string, chat types.JID, name string, lastMessageID string, lastMessageAt time.Time, unread int) error {
+	_, err := db.Exec(upsertChat, sessionID, chat.ToNonAD().String(), name, lastMessageID, lastMessageAt.UnixMilli(), unread)
+	return err
+}
+
+// RecordChatRead resets the unread count of a chat read through the api, or marks it unread
+func RecordChatRead(client *whatsmeow.Client, chat types.JID, read bool) {
+	setChatRead(sessionIDOf(client), chat, read)
+}
+
+func setChatRead(sessionID string, chat types.JID, read bool) {
+	if storeDB == nil {
+		return
+	}
+	if err := markChatRead(storeDB, sessionID, chat, read); err != nil {
+		log.Errorf("Failed to record the read state of chat %s: %v", chat, err)
+	}
+}
+
+func markChatRead(db *sql.DB, sessionID string, chat types.JID, read bool) error {
+	query := updateChatRead
+	if !read {
+		query = updateChatUnread
+	}
+	_, err := db.Exec(query, sessionID, chat.ToNonAD().String())
+	return err
+}
+
+// ListChats returns a page of the chats of the session of the client, by last activity. A private chat without a
+// known name falls back to the name of the contact
+func ListChats(client *whatsmeow.Client, newestFirst bool, limit int, offset int) (chats []Chat, total int, err error) {
+	if storeDB == nil {
+		return nil, 0, fmt.Errorf("chat store is not initialized")
+	}
+	chats, total, err = listChats(storeDB, sessionIDOf(client), newestFirst, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range chats {
+		if chats[i].Name != "" || client.Store.Contacts == nil {
+			continue
+		}
+		jid, err := types.ParseJID(chats[i].JID)
+		if err != nil || jid.Server != types.DefaultUserServer {
+			continue
+		}
+		if contact, err := client.Store.Contacts.GetContact(jid); err == nil {
+			chats[i].Name = contactDisplayName(contact)
+		}
+	}
+	return chats, total, nil
+}
+
+func contactDisplayName(contact types.ContactInfo) string {
+	for _, name := range []string{contact.FullName, contact.FirstName, contact.PushName, contact.BusinessName} {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func listChats(db *sql.DB, sessionID string, newestFirst bool, limit int, offset int) ([]Chat, int, error) {
+	var total int
+	if err := db.QueryRow(countChats, sessionID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count chats: %w", err)
+	}
+
+	query := selectChatsOldest
+	if newestFirst {
+		query = selectChatsRecent
+	}
+	rows, err := db.Query(query, sessionID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query chats: %w", err)
+	}
+	defer rows.Close()
+
+	chats := []Chat{}
+	for rows.Next() {
+		var chat Chat
+		var lastMessageAt int64
+		if err = rows.Scan(&chat.JID, &chat.Name, &chat.LastMessageID, &lastMessageAt, &chat.UnreadCount); err != nil {
+			return nil, 0, fmt.Errorf("failed to read chat: %w", err)
+		}
+		if lastMessageAt > 0 {
+			chat.LastMessageAt = time.UnixMilli(lastMessageAt)
+		}
+		chats = append(chats, chat)
+	}
+	return chats, total, rows.Err()
+}
+
+// clearSessionChats forgets the chats recorded for a session
+func clearSessionChats(sessionID string) {
+	if storeDB == nil {
+		return
+	}
+	if _, err := storeDB.Exec(deleteSessionChats, sessionID); err != nil {
+		log.Errorf("Failed to clear the chats of session %s: %v", sessionID, err)
+	}
+}
@@ -0,0 +1,104 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestChatDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "chats.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, initLocalStores(db))
+	t.Cleanup(func() { storeDB = nil })
+	return db
+}
+
+func TestChatStore(t *testing.T) {
+	db := newTestChatDB(t)
+	alice := types.JID{User: "628123456789", Device: 12, Server: types.DefaultUserServer}
+	group := types.NewJID("120363025246125888", types.GroupServer)
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	require.NoError(t, saveChatMessage(db, "default", alice, "Alice", "3EB0A1", now, 1))
+	require.NoError(t, saveChatMessage(db, "default", alice, "", "3EB0A2", now.Add(time.Second), 1))
+	// An older message delivered late doesn't become the last message, it is still unread
+	require.NoError(t, saveChatMessage(db, "default", alice, "", "3EB0A0", now.Add(-time.Minute), 1))
+	require.NoError(t, saveChatMessage(db, "default", group, "", "3EB0G1", now.Add(2*time.Second), 1))
+	recordGroupName("default", &events.GroupInfo{JID: group, Name: &types.GroupName{Name: "Weekend hike"}})
+
+	chats, total, err := listChats(db, "default", true, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []Chat{
+		{JID: group.String(), Name: "Weekend hike", LastMessageID: "3EB0G1", LastMessageAt: now.Add(2 * time.Second), UnreadCount: 1},
+		{JID: "628123456789@s.whatsapp.net", Name: "Alice", LastMessageID: "3EB0A2", LastMessageAt: now.Add(time.Second), UnreadCount: 3},
+	}, chats)
+
+	// Writing in a chat reads it
+	require.NoError(t, saveChatMessage(db, "default", group, "", "3EB0G2", now.Add(3*time.Second), 0))
+	chats, _, err = listChats(db, "default", false, 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "628123456789@s.whatsapp.net", chats[0].JID)
+	chats, _, err = listChats(db, "default", false, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, chats[0].UnreadCount)
+
+	require.NoError(t, markChatRead(db, "default", alice, true))
+	require.NoError(t, markChatRead(db, "default", group, false))
+	chats, _, err = listChats(db, "default", true, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, chats[0].UnreadCount)
+	assert.Equal(t, 0, chats[1].UnreadCount)
+
+	// A history sync older than what is known doesn't change the chat, a newer one takes its unread count
+	require.NoError(t, saveChat(db, "default", alice, "", "3EB0OLD", now, 7))
+	require.NoError(t, saveChat(db, "sales", alice, "Alice", "3EB0H1", now, 2))
+	chats, _, err = listChats(db, "default", true, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "3EB0A2", chats[1].LastMessageID)
+	assert.Equal(t, 0, chats[1].UnreadCount)
+
+	clearSessionChats("default")
+	_, total, err = listChats(db, "default", true, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	chats, total, err = listChats(db, "sales", true, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, 2, chats[0].UnreadCount)
+}
+
+func TestRecordChatMessageRedelivered(t *testing.T) {
+	storeDB = newTestChatDB(t)
+	alice := types.NewJID("628123456789", types.DefaultUserServer)
+	evt := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: alice, Sender: alice},
+			ID:            "3EB0REDELIVERED",
+			Timestamp:     time.Now(),
+		},
+		Message: &waE2E.Message{Conversation: proto.String("Hello")},
+	}
+
+	// The same message delivered twice, e.g. after a reconnect, is unread once
+	for range 2 {
+		recordChatMessage("default", evt)
+		recordMessage("default", evt)
+	}
+
+	chats, _, err := listChats(storeDB, "default", true, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, 1, chats[0].UnreadCount)
+}
@@ -0,0 +1,107 @@
+package whatsapp
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// contactCheckBatchSize is the number of phones asked to whatsapp in a single query
+const contactCheckBatchSize = 50
+
+// OnWhatsappResult tells whether a phone number is registered on whatsapp and under which jid
+type OnWhatsappResult struct {
+	Phone        string
+	IsIn         bool
+	JID          types.JID
+	VerifiedName string
+}
+
+type onWhatsappChecker interface {
+	IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error)
+}
+
+// onWhatsappCache is keyed by session and phone
+var onWhatsappCache = utils.NewTTLCache[string, OnWhatsappResult]()
+
+// CheckOnWhatsapp looks the phones up on whatsapp, results are cached for config.WhatsappContactCheckCacheTTL
+// because whatsapp flags accounts that query too many numbers. Results keep the order of the phones
+func CheckOnWhatsapp(client *whatsmeow.Client, phones []string) ([]OnWhatsappResult, error) {
+	if client == nil {
+		return nil, pkgError.ErrWaCLI
+	}
+	return checkOnWhatsapp(sessionIDOf(client), client, phones)
+}
+
+func checkOnWhatsapp(sessionID string, checker onWhatsappChecker, phones []string) ([]OnWhatsappResult, error) {
+	known := make(map[string]OnWhatsappResult, len(phones))
+	var missing []string
+
+	// +62 812-345 and 62812345@s.whatsapp.net are the same number
+	normalized := make([]string, len(phones))
+	for i, phone := range phones {
+		jid, err := NormalizeJID(phone)
+		if err != nil {
+			return nil, err
+		}
+		if jid.Server != types.DefaultUserServer {
+			return nil, pkgError.InvalidJID(fmt.Sprintf("%s is not a phone number", phone))
+		}
+		normalized[i] = jid.User
+	}
+
+	for _, phone := range normalized {
+		if _, ok := known[phone]; ok || slices.Contains(missing, phone) {
+			continue
+		}
+		if cached, ok := onWhatsappCache.Get(sessionID + "|" + phone); ok {
+			known[phone] = cached
+			continue
+		}
+		missing = append(missing, phone)
+	}
+
+	for start := 0; start < len(missing); start += contactCheckBatchSize {
+		batch := missing[start:min(start+contactCheckBatchSize, len(missing))]
+		queries := make([]string, len(batch))
+		for i, phone := range batch {
+			queries[i] = "+" + phone
+		}
+
+		responses, err := checker.IsOnWhatsApp(queries)
+		if err != nil {
+			return nil, pkgError.InternalServerError("failed to check the phones on whatsapp: " + err.Error())
+		}
+
+		for _, phone := range batch {
+			// Numbers missing from the answer are not registered
+			known[phone] = OnWhatsappResult{Phone: phone}
+		}
+		for _, response := range responses {
+			phone := strings.TrimPrefix(response.Query, "+")
+			result := OnWhatsappResult{Phone: phone, IsIn: response.IsIn}
+			if response.IsIn {
+				result.JID = response.JID
+			}
+			if response.VerifiedName != nil {
+				result.VerifiedName = response.VerifiedName.Details.GetVerifiedName()
+			}
+			known[phone] = result
+		}
+		for _, phone := range batch {
+			onWhatsappCache.Set(sessionID+"|"+phone, known[phone], config.WhatsappContactCheckCacheTTL, 0)
+		}
+	}
+
+	results := make([]OnWhatsappResult, 0, len(phones))
+	for _, phone := range normalized {
+		results = append(results, known[phone])
+	}
+	return results, nil
+}
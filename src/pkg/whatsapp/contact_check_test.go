@@ -0,0 +1,97 @@
+package whatsapp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// fakeOnWhatsappChecker registers the phones ending with an even digit
+type fakeOnWhatsappChecker struct {
+	batches [][]string
+	err     error
+}
+
+func (f *fakeOnWhatsappChecker) IsOnWhatsApp(phones []string) ([]types.IsOnWhatsAppResponse, error) {
+	f.batches = append(f.batches, phones)
+	if f.err != nil {
+		return nil, f.err
+	}
+	var responses []types.IsOnWhatsAppResponse
+	for _, phone := range phones {
+		user := strings.TrimPrefix(phone, "+")
+		isIn := (user[len(user)-1]-'0')%2 == 0
+		responses = append(responses, types.IsOnWhatsAppResponse{Query: phone, IsIn: isIn, JID: types.NewJID(user, types.DefaultUserServer)})
+	}
+	return responses, nil
+}
+
+func resetOnWhatsappCache(t *testing.T) {
+	previous := config.WhatsappContactCheckCacheTTL
+	config.WhatsappContactCheckCacheTTL = time.Hour
+	onWhatsappCache = utils.NewTTLCache[string, OnWhatsappResult]()
+	t.Cleanup(func() { config.WhatsappContactCheckCacheTTL = previous })
+}
+
+func TestCheckOnWhatsapp(t *testing.T) {
+	resetOnWhatsappCache(t)
+	checker := &fakeOnWhatsappChecker{}
+
+	results, err := checkOnWhatsapp(DefaultSessionID, checker, []string{"+62 812-2002", "62812001", "62812002@s.whatsapp.net"})
+	require.NoError(t, err)
+	assert.Equal(t, []OnWhatsappResult{
+		{Phone: "628122002", IsIn: true, JID: types.NewJID("628122002", types.DefaultUserServer)},
+		{Phone: "62812001"},
+		{Phone: "62812002", IsIn: true, JID: types.NewJID("62812002", types.DefaultUserServer)},
+	}, results)
+	assert.Equal(t, [][]string{{"+628122002", "+62812001", "+62812002"}}, checker.batches)
+
+	// Duplicates are asked once, cached phones are not asked again
+	_, err = checkOnWhatsapp(DefaultSessionID, checker, []string{"62812001", "+62 812 004", "62812004"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"+62812004"}, checker.batches[1])
+}
+
+func TestCheckOnWhatsappRejectsGroups(t *testing.T) {
+	resetOnWhatsappCache(t)
+	checker := &fakeOnWhatsappChecker{}
+
+	_, err := checkOnWhatsapp(DefaultSessionID, checker, []string{"120363025246125888@g.us"})
+	assert.Error(t, err)
+	assert.Empty(t, checker.batches)
+}
+
+func TestCheckOnWhatsappBatches(t *testing.T) {
+	resetOnWhatsappCache(t)
+	checker := &fakeOnWhatsappChecker{}
+
+	var phones []string
+	for i := 0; i < contactCheckBatchSize+10; i++ {
+		phones = append(phones, fmt.Sprintf("62812%04d", i))
+	}
+	results, err := checkOnWhatsapp(DefaultSessionID, checker, phones)
+	require.NoError(t, err)
+	assert.Len(t, results, len(phones))
+	assert.Len(t, checker.batches, 2)
+	assert.Len(t, checker.batches[0], contactCheckBatchSize)
+	assert.Len(t, checker.batches[1], 10)
+}
+
+func TestCheckOnWhatsappError(t *testing.T) {
+	resetOnWhatsappCache(t)
+	checker := &fakeOnWhatsappChecker{err: errors.New("rate-overlimit")}
+
+	_, err := checkOnWhatsapp(DefaultSessionID, checker, []string{"62812001"})
+	assert.Error(t, err)
+	// Failures are not cached
+	_, _ = checkOnWhatsapp(DefaultSessionID, checker, []string{"62812001"})
+	assert.Len(t, checker.batches, 2)
+}
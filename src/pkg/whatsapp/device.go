@@ -0,0 +1,81 @@
+package whatsapp
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+const whatsmeowModule = "go.mau.fi/whatsmeow"
+
+// DeviceInfo is the device linked to a session, read from the store and the connection without a round trip to whatsapp
+type DeviceInfo struct {
+	JID          string
+	LID          string
+	Platform     string
+	PushName     string
+	BusinessName string
+	IsConnected  bool
+	IsLoggedIn   bool
+	// ConnectedAt is zero while the session isn't connected
+	ConnectedAt      time.Time
+	WhatsmeowVersion string
+}
+
+var (
+	connectedAtMu sync.RWMutex
+	connectedAt   = map[string]time.Time{}
+)
+
+// markConnected records the time the session connected, a reconnect starts the uptime over
+func markConnected(sessionID string) {
+	connectedAtMu.Lock()
+	defer connectedAtMu.Unlock()
+	connectedAt[sessionID] = time.Now()
+}
+
+// whatsmeowVersion is the version of the whatsmeow module built into the binary, the replacement one if replaced
+var whatsmeowVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != whatsmeowModule {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+})
+
+// GetDeviceInfo describes the device of the client session, it neither connects nor reconnects the client
+func GetDeviceInfo(client *whatsmeow.Client) DeviceInfo {
+	info := DeviceInfo{
+		IsConnected:      client.IsConnected(),
+		IsLoggedIn:       client.IsLoggedIn(),
+		WhatsmeowVersion: whatsmeowVersion(),
+	}
+	if device := client.Store; device != nil {
+		if device.ID != nil {
+			info.JID = device.ID.String()
+		}
+		if !device.LID.IsEmpty() {
+			info.LID = device.LID.String()
+		}
+		info.Platform = device.Platform
+		info.PushName = device.PushName
+		info.BusinessName = device.BusinessName
+	}
+	if info.IsConnected {
+		connectedAtMu.RLock()
+		info.ConnectedAt = connectedAt[sessionIDOf(client)]
+		connectedAtMu.RUnlock()
+	}
+	return info
+}
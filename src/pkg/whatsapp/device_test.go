@@ -0,0 +1,32 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestGetDeviceInfo(t *testing.T) {
+	jid := types.NewADJID("628123456789", 0, 3)
+	client := &whatsmeow.Client{Store: &store.Device{
+		ID:           &jid,
+		Platform:     "smba",
+		PushName:     "Acme",
+		BusinessName: "Acme Store",
+	}}
+	markConnected(sessionIDOf(client))
+
+	info := GetDeviceInfo(client)
+	assert.Equal(t, "628123456789:3@s.whatsapp.net", info.JID)
+	assert.Empty(t, info.LID)
+	assert.Equal(t, "smba", info.Platform)
+	assert.Equal(t, "Acme", info.PushName)
+	assert.Equal(t, "Acme Store", info.BusinessName)
+	assert.False(t, info.IsConnected)
+	// The uptime only counts while the client is connected
+	assert.True(t, info.ConnectedAt.IsZero())
+	assert.NotEmpty(t, info.WhatsmeowVersion)
+}
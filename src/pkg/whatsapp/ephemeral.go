@@ -0,0 +1,78 @@
+package whatsapp
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// DisappearingTimers are the disappearing message durations (in seconds) accepted by WhatsApp, 0 means off
+var DisappearingTimers = []uint32{
+	uint32(whatsmeow.DisappearingTimerOff.Seconds()),
+	uint32(whatsmeow.DisappearingTimer24Hours.Seconds()),
+	uint32(whatsmeow.DisappearingTimer7Days.Seconds()),
+	uint32(whatsmeow.DisappearingTimer90Days.Seconds()),
+}
+
+// chatEphemeralTimers caches the disappearing timer of chats, keyed by session and the chat jid without device
+var chatEphemeralTimers sync.Map
+
+func chatEphemeralTimerKey(sessionID string, chat types.JID) string {
+	return sessionID + "|" + chat.ToNonAD().String()
+}
+
+// SetChatEphemeralTimer remembers the disappearing timer of a chat of the session of the client
+func SetChatEphemeralTimer(client *whatsmeow.Client, chat types.JID, timer time.Duration) {
+	chatEphemeralTimers.Store(chatEphemeralTimerKey(sessionIDOf(client), chat), uint32(timer.Seconds()))
+}
+
+// GetChatEphemeralTimer returns the disappearing timer in seconds of a chat.
+// Private chats are learned from incoming messages, groups are fetched from the group info when unknown.
+func GetChatEphemeralTimer(waCli *whatsmeow.Client, chat types.JID) uint32 {
+	key := chatEphemeralTimerKey(sessionIDOf(waCli), chat)
+	if timer, ok := chatEphemeralTimers.Load(key); ok {
+		return timer.(uint32)
+	}
+
+	if chat.Server == types.GroupServer && waCli != nil {
+		groupInfo, err := waCli.GetGroupInfo(chat)
+		if err != nil {
+			log.Warnf("Failed to get disappearing timer of %s: %v", chat.String(), err)
+			return 0
+		}
+		timer := uint32(0)
+		if groupInfo.IsEphemeral {
+			timer = groupInfo.DisappearingTimer
+		}
+		chatEphemeralTimers.Store(key, timer)
+		return timer
+	}
+	return 0
+}
+
+// trackChatEphemeralTimer learns the disappearing timer of a chat from an incoming message
+func trackChatEphemeralTimer(sessionID string, evt *events.Message) {
+	key := chatEphemeralTimerKey(sessionID, evt.Info.Chat)
+	if protocolMessage := evt.Message.GetProtocolMessage(); protocolMessage.GetType() == waE2E.ProtocolMessage_EPHEMERAL_SETTING {
+		chatEphemeralTimers.Store(key, protocolMessage.GetEphemeralExpiration())
+		return
+	}
+	if contextInfo := getMessageContextInfo(evt.Message); contextInfo != nil && contextInfo.Expiration != nil {
+		chatEphemeralTimers.Store(key, contextInfo.GetExpiration())
+	}
+}
+
+// clearSessionEphemeralTimers forgets the disappearing timers of the chats of a session
+func clearSessionEphemeralTimers(sessionID string) {
+	chatEphemeralTimers.Range(func(key, _ any) bool {
+		if strings.HasPrefix(key.(string), sessionID+"|") {
+			chatEphemeralTimers.Delete(key)
+		}
+		return true
+	})
+}
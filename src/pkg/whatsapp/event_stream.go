@@ -0,0 +1,60 @@
+package whatsapp
+
+import "sync"
+
+// eventStream is a stream of the event payloads registered with RegisterEventStream, like /ws/events
+type eventStream struct {
+	publish func(eventType, sessionID string, payload any)
+	active  func() bool
+}
+
+var (
+	eventStreams         []eventStream
+	loginChangeListeners []func(code, message string, result any)
+	eventStreamsMu       sync.RWMutex
+)
+
+// RegisterEventStream adds a stream every event payload is published to, active reports whether a client is
+// listening so the payloads are only built when someone receives them
+func RegisterEventStream(publish func(eventType, sessionID string, payload any), active func() bool) {
+	eventStreamsMu.Lock()
+	defer eventStreamsMu.Unlock()
+	eventStreams = append(eventStreams, eventStream{publish: publish, active: active})
+}
+
+// RegisterLoginChangeListener adds a listener of the login changes like LOGIN_SUCCESS, shown by the web ui
+func RegisterLoginChangeListener(listener func(code, message string, result any)) {
+	eventStreamsMu.Lock()
+	defer eventStreamsMu.Unlock()
+	loginChangeListeners = append(loginChangeListeners, listener)
+}
+
+// hasEventStreams reports whether a registered stream has a client, like a /ws/events or /events/sse client
+func hasEventStreams() bool {
+	eventStreamsMu.RLock()
+	defer eventStreamsMu.RUnlock()
+	for _, stream := range eventStreams {
+		if stream.active() {
+			return true
+		}
+	}
+	return false
+}
+
+// publishEvent sends the payload to every registered stream, the streams filter the event types on their own
+func publishEvent(eventType, sessionID string, payload any) {
+	eventStreamsMu.RLock()
+	defer eventStreamsMu.RUnlock()
+	for _, stream := range eventStreams {
+		stream.publish(eventType, sessionID, payload)
+	}
+}
+
+// broadcastLoginChange sends a login change to every registered listener
+func broadcastLoginChange(code, message string, result any) {
+	eventStreamsMu.RLock()
+	defer eventStreamsMu.RUnlock()
+	for _, listener := range loginChangeListeners {
+		listener(code, message, result)
+	}
+}
@@ -0,0 +1,27 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterEventStream(t *testing.T) {
+	origStreams := eventStreams
+	defer func() { eventStreams = origStreams }()
+	eventStreams = nil
+
+	assert.False(t, hasEventStreams())
+
+	var active bool
+	var published []string
+	RegisterEventStream(func(eventType, sessionID string, _ any) {
+		published = append(published, sessionID+":"+eventType)
+	}, func() bool { return active })
+	assert.False(t, hasEventStreams())
+
+	active = true
+	assert.True(t, hasEventStreams())
+	publishEvent("message", "default", map[string]any{"event_type": "message"})
+	assert.Equal(t, []string{"default:message"}, published)
+}
@@ -0,0 +1,84 @@
+package whatsapp
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+const (
+	ConnectionStateConnected  = "connected"
+	ConnectionStateConnecting = "connecting"
+	ConnectionStateLoggedOut  = "logged_out"
+)
+
+// SessionHealth is the connection state of a session, computed locally without a round trip to whatsapp. It is
+// served without credentials, so it doesn't tell the account of the session
+type SessionHealth struct {
+	SessionID   string     `json:"session_id"`
+	State       string     `json:"state"`
+	LastEventAt *time.Time `json:"last_event_at,omitempty"`
+}
+
+// IsExpectedConnected tells whether the session is paired and so should be connected, a logged out session is
+// waiting to be paired on purpose
+func (health SessionHealth) IsExpectedConnected() bool {
+	return health.State != ConnectionStateLoggedOut
+}
+
+var (
+	lastEventMu sync.RWMutex
+	lastEventAt = map[string]time.Time{}
+)
+
+// touchSession records that the session just received an event from whatsapp
+func touchSession(sessionID string) {
+	lastEventMu.Lock()
+	defer lastEventMu.Unlock()
+	lastEventAt[sessionID] = time.Now()
+}
+
+// connectionState tells whether the client is connected, logged out, or still (re)connecting with a saved session
+func connectionState(client *whatsmeow.Client) string {
+	switch {
+	case client == nil || client.Store == nil || client.Store.ID == nil:
+		return ConnectionStateLoggedOut
+	case client.IsLoggedIn():
+		return ConnectionStateConnected
+	default:
+		return ConnectionStateConnecting
+	}
+}
+
+// GetSessionsHealth returns the health of every session, the default session first
+func GetSessionsHealth() []SessionHealth {
+	sessionsMu.RLock()
+	ids := make([]string, 0, len(sessionClients))
+	for id := range sessionClients {
+		ids = append(ids, id)
+	}
+	sessionsMu.RUnlock()
+
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i] == DefaultSessionID || ids[j] == DefaultSessionID {
+			return ids[i] == DefaultSessionID
+		}
+		return ids[i] < ids[j]
+	})
+
+	lastEventMu.RLock()
+	defer lastEventMu.RUnlock()
+
+	result := make([]SessionHealth, 0, len(ids))
+	for _, id := range ids {
+		client := GetSessionClient(id)
+		health := SessionHealth{SessionID: id, State: connectionState(client)}
+		if at, ok := lastEventAt[id]; ok {
+			health.LastEventAt = &at
+		}
+		result = append(result, health)
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestConnectionState(t *testing.T) {
+	assert.Equal(t, ConnectionStateLoggedOut, connectionState(nil))
+	assert.Equal(t, ConnectionStateLoggedOut, connectionState(&whatsmeow.Client{Store: &store.Device{}}))
+
+	jid := types.NewADJID("628123456789", 0, 3)
+	assert.Equal(t, ConnectionStateConnecting, connectionState(&whatsmeow.Client{Store: &store.Device{ID: &jid}}))
+}
+
+func TestGetSessionsHealth(t *testing.T) {
+	jid := types.NewADJID("628123456789", 0, 3)
+	registerSession("zz-health", &whatsmeow.Client{Store: &store.Device{ID: &jid}})
+	t.Cleanup(func() {
+		sessionsMu.Lock()
+		delete(sessionClients, "zz-health")
+		sessionsMu.Unlock()
+	})
+	touchSession("zz-health")
+
+	sessions := GetSessionsHealth()
+	last := sessions[len(sessions)-1]
+	assert.Equal(t, "zz-health", last.SessionID)
+	assert.Equal(t, ConnectionStateConnecting, last.State)
+	assert.True(t, last.IsExpectedConnected())
+	assert.NotNil(t, last.LastEventAt)
+	assert.False(t, SessionHealth{State: ConnectionStateLoggedOut}.IsExpectedConnected())
+}
@@ -2,22 +2,23 @@ package whatsapp
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
-	"github.com/aldinokemal/go-whatsapp-web-multidevice/internal/websocket"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/metrics"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
-	"github.com/sirupsen/logrus"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/appstate"
 	"go.mau.fi/whatsmeow/proto/waE2E"
-	"go.mau.fi/whatsmeow/store"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
@@ -35,6 +36,36 @@ type ExtractedMedia struct {
 type evtReaction struct {
 	ID      string `json:"id,omitempty"`
 	Message string `json:"message,omitempty"`
+	Action  string `json:"action,omitempty"`
+}
+
+type evtQuoted struct {
+	ID          string `json:"id,omitempty"`
+	Participant string `json:"participant,omitempty"`
+	Text        string `json:"text,omitempty"`
+}
+
+type evtPoll struct {
+	Question  string   `json:"question"`
+	Options   []string `json:"options"`
+	MaxAnswer uint32   `json:"max_answer,omitempty"`
+}
+
+type evtPollVote struct {
+	PollID          string   `json:"poll_id"`
+	SelectedOptions []string `json:"selected_options,omitempty"`
+	Decrypted       bool     `json:"decrypted"`
+}
+
+type evtButtonsResponse struct {
+	SelectedButtonID    string `json:"selected_button_id"`
+	SelectedDisplayText string `json:"selected_display_text,omitempty"`
+}
+
+type evtListResponse struct {
+	SelectedRowID string `json:"selected_row_id"`
+	Title         string `json:"title,omitempty"`
+	Description   string `json:"description,omitempty"`
 }
 
 type evtMessage struct {
@@ -46,16 +77,17 @@ type evtMessage struct {
 
 // Global variables
 var (
-	cli           *whatsmeow.Client
 	log           waLog.Logger
 	historySyncID int32
 	startupTime   = time.Now().Unix()
+	// databaseSchemePattern matches the scheme of a database uri, a sqlite path has none
+	databaseSchemePattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*)://`)
 )
 
 // InitWaDB initializes the WhatsApp database connection
 func InitWaDB() *sqlstore.Container {
-	log = waLog.Stdout("Main", config.WhatsappLogLevel, true)
-	dbLog := waLog.Stdout("Database", config.WhatsappLogLevel, true)
+	log = newLogger("Main")
+	dbLog := newLogger("Database")
 
 	storeContainer, err := initDatabase(dbLog)
 	if err != nil {
@@ -66,81 +98,132 @@ func InitWaDB() *sqlstore.Container {
 	return storeContainer
 }
 
-// initDatabase creates and returns a database store container based on the configured URI
+// initDatabase creates and returns a database store container based on the configured URI,
+// the whatsmeow tables and the reaction table are created or migrated before the container is returned
 func initDatabase(dbLog waLog.Logger) (*sqlstore.Container, error) {
-	if strings.HasPrefix(config.DBURI, "file:") {
-		return sqlstore.New("sqlite3", config.DBURI, dbLog)
-	} else if strings.HasPrefix(config.DBURI, "postgres:") {
-		return sqlstore.New("postgres", config.DBURI, dbLog)
+	dialect, uri, err := databaseDialect(config.DBURI)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(dialect, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s database: %w", dialect, err)
+	}
+	if dialect == "postgres" {
+		// Several replicas share the database, so every one of them keeps a bounded pool
+		db.SetMaxOpenConns(config.DBMaxOpenConns)
+		db.SetMaxIdleConns(config.DBMaxIdleConns)
+		db.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	}
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to connect to %s database: %w", dialect, err)
 	}
 
-	return nil, fmt.Errorf("unknown database type: %s. Currently only sqlite3(file:) and postgres are supported", config.DBURI)
+	container := sqlstore.NewWithDB(db, dialect, dbLog)
+	if err = container.Upgrade(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to upgrade database: %w", err)
+	}
+	if err = initLocalStores(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return container, nil
 }
 
-// InitWaCLI initializes the WhatsApp client
-func InitWaCLI(storeContainer *sqlstore.Container) *whatsmeow.Client {
-	device, err := storeContainer.GetFirstDevice()
-	if err != nil {
-		log.Errorf("Failed to get device: %v", err)
-		panic(err)
-	}
-
-	if device == nil {
-		log.Errorf("No device found")
-		panic("No device found")
-	}
-
-	// Configure device properties
-	osName := fmt.Sprintf("%s %s", config.AppOs, config.AppVersion)
-	store.DeviceProps.PlatformType = &config.AppPlatform
-	store.DeviceProps.Os = &osName
-
-	// Create and configure the client
-	cli = whatsmeow.NewClient(device, waLog.Stdout("Client", config.WhatsappLogLevel, true))
-	cli.EnableAutoReconnect = true
-	cli.AutoTrustIdentity = true
-	cli.AddEventHandler(handler)
-
-	return cli
-}
-
-// handler is the main event handler for WhatsApp events
-func handler(rawEvt interface{}) {
-	switch evt := rawEvt.(type) {
-	case *events.DeleteForMe:
-		handleDeleteForMe(evt)
-	case *events.AppStateSyncComplete:
-		handleAppStateSyncComplete(evt)
-	case *events.PairSuccess:
-		handlePairSuccess(evt)
-	case *events.LoggedOut:
-		handleLoggedOut()
-	case *events.Connected, *events.PushNameSetting:
-		handleConnectionEvents()
-	case *events.StreamReplaced:
-		handleStreamReplaced()
-	case *events.Message:
-		handleMessage(evt)
-	case *events.Receipt:
-		handleReceipt(evt)
-	case *events.Presence:
-		handlePresence(evt)
-	case *events.HistorySync:
-		handleHistorySync(evt)
-	case *events.AppState:
-		handleAppState(evt)
+// databaseDialect picks the driver of the uri: postgres:// and postgresql:// use postgres, file: and plain paths use
+// sqlite, any other scheme is an error. A plain sqlite path is turned into a file: uri with foreign keys on,
+// whatsmeow requires them
+func databaseDialect(uri string) (dialect string, dsn string, err error) {
+	if strings.HasPrefix(uri, "postgres:") || strings.HasPrefix(uri, "postgresql:") {
+		return "postgres", uri, nil
+	}
+	if strings.HasPrefix(uri, "file:") {
+		return "sqlite3", uri, nil
+	}
+	// Only the scheme is told, the rest of the uri may hold the credentials
+	if scheme := databaseSchemePattern.FindStringSubmatch(uri); scheme != nil {
+		return "", "", fmt.Errorf("unknown database type: %s. Currently only sqlite3(file:) and postgres are supported", scheme[1])
+	}
+	separator := "?"
+	if strings.Contains(uri, "?") {
+		separator = "&"
+	}
+	return "sqlite3", "file:" + uri + separator + "_foreign_keys=on", nil
+}
+
+// newEventHandler returns the handler of the events received by the client of a session
+func newEventHandler(sessionID string, client *whatsmeow.Client) func(rawEvt interface{}) {
+	return func(rawEvt interface{}) {
+		touchSession(sessionID)
+
+		switch evt := rawEvt.(type) {
+		case *events.DeleteForMe:
+			handleDeleteForMe(evt)
+		case *events.AppStateSyncComplete:
+			handleAppStateSyncComplete(client, evt)
+		case *events.PairSuccess:
+			handlePairSuccess(sessionID, client, evt)
+		case *events.LoggedOut:
+			handleLoggedOut(client)
+			handleConnectionWebhook(sessionID, evt)
+		case *events.Connected:
+			markConnected(sessionID)
+			if client.Store.ID != nil {
+				SetLoginState(client, LoginStateLoggedIn, time.Time{})
+			}
+			handleConnectionEvents(client)
+			resubscribePresences(sessionID, client)
+			handleConnectionWebhook(sessionID, evt)
+		case *events.PushNameSetting:
+			handleConnectionEvents(client)
+		case *events.Disconnected:
+			handleConnectionWebhook(sessionID, evt)
+		case *events.StreamReplaced:
+			handleStreamReplaced(sessionID, client)
+		case *events.Message:
+			handleMessage(sessionID, client, evt)
+		case *events.Receipt:
+			handleReceipt(sessionID, evt)
+		case *events.Presence:
+			handlePresence(sessionID, evt)
+		case *events.ChatPresence:
+			handleChatPresence(sessionID, evt)
+		case *events.GroupInfo:
+			handleGroupInfo(sessionID, evt)
+		case *events.HistorySync:
+			handleHistorySync(client, evt)
+			recordHistoryChats(sessionID, evt)
+		case *events.MarkChatAsRead:
+			setChatRead(sessionID, evt.JID, evt.Action.GetRead())
+		case *events.AppState:
+			handleAppState(evt)
+		}
+		handleRawEvent(sessionID, rawEvt)
 	}
 }
 
 // Event handler functions
 
+// handleRawEvent forwards the events without a webhook payload of their own when config.WhatsappWebhookRawEvents is
+// set, webhookEventType leaves out the sensitive and the sync events
+func handleRawEvent(sessionID string, evt any) {
+	if webhookEventType(evt) != "raw" {
+		return
+	}
+	if isEventForwardingEnabled() {
+		enqueueWebhookEvent(sessionID, evt)
+	}
+}
+
 func handleDeleteForMe(evt *events.DeleteForMe) {
 	log.Infof("Deleted message %s for %s", evt.MessageID, evt.SenderJID.String())
 }
 
-func handleAppStateSyncComplete(evt *events.AppStateSyncComplete) {
-	if len(cli.Store.PushName) > 0 && evt.Name == appstate.WAPatchCriticalBlock {
-		if err := cli.SendPresence(types.PresenceAvailable); err != nil {
+func handleAppStateSyncComplete(client *whatsmeow.Client, evt *events.AppStateSyncComplete) {
+	if len(client.Store.PushName) > 0 && evt.Name == appstate.WAPatchCriticalBlock {
+		if err := SendPresence(client, types.PresenceAvailable); err != nil {
 			log.Warnf("Failed to send available presence: %v", err)
 		} else {
 			log.Infof("Marked self as available")
@@ -148,39 +231,47 @@ func handleAppStateSyncComplete(evt *events.AppStateSyncComplete) {
 	}
 }
 
-func handlePairSuccess(evt *events.PairSuccess) {
-	websocket.Broadcast <- websocket.BroadcastMessage{
-		Code:    "LOGIN_SUCCESS",
-		Message: fmt.Sprintf("Successfully pair with %s", evt.ID.String()),
+func handlePairSuccess(sessionID string, client *whatsmeow.Client, evt *events.PairSuccess) {
+	SetLoginState(client, LoginStateLoggedIn, time.Time{})
+	if err := rememberSessionDevice(sessionID, evt.ID); err != nil {
+		log.Errorf("Failed to remember the device of session %s: %v", sessionID, err)
 	}
+	broadcastLoginChange("LOGIN_SUCCESS", fmt.Sprintf("Successfully pair with %s", evt.ID.String()), nil)
 }
 
-func handleLoggedOut() {
-	websocket.Broadcast <- websocket.BroadcastMessage{
-		Code:   "LIST_DEVICES",
-		Result: nil,
-	}
+func handleLoggedOut(client *whatsmeow.Client) {
+	SetLoginState(client, LoginStateLoggedOut, time.Time{})
+	broadcastLoginChange("LIST_DEVICES", "", nil)
 }
 
-func handleConnectionEvents() {
-	if len(cli.Store.PushName) == 0 {
+func handleConnectionEvents(client *whatsmeow.Client) {
+	if len(client.Store.PushName) == 0 {
 		return
 	}
 
 	// Send presence available when connecting and when the pushname is changed.
 	// This makes sure that outgoing messages always have the right pushname.
-	if err := cli.SendPresence(types.PresenceAvailable); err != nil {
+	if err := SendPresence(client, types.PresenceAvailable); err != nil {
 		log.Warnf("Failed to send available presence: %v", err)
 	} else {
 		log.Infof("Marked self as available")
 	}
 }
 
-func handleStreamReplaced() {
-	os.Exit(0)
+// handleConnectionWebhook forwards the connected, disconnected and logged out events
+func handleConnectionWebhook(sessionID string, evt any) {
+	if isEventForwardingEnabled() {
+		enqueueWebhookEvent(sessionID, evt)
+	}
 }
 
-func handleMessage(evt *events.Message) {
+// handleStreamReplaced disconnects a session another client connected with, the other sessions keep running
+func handleStreamReplaced(sessionID string, client *whatsmeow.Client) {
+	log.Warnf("Session %s was replaced by another connection of the same device, disconnecting it", sessionID)
+	client.Disconnect()
+}
+
+func handleMessage(sessionID string, client *whatsmeow.Client, evt *events.Message) {
 	// Log message metadata
 	metaParts := buildMessageMetaParts(evt)
 	log.Infof("Received message %s from %s (%s): %+v",
@@ -190,18 +281,73 @@ func handleMessage(evt *events.Message) {
 		evt.Message,
 	)
 
+	metrics.MessagesReceived.WithLabelValues(messageTypeLabel(evt)).Inc()
+
 	// Record the message
 	message := ExtractMessageText(evt)
 	utils.RecordMessage(evt.Info.ID, evt.Info.Sender.String(), message)
 
+	// Remember the disappearing timer of the chat for outgoing messages
+	trackChatEphemeralTimer(sessionID, evt)
+
+	// Keep the media keys so the media can be downloaded on demand
+	cacheMessageMedia(sessionID, evt)
+
+	// Keep the content so the message can be forwarded or quoted
+	cacheMessageContent(sessionID, evt)
+
+	// Keep the reactions so GET /message/:id/reactions survives a restart
+	recordReaction(sessionID, evt)
+
+	// Keep the chat list of GET /chats, before the history so a redelivered message is told apart
+	recordChatMessage(sessionID, evt)
+
+	// Keep the history of GET /chat/:jid/messages
+	recordMessage(sessionID, evt)
+
+	// Keep the statuses of contacts for GET /status
+	recordStatus(sessionID, evt)
+
+	// Messages sent from our other devices can be edited or revoked as well, and their delivery is tracked
+	recordMessageSent(sessionID, evt)
+
 	// Handle image message if present
-	handleImageMessage(evt)
+	handleImageMessage(client, evt)
 
 	// Handle auto-reply if configured
-	handleAutoReply(evt)
+	handleAutoReply(client, evt)
 
 	// Forward to webhook if configured
-	handleWebhookForward(evt)
+	handleWebhookForward(sessionID, client, evt)
+}
+
+// handleSentMessage keeps a message sent through the api like one sent from another device of this account,
+// whatsapp doesn't echo it back as an event
+func handleSentMessage(client *whatsmeow.Client, to types.JID, resp whatsmeow.SendResponse, msg *waE2E.Message) {
+	if client.Store.ID == nil {
+		return
+	}
+	sessionID := sessionIDOf(client)
+	evt := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{
+				Chat:     to,
+				Sender:   client.Store.ID.ToNonAD(),
+				IsFromMe: true,
+				IsGroup:  to.Server == types.GroupServer,
+			},
+			ID:        resp.ID,
+			PushName:  client.Store.PushName,
+			Timestamp: resp.Timestamp,
+		},
+		Message: msg,
+	}
+
+	cacheMessageMedia(sessionID, evt)
+	cacheMessageContent(sessionID, evt)
+	recordChatMessage(sessionID, evt)
+	recordMessage(sessionID, evt)
+	recordMessageSent(sessionID, evt)
 }
 
 func buildMessageMetaParts(evt *events.Message) []string {
@@ -221,9 +367,12 @@ func buildMessageMetaParts(evt *events.Message) []string {
 	return metaParts
 }
 
-func handleImageMessage(evt *events.Message) {
+func handleImageMessage(client *whatsmeow.Client, evt *events.Message) {
 	if img := evt.Message.GetImageMessage(); img != nil {
-		if path, err := ExtractMedia(config.PathStorages, img); err != nil {
+		var tooLarge *MediaTooLargeError
+		if path, err := ExtractMedia(client, config.PathStorages, img); errors.As(err, &tooLarge) {
+			return
+		} else if err != nil {
 			log.Errorf("Failed to download image: %v", err)
 		} else {
 			log.Infof("Image downloaded to %s", path)
@@ -231,47 +380,76 @@ func handleImageMessage(evt *events.Message) {
 	}
 }
 
-func handleAutoReply(evt *events.Message) {
+func handleAutoReply(client *whatsmeow.Client, evt *events.Message) {
+	// A channel can't be answered, only its owner and admins post to it
+	if evt.Info.Chat.Server == types.NewsletterServer {
+		return
+	}
+
+	// A keyword rule answers in the chat the message came from, and replaces the fixed auto reply
+	if !evt.Info.IsFromMe && !evt.Info.IsIncomingBroadcast() {
+		text := evt.Message.GetConversation()
+		if text == "" {
+			text = evt.Message.GetExtendedTextMessage().GetText()
+		}
+		if rule, ok := matchAutoReply(evt.Info.Chat, text); ok {
+			log.Debugf("Auto reply rule %s matched message %s", rule.ID, evt.Info.ID)
+			go func() {
+				_, _ = SendMessage(
+					context.Background(),
+					client,
+					evt.Info.Chat.ToNonAD(),
+					&waE2E.Message{Conversation: proto.String(rule.Reply)},
+				)
+			}()
+			return
+		}
+	}
+
 	if config.WhatsappAutoReplyMessage != "" &&
 		!isGroupJid(evt.Info.Chat.String()) &&
 		!evt.Info.IsIncomingBroadcast() &&
 		evt.Message.GetExtendedTextMessage().GetText() != "" {
-		_, _ = cli.SendMessage(
-			context.Background(),
-			FormatJID(evt.Info.Sender.String()),
-			&waE2E.Message{Conversation: proto.String(config.WhatsappAutoReplyMessage)},
-		)
+		// Sent aside from the event handler so a blocking send limiter doesn't hold the following events
+		go func() {
+			_, _ = SendMessage(
+				context.Background(),
+				client,
+				FormatJID(evt.Info.Sender.String()),
+				&waE2E.Message{Conversation: proto.String(config.WhatsappAutoReplyMessage)},
+			)
+		}()
 	}
 }
 
-func handleWebhookForward(evt *events.Message) {
-	if len(config.WhatsappWebhook) > 0 &&
-		!strings.Contains(evt.Info.SourceString(), "broadcast") &&
-		!isFromMySelf(evt.Info.SourceString()) {
-		go func(evt *events.Message) {
-			if err := forwardToWebhook(evt); err != nil {
-				logrus.Error("Failed forward to webhook: ", err)
-			}
-		}(evt)
+func handleWebhookForward(sessionID string, client *whatsmeow.Client, evt *events.Message) {
+	// Broadcast lists aren't forwarded, the statuses of contacts are forwarded as status events
+	isBroadcastList := strings.Contains(evt.Info.SourceString(), "broadcast") && evt.Info.Chat != types.StatusBroadcastJID
+	if isEventForwardingEnabled() &&
+		!isBroadcastList &&
+		!isFromMySelf(client, evt.Info.SourceString()) {
+		enqueueWebhookEvent(sessionID, evt)
 	}
 }
 
-func handleReceipt(evt *events.Receipt) {
+func handleReceipt(sessionID string, evt *events.Receipt) {
 	if evt.Type == types.ReceiptTypeRead || evt.Type == types.ReceiptTypeReadSelf {
 		log.Infof("%v was read by %s at %s", evt.MessageIDs, evt.SourceString(), evt.Timestamp)
+		// Read on another device of this account
+		if evt.Type == types.ReceiptTypeReadSelf {
+			setChatRead(sessionID, evt.Chat, true)
+		}
 	} else if evt.Type == types.ReceiptTypeDelivered {
 		log.Infof("%s was delivered to %s at %s", evt.MessageIDs[0], evt.SourceString(), evt.Timestamp)
 	}
-	if len(config.WhatsappWebhook) > 0 {
-		go func(evt *events.Receipt) {
-			if err := forwardToWebhook(evt); err != nil {
-				logrus.Error("Failed forward to webhook: ", err)
-			}
-		}(evt)
+	recordReceiptStatus(sessionID, evt)
+	if isEventForwardingEnabled() {
+		enqueueWebhookEvent(sessionID, evt)
 	}
 }
 
-func handlePresence(evt *events.Presence) {
+func handlePresence(sessionID string, evt *events.Presence) {
+	recordPresence(sessionID, evt)
 	if evt.Unavailable {
 		if evt.LastSeen.IsZero() {
 			log.Infof("%s is now offline", evt.From)
@@ -282,21 +460,41 @@ func handlePresence(evt *events.Presence) {
 		log.Infof("%s is now online", evt.From)
 	}
 
-	if len(config.WhatsappWebhook) > 0 {
-		go func(evt *events.Presence) {
-			if err := forwardToWebhook(evt); err != nil {
-				logrus.Error("Failed forward to webhook: ", err)
-			}
-		}(evt)
+	if isEventForwardingEnabled() {
+		enqueueWebhookEvent(sessionID, evt)
+	}
+}
+
+func handleChatPresence(sessionID string, evt *events.ChatPresence) {
+	log.Debugf("%s is %s in %s", evt.Sender, evt.State, evt.Chat)
+
+	if isEventForwardingEnabled() {
+		enqueueWebhookEvent(sessionID, evt)
 	}
 }
 
-func handleHistorySync(evt *events.HistorySync) {
+func handleGroupInfo(sessionID string, evt *events.GroupInfo) {
+	recordGroupName(sessionID, evt)
+	if isEventForwardingEnabled() {
+		enqueueWebhookEvent(sessionID, evt)
+	}
+}
+
+// EmitGroupInfoChange forwards a change made through the api to the webhook, so consumers see it
+// like the changes made by other participants
+func EmitGroupInfoChange(client *whatsmeow.Client, evt *events.GroupInfo) {
+	recordGroupName(sessionIDOf(client), evt)
+	if isEventForwardingEnabled() {
+		enqueueWebhookEvent(sessionIDOf(client), evt)
+	}
+}
+
+func handleHistorySync(client *whatsmeow.Client, evt *events.HistorySync) {
 	id := atomic.AddInt32(&historySyncID, 1)
 	fileName := fmt.Sprintf("%s/history-%d-%s-%d-%s.json",
 		config.PathStorages,
 		startupTime,
-		cli.Store.ID.String(),
+		client.Store.ID.String(),
 		id,
 		evt.Data.SyncType.String(),
 	)
@@ -321,3 +519,14 @@ func handleHistorySync(evt *events.HistorySync) {
 func handleAppState(evt *events.AppState) {
 	log.Debugf("App state event: %+v / %+v", evt.Index, evt.SyncActionValue)
 }
+
+// messageTypeLabel names the message type for the metrics, media messages use their media type
+func messageTypeLabel(evt *events.Message) string {
+	if evt.Info.MediaType != "" {
+		return evt.Info.MediaType
+	}
+	if evt.Info.Type != "" {
+		return evt.Info.Type
+	}
+	return "unknown"
+}
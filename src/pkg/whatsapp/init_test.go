@@ -0,0 +1,55 @@
+package whatsapp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+func TestDatabaseDialect(t *testing.T) {
+	tests := []struct {
+		uri     string
+		dialect string
+		dsn     string
+	}{
+		{"postgres://user:secret@db:5432/whatsapp", "postgres", "postgres://user:secret@db:5432/whatsapp"},
+		{"postgresql://user:secret@db/whatsapp?sslmode=disable", "postgres", "postgresql://user:secret@db/whatsapp?sslmode=disable"},
+		{"file:storages/whatsapp.db?_foreign_keys=on", "sqlite3", "file:storages/whatsapp.db?_foreign_keys=on"},
+		{"storages/whatsapp.db", "sqlite3", "file:storages/whatsapp.db?_foreign_keys=on"},
+		{"storages/whatsapp.db?cache=shared", "sqlite3", "file:storages/whatsapp.db?cache=shared&_foreign_keys=on"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			dialect, dsn, err := databaseDialect(tt.uri)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.dialect, dialect)
+			assert.Equal(t, tt.dsn, dsn)
+		})
+	}
+}
+
+func TestDatabaseDialectUnknownScheme(t *testing.T) {
+	for _, uri := range []string{"postgress://user:secret@db/whatsapp", "mysql://user:secret@db/whatsapp"} {
+		_, _, err := databaseDialect(uri)
+		assert.ErrorContains(t, err, "unknown database type")
+		assert.NotContains(t, err.Error(), "secret")
+	}
+}
+
+func TestInitDatabaseMigratesSQLite(t *testing.T) {
+	previous := config.DBURI
+	t.Cleanup(func() { config.DBURI = previous })
+	config.DBURI = filepath.Join(t.TempDir(), "whatsapp.db")
+
+	container, err := initDatabase(waLog.Noop)
+	require.NoError(t, err)
+	devices, err := container.GetAllDevices()
+	assert.NoError(t, err)
+	assert.Empty(t, devices)
+}
@@ -0,0 +1,118 @@
+package whatsapp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// E.164 numbers have at most 15 digits with the calling code, the shortest ones in use have 7
+const (
+	phoneMinDigits = 7
+	phoneMaxDigits = 15
+)
+
+// The kinds of chats a jid can be
+const (
+	JIDTypeUser       = "user"
+	JIDTypeGroup      = "group"
+	JIDTypeBroadcast  = "broadcast"
+	JIDTypeNewsletter = "newsletter"
+)
+
+var (
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+	digitsPattern   = regexp.MustCompile(`^\d+$`)
+	// Groups created before 2022 have the creator phone and the creation time as id
+	legacyGroupPattern = regexp.MustCompile(`^\d+-\d+$`)
+)
+
+// NormalizeJID parses the phone numbers and jids accepted by the api into a canonical jid. A phone number may have
+// a leading + or 00, spaces, dashes, dots and parentheses, a leading 0 is replaced by config.WhatsappDefaultCountryCode.
+// A number of more than 15 digits is a group id. The @c.us server of whatsapp web is read as @s.whatsapp.net
+func NormalizeJID(input string) (types.JID, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return types.JID{}, pkgError.InvalidJID("phone can't be empty")
+	}
+
+	if !strings.ContainsRune(input, '@') {
+		if legacyGroupPattern.MatchString(input) {
+			return types.NewJID(input, types.GroupServer), nil
+		}
+		if digits := phoneSeparators.Replace(input); digitsPattern.MatchString(digits) && len(digits) > phoneMaxDigits && !strings.HasPrefix(input, "+") {
+			return types.NewJID(digits, types.GroupServer), nil
+		}
+		phone, err := internationalPhone(input)
+		if err != nil {
+			return types.JID{}, err
+		}
+		return types.NewJID(phone, types.DefaultUserServer), nil
+	}
+
+	jid, err := types.ParseJID(input)
+	if err != nil || jid.User == "" {
+		return types.JID{}, pkgError.InvalidJID(fmt.Sprintf("%s is not a valid jid", input))
+	}
+	switch jid.Server {
+	case "c.us", types.DefaultUserServer:
+		phone, err := internationalPhone(jid.User)
+		if err != nil {
+			return types.JID{}, err
+		}
+		// A recipient is the account, never one of its devices
+		return types.NewJID(phone, types.DefaultUserServer), nil
+	case types.GroupServer:
+		if !digitsPattern.MatchString(jid.User) && !legacyGroupPattern.MatchString(jid.User) {
+			return types.JID{}, pkgError.InvalidJID(fmt.Sprintf("%s is not a valid group jid", input))
+		}
+	}
+	return jid, nil
+}
+
+// internationalPhone returns the digits of an international phone number, the calling code included
+func internationalPhone(input string) (string, error) {
+	phone := phoneSeparators.Replace(input)
+	international := strings.HasPrefix(phone, "+")
+	phone = strings.TrimPrefix(phone, "+")
+	if !digitsPattern.MatchString(phone) {
+		return "", pkgError.InvalidJID(fmt.Sprintf("phone %s may only contain digits, spaces, dashes, dots, parentheses and a leading +", input))
+	}
+
+	switch {
+	case international:
+	case strings.HasPrefix(phone, "00"):
+		phone = phone[2:]
+	case strings.HasPrefix(phone, "0"):
+		countryCode := strings.TrimPrefix(config.WhatsappDefaultCountryCode, "+")
+		if !digitsPattern.MatchString(countryCode) {
+			return "", pkgError.InvalidJID(fmt.Sprintf("phone %s has no country code, use the international format like +62812345678", input))
+		}
+		phone = countryCode + phone[1:]
+	}
+
+	if len(phone) < phoneMinDigits || len(phone) > phoneMaxDigits {
+		return "", pkgError.InvalidJID(fmt.Sprintf("phone %s must have %d to %d digits including the country code", input, phoneMinDigits, phoneMaxDigits))
+	}
+	return phone, nil
+}
+
+// JIDType tells whether the jid is a user, a group, a broadcast list or a newsletter from its server. Users hidden
+// behind a lid are users too, the jids of the other servers have no type
+func JIDType(jid types.JID) string {
+	switch jid.Server {
+	case types.DefaultUserServer, types.HiddenUserServer:
+		return JIDTypeUser
+	case types.GroupServer:
+		return JIDTypeGroup
+	case types.BroadcastServer:
+		return JIDTypeBroadcast
+	case types.NewsletterServer:
+		return JIDTypeNewsletter
+	}
+	return ""
+}
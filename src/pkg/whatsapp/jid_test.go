@@ -0,0 +1,104 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestNormalizeJID(t *testing.T) {
+	previous := config.WhatsappDefaultCountryCode
+	t.Cleanup(func() { config.WhatsappDefaultCountryCode = previous })
+	config.WhatsappDefaultCountryCode = "62"
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Indonesia
+		{"6281234567890", "6281234567890@s.whatsapp.net"},
+		{"+62 812-3456-7890", "6281234567890@s.whatsapp.net"},
+		{"081234567890", "6281234567890@s.whatsapp.net"},
+		{"0062 812 3456 7890", "6281234567890@s.whatsapp.net"},
+		// United States
+		{"+1 (415) 555-2671", "14155552671@s.whatsapp.net"},
+		{"1.415.555.2671", "14155552671@s.whatsapp.net"},
+		// Already a jid
+		{"6281234567890@s.whatsapp.net", "6281234567890@s.whatsapp.net"},
+		{"6281234567890:12@s.whatsapp.net", "6281234567890@s.whatsapp.net"},
+		{"6281234567890@c.us", "6281234567890@s.whatsapp.net"},
+		{" 6281234567890@s.whatsapp.net ", "6281234567890@s.whatsapp.net"},
+		{"120363025246125888@g.us", "120363025246125888@g.us"},
+		{"120363025246125888", "120363025246125888@g.us"},
+		{"6281234567890-1603000000", "6281234567890-1603000000@g.us"},
+		{"120363144038483540@newsletter", "120363144038483540@newsletter"},
+		{"status@broadcast", "status@broadcast"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			jid, err := NormalizeJID(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, jid.String())
+		})
+	}
+}
+
+func TestNormalizeJIDInvalid(t *testing.T) {
+	previous := config.WhatsappDefaultCountryCode
+	t.Cleanup(func() { config.WhatsappDefaultCountryCode = previous })
+	config.WhatsappDefaultCountryCode = ""
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"", "phone can't be empty"},
+		{"0812345678", "phone 0812345678 has no country code, use the international format like +62812345678"},
+		{"62812abc", "phone 62812abc may only contain digits, spaces, dashes, dots, parentheses and a leading +"},
+		{"+62 812-3", "phone +62 812-3 must have 7 to 15 digits including the country code"},
+		{"+62 8123 4567 8901 2345", "phone +62 8123 4567 8901 2345 must have 7 to 15 digits including the country code"},
+		{"abc@s.whatsapp.net", "phone abc may only contain digits, spaces, dashes, dots, parentheses and a leading +"},
+		{"@s.whatsapp.net", "@s.whatsapp.net is not a valid jid"},
+		{"group@g.us", "group@g.us is not a valid group jid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := NormalizeJID(tt.input)
+			assert.Equal(t, pkgError.InvalidJID(tt.err), err)
+		})
+	}
+}
+
+func TestSanitizePhone(t *testing.T) {
+	phone := "+62 812-3456-7890"
+	SanitizePhone(&phone)
+	assert.Equal(t, "6281234567890@s.whatsapp.net", phone)
+
+	// An invalid phone is kept for the validation to reject it
+	invalid := "62812abc"
+	SanitizePhone(&invalid)
+	assert.Equal(t, "62812abc", invalid)
+}
+
+func TestJIDType(t *testing.T) {
+	tests := []struct {
+		jid      types.JID
+		expected string
+	}{
+		{types.NewJID("6281234567890", types.DefaultUserServer), JIDTypeUser},
+		{types.NewJID("123456789012345", types.HiddenUserServer), JIDTypeUser},
+		{types.NewJID("120363025246125486", types.GroupServer), JIDTypeGroup},
+		{types.NewJID("1675884163", types.BroadcastServer), JIDTypeBroadcast},
+		{types.StatusBroadcastJID, JIDTypeBroadcast},
+		{types.NewJID("120363144038483540", types.NewsletterServer), JIDTypeNewsletter},
+		{types.NewJID("867051314767696", types.BotServer), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.jid.String(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, JIDType(tt.jid))
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// LiveLocationMaxDuration is the longest share, the updates are edits of the first message and WhatsApp stops
+// accepting them once MessageEditWindow elapsed
+const LiveLocationMaxDuration = MessageEditWindow
+
+// liveLocationEndedCaption is the caption of the last update, it tells the receivers the share ended
+const liveLocationEndedCaption = "Live location ended"
+
+// LiveLocation is a position shared live, the same position is sent again every config.WhatsappLiveLocationInterval
+// so the receivers keep showing the share as live until it ends
+type LiveLocation struct {
+	Latitude  float64
+	Longitude float64
+	Caption   string
+}
+
+// liveLocationKey identifies a share, the message ids are only unique within a session
+type liveLocationKey struct {
+	sessionID string
+	messageID types.MessageID
+}
+
+// liveLocationShare is a running share, update sends the message of the next position to the chat
+type liveLocationShare struct {
+	sessionID string
+	startedAt time.Time
+	endsAt    time.Time
+	update    func(msg *waE2E.Message) error
+	stop      chan struct{}
+	stopOnce  sync.Once
+
+	// mu guards the position and the sequence, they change on every update
+	mu       sync.Mutex
+	location LiveLocation
+	sequence int64
+}
+
+var (
+	liveLocations   = make(map[liveLocationKey]*liveLocationShare)
+	liveLocationsMu sync.Mutex
+)
+
+// StartLiveLocation sends the live location to the chat and keeps updating it until duration elapsed
+// or StopLiveLocation is called, duration is capped at LiveLocationMaxDuration
+func StartLiveLocation(ctx context.Context, client *whatsmeow.Client, chat types.JID, location LiveLocation, duration time.Duration) (whatsmeow.SendResponse, time.Time, error) {
+	share := &liveLocationShare{
+		sessionID: sessionIDOf(client),
+		location:  location,
+		startedAt: time.Now(),
+		stop:      make(chan struct{}),
+	}
+	share.endsAt = share.startedAt.Add(min(duration, LiveLocationMaxDuration))
+
+	resp, err := SendMessage(ctx, client, chat, share.message())
+	if err != nil {
+		return resp, time.Time{}, err
+	}
+
+	// The updates are edits of the first message, that's how the receivers tie them to the share
+	share.update = func(msg *waE2E.Message) error {
+		_, err := SendMessage(context.Background(), client, chat, client.BuildEdit(chat, resp.ID, msg))
+		return err
+	}
+	startLiveLocationShare(resp.ID, share)
+	return resp, share.endsAt, nil
+}
+
+// UpdateLiveLocation moves a running share of the session of the client to a new position and sends it right away
+func UpdateLiveLocation(client *whatsmeow.Client, messageID types.MessageID, location LiveLocation) error {
+	return updateLiveLocation(sessionIDOf(client), messageID, location)
+}
+
+func updateLiveLocation(sessionID string, messageID types.MessageID, location LiveLocation) error {
+	share, err := getLiveLocationShare(sessionID, messageID)
+	if err != nil {
+		return err
+	}
+	share.mu.Lock()
+	share.location = location
+	share.mu.Unlock()
+	return share.update(share.message())
+}
+
+// StopLiveLocation ends a share of the session of the client before its duration elapsed, the receivers get
+// a last update telling them the share ended
+func StopLiveLocation(client *whatsmeow.Client, messageID types.MessageID) error {
+	return stopLiveLocation(sessionIDOf(client), messageID)
+}
+
+func stopLiveLocation(sessionID string, messageID types.MessageID) error {
+	share, err := getLiveLocationShare(sessionID, messageID)
+	if err != nil {
+		return err
+	}
+	share.stopOnce.Do(func() { close(share.stop) })
+	return share.update(share.endedMessage())
+}
+
+func getLiveLocationShare(sessionID string, messageID types.MessageID) (*liveLocationShare, error) {
+	liveLocationsMu.Lock()
+	share, ok := liveLocations[liveLocationKey{sessionID: sessionID, messageID: messageID}]
+	liveLocationsMu.Unlock()
+	if !ok {
+		return nil, pkgError.NotFoundError(fmt.Sprintf("live location %s is unknown or already ended", messageID))
+	}
+	return share, nil
+}
+
+// stopSessionLiveLocations ends the shares of a session that logged out, it can't send anything anymore
+func stopSessionLiveLocations(sessionID string) {
+	liveLocationsMu.Lock()
+	defer liveLocationsMu.Unlock()
+	for key, share := range liveLocations {
+		if key.sessionID == sessionID {
+			share.stopOnce.Do(func() { close(share.stop) })
+		}
+	}
+}
+
+func startLiveLocationShare(messageID types.MessageID, share *liveLocationShare) {
+	key := liveLocationKey{sessionID: share.sessionID, messageID: messageID}
+	liveLocationsMu.Lock()
+	liveLocations[key] = share
+	liveLocationsMu.Unlock()
+
+	go func() {
+		defer func() {
+			liveLocationsMu.Lock()
+			delete(liveLocations, key)
+			liveLocationsMu.Unlock()
+		}()
+
+		ticker := time.NewTicker(config.WhatsappLiveLocationInterval)
+		defer ticker.Stop()
+		ended := time.NewTimer(time.Until(share.endsAt))
+		defer ended.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := share.update(share.message()); err != nil {
+					log.Errorf("Failed to update live location %s: %v", messageID, err)
+				}
+			case <-share.stop:
+				return
+			case <-ended.C:
+				if err := share.update(share.endedMessage()); err != nil {
+					log.Errorf("Failed to end live location %s: %v", messageID, err)
+				}
+				return
+			}
+		}
+	}()
+}
+
+// message returns the live location message of the next update, the sequence number grows with every update
+// and the time offset is the number of seconds since the share started
+func (share *liveLocationShare) message() *waE2E.Message {
+	share.mu.Lock()
+	defer share.mu.Unlock()
+	share.sequence++
+	return &waE2E.Message{LiveLocationMessage: &waE2E.LiveLocationMessage{
+		DegreesLatitude:  proto.Float64(share.location.Latitude),
+		DegreesLongitude: proto.Float64(share.location.Longitude),
+		Caption:          proto.String(share.location.Caption),
+		SequenceNumber:   proto.Int64(share.sequence),
+		TimeOffset:       proto.Uint32(uint32(time.Since(share.startedAt).Seconds())),
+	}}
+}
+
+// endedMessage returns the last update of the share, at the last position
+func (share *liveLocationShare) endedMessage() *waE2E.Message {
+	msg := share.message()
+	msg.LiveLocationMessage.Caption = proto.String(liveLocationEndedCaption)
+	return msg
+}
@@ -0,0 +1,139 @@
+package whatsapp
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+type recordedLiveLocation struct {
+	mu       sync.Mutex
+	messages []*waE2E.LiveLocationMessage
+}
+
+func (r *recordedLiveLocation) update(msg *waE2E.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg.GetLiveLocationMessage())
+	return nil
+}
+
+func (r *recordedLiveLocation) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.messages)
+}
+
+func (r *recordedLiveLocation) last() *waE2E.LiveLocationMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.messages[len(r.messages)-1]
+}
+
+func isLiveLocationRunning(sessionID string, messageID types.MessageID) bool {
+	liveLocationsMu.Lock()
+	defer liveLocationsMu.Unlock()
+	_, ok := liveLocations[liveLocationKey{sessionID: sessionID, messageID: messageID}]
+	return ok
+}
+
+func TestLiveLocationShareUpdatesUntilEnded(t *testing.T) {
+	previous := config.WhatsappLiveLocationInterval
+	t.Cleanup(func() { config.WhatsappLiveLocationInterval = previous })
+	config.WhatsappLiveLocationInterval = 10 * time.Millisecond
+
+	recorded := &recordedLiveLocation{}
+	share := &liveLocationShare{
+		sessionID: DefaultSessionID,
+		location:  LiveLocation{Latitude: -6.2, Longitude: 106.8, Caption: "on my way"},
+		startedAt: time.Now(),
+		endsAt:    time.Now().Add(100 * time.Millisecond),
+		update:    recorded.update,
+		stop:      make(chan struct{}),
+	}
+	first := share.message()
+	startLiveLocationShare("3EB0LIVE", share)
+
+	assert.Eventually(t, func() bool { return !isLiveLocationRunning(DefaultSessionID, "3EB0LIVE") }, time.Second, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, recorded.count(), 2)
+	assert.Equal(t, liveLocationEndedCaption, recorded.last().GetCaption())
+
+	recorded.mu.Lock()
+	defer recorded.mu.Unlock()
+	assert.Equal(t, int64(1), first.GetLiveLocationMessage().GetSequenceNumber())
+	assert.Equal(t, int64(2), recorded.messages[0].GetSequenceNumber())
+	assert.Equal(t, -6.2, recorded.messages[0].GetDegreesLatitude())
+	assert.Equal(t, "on my way", recorded.messages[0].GetCaption())
+}
+
+func TestStopLiveLocation(t *testing.T) {
+	recorded := &recordedLiveLocation{}
+	share := &liveLocationShare{
+		sessionID: DefaultSessionID,
+		startedAt: time.Now(),
+		endsAt:    time.Now().Add(time.Hour),
+		update:    recorded.update,
+		stop:      make(chan struct{}),
+	}
+	startLiveLocationShare("3EB0STOP", share)
+	assert.True(t, isLiveLocationRunning(DefaultSessionID, "3EB0STOP"))
+
+	// Another session can't stop the share, the message ids are only unique within a session
+	err := stopLiveLocation("sales", "3EB0STOP")
+	assert.IsType(t, pkgError.NotFoundError(""), err)
+	assert.True(t, isLiveLocationRunning(DefaultSessionID, "3EB0STOP"))
+
+	assert.NoError(t, StopLiveLocation(nil, "3EB0STOP"))
+	assert.Eventually(t, func() bool { return !isLiveLocationRunning(DefaultSessionID, "3EB0STOP") }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, recorded.count())
+	assert.Equal(t, liveLocationEndedCaption, recorded.last().GetCaption())
+
+	err = StopLiveLocation(nil, "3EB0STOP")
+	assert.IsType(t, pkgError.NotFoundError(""), err)
+}
+
+func TestUpdateLiveLocation(t *testing.T) {
+	recorded := &recordedLiveLocation{}
+	share := &liveLocationShare{
+		sessionID: DefaultSessionID,
+		location:  LiveLocation{Latitude: -6.2, Longitude: 106.8},
+		startedAt: time.Now(),
+		endsAt:    time.Now().Add(time.Hour),
+		update:    recorded.update,
+		stop:      make(chan struct{}),
+	}
+	startLiveLocationShare("3EB0MOVE", share)
+	t.Cleanup(func() { _ = StopLiveLocation(nil, "3EB0MOVE") })
+
+	assert.NoError(t, UpdateLiveLocation(nil, "3EB0MOVE", LiveLocation{Latitude: -6.3, Longitude: 106.9, Caption: "almost there"}))
+	assert.Equal(t, -6.3, recorded.last().GetDegreesLatitude())
+	assert.Equal(t, 106.9, recorded.last().GetDegreesLongitude())
+	assert.Equal(t, "almost there", recorded.last().GetCaption())
+
+	err := updateLiveLocation("sales", "3EB0MOVE", LiveLocation{})
+	assert.IsType(t, pkgError.NotFoundError(""), err)
+}
+
+func TestStopSessionLiveLocations(t *testing.T) {
+	recorded := &recordedLiveLocation{}
+	for id, sessionID := range map[types.MessageID]string{"3EB0SALES": "sales", "3EB0SUPPORT": "support"} {
+		startLiveLocationShare(id, &liveLocationShare{
+			sessionID: sessionID,
+			startedAt: time.Now(),
+			endsAt:    time.Now().Add(time.Hour),
+			update:    recorded.update,
+			stop:      make(chan struct{}),
+		})
+	}
+
+	stopSessionLiveLocations("sales")
+	assert.Eventually(t, func() bool { return !isLiveLocationRunning("sales", "3EB0SALES") }, time.Second, 5*time.Millisecond)
+	assert.True(t, isLiveLocationRunning("support", "3EB0SUPPORT"))
+	assert.NoError(t, stopLiveLocation("support", "3EB0SUPPORT"))
+}
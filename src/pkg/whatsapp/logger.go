@@ -0,0 +1,63 @@
+package whatsapp
+
+import (
+	"strings"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/sirupsen/logrus"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+var waLogLevels = map[string]logrus.Level{
+	"DEBUG": logrus.DebugLevel,
+	"INFO":  logrus.InfoLevel,
+	"WARN":  logrus.WarnLevel,
+	"ERROR": logrus.ErrorLevel,
+}
+
+// logrusLogger writes the whatsmeow logs through logrus, so they share the json format and the redaction of the
+// application logs. The level of config.WhatsappLogLevel still applies on top of the logrus level
+type logrusLogger struct {
+	entry *logrus.Entry
+	level logrus.Level
+}
+
+// newLogger returns the logger of a whatsmeow module, the colored stdout logger unless the logs are json or redacted
+func newLogger(module string) waLog.Logger {
+	if !strings.EqualFold(config.AppLogFormat, utils.LogFormatJSON) && !config.AppLogRedact {
+		return waLog.Stdout(module, config.WhatsappLogLevel, true)
+	}
+	level, ok := waLogLevels[strings.ToUpper(config.WhatsappLogLevel)]
+	if !ok {
+		level = logrus.ErrorLevel
+	}
+	return &logrusLogger{entry: logrus.WithField("module", module), level: level}
+}
+
+func (logger *logrusLogger) logf(level logrus.Level, msg string, args ...interface{}) {
+	if level <= logger.level {
+		logger.entry.Logf(level, msg, args...)
+	}
+}
+
+func (logger *logrusLogger) Errorf(msg string, args ...interface{}) {
+	logger.logf(logrus.ErrorLevel, msg, args...)
+}
+
+func (logger *logrusLogger) Warnf(msg string, args ...interface{}) {
+	logger.logf(logrus.WarnLevel, msg, args...)
+}
+
+func (logger *logrusLogger) Infof(msg string, args ...interface{}) {
+	logger.logf(logrus.InfoLevel, msg, args...)
+}
+
+func (logger *logrusLogger) Debugf(msg string, args ...interface{}) {
+	logger.logf(logrus.DebugLevel, msg, args...)
+}
+
+func (logger *logrusLogger) Sub(module string) waLog.Logger {
+	parent, _ := logger.entry.Data["module"].(string)
+	return &logrusLogger{entry: logrus.WithField("module", parent+"/"+module), level: logger.level}
+}
@@ -0,0 +1,144 @@
+package whatsapp
+
+import (
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// LoginState tells whether the session is logged in, or which login flow it is waiting for
+type LoginState string
+
+const (
+	LoginStateLoggedOut       LoginState = "logged_out"
+	LoginStateWaitingQR       LoginState = "waiting_qr"
+	LoginStateWaitingPairCode LoginState = "waiting_pair_code"
+	LoginStateLoggedIn        LoginState = "logged_in"
+)
+
+// PairCodeTimeout is how long a pairing code can be entered on the phone. The exact expiry of the code is unknown,
+// but the login websocket is closed once the QR codes generated alongside run out, which takes 160 seconds.
+const PairCodeTimeout = 160 * time.Second
+
+type loginStateEntry struct {
+	state     LoginState
+	expiresAt time.Time
+	// qrCode is the QR code to scan while the state is waiting_qr
+	qrCode string
+}
+
+var (
+	loginStateMu sync.RWMutex
+	loginStates  = map[string]loginStateEntry{}
+	// loginListeners are the callbacks of the login event listeners, by session id
+	loginListeners = map[string]map[*loginListener]struct{}{}
+)
+
+const (
+	LoginEventQR      = "qr"
+	LoginEventSuccess = "success"
+	LoginEventTimeout = "timeout"
+)
+
+// LoginEvent is a step of the QR login: a new QR code to scan, the pairing succeeded, or the QR codes ran out
+type LoginEvent struct {
+	Event     string
+	Code      string
+	ExpiresAt time.Time
+}
+
+type loginListener struct {
+	notify func(LoginEvent)
+}
+
+// OnLoginEvent calls notify with the login events of the client session until the returned function is called.
+// notify is called while the login state is being updated, it must not block
+func OnLoginEvent(client *whatsmeow.Client, notify func(LoginEvent)) (remove func()) {
+	loginStateMu.Lock()
+	defer loginStateMu.Unlock()
+
+	sessionID := sessionIDOf(client)
+	listener := &loginListener{notify: notify}
+	if loginListeners[sessionID] == nil {
+		loginListeners[sessionID] = map[*loginListener]struct{}{}
+	}
+	loginListeners[sessionID][listener] = struct{}{}
+	return func() {
+		loginStateMu.Lock()
+		defer loginStateMu.Unlock()
+
+		delete(loginListeners[sessionID], listener)
+		if len(loginListeners[sessionID]) == 0 {
+			delete(loginListeners, sessionID)
+		}
+	}
+}
+
+// notifyLogin sends the login event following the change of the session state from previous to next, the caller
+// holds loginStateMu
+func notifyLogin(sessionID string, previous, next loginStateEntry) {
+	var evt LoginEvent
+	switch {
+	case next.state == LoginStateWaitingQR && next.qrCode != "" && next.qrCode != previous.qrCode:
+		evt = LoginEvent{Event: LoginEventQR, Code: next.qrCode, ExpiresAt: next.expiresAt}
+	case next.state == LoginStateLoggedIn && previous.state != LoginStateLoggedIn:
+		evt = LoginEvent{Event: LoginEventSuccess}
+	case next.state == LoginStateLoggedOut && previous.state == LoginStateWaitingQR:
+		evt = LoginEvent{Event: LoginEventTimeout}
+	default:
+		return
+	}
+	for listener := range loginListeners[sessionID] {
+		listener.notify(evt)
+	}
+}
+
+// SetLoginState records the login flow in progress of the client session, expiresAt is zero when the state doesn't expire
+func SetLoginState(client *whatsmeow.Client, state LoginState, expiresAt time.Time) {
+	loginStateMu.Lock()
+	defer loginStateMu.Unlock()
+
+	sessionID := sessionIDOf(client)
+	entry := loginStateEntry{state: state, expiresAt: expiresAt}
+	notifyLogin(sessionID, loginStates[sessionID], entry)
+	loginStates[sessionID] = entry
+}
+
+// GetLoginState returns the login state of the client session, a waiting state falls back to logged out once it expired
+func GetLoginState(client *whatsmeow.Client) (LoginState, time.Time) {
+	loginStateMu.RLock()
+	defer loginStateMu.RUnlock()
+
+	entry, ok := loginStates[sessionIDOf(client)]
+	if !ok || (!entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt)) {
+		return LoginStateLoggedOut, time.Time{}
+	}
+	return entry.state, entry.expiresAt
+}
+
+// SetLoginQR records the QR code to scan for the client session, whatsmeow rotates it until it's scanned or the
+// codes run out, each code replacing the previous one
+func SetLoginQR(client *whatsmeow.Client, code string, expiresAt time.Time) {
+	loginStateMu.Lock()
+	defer loginStateMu.Unlock()
+
+	sessionID := sessionIDOf(client)
+	entry := loginStateEntry{state: LoginStateWaitingQR, expiresAt: expiresAt, qrCode: code}
+	notifyLogin(sessionID, loginStates[sessionID], entry)
+	loginStates[sessionID] = entry
+}
+
+// GetLoginQR returns the QR code to scan for the client session, ok is false when there's no QR code pending
+func GetLoginQR(client *whatsmeow.Client) (code string, expiresAt time.Time, ok bool) {
+	state, expiresAt := GetLoginState(client)
+	if state != LoginStateWaitingQR {
+		return "", time.Time{}, false
+	}
+
+	loginStateMu.RLock()
+	defer loginStateMu.RUnlock()
+
+	entry := loginStates[sessionIDOf(client)]
+	return entry.qrCode, expiresAt, entry.qrCode != ""
+}
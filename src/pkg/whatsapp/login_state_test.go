@@ -0,0 +1,80 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginStateExpires(t *testing.T) {
+	t.Cleanup(func() { SetLoginState(nil, LoginStateLoggedOut, time.Time{}) })
+
+	expiresAt := time.Now().Add(PairCodeTimeout)
+	SetLoginState(nil, LoginStateWaitingPairCode, expiresAt)
+	state, gotExpiresAt := GetLoginState(nil)
+	assert.Equal(t, LoginStateWaitingPairCode, state)
+	assert.Equal(t, expiresAt, gotExpiresAt)
+
+	SetLoginState(nil, LoginStateWaitingQR, time.Now().Add(-time.Second))
+	state, gotExpiresAt = GetLoginState(nil)
+	assert.Equal(t, LoginStateLoggedOut, state)
+	assert.True(t, gotExpiresAt.IsZero())
+
+	SetLoginState(nil, LoginStateLoggedIn, time.Time{})
+	state, _ = GetLoginState(nil)
+	assert.Equal(t, LoginStateLoggedIn, state)
+}
+
+func TestLoginQR(t *testing.T) {
+	t.Cleanup(func() { SetLoginState(nil, LoginStateLoggedOut, time.Time{}) })
+
+	_, _, ok := GetLoginQR(nil)
+	assert.False(t, ok)
+
+	expiresAt := time.Now().Add(time.Minute)
+	SetLoginQR(nil, "2@first", expiresAt)
+	SetLoginQR(nil, "2@second", expiresAt)
+	code, gotExpiresAt, ok := GetLoginQR(nil)
+	assert.True(t, ok)
+	assert.Equal(t, "2@second", code)
+	assert.Equal(t, expiresAt, gotExpiresAt)
+	state, _ := GetLoginState(nil)
+	assert.Equal(t, LoginStateWaitingQR, state)
+
+	SetLoginQR(nil, "2@expired", time.Now().Add(-time.Second))
+	_, _, ok = GetLoginQR(nil)
+	assert.False(t, ok)
+
+	SetLoginQR(nil, "2@scanned", expiresAt)
+	SetLoginState(nil, LoginStateLoggedIn, time.Time{})
+	_, _, ok = GetLoginQR(nil)
+	assert.False(t, ok)
+}
+
+func TestOnLoginEvent(t *testing.T) {
+	t.Cleanup(func() { SetLoginState(nil, LoginStateLoggedOut, time.Time{}) })
+	SetLoginState(nil, LoginStateLoggedOut, time.Time{})
+
+	var received []LoginEvent
+	remove := OnLoginEvent(nil, func(evt LoginEvent) { received = append(received, evt) })
+
+	expiresAt := time.Now().Add(time.Minute)
+	SetLoginQR(nil, "2@first", expiresAt)
+	SetLoginQR(nil, "2@second", expiresAt)
+	SetLoginState(nil, LoginStateLoggedOut, time.Time{})
+	SetLoginQR(nil, "2@third", expiresAt)
+	SetLoginState(nil, LoginStateLoggedIn, time.Time{})
+	SetLoginState(nil, LoginStateLoggedIn, time.Time{})
+	remove()
+	SetLoginQR(nil, "2@removed", expiresAt)
+
+	assert.Equal(t, []LoginEvent{
+		{Event: LoginEventQR, Code: "2@first", ExpiresAt: expiresAt},
+		{Event: LoginEventQR, Code: "2@second", ExpiresAt: expiresAt},
+		{Event: LoginEventTimeout},
+		{Event: LoginEventQR, Code: "2@third", ExpiresAt: expiresAt},
+		{Event: LoginEventSuccess},
+	}, received)
+	assert.Empty(t, loginListeners)
+}
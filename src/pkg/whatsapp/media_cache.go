@@ -0,0 +1,87 @@
+package whatsapp
+
+import (
+	"fmt"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// CachedMedia is the downloadable part of a received message, it holds the media keys needed to download it again
+type CachedMedia struct {
+	Media    whatsmeow.DownloadableMessage
+	MimeType string
+	FileName string
+}
+
+// sessionMediaKey identifies the media of a message received by a session
+type sessionMediaKey struct {
+	sessionID string
+	id        types.MessageID
+}
+
+var mediaCache = utils.NewTTLCache[sessionMediaKey, CachedMedia]()
+
+// MediaDownloadPath is the route downloading the media of a message received by the session of the client
+func MediaDownloadPath(client *whatsmeow.Client, messageID types.MessageID) string {
+	return mediaDownloadPath(sessionIDOf(client), messageID)
+}
+
+func mediaDownloadPath(sessionID string, messageID types.MessageID) string {
+	if sessionID == DefaultSessionID {
+		return fmt.Sprintf("/message/%s/media", messageID)
+	}
+	return fmt.Sprintf("/sessions/%s/message/%s/media", sessionID, messageID)
+}
+
+// getDownloadableMedia returns the media of the message with its mime type and file name
+func getDownloadableMedia(msg *waE2E.Message) (media whatsmeow.DownloadableMessage, mimeType string, fileName string) {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage(), msg.GetImageMessage().GetMimetype(), ""
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage(), msg.GetVideoMessage().GetMimetype(), ""
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage(), msg.GetAudioMessage().GetMimetype(), ""
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage(), msg.GetDocumentMessage().GetMimetype(), msg.GetDocumentMessage().GetFileName()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage(), msg.GetStickerMessage().GetMimetype(), ""
+	}
+	return nil, "", ""
+}
+
+// cacheMessageMedia keeps the media keys of a received message so GET /message/:id/media can download it later
+func cacheMessageMedia(sessionID string, evt *events.Message) {
+	media, mimeType, fileName := getDownloadableMedia(evt.Message)
+	if media == nil {
+		return
+	}
+
+	mediaCache.Set(sessionMediaKey{sessionID: sessionID, id: evt.Info.ID},
+		CachedMedia{Media: media, MimeType: mimeType, FileName: fileName},
+		config.WhatsappMediaCacheTTL, config.WhatsappMediaCacheSize)
+}
+
+// GetCachedMedia returns the media of a message received by the session of the client when it is still cached
+func GetCachedMedia(client *whatsmeow.Client, messageID types.MessageID) (CachedMedia, error) {
+	return getCachedMedia(sessionIDOf(client), messageID)
+}
+
+func getCachedMedia(sessionID string, messageID types.MessageID) (CachedMedia, error) {
+	cached, ok := mediaCache.Get(sessionMediaKey{sessionID: sessionID, id: messageID})
+	if !ok {
+		return CachedMedia{}, pkgError.NotFoundError(fmt.Sprintf("media of message %s is unknown or expired", messageID))
+	}
+	return cached, nil
+}
+
+// clearSessionMedia forgets the cached media of the messages a session received
+func clearSessionMedia(sessionID string) {
+	mediaCache.DeleteFunc(func(key sessionMediaKey, _ CachedMedia) bool { return key.sessionID == sessionID })
+}
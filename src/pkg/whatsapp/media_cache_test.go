@@ -0,0 +1,89 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func newMediaEvent(id types.MessageID) *events.Message {
+	return &events.Message{
+		Info: types.MessageInfo{ID: id},
+		Message: &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+			Mimetype: proto.String("application/pdf"),
+			FileName: proto.String("invoice.pdf"),
+		}},
+	}
+}
+
+func TestCacheMessageMedia(t *testing.T) {
+	cacheMessageMedia(DefaultSessionID, newMediaEvent("3EB0CACHED"))
+
+	cached, err := getCachedMedia(DefaultSessionID, "3EB0CACHED")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", cached.MimeType)
+	assert.Equal(t, "invoice.pdf", cached.FileName)
+
+	_, err = getCachedMedia(DefaultSessionID, "3EB0UNKNOWN")
+	assert.IsType(t, pkgError.NotFoundError(""), err)
+
+	// Messages without media are not cached
+	cacheMessageMedia(DefaultSessionID, &events.Message{Info: types.MessageInfo{ID: "3EB0TEXT"}, Message: &waE2E.Message{Conversation: proto.String("hi")}})
+	_, err = getCachedMedia(DefaultSessionID, "3EB0TEXT")
+	assert.Error(t, err)
+}
+
+func TestCacheMessageMediaEviction(t *testing.T) {
+	origSize := config.WhatsappMediaCacheSize
+	origTTL := config.WhatsappMediaCacheTTL
+	t.Cleanup(func() {
+		config.WhatsappMediaCacheSize = origSize
+		config.WhatsappMediaCacheTTL = origTTL
+	})
+
+	config.WhatsappMediaCacheSize = 2
+	cacheMessageMedia(DefaultSessionID, newMediaEvent("3EB0FIRST"))
+	cacheMessageMedia(DefaultSessionID, newMediaEvent("3EB0SECOND"))
+	cacheMessageMedia(DefaultSessionID, newMediaEvent("3EB0THIRD"))
+
+	_, err := getCachedMedia(DefaultSessionID, "3EB0FIRST")
+	assert.Error(t, err)
+	_, err = getCachedMedia(DefaultSessionID, "3EB0THIRD")
+	assert.NoError(t, err)
+
+	config.WhatsappMediaCacheTTL = -time.Minute
+	cacheMessageMedia(DefaultSessionID, newMediaEvent("3EB0EXPIRED"))
+	_, err = getCachedMedia(DefaultSessionID, "3EB0EXPIRED")
+	assert.Error(t, err)
+}
+
+func TestClearSessionMedia(t *testing.T) {
+	cacheMessageMedia("sales", newMediaEvent("3EB0SALES"))
+	cacheMessageMedia(DefaultSessionID, newMediaEvent("3EB0DEFAULT"))
+
+	clearSessionMedia("sales")
+
+	_, err := getCachedMedia("sales", "3EB0SALES")
+	assert.Error(t, err)
+	_, err = getCachedMedia(DefaultSessionID, "3EB0DEFAULT")
+	assert.NoError(t, err)
+}
+
+func TestCachedMediaPerSession(t *testing.T) {
+	cacheMessageMedia("sales", newMediaEvent("3EB0SHARED"))
+
+	_, err := getCachedMedia(DefaultSessionID, "3EB0SHARED")
+	assert.Error(t, err)
+	_, err = getCachedMedia("sales", "3EB0SHARED")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "/message/3EB0SHARED/media", mediaDownloadPath(DefaultSessionID, "3EB0SHARED"))
+	assert.Equal(t, "/sessions/sales/message/3EB0SHARED/media", mediaDownloadPath("sales", "3EB0SHARED"))
+}
@@ -0,0 +1,65 @@
+package whatsapp
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+)
+
+// SignMediaURL builds a fully-qualified url to GET /media/:id that expires after config.WhatsappWebhookMediaURLExpiry
+func SignMediaURL(mediaPath string) (string, error) {
+	id := filepath.Base(mediaPath)
+	expires := strconv.FormatInt(time.Now().Add(config.WhatsappWebhookMediaURLExpiry).Unix(), 10)
+
+	signature, err := mediaSignature(id, expires)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", signature)
+	return fmt.Sprintf("%s/media/%s?%s", strings.TrimRight(config.AppBaseURL, "/"), url.PathEscape(id), query.Encode()), nil
+}
+
+// VerifyMediaSignature rejects expired media urls and signatures that don't match the media id and expiry
+func VerifyMediaSignature(id, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || signature == "" {
+		return pkgError.ForbiddenError("invalid media signature")
+	}
+	if time.Now().Unix() > expiresAt {
+		return pkgError.ForbiddenError("media url has expired")
+	}
+
+	expected, err := mediaSignature(id, expires)
+	if err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("error when create signature %v", err))
+	}
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return pkgError.ForbiddenError("invalid media signature")
+	}
+	return nil
+}
+
+// MediaFilePath resolves the media id to a file inside config.PathMedia, ids with path separators are rejected
+func MediaFilePath(id string) (string, error) {
+	if id == "" || id == "." || id == ".." || id != filepath.Base(id) || strings.ContainsAny(id, `/\`) {
+		return "", pkgError.ForbiddenError("invalid media id")
+	}
+	return filepath.Join(config.PathMedia, id), nil
+}
+
+func mediaSignature(id, expires string) (string, error) {
+	if config.WhatsappWebhookMediaURLSecret == "" {
+		return "", fmt.Errorf("webhook media url secret is not set")
+	}
+	return getMessageDigestOrSignature([]byte(id+"."+expires), []byte(config.WhatsappWebhookMediaURLSecret))
+}
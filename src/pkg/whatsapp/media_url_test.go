@@ -0,0 +1,80 @@
+package whatsapp
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignMediaURL(t *testing.T) {
+	origBaseURL, origSecret := config.AppBaseURL, config.WhatsappWebhookMediaURLSecret
+	defer func() { config.AppBaseURL, config.WhatsappWebhookMediaURLSecret = origBaseURL, origSecret }()
+	config.AppBaseURL = "https://wa.example.com"
+	config.WhatsappWebhookMediaURLSecret = "media-secret"
+
+	signedURL, err := SignMediaURL("statics/media/1700000000-image.jpg")
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(signedURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/media/1700000000-image.jpg", parsed.Path)
+	assert.NoError(t, VerifyMediaSignature("1700000000-image.jpg", parsed.Query().Get("expires"), parsed.Query().Get("signature")))
+
+	// Without its own secret nothing is signed, the webhook secret is never used as a fallback
+	config.WhatsappWebhookMediaURLSecret = ""
+	_, err = SignMediaURL("statics/media/1700000000-image.jpg")
+	assert.Error(t, err)
+}
+
+func TestVerifyMediaSignature(t *testing.T) {
+	origSecret := config.WhatsappWebhookMediaURLSecret
+	defer func() { config.WhatsappWebhookMediaURLSecret = origSecret }()
+	config.WhatsappWebhookMediaURLSecret = "media-secret"
+
+	id := "1700000000-image.jpg"
+	validExpires := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	validSignature, _ := mediaSignature(id, validExpires)
+	expiredExpires := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	expiredSignature, _ := mediaSignature(id, expiredExpires)
+
+	tests := []struct {
+		name      string
+		id        string
+		expires   string
+		signature string
+		wantErr   bool
+	}{
+		{"should success with valid signature", id, validExpires, validSignature, false},
+		{"should error when expired", id, expiredExpires, expiredSignature, true},
+		{"should error with tampered id", "other.jpg", validExpires, validSignature, true},
+		{"should error with tampered expiry", id, strconv.FormatInt(time.Now().Add(48*time.Hour).Unix(), 10), validSignature, true},
+		{"should error without signature", id, validExpires, "", true},
+		{"should error with invalid expiry", id, "tomorrow", validSignature, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyMediaSignature(tt.id, tt.expires, tt.signature)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMediaFilePath(t *testing.T) {
+	path, err := MediaFilePath("1700000000-image.jpg")
+	assert.NoError(t, err)
+	assert.Equal(t, config.PathMedia+"/1700000000-image.jpg", path)
+
+	for _, id := range []string{"", ".", "..", "../whatsapp.db", "..%2Fwhatsapp.db/..", `..\whatsapp.db`, "sub/image.jpg"} {
+		_, err := MediaFilePath(id)
+		assert.Error(t, err, id)
+	}
+}
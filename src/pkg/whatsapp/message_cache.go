@@ -0,0 +1,67 @@
+package whatsapp
+
+import (
+	"fmt"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// CachedMessage is the content of a message received or sent by the session, kept so it can be forwarded or quoted
+type CachedMessage struct {
+	Message *waE2E.Message
+	Sender  types.JID
+}
+
+// sessionMessageKey identifies a message of a session, ids are only unique within a chat
+type sessionMessageKey struct {
+	sessionID string
+	chat      types.JID
+	id        types.MessageID
+}
+
+func newSessionMessageKey(sessionID string, chat types.JID, id types.MessageID) sessionMessageKey {
+	return sessionMessageKey{sessionID: sessionID, chat: chat.ToNonAD(), id: id}
+}
+
+var messageCache = utils.NewTTLCache[sessionMessageKey, CachedMessage]()
+
+// isContentMessage tells whether a message has content of its own, protocol messages, edits and reactions
+// only change another message
+func isContentMessage(msg *waE2E.Message) bool {
+	return msg != nil && msg.GetProtocolMessage() == nil && msg.GetEditedMessage() == nil && msg.GetReactionMessage() == nil
+}
+
+// cacheMessageContent keeps the content of a message, protocol messages, edits and reactions can't be forwarded or quoted
+func cacheMessageContent(sessionID string, evt *events.Message) {
+	if config.WhatsappMessageCacheSize <= 0 || !isContentMessage(evt.Message) {
+		return
+	}
+
+	messageCache.Set(newSessionMessageKey(sessionID, evt.Info.Chat, evt.Info.ID),
+		CachedMessage{Message: evt.Message, Sender: evt.Info.Sender.ToNonAD()},
+		config.WhatsappMessageCacheTTL, config.WhatsappMessageCacheSize)
+}
+
+// GetCachedMessage returns a message received or sent by the session of the client in chat
+func GetCachedMessage(client *whatsmeow.Client, chat types.JID, id types.MessageID) (CachedMessage, error) {
+	return getCachedMessage(sessionIDOf(client), chat, id)
+}
+
+func getCachedMessage(sessionID string, chat types.JID, id types.MessageID) (CachedMessage, error) {
+	cached, ok := messageCache.Get(newSessionMessageKey(sessionID, chat, id))
+	if !ok {
+		return CachedMessage{}, pkgError.NotFoundError(fmt.Sprintf("message %s is unknown or expired", id))
+	}
+	return cached, nil
+}
+
+// clearSessionMessages forgets the cached messages a session received
+func clearSessionMessages(sessionID string) {
+	messageCache.DeleteFunc(func(key sessionMessageKey, _ CachedMessage) bool { return key.sessionID == sessionID })
+}
@@ -0,0 +1,73 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func newChatTextEvent(chat types.JID, id types.MessageID, text string) *events.Message {
+	return &events.Message{
+		Info:    types.MessageInfo{MessageSource: types.MessageSource{Chat: chat, Sender: chat}, ID: id},
+		Message: &waE2E.Message{Conversation: proto.String(text)},
+	}
+}
+
+func TestCacheMessageContent(t *testing.T) {
+	chat := types.NewJID("6289685028129", types.DefaultUserServer)
+	cacheMessageContent(DefaultSessionID, newChatTextEvent(chat, "3EB0FORWARD", "hello"))
+
+	cached, err := getCachedMessage(DefaultSessionID, chat, "3EB0FORWARD")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", cached.Message.GetConversation())
+	assert.Equal(t, chat, cached.Sender)
+
+	// The message is only known in the chat and the session it was received in
+	_, err = getCachedMessage(DefaultSessionID, types.NewJID("6281234567890", types.DefaultUserServer), "3EB0FORWARD")
+	assert.IsType(t, pkgError.NotFoundError(""), err)
+	_, err = getCachedMessage("other", chat, "3EB0FORWARD")
+	assert.Error(t, err)
+
+	// Reactions are not cached
+	cacheMessageContent(DefaultSessionID, &events.Message{
+		Info:    types.MessageInfo{MessageSource: types.MessageSource{Chat: chat}, ID: "3EB0REACTION"},
+		Message: &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{Text: proto.String("👍")}},
+	})
+	_, err = getCachedMessage(DefaultSessionID, chat, "3EB0REACTION")
+	assert.Error(t, err)
+
+	clearSessionMessages(DefaultSessionID)
+	_, err = getCachedMessage(DefaultSessionID, chat, "3EB0FORWARD")
+	assert.Error(t, err)
+}
+
+func TestCacheMessageContentEviction(t *testing.T) {
+	origSize := config.WhatsappMessageCacheSize
+	t.Cleanup(func() {
+		config.WhatsappMessageCacheSize = origSize
+		clearSessionMessages(DefaultSessionID)
+	})
+
+	chat := types.NewJID("6289685028129", types.DefaultUserServer)
+	config.WhatsappMessageCacheSize = 2
+	cacheMessageContent(DefaultSessionID, newChatTextEvent(chat, "3EB0FIRST", "1"))
+	cacheMessageContent(DefaultSessionID, newChatTextEvent(chat, "3EB0SECOND", "2"))
+	cacheMessageContent(DefaultSessionID, newChatTextEvent(chat, "3EB0THIRD", "3"))
+
+	_, err := getCachedMessage(DefaultSessionID, chat, "3EB0FIRST")
+	assert.Error(t, err)
+	_, err = getCachedMessage(DefaultSessionID, chat, "3EB0THIRD")
+	assert.NoError(t, err)
+
+	// A size of 0 disables the cache
+	config.WhatsappMessageCacheSize = 0
+	cacheMessageContent(DefaultSessionID, newChatTextEvent(chat, "3EB0DISABLED", "4"))
+	_, err = getCachedMessage(DefaultSessionID, chat, "3EB0DISABLED")
+	assert.Error(t, err)
+}
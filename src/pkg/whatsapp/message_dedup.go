@@ -0,0 +1,52 @@
+package whatsapp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+var (
+	seenMessages      = make(map[string]time.Time)
+	seenMessagesOrder []string
+	seenMessagesMu    sync.Mutex
+)
+
+// isDuplicateMessage remembers the message and reports whether it was already seen within config.WhatsappDedupTTL.
+// whatsmeow can deliver the same message again after a reconnect, those must not reach the consumers twice
+func isDuplicateMessage(sessionID string, evt *events.Message) bool {
+	if config.WhatsappDedupCacheSize <= 0 || evt.Info.ID == "" {
+		return false
+	}
+	// Every session receives its own copy of a group message, so the session is part of the key
+	key := sessionID + "|" + evt.Info.Chat.String() + "|" + evt.Info.ID
+
+	seenMessagesMu.Lock()
+	defer seenMessagesMu.Unlock()
+
+	now := time.Now()
+	evictSeenMessages(now)
+	if seenAt, ok := seenMessages[key]; ok && now.Sub(seenAt) < config.WhatsappDedupTTL {
+		return true
+	}
+
+	seenMessages[key] = now
+	seenMessagesOrder = append(seenMessagesOrder, key)
+	evictSeenMessages(now)
+	return false
+}
+
+// evictSeenMessages drops the expired ids and the oldest ones above config.WhatsappDedupCacheSize, the caller must hold the lock
+func evictSeenMessages(now time.Time) {
+	for len(seenMessagesOrder) > 0 {
+		oldest := seenMessagesOrder[0]
+		seenAt, ok := seenMessages[oldest]
+		if ok && len(seenMessages) <= config.WhatsappDedupCacheSize && now.Sub(seenAt) < config.WhatsappDedupTTL {
+			break
+		}
+		delete(seenMessages, oldest)
+		seenMessagesOrder = seenMessagesOrder[1:]
+	}
+}
@@ -0,0 +1,103 @@
+package whatsapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func setDedupConfig(t *testing.T, size int, ttl time.Duration) {
+	oldSize, oldTTL := config.WhatsappDedupCacheSize, config.WhatsappDedupTTL
+	config.WhatsappDedupCacheSize, config.WhatsappDedupTTL = size, ttl
+	resetSeenMessages := func() {
+		seenMessagesMu.Lock()
+		seenMessages = make(map[string]time.Time)
+		seenMessagesOrder = nil
+		seenMessagesMu.Unlock()
+	}
+	resetSeenMessages()
+	t.Cleanup(func() {
+		config.WhatsappDedupCacheSize, config.WhatsappDedupTTL = oldSize, oldTTL
+		resetSeenMessages()
+	})
+}
+
+func newTextEvent(id types.MessageID) *events.Message {
+	return &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{
+				Chat:   types.NewJID("628123456789", types.DefaultUserServer),
+				Sender: types.NewJID("628123456789", types.DefaultUserServer),
+			},
+			ID: id,
+		},
+		Message: &waE2E.Message{Conversation: proto.String("hello")},
+	}
+}
+
+func TestIsDuplicateMessage(t *testing.T) {
+	setDedupConfig(t, 10, time.Hour)
+
+	assert.False(t, isDuplicateMessage(DefaultSessionID, newTextEvent("3EB0DEDUP1")))
+	assert.True(t, isDuplicateMessage(DefaultSessionID, newTextEvent("3EB0DEDUP1")))
+	// Another session receives its own copy of the message
+	assert.False(t, isDuplicateMessage("sales", newTextEvent("3EB0DEDUP1")))
+	assert.False(t, isDuplicateMessage(DefaultSessionID, newTextEvent("3EB0DEDUP2")))
+}
+
+func TestIsDuplicateMessageExpires(t *testing.T) {
+	setDedupConfig(t, 10, 20*time.Millisecond)
+
+	assert.False(t, isDuplicateMessage(DefaultSessionID, newTextEvent("3EB0EXPIRE")))
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, isDuplicateMessage(DefaultSessionID, newTextEvent("3EB0EXPIRE")))
+}
+
+func TestIsDuplicateMessageEvictsOldest(t *testing.T) {
+	setDedupConfig(t, 2, time.Hour)
+
+	for _, id := range []types.MessageID{"3EB0A", "3EB0B", "3EB0C"} {
+		assert.False(t, isDuplicateMessage(DefaultSessionID, newTextEvent(id)))
+	}
+	assert.Len(t, seenMessages, 2)
+	assert.False(t, isDuplicateMessage(DefaultSessionID, newTextEvent("3EB0A")))
+	assert.True(t, isDuplicateMessage(DefaultSessionID, newTextEvent("3EB0C")))
+}
+
+func TestIsDuplicateMessageDisabled(t *testing.T) {
+	setDedupConfig(t, 0, time.Hour)
+
+	assert.False(t, isDuplicateMessage(DefaultSessionID, newTextEvent("3EB0OFF")))
+	assert.False(t, isDuplicateMessage(DefaultSessionID, newTextEvent("3EB0OFF")))
+}
+
+func TestForwardToWebhookSkipsRedeliveredMessage(t *testing.T) {
+	setDedupConfig(t, 10, time.Hour)
+	previous := config.WhatsappWebhook
+	t.Cleanup(func() { config.WhatsappWebhook = previous })
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer server.Close()
+	config.WhatsappWebhook = []string{server.URL}
+
+	deduplicated := testutil.ToFloat64(metrics.MessagesDeduplicated.WithLabelValues("dedup"))
+	assert.NoError(t, forwardToWebhook("dedup", newTextEvent("3EB0REDELIVERED")))
+	assert.NoError(t, forwardToWebhook("dedup", newTextEvent("3EB0REDELIVERED")))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	assert.Equal(t, deduplicated+1, testutil.ToFloat64(metrics.MessagesDeduplicated.WithLabelValues("dedup")))
+}
@@ -0,0 +1,154 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	MessageStatusSent      = "sent"
+	MessageStatusDelivered = "delivered"
+	MessageStatusRead      = "read"
+)
+
+// MessageEditWindow is how long after sending a message WhatsApp still accepts an edit
+const MessageEditWindow = 15 * time.Minute
+
+// MessageRevokeWindow is how long after sending a message WhatsApp still accepts a delete for everyone
+const MessageRevokeWindow = 60 * time.Hour
+
+// MessageStatus is the delivery state of a message sent by this account, in a group it moves forward with the
+// first participant that received or read it
+type MessageStatus struct {
+	MessageID   string
+	ChatJID     string
+	Status      string
+	SentAt      time.Time
+	DeliveredAt time.Time
+	ReadAt      time.Time
+}
+
+// A timestamp of 0 is a state not reached yet. Only the first receipt of a state sets its timestamp and a read
+// receipt implies the delivery, receipts can arrive out of order or more than once
+const (
+	createMessageStatusTable = `CREATE TABLE IF NOT EXISTS whatsapp_message_status (
+	session_id   TEXT NOT NULL,
+	message_id   TEXT NOT NULL,
+	chat_jid     TEXT NOT NULL,
+	sent_at      BIGINT NOT NULL,
+	delivered_at BIGINT NOT NULL DEFAULT 0,
+	read_at      BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (session_id, chat_jid, message_id)
+)`
+	insertMessageStatus = `INSERT INTO whatsapp_message_status (session_id, message_id, chat_jid, sent_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (session_id, chat_jid, message_id) DO NOTHING`
+	updateMessageDelivered = `UPDATE whatsapp_message_status SET delivered_at = $1
+WHERE session_id = $2 AND chat_jid = $3 AND message_id = $4 AND delivered_at = 0`
+	updateMessageRead = `UPDATE whatsapp_message_status SET
+	read_at = $1,
+	delivered_at = CASE WHEN delivered_at = 0 THEN $1 ELSE delivered_at END
+WHERE session_id = $2 AND chat_jid = $3 AND message_id = $4 AND read_at = 0`
+	selectMessageStatus = `SELECT message_id, chat_jid, sent_at, delivered_at, read_at FROM whatsapp_message_status
+WHERE session_id = $1 AND chat_jid = $2 AND message_id = $3`
+	deleteSessionMessageStatus = `DELETE FROM whatsapp_message_status WHERE session_id = $1`
+)
+
+// initMessageStatusStore creates the message status table in the database of the whatsmeow store
+func initMessageStatusStore(db *sql.DB) error {
+	if _, err := db.Exec(createMessageStatusTable); err != nil {
+		return fmt.Errorf("failed to create message status table: %w", err)
+	}
+	return nil
+}
+
+// recordMessageSent starts tracking the delivery of a message sent by this account, the tracked messages are the
+// ones the account can edit or delete for everyone
+func recordMessageSent(sessionID string, evt *events.Message) {
+	if storeDB == nil || !evt.Info.IsFromMe || !isContentMessage(evt.Message) {
+		return
+	}
+	if err := saveMessageSent(storeDB, sessionID, evt.Info.Chat, evt.Info.ID, evt.Info.Timestamp); err != nil {
+		log.Errorf("Failed to record the status of message %s: %v", evt.Info.ID, err)
+	}
+}
+
+func saveMessageSent(db *sql.DB, sessionID string, chat types.JID, messageID string, sentAt time.Time) error {
+	_, err := db.Exec(insertMessageStatus, sessionID, messageID, chat.ToNonAD().String(), sentAt.UnixMilli())
+	return err
+}
+
+// recordReceiptStatus moves the messages of a receipt forward, receipts of messages not sent by this account
+// match no row
+func recordReceiptStatus(sessionID string, evt *events.Receipt) {
+	if storeDB == nil || evt.IsFromMe {
+		return
+	}
+
+	var query string
+	switch evt.Type {
+	case types.ReceiptTypeDelivered:
+		query = updateMessageDelivered
+	case types.ReceiptTypeRead, types.ReceiptTypePlayed:
+		query = updateMessageRead
+	default:
+		return
+	}
+	for _, id := range evt.MessageIDs {
+		if err := saveReceiptStatus(storeDB, query, sessionID, evt.Chat, id, evt.Timestamp); err != nil {
+			log.Errorf("Failed to record the receipt of message %s: %v", id, err)
+		}
+	}
+}
+
+func saveReceiptStatus(db *sql.DB, query string, sessionID string, chat types.JID, messageID string, at time.Time) error {
+	_, err := db.Exec(query, at.UnixMilli(), sessionID, chat.ToNonAD().String(), messageID)
+	return err
+}
+
+// GetMessageStatus returns the delivery state of a message the session of the client sent to chat
+func GetMessageStatus(client *whatsmeow.Client, chat types.JID, messageID string) (MessageStatus, error) {
+	if storeDB == nil {
+		return MessageStatus{}, fmt.Errorf("message status store is not initialized")
+	}
+	return getMessageStatus(storeDB, sessionIDOf(client), chat, messageID)
+}
+
+func getMessageStatus(db *sql.DB, sessionID string, chat types.JID, messageID string) (MessageStatus, error) {
+	var status MessageStatus
+	var sentAt, deliveredAt, readAt int64
+	err := db.QueryRow(selectMessageStatus, sessionID, chat.ToNonAD().String(), messageID).Scan(&status.MessageID, &status.ChatJID, &sentAt, &deliveredAt, &readAt)
+	if err == sql.ErrNoRows {
+		return status, pkgError.NotFoundError(fmt.Sprintf("message %s was not sent by this account or is unknown", messageID))
+	} else if err != nil {
+		return status, fmt.Errorf("failed to query the status of message %s: %w", messageID, err)
+	}
+
+	status.Status = MessageStatusSent
+	status.SentAt = time.UnixMilli(sentAt)
+	if deliveredAt > 0 {
+		status.Status = MessageStatusDelivered
+		status.DeliveredAt = time.UnixMilli(deliveredAt)
+	}
+	if readAt > 0 {
+		status.Status = MessageStatusRead
+		status.ReadAt = time.UnixMilli(readAt)
+	}
+	return status, nil
+}
+
+// clearSessionMessageStatus forgets the delivery states tracked for a session
+func clearSessionMessageStatus(sessionID string) {
+	if storeDB == nil {
+		return
+	}
+	if _, err := storeDB.Exec(deleteSessionMessageStatus, sessionID); err != nil {
+		log.Errorf("Failed to clear the message statuses of session %s: %v", sessionID, err)
+	}
+}
@@ -0,0 +1,89 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func newTestMessageStatusDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "status.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, initLocalStores(db))
+	t.Cleanup(func() { storeDB = nil })
+	return db
+}
+
+func newTestReceipt(chat types.JID, receiptType types.ReceiptType, at time.Time, ids ...types.MessageID) *events.Receipt {
+	return &events.Receipt{
+		MessageSource: types.MessageSource{Chat: chat, Sender: chat},
+		MessageIDs:    ids,
+		Timestamp:     at,
+		Type:          receiptType,
+	}
+}
+
+func TestMessageStatus(t *testing.T) {
+	db := newTestMessageStatusDB(t)
+	chat := types.NewJID("628123456789", types.DefaultUserServer)
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	sent := newChatTextEvent(chat, "3EB0A1", "hello")
+	sent.Info.IsFromMe = true
+	sent.Info.Timestamp = now
+	recordMessageSent("default", sent)
+	// Received messages have no delivery to track
+	recordMessageSent("default", newChatTextEvent(chat, "3EB0B1", "hi"))
+
+	status, err := getMessageStatus(db, "default", chat, "3EB0A1")
+	require.NoError(t, err)
+	assert.Equal(t, MessageStatus{MessageID: "3EB0A1", ChatJID: chat.String(), Status: MessageStatusSent, SentAt: now}, status)
+	_, err = getMessageStatus(db, "default", chat, "3EB0B1")
+	assert.Error(t, err)
+
+	recordReceiptStatus("default", newTestReceipt(chat, types.ReceiptTypeDelivered, now.Add(time.Second), "3EB0A1"))
+	// A redelivered receipt keeps the first timestamp
+	recordReceiptStatus("default", newTestReceipt(chat, types.ReceiptTypeDelivered, now.Add(time.Minute), "3EB0A1"))
+	status, err = getMessageStatus(db, "default", chat, "3EB0A1")
+	require.NoError(t, err)
+	assert.Equal(t, MessageStatusDelivered, status.Status)
+	assert.Equal(t, now.Add(time.Second), status.DeliveredAt)
+
+	recordReceiptStatus("default", newTestReceipt(chat, types.ReceiptTypeRead, now.Add(2*time.Second), "3EB0A1"))
+	status, err = getMessageStatus(db, "default", chat, "3EB0A1")
+	require.NoError(t, err)
+	assert.Equal(t, MessageStatusRead, status.Status)
+	assert.Equal(t, now.Add(2*time.Second), status.ReadAt)
+
+	// A read receipt arriving first implies the delivery
+	sent.Info.ID = "3EB0A2"
+	recordMessageSent("default", sent)
+	recordReceiptStatus("default", newTestReceipt(chat, types.ReceiptTypeRead, now.Add(3*time.Second), "3EB0A2"))
+	status, err = getMessageStatus(db, "default", chat, "3EB0A2")
+	require.NoError(t, err)
+	assert.Equal(t, MessageStatusRead, status.Status)
+	assert.Equal(t, now.Add(3*time.Second), status.DeliveredAt)
+
+	// Ids are only unique within a chat
+	other := types.NewJID("628987654321", types.DefaultUserServer)
+	recordReceiptStatus("default", newTestReceipt(other, types.ReceiptTypeRead, now, "3EB0A3"))
+	sent.Info.ID = "3EB0A3"
+	recordMessageSent("default", sent)
+	status, err = getMessageStatus(db, "default", chat, "3EB0A3")
+	require.NoError(t, err)
+	assert.Equal(t, MessageStatusSent, status.Status)
+	_, err = getMessageStatus(db, "default", other, "3EB0A3")
+	assert.Error(t, err)
+
+	clearSessionMessageStatus("default")
+	_, err = getMessageStatus(db, "default", chat, "3EB0A1")
+	assert.Error(t, err)
+}
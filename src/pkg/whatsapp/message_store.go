@@ -0,0 +1,304 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// StoredMessage is a message of a chat as it was received or sent, edits and deletes for everyone are applied to it
+type StoredMessage struct {
+	ID        string
+	ChatJID   string
+	SenderJID string
+	IsFromMe  bool
+	PushName  string
+	Type      string
+	Text      string
+	MediaType string
+	MimeType  string
+	FileName  string
+	SentAt    time.Time
+}
+
+// MessageCursor bounds a page of the history, ID takes precedence over Time
+type MessageCursor struct {
+	ID   string
+	Time time.Time
+}
+
+// IsEmpty tells whether the cursor bounds nothing
+func (cursor MessageCursor) IsEmpty() bool {
+	return cursor.ID == "" && cursor.Time.IsZero()
+}
+
+const (
+	createMessageTable = `CREATE TABLE IF NOT EXISTS whatsapp_messages (
+	session_id   TEXT NOT NULL,
+	chat_jid     TEXT NOT NULL,
+	message_id   TEXT NOT NULL,
+	sender_jid   TEXT NOT NULL,
+	is_from_me   INTEGER NOT NULL DEFAULT 0,
+	push_name    TEXT NOT NULL DEFAULT '',
+	message_type TEXT NOT NULL,
+	text         TEXT NOT NULL DEFAULT '',
+	media_type   TEXT NOT NULL DEFAULT '',
+	mime_type    TEXT NOT NULL DEFAULT '',
+	file_name    TEXT NOT NULL DEFAULT '',
+	sent_at      BIGINT NOT NULL,
+	PRIMARY KEY (session_id, chat_jid, message_id)
+)`
+	createMessageIndex = `CREATE INDEX IF NOT EXISTS whatsapp_messages_chat_sent_at
+ON whatsapp_messages (session_id, chat_jid, sent_at)`
+	// A redelivered message keeps the first copy, it may have been edited since
+	insertMessage = `INSERT INTO whatsapp_messages (session_id, chat_jid, message_id, sender_jid, is_from_me, push_name, message_type, text, media_type, mime_type, file_name, sent_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (session_id, chat_jid, message_id) DO NOTHING`
+	updateMessageText   = `UPDATE whatsapp_messages SET text = $1 WHERE session_id = $2 AND chat_jid = $3 AND message_id = $4`
+	updateMessageRevoke = `UPDATE whatsapp_messages SET message_type = 'revoked', text = '', media_type = '', mime_type = '', file_name = ''
+WHERE session_id = $1 AND chat_jid = $2 AND message_id = $3`
+	selectMessageExists   = `SELECT EXISTS (SELECT 1 FROM whatsapp_messages WHERE session_id = $1 AND chat_jid = $2 AND message_id = $3)`
+	selectMessageSentAt   = `SELECT sent_at FROM whatsapp_messages WHERE session_id = $1 AND chat_jid = $2 AND message_id = $3`
+	selectMessageColumns  = `SELECT message_id, chat_jid, sender_jid, is_from_me, push_name, message_type, text, media_type, mime_type, file_name, sent_at FROM whatsapp_messages`
+	deleteSessionMessages = `DELETE FROM whatsapp_messages WHERE session_id = $1`
+)
+
+// initMessageStore creates the message table in the database of the whatsmeow store
+func initMessageStore(db *sql.DB) error {
+	for _, query := range []string{createMessageTable, createMessageIndex} {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create message table: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordMessage stores a message of a chat, an edit or a delete for everyone updates the message it targets
+func recordMessage(sessionID string, evt *events.Message) {
+	if storeDB == nil || evt.Message == nil || evt.Info.Chat == types.StatusBroadcastJID {
+		return
+	}
+
+	var err error
+	if protocolMessage := evt.Message.GetProtocolMessage(); protocolMessage != nil {
+		target := protocolMessage.GetKey().GetID()
+		switch protocolMessage.GetType() {
+		case waE2E.ProtocolMessage_MESSAGE_EDIT:
+			err = editStoredMessage(storeDB, sessionID, evt.Info.Chat, target, editedMessageText(protocolMessage.GetEditedMessage()))
+		case waE2E.ProtocolMessage_REVOKE:
+			err = revokeStoredMessage(storeDB, sessionID, evt.Info.Chat, target)
+		}
+	} else if isContentMessage(evt.Message) {
+		err = saveMessage(storeDB, sessionID, newStoredMessage(evt))
+	}
+	if err != nil {
+		log.Errorf("Failed to record message %s of chat %s: %v", evt.Info.ID, evt.Info.Chat, err)
+	}
+}
+
+// isMessageStored tells whether the message is in the history already, i.e. an event of it is a redelivery
+func isMessageStored(db *sql.DB, sessionID string, chat types.JID, messageID string) (bool, error) {
+	var stored bool
+	if err := db.QueryRow(selectMessageExists, sessionID, chat.ToNonAD().String(), messageID).Scan(&stored); err != nil {
+		return false, fmt.Errorf("failed to query message %s: %w", messageID, err)
+	}
+	return stored, nil
+}
+
+func newStoredMessage(evt *events.Message) StoredMessage {
+	stored := StoredMessage{
+		ID:        evt.Info.ID,
+		ChatJID:   evt.Info.Chat.ToNonAD().String(),
+		SenderJID: evt.Info.Sender.ToNonAD().String(),
+		IsFromMe:  evt.Info.IsFromMe,
+		PushName:  evt.Info.PushName,
+		Type:      storedMessageType(evt.Message),
+		Text:      editedMessageText(evt.Message),
+		SentAt:    evt.Info.Timestamp,
+	}
+	if media, mimeType, fileName := getDownloadableMedia(evt.Message); media != nil {
+		stored.MediaType = stored.Type
+		stored.MimeType = mimeType
+		stored.FileName = fileName
+	}
+	return stored
+}
+
+// storedMessageType names the content of a message
+func storedMessageType(msg *waE2E.Message) string {
+	switch {
+	case msg.GetConversation() != "" || msg.GetExtendedTextMessage() != nil:
+		return "text"
+	case msg.GetImageMessage() != nil:
+		return "image"
+	case msg.GetVideoMessage() != nil:
+		return "video"
+	case msg.GetAudioMessage() != nil:
+		return "audio"
+	case msg.GetDocumentMessage() != nil:
+		return "document"
+	case msg.GetStickerMessage() != nil:
+		return "sticker"
+	case msg.GetContactMessage() != nil || msg.GetContactsArrayMessage() != nil:
+		return "contact"
+	case msg.GetLocationMessage() != nil:
+		return "location"
+	case msg.GetLiveLocationMessage() != nil:
+		return "live_location"
+	case msg.GetPollCreationMessage() != nil || msg.GetPollCreationMessageV3() != nil:
+		return "poll"
+	default:
+		return "other"
+	}
+}
+
+func saveMessage(db *sql.DB, sessionID string, msg StoredMessage) error {
+	isFromMe := 0
+	if msg.IsFromMe {
+		isFromMe = 1
+	}
+	_, err := db.Exec(insertMessage, sessionID, msg.ChatJID, msg.ID, msg.SenderJID, isFromMe, msg.PushName, msg.Type,
+		msg.Text, msg.MediaType, msg.MimeType, msg.FileName, msg.SentAt.UnixMilli())
+	return err
+}
+
+func editStoredMessage(db *sql.DB, sessionID string, chat types.JID, messageID string, text string) error {
+	_, err := db.Exec(updateMessageText, text, sessionID, chat.ToNonAD().String(), messageID)
+	return err
+}
+
+func revokeStoredMessage(db *sql.DB, sessionID string, chat types.JID, messageID string) error {
+	_, err := db.Exec(updateMessageRevoke, sessionID, chat.ToNonAD().String(), messageID)
+	return err
+}
+
+// GetChatMessages returns a page of the stored messages of a chat, oldest first. Without after the page ends at
+// before (or the latest message), with only after it starts right after it. hasMore tells more messages are
+// beyond the end the page was taken from
+func GetChatMessages(client *whatsmeow.Client, chat types.JID, before MessageCursor, after MessageCursor, limit int) (messages []StoredMessage, hasMore bool, err error) {
+	if storeDB == nil {
+		return nil, false, fmt.Errorf("message store is not initialized")
+	}
+	return listMessages(storeDB, sessionIDOf(client), chat, before, after, limit)
+}
+
+func listMessages(db *sql.DB, sessionID string, chat types.JID, before MessageCursor, after MessageCursor, limit int) ([]StoredMessage, bool, error) {
+	chatJID := chat.ToNonAD().String()
+	args := []any{sessionID, chatJID}
+	conditions := []string{"session_id = $1", "chat_jid = $2"}
+
+	// A message id cursor compares on (sent_at, message_id), so messages sharing a timestamp are not skipped
+	bound := func(cursor MessageCursor, operator string) error {
+		if cursor.ID == "" {
+			args = append(args, cursor.Time.UnixMilli())
+			conditions = append(conditions, fmt.Sprintf("sent_at %s $%d", operator, len(args)))
+			return nil
+		}
+		var sentAt int64
+		if err := db.QueryRow(selectMessageSentAt, sessionID, chatJID, cursor.ID).Scan(&sentAt); err == sql.ErrNoRows {
+			return pkgError.NotFoundError(fmt.Sprintf("message %s is not stored for chat %s", cursor.ID, chatJID))
+		} else if err != nil {
+			return fmt.Errorf("failed to query message %s: %w", cursor.ID, err)
+		}
+		args = append(args, sentAt, cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(sent_at %[1]s $%[2]d OR (sent_at = $%[2]d AND message_id %[1]s $%[3]d))", operator, len(args)-1, len(args)))
+		return nil
+	}
+	if !before.IsEmpty() {
+		if err := bound(before, "<"); err != nil {
+			return nil, false, err
+		}
+	}
+	if !after.IsEmpty() {
+		if err := bound(after, ">"); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// The page is taken from the end it is anchored to, one more row tells whether more messages follow
+	newestFirst := !(before.IsEmpty() && !after.IsEmpty())
+	order := "ASC"
+	if newestFirst {
+		order = "DESC"
+	}
+	args = append(args, limit+1)
+	query := fmt.Sprintf("%s WHERE %s ORDER BY sent_at %s, message_id %s LIMIT $%d",
+		selectMessageColumns, strings.Join(conditions, " AND "), order, order, len(args))
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query messages of chat %s: %w", chatJID, err)
+	}
+	defer rows.Close()
+
+	messages := []StoredMessage{}
+	for rows.Next() {
+		msg, err := scanStoredMessage(rows)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to read message of chat %s: %w", chatJID, err)
+		}
+		messages = append(messages, msg)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	if newestFirst {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+	return messages, hasMore, nil
+}
+
+// GetStoredMessage returns a message of chat stored for the session of the client
+func GetStoredMessage(client *whatsmeow.Client, chat types.JID, messageID string) (StoredMessage, error) {
+	if storeDB == nil {
+		return StoredMessage{}, fmt.Errorf("message store is not initialized")
+	}
+	return getStoredMessage(storeDB, sessionIDOf(client), chat, messageID)
+}
+
+func getStoredMessage(db *sql.DB, sessionID string, chat types.JID, messageID string) (StoredMessage, error) {
+	chatJID := chat.ToNonAD().String()
+	row := db.QueryRow(selectMessageColumns+` WHERE session_id = $1 AND chat_jid = $2 AND message_id = $3`, sessionID, chatJID, messageID)
+	msg, err := scanStoredMessage(row)
+	if err == sql.ErrNoRows {
+		return msg, pkgError.NotFoundError(fmt.Sprintf("message %s is not stored for chat %s", messageID, chatJID))
+	} else if err != nil {
+		return msg, fmt.Errorf("failed to query message %s: %w", messageID, err)
+	}
+	return msg, nil
+}
+
+func scanStoredMessage(row interface{ Scan(dest ...any) error }) (StoredMessage, error) {
+	var msg StoredMessage
+	var isFromMe int
+	var sentAt int64
+	err := row.Scan(&msg.ID, &msg.ChatJID, &msg.SenderJID, &isFromMe, &msg.PushName, &msg.Type, &msg.Text,
+		&msg.MediaType, &msg.MimeType, &msg.FileName, &sentAt)
+	msg.IsFromMe = isFromMe == 1
+	msg.SentAt = time.UnixMilli(sentAt)
+	return msg, err
+}
+
+// clearSessionStoredMessages forgets the messages stored for a session
+func clearSessionStoredMessages(sessionID string) {
+	if storeDB == nil {
+		return
+	}
+	if _, err := storeDB.Exec(deleteSessionMessages, sessionID); err != nil {
+		log.Errorf("Failed to clear the messages of session %s: %v", sessionID, err)
+	}
+}
@@ -0,0 +1,117 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestMessageDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "messages.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, initLocalStores(db))
+	t.Cleanup(func() { storeDB = nil })
+	return db
+}
+
+func messageIDs(messages []StoredMessage) []string {
+	ids := []string{}
+	for _, msg := range messages {
+		ids = append(ids, msg.ID)
+	}
+	return ids
+}
+
+func TestMessageStore(t *testing.T) {
+	db := newTestMessageDB(t)
+	chat := types.NewJID("628123456789", types.DefaultUserServer)
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	for i, id := range []string{"3EB0A1", "3EB0A2", "3EB0A3", "3EB0A4"} {
+		evt := newChatTextEvent(chat, id, "hello "+id)
+		evt.Info.Timestamp = now.Add(time.Duration(i) * time.Second)
+		recordMessage("default", evt)
+	}
+	// A message sharing the timestamp of another is still paged once
+	evt := newChatTextEvent(chat, "3EB0A5", "")
+	evt.Info.Timestamp = now.Add(3 * time.Second)
+	evt.Message = &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+		Mimetype: proto.String("application/pdf"),
+		FileName: proto.String("invoice.pdf"),
+		Caption:  proto.String("the invoice"),
+	}}
+	recordMessage("default", evt)
+
+	messages, hasMore, err := listMessages(db, "default", chat, MessageCursor{}, MessageCursor{}, 2)
+	require.NoError(t, err)
+	assert.True(t, hasMore)
+	assert.Equal(t, []string{"3EB0A4", "3EB0A5"}, messageIDs(messages))
+	assert.Equal(t, StoredMessage{
+		ID:        "3EB0A5",
+		ChatJID:   chat.String(),
+		SenderJID: chat.String(),
+		Type:      "document",
+		Text:      "the invoice",
+		MediaType: "document",
+		MimeType:  "application/pdf",
+		FileName:  "invoice.pdf",
+		SentAt:    now.Add(3 * time.Second),
+	}, messages[1])
+
+	messages, hasMore, err = listMessages(db, "default", chat, MessageCursor{ID: "3EB0A4"}, MessageCursor{}, 2)
+	require.NoError(t, err)
+	assert.True(t, hasMore)
+	assert.Equal(t, []string{"3EB0A2", "3EB0A3"}, messageIDs(messages))
+
+	messages, hasMore, err = listMessages(db, "default", chat, MessageCursor{}, MessageCursor{ID: "3EB0A2"}, 10)
+	require.NoError(t, err)
+	assert.False(t, hasMore)
+	assert.Equal(t, []string{"3EB0A3", "3EB0A4", "3EB0A5"}, messageIDs(messages))
+
+	messages, _, err = listMessages(db, "default", chat, MessageCursor{Time: now.Add(3 * time.Second)}, MessageCursor{ID: "3EB0A1"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3EB0A2", "3EB0A3"}, messageIDs(messages))
+
+	_, _, err = listMessages(db, "default", chat, MessageCursor{ID: "3EB0FF"}, MessageCursor{}, 10)
+	assert.Error(t, err)
+
+	stored, err := getStoredMessage(db, "default", chat, "3EB0A2")
+	require.NoError(t, err)
+	assert.Equal(t, "hello 3EB0A2", stored.Text)
+	assert.False(t, stored.IsFromMe)
+	_, err = getStoredMessage(db, "other", chat, "3EB0A2")
+	assert.IsType(t, pkgError.NotFoundError(""), err)
+
+	// Edits and deletes for everyone apply to the stored message
+	recordMessage("default", &events.Message{Info: evt.Info, Message: &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{
+		Type:          waE2E.ProtocolMessage_MESSAGE_EDIT.Enum(),
+		Key:           &waCommon.MessageKey{ID: proto.String("3EB0A1")},
+		EditedMessage: &waE2E.Message{Conversation: proto.String("hello again")},
+	}}})
+	recordMessage("default", &events.Message{Info: evt.Info, Message: &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{
+		Type: waE2E.ProtocolMessage_REVOKE.Enum(),
+		Key:  &waCommon.MessageKey{ID: proto.String("3EB0A5")},
+	}}})
+	messages, _, err = listMessages(db, "default", chat, MessageCursor{}, MessageCursor{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "hello again", messages[0].Text)
+	assert.Equal(t, "revoked", messages[4].Type)
+	assert.Empty(t, messages[4].MediaType)
+
+	clearSessionStoredMessages("default")
+	messages, _, err = listMessages(db, "default", chat, MessageCursor{}, MessageCursor{}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
@@ -0,0 +1,114 @@
+package whatsapp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// newsletterRoleCacheTTL is how long the role of the session in a channel is trusted, a revoked admin may still
+// post for that long
+const newsletterRoleCacheTTL = 5 * time.Minute
+
+// newsletterRoles is keyed by session and channel jid
+var newsletterRoles = utils.NewTTLCache[string, types.NewsletterRole]()
+
+// newsletterInviteLink matches the invite link of a channel, the code is its last path segment
+var newsletterInviteLink = regexp.MustCompile(`^(?:https?://)?(?:www\.)?whatsapp\.com/channel/([A-Za-z0-9]+)/?$`)
+
+// NewsletterInviteCode returns the code of a channel invite link, a bare code is returned as is
+func NewsletterInviteCode(link string) (string, error) {
+	link = strings.TrimSpace(link)
+	if match := newsletterInviteLink.FindStringSubmatch(link); match != nil {
+		return match[1], nil
+	}
+	if link != "" && !strings.ContainsAny(link, "/:.@") {
+		return link, nil
+	}
+	return "", pkgError.ValidationError(fmt.Sprintf("%s is not a valid channel invite link", link))
+}
+
+// ParseNewsletterJID parses the jid of a channel, the jids of the other servers are rejected
+func ParseNewsletterJID(input string) (types.JID, error) {
+	jid, err := NormalizeJID(input)
+	if err != nil {
+		return types.JID{}, err
+	}
+	if jid.Server != types.NewsletterServer {
+		return types.JID{}, pkgError.InvalidJID(fmt.Sprintf("%s is not a newsletter jid", input))
+	}
+	return jid, nil
+}
+
+// ResolveNewsletter returns the metadata of the channel of a jid or, when there's no jid, of an invite link
+func ResolveNewsletter(client *whatsmeow.Client, newsletterID, inviteLink string) (*types.NewsletterMetadata, error) {
+	MustLogin(client)
+
+	if newsletterID != "" {
+		jid, err := ParseNewsletterJID(newsletterID)
+		if err != nil {
+			return nil, err
+		}
+		return client.GetNewsletterInfo(jid)
+	}
+
+	code, err := NewsletterInviteCode(inviteLink)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := client.GetNewsletterInfoWithInvite(code)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		return nil, pkgError.NotFoundError(fmt.Sprintf("channel of invite link %s not found", inviteLink))
+	}
+	return metadata, nil
+}
+
+// CheckNewsletterMessage tells whether msg can be posted to the channel jid. Only the owner and the admins of a
+// channel post to it, and only text and image messages are supported for now
+func CheckNewsletterMessage(client *whatsmeow.Client, jid types.JID, msg *waE2E.Message) error {
+	if !isNewsletterMessageSupported(msg) {
+		return pkgError.ValidationError("only text and image messages can be sent to a newsletter")
+	}
+	return CheckNewsletterRole(client, jid)
+}
+
+// CheckNewsletterRole tells whether the session may post to the channel jid, which only its owner and admins do.
+// The role is cached for newsletterRoleCacheTTL so sends don't fetch the channel every time, check it before
+// uploading the media of a message
+func CheckNewsletterRole(client *whatsmeow.Client, jid types.JID) error {
+	key := sessionIDOf(client) + "|" + jid.String()
+	role, ok := newsletterRoles.Get(key)
+	if !ok {
+		metadata, err := client.GetNewsletterInfo(jid)
+		if err != nil {
+			return err
+		}
+		if metadata != nil && metadata.ViewerMeta != nil {
+			role = metadata.ViewerMeta.Role
+		}
+		newsletterRoles.Set(key, role, newsletterRoleCacheTTL, 0)
+	}
+
+	if role != types.NewsletterRoleOwner && role != types.NewsletterRoleAdmin {
+		return pkgError.ForbiddenError(fmt.Sprintf("only the owner or an admin of newsletter %s can send messages to it", jid.String()))
+	}
+	return nil
+}
+
+func isNewsletterMessageSupported(msg *waE2E.Message) bool {
+	switch {
+	case msg.GetConversation() != "", msg.GetExtendedTextMessage() != nil, msg.GetImageMessage() != nil:
+		return true
+	}
+	return false
+}
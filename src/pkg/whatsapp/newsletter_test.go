@@ -0,0 +1,72 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewsletterInviteCode(t *testing.T) {
+	tests := []struct {
+		link    string
+		want    string
+		wantErr bool
+	}{
+		{"https://whatsapp.com/channel/0029VaD8wEhJ93wZe1nN9A2Q", "0029VaD8wEhJ93wZe1nN9A2Q", false},
+		{"https://www.whatsapp.com/channel/0029VaD8wEhJ93wZe1nN9A2Q/", "0029VaD8wEhJ93wZe1nN9A2Q", false},
+		{"whatsapp.com/channel/0029VaD8wEhJ93wZe1nN9A2Q", "0029VaD8wEhJ93wZe1nN9A2Q", false},
+		{" 0029VaD8wEhJ93wZe1nN9A2Q ", "0029VaD8wEhJ93wZe1nN9A2Q", false},
+		{"https://chat.whatsapp.com/F4PFnISxTxq0sKZAr3", "", true},
+		{"120363024512399999@newsletter", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		code, err := NewsletterInviteCode(tt.link)
+		if tt.wantErr {
+			assert.IsType(t, pkgError.ValidationError(""), err, tt.link)
+			continue
+		}
+		assert.NoError(t, err, tt.link)
+		assert.Equal(t, tt.want, code, tt.link)
+	}
+}
+
+func TestParseNewsletterJID(t *testing.T) {
+	jid, err := ParseNewsletterJID("120363024512399999@newsletter")
+	assert.NoError(t, err)
+	assert.Equal(t, "120363024512399999@newsletter", jid.String())
+
+	_, err = ParseNewsletterJID("120363024512399999@g.us")
+	assert.EqualError(t, err, "120363024512399999@g.us is not a newsletter jid")
+	_, err = ParseNewsletterJID("+62 812 3456 7890")
+	assert.Error(t, err)
+}
+
+func TestIsNewsletterMessageSupported(t *testing.T) {
+	assert.True(t, isNewsletterMessageSupported(&waE2E.Message{Conversation: proto.String("hi")}))
+	assert.True(t, isNewsletterMessageSupported(&waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{Text: proto.String("hi")}}))
+	assert.True(t, isNewsletterMessageSupported(&waE2E.Message{ImageMessage: &waE2E.ImageMessage{}}))
+	assert.False(t, isNewsletterMessageSupported(&waE2E.Message{VideoMessage: &waE2E.VideoMessage{}}))
+	assert.False(t, isNewsletterMessageSupported(&waE2E.Message{PollCreationMessage: &waE2E.PollCreationMessage{}}))
+}
+
+func TestCheckNewsletterRoleCached(t *testing.T) {
+	owned := types.NewJID("120363144038483540", types.NewsletterServer)
+	followed := types.NewJID("120363144038483541", types.NewsletterServer)
+	newsletterRoles.Set(DefaultSessionID+"|"+owned.String(), types.NewsletterRoleOwner, time.Minute, 0)
+	newsletterRoles.Set(DefaultSessionID+"|"+followed.String(), types.NewsletterRoleSubscriber, time.Minute, 0)
+	t.Cleanup(func() {
+		newsletterRoles.Delete(DefaultSessionID + "|" + owned.String())
+		newsletterRoles.Delete(DefaultSessionID + "|" + followed.String())
+	})
+
+	// The cached role answers without asking whatsapp
+	assert.NoError(t, CheckNewsletterRole(nil, owned))
+	assert.Equal(t, pkgError.ForbiddenError("only the owner or an admin of newsletter 120363144038483541@newsletter can send messages to it"),
+		CheckNewsletterRole(nil, followed))
+}
@@ -0,0 +1,153 @@
+package whatsapp
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waBinary "go.mau.fi/whatsmeow/binary"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// PresenceSubscription is a contact whose presence a session subscribed to, with the last presence received.
+// Whatsapp only sends the presence of others while the own presence is available
+type PresenceSubscription struct {
+	JID          types.JID
+	SubscribedAt time.Time
+	Available    bool
+	LastSeen     time.Time
+	UpdatedAt    time.Time
+}
+
+// sessionPresence holds the presence state of a session, the subscriptions are kept in memory and sent again
+// after every reconnect since whatsapp drops them with the connection
+type sessionPresence struct {
+	own           types.Presence
+	subscriptions map[types.JID]*PresenceSubscription
+}
+
+var (
+	presences   = make(map[string]*sessionPresence)
+	presencesMu sync.Mutex
+)
+
+func sessionPresenceOf(sessionID string) *sessionPresence {
+	presence, ok := presences[sessionID]
+	if !ok {
+		presence = &sessionPresence{subscriptions: make(map[types.JID]*PresenceSubscription)}
+		presences[sessionID] = presence
+	}
+	return presence
+}
+
+// SendPresence sets the own presence of the session and remembers it
+func SendPresence(client *whatsmeow.Client, presence types.Presence) error {
+	if err := client.SendPresence(presence); err != nil {
+		return err
+	}
+	presencesMu.Lock()
+	sessionPresenceOf(sessionIDOf(client)).own = presence
+	presencesMu.Unlock()
+	return nil
+}
+
+// SubscribePresence subscribes to the presence of jid, the presence events of the contact are forwarded from now on
+func SubscribePresence(client *whatsmeow.Client, jid types.JID) (PresenceSubscription, error) {
+	jid = jid.ToNonAD()
+	if err := client.SubscribePresence(jid); err != nil {
+		return PresenceSubscription{}, err
+	}
+
+	presencesMu.Lock()
+	defer presencesMu.Unlock()
+	subscriptions := sessionPresenceOf(sessionIDOf(client)).subscriptions
+	subscription, ok := subscriptions[jid]
+	if !ok {
+		subscription = &PresenceSubscription{JID: jid, SubscribedAt: time.Now()}
+		subscriptions[jid] = subscription
+	}
+	return *subscription, nil
+}
+
+// UnsubscribePresence stops the subscription to the presence of jid, false when there was none
+func UnsubscribePresence(client *whatsmeow.Client, jid types.JID) (bool, error) {
+	jid = jid.ToNonAD()
+	presencesMu.Lock()
+	subscriptions := sessionPresenceOf(sessionIDOf(client)).subscriptions
+	_, ok := subscriptions[jid]
+	delete(subscriptions, jid)
+	presencesMu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	// whatsmeow has no call for it, the unsubscribe node mirrors the subscribe one
+	return true, client.DangerousInternals().SendNode(waBinary.Node{
+		Tag:   "presence",
+		Attrs: waBinary.Attrs{"type": "unsubscribe", "to": jid},
+	})
+}
+
+// GetPresenceSubscriptions returns the own presence of the session and its subscriptions, ordered by jid
+func GetPresenceSubscriptions(client *whatsmeow.Client) (types.Presence, []PresenceSubscription) {
+	presencesMu.Lock()
+	defer presencesMu.Unlock()
+	presence := sessionPresenceOf(sessionIDOf(client))
+	subscriptions := make([]PresenceSubscription, 0, len(presence.subscriptions))
+	for _, subscription := range presence.subscriptions {
+		subscriptions = append(subscriptions, *subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].JID.String() < subscriptions[j].JID.String()
+	})
+	return presence.own, subscriptions
+}
+
+// recordPresence keeps the last presence received for a subscribed contact
+func recordPresence(sessionID string, evt *events.Presence) {
+	presencesMu.Lock()
+	defer presencesMu.Unlock()
+	presence, ok := presences[sessionID]
+	if !ok {
+		return
+	}
+	subscription, ok := presence.subscriptions[evt.From.ToNonAD()]
+	if !ok {
+		return
+	}
+	subscription.Available = !evt.Unavailable
+	if !evt.LastSeen.IsZero() {
+		subscription.LastSeen = evt.LastSeen
+	}
+	subscription.UpdatedAt = time.Now()
+}
+
+// resubscribePresences sends the subscriptions of a session again once it reconnected
+func resubscribePresences(sessionID string, client *whatsmeow.Client) {
+	presencesMu.Lock()
+	jids := make([]types.JID, 0)
+	if presence, ok := presences[sessionID]; ok {
+		for jid := range presence.subscriptions {
+			jids = append(jids, jid)
+		}
+	}
+	presencesMu.Unlock()
+
+	for _, jid := range jids {
+		if err := client.SubscribePresence(jid); err != nil {
+			log.Warnf("Failed to subscribe again to the presence of %s: %v", jid, err)
+		}
+	}
+	if len(jids) > 0 {
+		log.Infof("Subscribed again to the presence of %d contacts", len(jids))
+	}
+}
+
+// clearSessionPresences forgets the presence state of a session that logged out
+func clearSessionPresences(sessionID string) {
+	presencesMu.Lock()
+	delete(presences, sessionID)
+	presencesMu.Unlock()
+}
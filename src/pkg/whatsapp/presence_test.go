@@ -0,0 +1,59 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+func TestRecordPresence(t *testing.T) {
+	t.Cleanup(func() { clearSessionPresences(DefaultSessionID) })
+
+	alice := types.NewJID("6289685028129", types.DefaultUserServer)
+	bob := types.NewJID("6289685028130", types.DefaultUserServer)
+	presencesMu.Lock()
+	subscriptions := sessionPresenceOf(DefaultSessionID).subscriptions
+	subscriptions[bob] = &PresenceSubscription{JID: bob, SubscribedAt: time.Now()}
+	subscriptions[alice] = &PresenceSubscription{JID: alice, SubscribedAt: time.Now()}
+	presencesMu.Unlock()
+
+	recordPresence(DefaultSessionID, &events.Presence{From: alice})
+	lastSeen := time.Unix(1735689600, 0)
+	recordPresence(DefaultSessionID, &events.Presence{From: bob, Unavailable: true, LastSeen: lastSeen})
+	// The presence of a contact without subscription is not tracked
+	recordPresence(DefaultSessionID, &events.Presence{From: types.NewJID("6289685028131", types.DefaultUserServer)})
+
+	_, got := GetPresenceSubscriptions(nil)
+	assert.Len(t, got, 2)
+	assert.Equal(t, alice, got[0].JID)
+	assert.True(t, got[0].Available)
+	assert.True(t, got[0].LastSeen.IsZero())
+	assert.False(t, got[0].UpdatedAt.IsZero())
+	assert.Equal(t, bob, got[1].JID)
+	assert.False(t, got[1].Available)
+	assert.Equal(t, lastSeen, got[1].LastSeen)
+
+	// An unavailable presence without last seen keeps the last one known
+	recordPresence(DefaultSessionID, &events.Presence{From: bob})
+	recordPresence(DefaultSessionID, &events.Presence{From: bob, Unavailable: true})
+	_, got = GetPresenceSubscriptions(nil)
+	assert.Equal(t, lastSeen, got[1].LastSeen)
+}
+
+func TestClearSessionPresences(t *testing.T) {
+	alice := types.NewJID("6289685028129", types.DefaultUserServer)
+	presencesMu.Lock()
+	presence := sessionPresenceOf(DefaultSessionID)
+	presence.own = types.PresenceAvailable
+	presence.subscriptions[alice] = &PresenceSubscription{JID: alice, SubscribedAt: time.Now()}
+	presencesMu.Unlock()
+
+	clearSessionPresences(DefaultSessionID)
+	own, got := GetPresenceSubscriptions(nil)
+	assert.Empty(t, own)
+	assert.Empty(t, got)
+	clearSessionPresences(DefaultSessionID)
+}
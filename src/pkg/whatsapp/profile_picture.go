@@ -0,0 +1,67 @@
+package whatsapp
+
+import (
+	"errors"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	ProfilePictureAvailable  = "available"
+	ProfilePictureNotSet     = "not_set"
+	ProfilePictureRestricted = "restricted" // hidden from us by the privacy settings of the contact
+)
+
+// ProfilePicture is the profile picture of a contact, URL and ID are only set when Status is available
+type ProfilePicture struct {
+	Status string `json:"status"`
+	URL    string `json:"url,omitempty"`
+	ID     string `json:"id,omitempty"`
+}
+
+type profilePictureGetter interface {
+	GetProfilePictureInfo(jid types.JID, params *whatsmeow.GetProfilePictureParams) (*types.ProfilePictureInfo, error)
+}
+
+// profilePictureCache is keyed by session and jid
+var profilePictureCache = utils.NewTTLCache[string, ProfilePicture]()
+
+// GetProfilePicture fetches the profile picture of the jid, cached for config.WhatsappProfilePictureCacheTTL.
+// A missing or hidden picture is not an error, it is reported through the status
+func GetProfilePicture(client *whatsmeow.Client, jid types.JID) (ProfilePicture, error) {
+	if client == nil {
+		return ProfilePicture{}, pkgError.ErrWaCLI
+	}
+	return getProfilePicture(sessionIDOf(client), client, jid)
+}
+
+func getProfilePicture(sessionID string, getter profilePictureGetter, jid types.JID) (ProfilePicture, error) {
+	// What a contact shows depends on its privacy settings towards us, so the cache is per session
+	key := sessionID + "|" + jid.ToNonAD().String()
+
+	if cached, ok := profilePictureCache.Get(key); ok {
+		return cached, nil
+	}
+
+	var picture ProfilePicture
+	info, err := getter.GetProfilePictureInfo(jid, &whatsmeow.GetProfilePictureParams{})
+	switch {
+	case errors.Is(err, whatsmeow.ErrProfilePictureUnauthorized):
+		picture.Status = ProfilePictureRestricted
+	case errors.Is(err, whatsmeow.ErrProfilePictureNotSet):
+		picture.Status = ProfilePictureNotSet
+	case err != nil:
+		return picture, err
+	case info == nil:
+		picture.Status = ProfilePictureNotSet
+	default:
+		picture = ProfilePicture{Status: ProfilePictureAvailable, URL: info.URL, ID: info.ID}
+	}
+
+	profilePictureCache.Set(key, picture, config.WhatsappProfilePictureCacheTTL, 0)
+	return picture, nil
+}
@@ -0,0 +1,101 @@
+package whatsapp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+type fakeProfilePictureGetter struct {
+	info  *types.ProfilePictureInfo
+	err   error
+	calls int
+}
+
+func (f *fakeProfilePictureGetter) GetProfilePictureInfo(types.JID, *whatsmeow.GetProfilePictureParams) (*types.ProfilePictureInfo, error) {
+	f.calls++
+	return f.info, f.err
+}
+
+func resetProfilePictureCache(t *testing.T, ttl time.Duration) {
+	previous := config.WhatsappProfilePictureCacheTTL
+	config.WhatsappProfilePictureCacheTTL = ttl
+	profilePictureCache = utils.NewTTLCache[string, ProfilePicture]()
+	t.Cleanup(func() { config.WhatsappProfilePictureCacheTTL = previous })
+}
+
+func TestGetProfilePicture(t *testing.T) {
+	jid := types.NewJID("628123456789", types.DefaultUserServer)
+	tests := []struct {
+		name    string
+		getter  *fakeProfilePictureGetter
+		want    ProfilePicture
+		wantErr bool
+	}{
+		{
+			name:   "should return the picture",
+			getter: &fakeProfilePictureGetter{info: &types.ProfilePictureInfo{URL: "https://pps.whatsapp.net/v/t61", ID: "1728"}},
+			want:   ProfilePicture{Status: ProfilePictureAvailable, URL: "https://pps.whatsapp.net/v/t61", ID: "1728"},
+		},
+		{
+			name:   "should report a hidden picture",
+			getter: &fakeProfilePictureGetter{err: whatsmeow.ErrProfilePictureUnauthorized},
+			want:   ProfilePicture{Status: ProfilePictureRestricted},
+		},
+		{
+			name:   "should report a missing picture",
+			getter: &fakeProfilePictureGetter{err: whatsmeow.ErrProfilePictureNotSet},
+			want:   ProfilePicture{Status: ProfilePictureNotSet},
+		},
+		{
+			name:   "should report a missing picture without info",
+			getter: &fakeProfilePictureGetter{},
+			want:   ProfilePicture{Status: ProfilePictureNotSet},
+		},
+		{
+			name:    "should return other errors",
+			getter:  &fakeProfilePictureGetter{err: errors.New("timeout")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetProfilePictureCache(t, time.Hour)
+			picture, err := getProfilePicture(DefaultSessionID, tt.getter, jid)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, picture)
+		})
+	}
+}
+
+func TestGetProfilePictureIsCached(t *testing.T) {
+	resetProfilePictureCache(t, time.Hour)
+	jid := types.NewJID("628123456789", types.DefaultUserServer)
+	getter := &fakeProfilePictureGetter{err: whatsmeow.ErrProfilePictureUnauthorized}
+
+	for i := 0; i < 3; i++ {
+		_, _ = getProfilePicture(DefaultSessionID, getter, jid)
+	}
+	assert.Equal(t, 1, getter.calls)
+
+	// Another session may see a different picture
+	_, _ = getProfilePicture("sales", getter, jid)
+	assert.Equal(t, 2, getter.calls)
+
+	// Errors are not cached
+	failing := &fakeProfilePictureGetter{err: errors.New("timeout")}
+	_, _ = getProfilePicture(DefaultSessionID, failing, types.NewJID("628999", types.DefaultUserServer))
+	_, _ = getProfilePicture(DefaultSessionID, failing, types.NewJID("628999", types.DefaultUserServer))
+	assert.Equal(t, 2, failing.calls)
+}
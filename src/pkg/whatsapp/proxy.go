@@ -0,0 +1,75 @@
+package whatsapp
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"go.mau.fi/whatsmeow"
+)
+
+const proxyDialTimeout = 5 * time.Second
+
+// proxyDefaultPorts are the ports dialed for a proxy url without a port
+var proxyDefaultPorts = map[string]string{"http": "80", "https": "443", "socks5": "1080"}
+
+// parseProxyURL parses an http, https or socks5 proxy url
+func parseProxyURL(raw string) (*url.URL, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("proxy url %q is not valid: %w", raw, err)
+	}
+	if _, ok := proxyDefaultPorts[parsed.Scheme]; !ok {
+		return nil, fmt.Errorf("proxy url %q uses unsupported scheme %q, available schemes: http,https,socks5", raw, parsed.Scheme)
+	}
+	if parsed.Hostname() == "" {
+		return nil, fmt.Errorf("proxy url %q has no host", raw)
+	}
+	return parsed, nil
+}
+
+// proxyAddress returns the host:port dialed to reach the proxy
+func proxyAddress(proxyURL *url.URL) string {
+	port := proxyURL.Port()
+	if port == "" {
+		port = proxyDefaultPorts[proxyURL.Scheme]
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// checkProxyReachable opens a tcp connection to the proxy, it doesn't tell whether the proxy forwards the traffic
+func checkProxyReachable(proxyURL *url.URL) error {
+	conn, err := net.DialTimeout("tcp", proxyAddress(proxyURL), proxyDialTimeout)
+	if err != nil {
+		return fmt.Errorf("proxy %s is unreachable: %w", proxyURL.Redacted(), err)
+	}
+	return conn.Close()
+}
+
+// ValidateProxyConfig makes sure the proxies of the whatsapp connection and of the webhooks are valid and reachable
+// before the app starts
+func ValidateProxyConfig() error {
+	for _, raw := range []string{config.WhatsappProxyURL, config.WhatsappWebhookProxyURL} {
+		if raw == "" {
+			continue
+		}
+		proxyURL, err := parseProxyURL(raw)
+		if err != nil {
+			return err
+		}
+		if err = checkProxyReachable(proxyURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyProxy routes the websocket and the media transfers of the client through config.WhatsappProxyURL
+func applyProxy(client *whatsmeow.Client) error {
+	if config.WhatsappProxyURL == "" {
+		return nil
+	}
+	return client.SetProxyAddress(config.WhatsappProxyURL)
+}
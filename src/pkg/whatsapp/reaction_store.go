@@ -0,0 +1,118 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// Reaction is the current emoji of a participant on a message
+type Reaction struct {
+	SenderJID string
+	Emoji     string
+	ReactedAt time.Time
+}
+
+// The removal of a reaction is kept as an empty emoji, so a late delivered older reaction can't bring it back.
+// Placeholders use the $n form, both postgres and sqlite understand it
+const (
+	createReactionTable = `CREATE TABLE IF NOT EXISTS whatsapp_message_reactions (
+	session_id TEXT NOT NULL,
+	chat_jid   TEXT NOT NULL,
+	message_id TEXT NOT NULL,
+	sender_jid TEXT NOT NULL,
+	emoji      TEXT NOT NULL,
+	reacted_at BIGINT NOT NULL,
+	PRIMARY KEY (session_id, chat_jid, message_id, sender_jid)
+)`
+	upsertReaction = `INSERT INTO whatsapp_message_reactions (session_id, chat_jid, message_id, sender_jid, emoji, reacted_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (session_id, chat_jid, message_id, sender_jid) DO UPDATE SET emoji = excluded.emoji, reacted_at = excluded.reacted_at
+WHERE whatsapp_message_reactions.reacted_at <= excluded.reacted_at`
+	selectReactions = `SELECT sender_jid, emoji, reacted_at FROM whatsapp_message_reactions
+WHERE session_id = $1 AND chat_jid = $2 AND message_id = $3 AND emoji <> ''
+ORDER BY reacted_at`
+	deleteSessionReactions = `DELETE FROM whatsapp_message_reactions WHERE session_id = $1`
+)
+
+// initReactionStore creates the reaction table in the database of the whatsmeow store
+func initReactionStore(db *sql.DB) error {
+	if _, err := db.Exec(createReactionTable); err != nil {
+		return fmt.Errorf("failed to create reaction table: %w", err)
+	}
+	return nil
+}
+
+// recordReaction stores the reaction of a received message, an empty emoji means the reaction was removed
+func recordReaction(sessionID string, evt *events.Message) {
+	reactionMessage := evt.Message.GetReactionMessage()
+	if reactionMessage == nil || storeDB == nil {
+		return
+	}
+
+	reactedAt := evt.Info.Timestamp
+	if ms := reactionMessage.GetSenderTimestampMS(); ms > 0 {
+		reactedAt = time.UnixMilli(ms)
+	}
+	err := saveReaction(storeDB, sessionID, evt.Info.Chat, reactionMessage.GetKey().GetID(), evt.Info.Sender, reactionMessage.GetText(), reactedAt)
+	if err != nil {
+		log.Errorf("Failed to record reaction of %s on message %s: %v", evt.Info.Sender, reactionMessage.GetKey().GetID(), err)
+	}
+}
+
+// RecordSentReaction stores a reaction sent through the api, whatsapp doesn't echo it back as an event
+func RecordSentReaction(client *whatsmeow.Client, chat types.JID, messageID string, emoji string, reactedAt time.Time) {
+	if storeDB == nil || client.Store.ID == nil {
+		return
+	}
+	if err := saveReaction(storeDB, sessionIDOf(client), chat, messageID, *client.Store.ID, emoji, reactedAt); err != nil {
+		log.Errorf("Failed to record sent reaction on message %s: %v", messageID, err)
+	}
+}
+
+func saveReaction(db *sql.DB, sessionID string, chat types.JID, messageID string, sender types.JID, emoji string, reactedAt time.Time) error {
+	_, err := db.Exec(upsertReaction, sessionID, chat.ToNonAD().String(), messageID, sender.ToNonAD().String(), emoji, reactedAt.UnixMilli())
+	return err
+}
+
+// GetMessageReactions returns the current reactions on a message of a chat, oldest first
+func GetMessageReactions(client *whatsmeow.Client, chat types.JID, messageID string) ([]Reaction, error) {
+	if storeDB == nil {
+		return nil, fmt.Errorf("reaction store is not initialized")
+	}
+	return listReactions(storeDB, sessionIDOf(client), chat, messageID)
+}
+
+func listReactions(db *sql.DB, sessionID string, chat types.JID, messageID string) ([]Reaction, error) {
+	rows, err := db.Query(selectReactions, sessionID, chat.ToNonAD().String(), messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reactions of message %s: %w", messageID, err)
+	}
+	defer rows.Close()
+
+	reactions := []Reaction{}
+	for rows.Next() {
+		var reaction Reaction
+		var reactedAt int64
+		if err = rows.Scan(&reaction.SenderJID, &reaction.Emoji, &reactedAt); err != nil {
+			return nil, fmt.Errorf("failed to read reaction of message %s: %w", messageID, err)
+		}
+		reaction.ReactedAt = time.UnixMilli(reactedAt)
+		reactions = append(reactions, reaction)
+	}
+	return reactions, rows.Err()
+}
+
+// clearSessionReactions forgets the reactions recorded for a session
+func clearSessionReactions(sessionID string) {
+	if storeDB == nil {
+		return
+	}
+	if _, err := storeDB.Exec(deleteSessionReactions, sessionID); err != nil {
+		log.Errorf("Failed to clear the reactions of session %s: %v", sessionID, err)
+	}
+}
@@ -0,0 +1,62 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func newTestReactionDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "reactions.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, initLocalStores(db))
+	t.Cleanup(func() { storeDB = nil })
+	return db
+}
+
+func TestReactionStore(t *testing.T) {
+	db := newTestReactionDB(t)
+	chat := types.NewJID("120363025246125888", types.GroupServer)
+	alice := types.JID{User: "628123456789", Device: 12, Server: types.DefaultUserServer}
+	bob := types.NewJID("628987654321", types.DefaultUserServer)
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	require.NoError(t, saveReaction(db, "default", chat, "3EB0", alice, "👍", now))
+	require.NoError(t, saveReaction(db, "default", chat, "3EB0", bob, "❤️", now.Add(time.Second)))
+	// The latest reaction of a participant replaces the previous one
+	require.NoError(t, saveReaction(db, "default", chat, "3EB0", alice, "😂", now.Add(2*time.Second)))
+	// An older reaction delivered late doesn't override the current one
+	require.NoError(t, saveReaction(db, "default", chat, "3EB0", bob, "😮", now))
+	require.NoError(t, saveReaction(db, "sales", chat, "3EB0", bob, "🙏", now))
+
+	reactions, err := listReactions(db, "default", chat, "3EB0")
+	require.NoError(t, err)
+	assert.Equal(t, []Reaction{
+		{SenderJID: bob.String(), Emoji: "❤️", ReactedAt: now.Add(time.Second)},
+		{SenderJID: "628123456789@s.whatsapp.net", Emoji: "😂", ReactedAt: now.Add(2 * time.Second)},
+	}, reactions)
+
+	// A removed reaction is not listed and can't be brought back by an older one
+	require.NoError(t, saveReaction(db, "default", chat, "3EB0", bob, "", now.Add(3*time.Second)))
+	require.NoError(t, saveReaction(db, "default", chat, "3EB0", bob, "❤️", now.Add(time.Second)))
+	reactions, err = listReactions(db, "default", chat, "3EB0")
+	require.NoError(t, err)
+	assert.Len(t, reactions, 1)
+	assert.Equal(t, "😂", reactions[0].Emoji)
+
+	clearSessionReactions("default")
+	reactions, err = listReactions(db, "default", chat, "3EB0")
+	require.NoError(t, err)
+	assert.Empty(t, reactions)
+
+	reactions, err = listReactions(db, "sales", chat, "3EB0")
+	require.NoError(t, err)
+	assert.Len(t, reactions, 1)
+}
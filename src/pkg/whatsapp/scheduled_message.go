@@ -0,0 +1,330 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+)
+
+const (
+	ScheduledMessagePending   = "pending"
+	ScheduledMessageSending   = "sending"
+	ScheduledMessageSent      = "sent"
+	ScheduledMessageFailed    = "failed"
+	ScheduledMessageCancelled = "cancelled"
+	ScheduledMessageExpired   = "expired"
+)
+
+// scheduledMessageCheckInterval is how often the due messages are looked up, it bounds how late a message is sent
+const scheduledMessageCheckInterval = time.Second
+
+// scheduledMessageSendTimeout bounds a send, a message stuck on a bad connection fails instead of staying sending
+const scheduledMessageSendTimeout = time.Minute
+
+// ScheduledMessage is a message waiting in the database to be sent at SendAt. Payload is the send request as json,
+// only the sender of the scheduler reads it
+type ScheduledMessage struct {
+	ID        string
+	SessionID string
+	Phone     string
+	Payload   string
+	SendAt    time.Time
+	Status    string
+	MessageID string
+	Error     string
+	CreatedAt time.Time
+	SentAt    time.Time
+}
+
+// ScheduledMessageSender sends a due message, it returns the id of the sent message
+type ScheduledMessageSender func(ctx context.Context, message ScheduledMessage) (string, error)
+
+// schedulerStop stops the scheduler loop, schedulerDone is closed once the messages being sent are done
+var (
+	schedulerStop chan struct{}
+	schedulerDone chan struct{}
+)
+
+const (
+	createScheduledMessageTable = `CREATE TABLE IF NOT EXISTS whatsapp_scheduled_messages (
+	id         TEXT NOT NULL PRIMARY KEY,
+	session_id TEXT NOT NULL,
+	phone      TEXT NOT NULL,
+	payload    TEXT NOT NULL,
+	send_at    BIGINT NOT NULL,
+	status     TEXT NOT NULL,
+	message_id TEXT NOT NULL DEFAULT '',
+	error      TEXT NOT NULL DEFAULT '',
+	created_at BIGINT NOT NULL,
+	sent_at    BIGINT NOT NULL DEFAULT 0
+)`
+	createScheduledMessageIndex = `CREATE INDEX IF NOT EXISTS whatsapp_scheduled_messages_due
+ON whatsapp_scheduled_messages (status, send_at)`
+	insertScheduledMessage = `INSERT INTO whatsapp_scheduled_messages (id, session_id, phone, payload, send_at, status, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	selectScheduledMessageColumns = `SELECT id, session_id, phone, payload, send_at, status, message_id, error, created_at, sent_at
+FROM whatsapp_scheduled_messages`
+	// A message is claimed before it's sent, so it can't be sent twice
+	updateScheduledMessageClaim = `UPDATE whatsapp_scheduled_messages SET status = $1 WHERE id = $2 AND status = $3`
+	updateScheduledMessageDone  = `UPDATE whatsapp_scheduled_messages SET status = $1, message_id = $2, error = $3, sent_at = $4 WHERE id = $5`
+	// A message being sent when the app stopped may or may not have left, it's failed rather than sent twice
+	updateScheduledMessageInterrupted = `UPDATE whatsapp_scheduled_messages SET status = $1, error = $2 WHERE status = $3`
+	deleteSessionScheduledMessages    = `DELETE FROM whatsapp_scheduled_messages WHERE session_id = $1`
+)
+
+// initScheduledMessageStore creates the schedule table in the database of the whatsmeow store
+func initScheduledMessageStore(db *sql.DB) error {
+	for _, query := range []string{createScheduledMessageTable, createScheduledMessageIndex} {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create scheduled message table: %w", err)
+		}
+	}
+	return nil
+}
+
+// ScheduleMessage stores a message of the session of the client to be sent at sendAt
+func ScheduleMessage(client *whatsmeow.Client, phone string, payload string, sendAt time.Time) (ScheduledMessage, error) {
+	if storeDB == nil {
+		return ScheduledMessage{}, fmt.Errorf("scheduled message store is not initialized")
+	}
+	message := ScheduledMessage{
+		ID:        uuid.NewString(),
+		SessionID: sessionIDOf(client),
+		Phone:     phone,
+		Payload:   payload,
+		SendAt:    time.UnixMilli(sendAt.UnixMilli()),
+		Status:    ScheduledMessagePending,
+		CreatedAt: time.UnixMilli(time.Now().UnixMilli()),
+	}
+	_, err := storeDB.Exec(insertScheduledMessage, message.ID, message.SessionID, message.Phone, message.Payload,
+		message.SendAt.UnixMilli(), message.Status, message.CreatedAt.UnixMilli())
+	if err != nil {
+		return ScheduledMessage{}, fmt.Errorf("failed to schedule the message: %w", err)
+	}
+	return message, nil
+}
+
+// ListScheduledMessages returns the scheduled messages of the session of the client by send time,
+// an empty status returns them all
+func ListScheduledMessages(client *whatsmeow.Client, status string) ([]ScheduledMessage, error) {
+	if storeDB == nil {
+		return nil, fmt.Errorf("scheduled message store is not initialized")
+	}
+	query := selectScheduledMessageColumns + ` WHERE session_id = $1 ORDER BY send_at, id`
+	args := []any{sessionIDOf(client)}
+	if status != "" {
+		query = selectScheduledMessageColumns + ` WHERE session_id = $1 AND status = $2 ORDER BY send_at, id`
+		args = append(args, status)
+	}
+	return queryScheduledMessages(storeDB, query, args...)
+}
+
+// CancelScheduledMessage stops a pending message of the session of the client from being sent
+func CancelScheduledMessage(client *whatsmeow.Client, id string) (ScheduledMessage, error) {
+	if storeDB == nil {
+		return ScheduledMessage{}, fmt.Errorf("scheduled message store is not initialized")
+	}
+	return cancelScheduledMessage(storeDB, sessionIDOf(client), id)
+}
+
+func cancelScheduledMessage(db *sql.DB, sessionID string, id string) (ScheduledMessage, error) {
+	messages, err := queryScheduledMessages(db, selectScheduledMessageColumns+` WHERE id = $1 AND session_id = $2`, id, sessionID)
+	if err != nil {
+		return ScheduledMessage{}, err
+	}
+	if len(messages) == 0 {
+		return ScheduledMessage{}, pkgError.NotFoundError(fmt.Sprintf("scheduled message %s is unknown", id))
+	}
+
+	message := messages[0]
+	claimed, err := claimScheduledMessage(db, id, ScheduledMessageCancelled)
+	if err != nil {
+		return ScheduledMessage{}, err
+	}
+	if !claimed {
+		return ScheduledMessage{}, pkgError.ValidationError(fmt.Sprintf("scheduled message %s is %s and can't be cancelled anymore", id, message.Status))
+	}
+	message.Status = ScheduledMessageCancelled
+	return message, nil
+}
+
+func queryScheduledMessages(db *sql.DB, query string, args ...any) ([]ScheduledMessage, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the scheduled messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := make([]ScheduledMessage, 0)
+	for rows.Next() {
+		var message ScheduledMessage
+		var sendAt, createdAt, sentAt int64
+		err = rows.Scan(&message.ID, &message.SessionID, &message.Phone, &message.Payload, &sendAt, &message.Status,
+			&message.MessageID, &message.Error, &createdAt, &sentAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read a scheduled message: %w", err)
+		}
+		message.SendAt = time.UnixMilli(sendAt)
+		message.CreatedAt = time.UnixMilli(createdAt)
+		if sentAt > 0 {
+			message.SentAt = time.UnixMilli(sentAt)
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// claimScheduledMessage moves a pending message to status, false when it's not pending anymore
+func claimScheduledMessage(db *sql.DB, id string, status string) (bool, error) {
+	result, err := db.Exec(updateScheduledMessageClaim, status, id, ScheduledMessagePending)
+	if err != nil {
+		return false, fmt.Errorf("failed to update scheduled message %s: %w", id, err)
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func finishScheduledMessage(db *sql.DB, id string, status string, messageID string, sendErr string, sentAt time.Time) {
+	var at int64
+	if !sentAt.IsZero() {
+		at = sentAt.UnixMilli()
+	}
+	if _, err := db.Exec(updateScheduledMessageDone, status, messageID, sendErr, at, id); err != nil {
+		logrus.Errorf("Failed to update scheduled message %s: %v", id, err)
+	}
+}
+
+// StartMessageScheduler sends the scheduled messages once they are due. The schedule lives in the database,
+// so the messages pending before a restart are picked up again
+func StartMessageScheduler(send ScheduledMessageSender) {
+	if storeDB == nil {
+		logrus.Warnf("Scheduled message store is not initialized, scheduled messages won't be sent")
+		return
+	}
+	db := storeDB
+	if err := failInterruptedScheduledMessages(db); err != nil {
+		logrus.Errorf("Failed to fail the interrupted scheduled messages: %v", err)
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	schedulerStop, schedulerDone = stop, done
+	go func() {
+		var sending sync.WaitGroup
+		defer close(done)
+		defer sending.Wait()
+		ticker := time.NewTicker(scheduledMessageCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				dispatchScheduledMessages(db, now, isSessionReady, send, &sending)
+			}
+		}
+	}()
+}
+
+// StopMessageScheduler stops picking due messages and waits for the ones being sent, the messages still pending
+// are sent after the next start
+func StopMessageScheduler(ctx context.Context) error {
+	if schedulerStop == nil {
+		return nil
+	}
+	close(schedulerStop)
+	schedulerStop = nil
+
+	select {
+	case <-schedulerDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// failInterruptedScheduledMessages fails the messages that were being sent when the app stopped
+func failInterruptedScheduledMessages(db *sql.DB) error {
+	_, err := db.Exec(updateScheduledMessageInterrupted, ScheduledMessageFailed,
+		"the app stopped while the message was being sent, it may not have been delivered", ScheduledMessageSending)
+	return err
+}
+
+func isSessionReady(sessionID string) bool {
+	client := GetSessionClient(sessionID)
+	return client != nil && client.IsConnected() && client.IsLoggedIn()
+}
+
+// dispatchScheduledMessages claims the messages due at now and sends each of them in its own goroutine added to
+// sending, so a slow send doesn't hold the ticker back. A message waits while its session is disconnected,
+// it expires once it's late by more than config.WhatsappScheduleMaxDelay
+func dispatchScheduledMessages(db *sql.DB, now time.Time, isReady func(sessionID string) bool, send ScheduledMessageSender, sending *sync.WaitGroup) {
+	messages, err := queryScheduledMessages(db, selectScheduledMessageColumns+` WHERE status = $1 AND send_at <= $2 ORDER BY send_at, id`,
+		ScheduledMessagePending, now.UnixMilli())
+	if err != nil {
+		logrus.Errorf("Failed to look up the due scheduled messages: %v", err)
+		return
+	}
+
+	for _, message := range messages {
+		late := now.Sub(message.SendAt)
+		if config.WhatsappScheduleMaxDelay > 0 && late > config.WhatsappScheduleMaxDelay {
+			if claimed, err := claimScheduledMessage(db, message.ID, ScheduledMessageExpired); err == nil && claimed {
+				finishScheduledMessage(db, message.ID, ScheduledMessageExpired, "",
+					fmt.Sprintf("not sent, it was due %s ago", late.Round(time.Second)), time.Time{})
+				logrus.Warnf("Scheduled message %s expired, it was due %s ago", message.ID, late.Round(time.Second))
+			}
+			continue
+		}
+		if !isReady(message.SessionID) {
+			continue
+		}
+
+		claimed, err := claimScheduledMessage(db, message.ID, ScheduledMessageSending)
+		if err != nil {
+			logrus.Errorf("Failed to claim scheduled message %s: %v", message.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		sending.Add(1)
+		go func(message ScheduledMessage) {
+			defer sending.Done()
+			sendScheduledMessage(db, message, send)
+		}(message)
+	}
+}
+
+// sendScheduledMessage sends a claimed message within scheduledMessageSendTimeout and records the outcome
+func sendScheduledMessage(db *sql.DB, message ScheduledMessage, send ScheduledMessageSender) {
+	ctx, cancel := context.WithTimeout(context.Background(), scheduledMessageSendTimeout)
+	defer cancel()
+
+	messageID, err := send(ctx, message)
+	if err != nil {
+		logrus.Errorf("Failed to send scheduled message %s: %v", message.ID, err)
+		finishScheduledMessage(db, message.ID, ScheduledMessageFailed, "", err.Error(), time.Time{})
+		return
+	}
+	finishScheduledMessage(db, message.ID, ScheduledMessageSent, messageID, "", time.Now())
+	logrus.Infof("Sent scheduled message %s as %s", message.ID, messageID)
+}
+
+// clearSessionScheduledMessages drops the schedule of a session that logged out
+func clearSessionScheduledMessages(sessionID string) {
+	if storeDB == nil {
+		return
+	}
+	if _, err := storeDB.Exec(deleteSessionScheduledMessages, sessionID); err != nil {
+		logrus.Errorf("Failed to clear the scheduled messages of session %s: %v", sessionID, err)
+	}
+}
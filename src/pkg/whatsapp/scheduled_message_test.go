@@ -0,0 +1,160 @@
+package whatsapp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScheduledMessageDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "schedule.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	require.NoError(t, initLocalStores(db))
+	t.Cleanup(func() { storeDB = nil })
+	return db
+}
+
+func getScheduledMessage(t *testing.T, id string) ScheduledMessage {
+	messages, err := ListScheduledMessages(nil, "")
+	require.NoError(t, err)
+	for _, message := range messages {
+		if message.ID == id {
+			return message
+		}
+	}
+	t.Fatalf("scheduled message %s not found", id)
+	return ScheduledMessage{}
+}
+
+func TestScheduledMessageStore(t *testing.T) {
+	db := newTestScheduledMessageDB(t)
+	now := time.Now()
+
+	later, err := ScheduleMessage(nil, "628123456789@s.whatsapp.net", `{"message":"later"}`, now.Add(time.Hour))
+	require.NoError(t, err)
+	sooner, err := ScheduleMessage(nil, "628123456789@s.whatsapp.net", `{"message":"sooner"}`, now.Add(time.Minute))
+	require.NoError(t, err)
+
+	messages, err := ListScheduledMessages(nil, ScheduledMessagePending)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, sooner, messages[0])
+	assert.Equal(t, later.ID, messages[1].ID)
+
+	cancelled, err := CancelScheduledMessage(nil, later.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ScheduledMessageCancelled, cancelled.Status)
+	_, err = CancelScheduledMessage(nil, later.ID)
+	assert.Equal(t, pkgError.ValidationError("scheduled message "+later.ID+" is cancelled and can't be cancelled anymore"), err)
+	_, err = CancelScheduledMessage(nil, "unknown")
+	assert.Equal(t, pkgError.NotFoundError("scheduled message unknown is unknown"), err)
+	// A message of another session can't be cancelled
+	_, err = cancelScheduledMessage(db, "other", sooner.ID)
+	assert.Error(t, err)
+
+	messages, err = ListScheduledMessages(nil, ScheduledMessagePending)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+
+	clearSessionScheduledMessages(DefaultSessionID)
+	messages, err = ListScheduledMessages(nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestDispatchScheduledMessages(t *testing.T) {
+	db := newTestScheduledMessageDB(t)
+	previous := config.WhatsappScheduleMaxDelay
+	t.Cleanup(func() { config.WhatsappScheduleMaxDelay = previous })
+	config.WhatsappScheduleMaxDelay = time.Hour
+
+	now := time.Now()
+	due, err := ScheduleMessage(nil, "628123456789@s.whatsapp.net", `{"message":"due"}`, now.Add(-time.Second))
+	require.NoError(t, err)
+	failing, err := ScheduleMessage(nil, "628123456789@s.whatsapp.net", `{"message":"failing"}`, now.Add(-time.Second))
+	require.NoError(t, err)
+	future, err := ScheduleMessage(nil, "628123456789@s.whatsapp.net", `{"message":"future"}`, now.Add(time.Minute))
+	require.NoError(t, err)
+	overdue, err := ScheduleMessage(nil, "628123456789@s.whatsapp.net", `{"message":"overdue"}`, now.Add(-2*time.Hour))
+	require.NoError(t, err)
+
+	var (
+		mu      sync.Mutex
+		sent    []string
+		sending sync.WaitGroup
+	)
+	send := func(ctx context.Context, message ScheduledMessage) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, message.ID)
+		if _, ok := ctx.Deadline(); !ok {
+			return "", errors.New("the send has no timeout")
+		}
+		if message.ID == failing.ID {
+			return "", errors.New("recipient unreachable")
+		}
+		return "3EB0" + message.ID[:4], nil
+	}
+
+	// Messages wait while their session is not connected, the overdue one expires anyway
+	dispatchScheduledMessages(db, now, func(string) bool { return false }, send, &sending)
+	sending.Wait()
+	assert.Empty(t, sent)
+	assert.Equal(t, ScheduledMessagePending, getScheduledMessage(t, due.ID).Status)
+	expired := getScheduledMessage(t, overdue.ID)
+	assert.Equal(t, ScheduledMessageExpired, expired.Status)
+	assert.Equal(t, "not sent, it was due 2h0m0s ago", expired.Error)
+
+	dispatchScheduledMessages(db, now, func(string) bool { return true }, send, &sending)
+	sending.Wait()
+	assert.ElementsMatch(t, []string{due.ID, failing.ID}, sent)
+	delivered := getScheduledMessage(t, due.ID)
+	assert.Equal(t, ScheduledMessageSent, delivered.Status)
+	assert.Equal(t, "3EB0"+due.ID[:4], delivered.MessageID)
+	assert.False(t, delivered.SentAt.IsZero())
+	failed := getScheduledMessage(t, failing.ID)
+	assert.Equal(t, ScheduledMessageFailed, failed.Status)
+	assert.Equal(t, "recipient unreachable", failed.Error)
+	assert.True(t, failed.SentAt.IsZero())
+	assert.Equal(t, ScheduledMessagePending, getScheduledMessage(t, future.ID).Status)
+
+	// A sent message is not sent again
+	sent = nil
+	dispatchScheduledMessages(db, now, func(string) bool { return true }, send, &sending)
+	sending.Wait()
+	assert.Empty(t, sent)
+}
+
+func TestFailInterruptedScheduledMessages(t *testing.T) {
+	db := newTestScheduledMessageDB(t)
+	message, err := ScheduleMessage(nil, "628123456789@s.whatsapp.net", `{"message":"hi"}`, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	claimed, err := claimScheduledMessage(db, message.ID, ScheduledMessageSending)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	require.NoError(t, failInterruptedScheduledMessages(db))
+	assert.Equal(t, ScheduledMessageFailed, getScheduledMessage(t, message.ID).Status)
+}
+
+func TestStopMessageScheduler(t *testing.T) {
+	newTestScheduledMessageDB(t)
+	StartMessageScheduler(func(context.Context, ScheduledMessage) (string, error) { return "", nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, StopMessageScheduler(ctx))
+	// Stopping a stopped scheduler does nothing
+	assert.NoError(t, StopMessageScheduler(ctx))
+}
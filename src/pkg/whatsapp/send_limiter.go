@@ -0,0 +1,116 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/metrics"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	SendRateLimitModeBlock  = "block"  // wait until the limiter allows the send
+	SendRateLimitModeReject = "reject" // fail the send with 429 right away
+)
+
+var (
+	// Every session sends from its own number, so each one gets its own limiter
+	sendLimiters   = map[string]*utils.RateLimiter{}
+	sendLimitersMu sync.Mutex
+)
+
+func init() {
+	metrics.RegisterSendLimiter(sendLimiterStates)
+}
+
+// SendMessage sends the message once the send limiter of the client session allows it, every outbound message goes through here
+func SendMessage(ctx context.Context, client *whatsmeow.Client, to types.JID, msg *waE2E.Message, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	if client == nil {
+		return whatsmeow.SendResponse{}, pkgError.ErrWaCLI
+	}
+	if err := waitSendLimiter(ctx, sessionIDOf(client)); err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	resp, err := client.SendMessage(ctx, to, msg, extra...)
+	if err == nil {
+		handleSentMessage(client, to, resp, msg)
+	}
+	return resp, err
+}
+
+// waitSendLimiter takes a slot of the session limiter, blocking or rejecting depending on config.WhatsappSendRateLimitMode
+func waitSendLimiter(ctx context.Context, sessionID string) error {
+	if config.WhatsappSendRateLimit <= 0 {
+		return nil
+	}
+	limiter := getSendLimiter(sessionID)
+
+	if config.WhatsappSendRateLimitMode == SendRateLimitModeReject {
+		if !limiter.Allow() {
+			metrics.SendLimiterRejected.WithLabelValues(sessionID).Inc()
+			return pkgError.TooManyRequestsError(fmt.Sprintf("send rate limit of %g messages per second reached, try again later", config.WhatsappSendRateLimit))
+		}
+		return nil
+	}
+
+	startedAt := time.Now()
+	err := limiter.Wait(ctx)
+	metrics.SendLimiterWaitSeconds.WithLabelValues(sessionID).Observe(time.Since(startedAt).Seconds())
+	if err != nil {
+		return pkgError.ContextError(fmt.Sprintf("stopped waiting for the send rate limiter: %v", err))
+	}
+	return nil
+}
+
+func getSendLimiter(sessionID string) *utils.RateLimiter {
+	sendLimitersMu.Lock()
+	defer sendLimitersMu.Unlock()
+
+	limiter, ok := sendLimiters[sessionID]
+	if !ok {
+		limiter = utils.NewBurstRateLimiter(config.WhatsappSendRateLimit, config.WhatsappSendRateBurst)
+		sendLimiters[sessionID] = limiter
+	}
+	return limiter
+}
+
+// sendLimiterStates reports the limiters of the sessions that sent at least one message
+func sendLimiterStates() []metrics.SendLimiterState {
+	sendLimitersMu.Lock()
+	defer sendLimitersMu.Unlock()
+
+	states := make([]metrics.SendLimiterState, 0, len(sendLimiters))
+	for sessionID, limiter := range sendLimiters {
+		states = append(states, metrics.SendLimiterState{
+			SessionID: sessionID,
+			Tokens:    limiter.Tokens(),
+			Rate:      limiter.Rate(),
+			Burst:     limiter.Burst(),
+		})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].SessionID < states[j].SessionID })
+	return states
+}
+
+// ValidateSendRateLimitConfig checks the send limiter settings at startup
+func ValidateSendRateLimitConfig() error {
+	if config.WhatsappSendRateLimit < 0 {
+		return fmt.Errorf("send rate limit must be zero or greater, got %g", config.WhatsappSendRateLimit)
+	}
+	if config.WhatsappSendRateBurst < 1 {
+		return fmt.Errorf("send rate burst must be at least 1, got %d", config.WhatsappSendRateBurst)
+	}
+	if config.WhatsappSendRateLimitMode != SendRateLimitModeBlock && config.WhatsappSendRateLimitMode != SendRateLimitModeReject {
+		return fmt.Errorf("send rate limit mode %q is not supported, available modes: %s,%s",
+			config.WhatsappSendRateLimitMode, SendRateLimitModeBlock, SendRateLimitModeReject)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package whatsapp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func setSendRateLimitConfig(t *testing.T, rate float64, burst int, mode string) {
+	oldRate, oldBurst, oldMode := config.WhatsappSendRateLimit, config.WhatsappSendRateBurst, config.WhatsappSendRateLimitMode
+	config.WhatsappSendRateLimit, config.WhatsappSendRateBurst, config.WhatsappSendRateLimitMode = rate, burst, mode
+	sendLimitersMu.Lock()
+	sendLimiters = map[string]*utils.RateLimiter{}
+	sendLimitersMu.Unlock()
+	t.Cleanup(func() {
+		config.WhatsappSendRateLimit, config.WhatsappSendRateBurst, config.WhatsappSendRateLimitMode = oldRate, oldBurst, oldMode
+		sendLimitersMu.Lock()
+		sendLimiters = map[string]*utils.RateLimiter{}
+		sendLimitersMu.Unlock()
+	})
+}
+
+func TestWaitSendLimiterReject(t *testing.T) {
+	setSendRateLimitConfig(t, 1, 2, SendRateLimitModeReject)
+
+	assert.NoError(t, waitSendLimiter(context.Background(), DefaultSessionID))
+	assert.NoError(t, waitSendLimiter(context.Background(), DefaultSessionID))
+
+	err := waitSendLimiter(context.Background(), DefaultSessionID)
+	assert.IsType(t, pkgError.TooManyRequestsError(""), err)
+	// Another session has its own limiter
+	assert.NoError(t, waitSendLimiter(context.Background(), "sales"))
+}
+
+func TestWaitSendLimiterBlock(t *testing.T) {
+	setSendRateLimitConfig(t, 10, 1, SendRateLimitModeBlock)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, waitSendLimiter(context.Background(), DefaultSessionID))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.IsType(t, pkgError.ContextError(""), waitSendLimiter(ctx, DefaultSessionID))
+}
+
+func TestWaitSendLimiterUnlimited(t *testing.T) {
+	setSendRateLimitConfig(t, 0, 1, SendRateLimitModeReject)
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, waitSendLimiter(context.Background(), DefaultSessionID))
+	}
+	assert.Empty(t, sendLimiterStates())
+}
+
+func TestSendLimiterStates(t *testing.T) {
+	setSendRateLimitConfig(t, 2, 3, SendRateLimitModeReject)
+	assert.NoError(t, waitSendLimiter(context.Background(), "sales"))
+	assert.NoError(t, waitSendLimiter(context.Background(), DefaultSessionID))
+
+	states := sendLimiterStates()
+	assert.Len(t, states, 2)
+	assert.Equal(t, DefaultSessionID, states[0].SessionID)
+	assert.Equal(t, float64(2), states[0].Rate)
+	assert.Equal(t, float64(3), states[0].Burst)
+	assert.InDelta(t, 2, states[0].Tokens, 0.1)
+}
+
+func TestValidateSendRateLimitConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    float64
+		burst   int
+		mode    string
+		wantErr bool
+	}{
+		{"should accept the defaults", 0, 5, SendRateLimitModeBlock, false},
+		{"should accept reject mode", 1.5, 1, SendRateLimitModeReject, false},
+		{"should reject a negative rate", -1, 5, SendRateLimitModeBlock, true},
+		{"should reject a burst below one", 1, 0, SendRateLimitModeBlock, true},
+		{"should reject an unknown mode", 1, 5, "drop", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setSendRateLimitConfig(t, tt.rate, tt.burst, tt.mode)
+			err := ValidateSendRateLimitConfig()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// DefaultSessionID is the session served on the root routes, it always exists
+const DefaultSessionID = "default"
+
+var (
+	sessionsMu      sync.RWMutex
+	sessionClients  = map[string]*whatsmeow.Client{}
+	sessionDeviceMu sync.Mutex
+)
+
+var sessionIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// ValidateSessionIDs checks the session ids can be used in the /sessions/:session_id routes
+func ValidateSessionIDs(sessionIDs []string) error {
+	for _, id := range sessionIDs {
+		if !sessionIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid session id %q, use at most 32 letters, digits, - or _", id)
+		}
+	}
+	return nil
+}
+
+// InitWaSessions creates a client for the default session and for every configured session id.
+// Each session is linked to its own device, the device of a session is remembered in config.PathSessionStore.
+func InitWaSessions(storeContainer *sqlstore.Container, sessionIDs []string) map[string]*whatsmeow.Client {
+	devices, err := loadSessionDevices()
+	if err != nil {
+		log.Errorf("Failed to load session devices: %v", err)
+		panic(err)
+	}
+
+	ids := []string{DefaultSessionID}
+	for _, id := range sessionIDs {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	clients := make(map[string]*whatsmeow.Client, len(ids))
+	for _, id := range ids {
+		device, err := sessionDevice(storeContainer, id, devices)
+		if err != nil {
+			log.Errorf("Failed to get device of session %s: %v", id, err)
+			panic(err)
+		}
+		clients[id] = newWaCLI(id, device)
+	}
+	return clients
+}
+
+// GetSessionClient returns the client of a session, nil when the session doesn't exist
+func GetSessionClient(sessionID string) *whatsmeow.Client {
+	sessionsMu.RLock()
+	defer sessionsMu.RUnlock()
+	return sessionClients[sessionID]
+}
+
+// sessionIDOf returns the session a client belongs to, clients that aren't registered are the default session
+func sessionIDOf(client *whatsmeow.Client) string {
+	sessionsMu.RLock()
+	defer sessionsMu.RUnlock()
+	for id, sessionClient := range sessionClients {
+		if client != nil && sessionClient == client {
+			return id
+		}
+	}
+	return DefaultSessionID
+}
+
+// SessionFilePrefix is the prefix of the files written for the session of the client. A dot can't be part of
+// a session id, so the files of one session never match the prefix of another
+func SessionFilePrefix(client *whatsmeow.Client) string {
+	return sessionFilePrefix(sessionIDOf(client))
+}
+
+func sessionFilePrefix(sessionID string) string {
+	return sessionID + "."
+}
+
+// removeSessionDownloads deletes the media downloaded for the session, ExtractMedia names them
+// <prefix><unix>-<uuid><ext> in config.PathMedia and config.PathStorages
+func removeSessionDownloads(sessionID string) {
+	for _, dir := range []string{config.PathMedia, config.PathStorages} {
+		files, err := filepath.Glob(filepath.Join(dir, sessionFilePrefix(sessionID)+"[0-9]*-*"))
+		if err != nil {
+			log.Errorf("Failed to list the downloads of session %s: %v", sessionID, err)
+			continue
+		}
+		for _, file := range files {
+			if err = os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Errorf("Failed to delete the download %s of session %s: %v", file, sessionID, err)
+			}
+		}
+	}
+}
+
+// DisconnectSessions tells whatsapp that every logged in session goes offline and closes the connections, so the
+// messages sent meanwhile wait on the server for the next connection
+func DisconnectSessions() {
+	sessionsMu.RLock()
+	clients := maps.Clone(sessionClients)
+	sessionsMu.RUnlock()
+
+	for sessionID, client := range clients {
+		if client.IsConnected() && client.IsLoggedIn() {
+			if err := SendPresence(client, types.PresenceUnavailable); err != nil {
+				logrus.Warnf("Failed to send the offline presence of session %s: %v", sessionID, err)
+			}
+		}
+		client.Disconnect()
+	}
+}
+
+func registerSession(sessionID string, client *whatsmeow.Client) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	sessionClients[sessionID] = client
+}
+
+func newWaCLI(sessionID string, device *store.Device) *whatsmeow.Client {
+	// Configure device properties
+	osName := fmt.Sprintf("%s %s", config.AppOs, config.AppVersion)
+	store.DeviceProps.PlatformType = &config.AppPlatform
+	store.DeviceProps.Os = &osName
+
+	// Create and configure the client
+	client := whatsmeow.NewClient(device, newLogger("Client"))
+	client.EnableAutoReconnect = true
+	client.AutoTrustIdentity = true
+	// ValidateProxyConfig stops the app on an invalid proxy before any client is created
+	if err := applyProxy(client); err != nil {
+		logrus.Errorf("Failed to set the proxy of session %s, connecting without it: %v", sessionID, err)
+	}
+	client.AddEventHandler(newEventHandler(sessionID, client))
+
+	registerSession(sessionID, client)
+	return client
+}
+
+// sessionDevice returns the device remembered for the session, or a new device to pair.
+// Without a remembered device the default session keeps using the first device not claimed by another session.
+func sessionDevice(storeContainer *sqlstore.Container, sessionID string, devices map[string]string) (*store.Device, error) {
+	if jid, ok := devices[sessionID]; ok {
+		parsed, err := types.ParseJID(jid)
+		if err != nil {
+			return nil, err
+		}
+		device, err := storeContainer.GetDevice(parsed)
+		if err != nil {
+			return nil, err
+		}
+		if device != nil {
+			return device, nil
+		}
+	}
+
+	if sessionID == DefaultSessionID {
+		all, err := storeContainer.GetAllDevices()
+		if err != nil {
+			return nil, err
+		}
+		for _, device := range all {
+			if !slices.Contains(sessionDeviceJIDs(devices), device.ID.String()) {
+				return device, nil
+			}
+		}
+	}
+
+	return storeContainer.NewDevice(), nil
+}
+
+func sessionDeviceJIDs(devices map[string]string) []string {
+	jids := make([]string, 0, len(devices))
+	for _, jid := range devices {
+		jids = append(jids, jid)
+	}
+	return jids
+}
+
+func loadSessionDevices() (map[string]string, error) {
+	devices := map[string]string{}
+	data, err := os.ReadFile(config.PathSessionStore)
+	if errors.Is(err, os.ErrNotExist) {
+		return devices, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &devices); err != nil {
+		return nil, fmt.Errorf("invalid session store %s: %w", config.PathSessionStore, err)
+	}
+	return devices, nil
+}
+
+// WipeSession forgets everything kept locally about a session once it logged out: its device, the cached media
+// keys, the content and the disappearing timers of its messages, the reactions on them, its chat list, message history and delivery
+// statuses, its live locations and its login state.
+// A connection webhook with reason logout is sent.
+func WipeSession(client *whatsmeow.Client) {
+	sessionID := sessionIDOf(client)
+
+	clearSessionMedia(sessionID)
+	removeSessionDownloads(sessionID)
+	clearSessionMessages(sessionID)
+	clearSessionEphemeralTimers(sessionID)
+	clearSessionReactions(sessionID)
+	clearSessionChats(sessionID)
+	clearSessionStoredMessages(sessionID)
+	clearSessionMessageStatus(sessionID)
+	clearSessionScheduledMessages(sessionID)
+	stopSessionLiveLocations(sessionID)
+	clearSessionPresences(sessionID)
+	clearSessionStatuses(sessionID)
+	if err := forgetSessionDevice(sessionID); err != nil {
+		log.Errorf("Failed to forget the device of session %s: %v", sessionID, err)
+	}
+	SetLoginState(client, LoginStateLoggedOut, time.Time{})
+
+	if isEventForwardingEnabled() {
+		enqueueWebhookEvent(sessionID, &apiLogout{})
+	}
+}
+
+// rememberSessionDevice stores the device a session has been paired with, so it's reused after a restart
+func rememberSessionDevice(sessionID string, jid types.JID) error {
+	return updateSessionDevices(func(devices map[string]string) {
+		devices[sessionID] = jid.String()
+	})
+}
+
+func forgetSessionDevice(sessionID string) error {
+	return updateSessionDevices(func(devices map[string]string) {
+		delete(devices, sessionID)
+	})
+}
+
+func updateSessionDevices(update func(devices map[string]string)) error {
+	sessionDeviceMu.Lock()
+	defer sessionDeviceMu.Unlock()
+
+	devices, err := loadSessionDevices()
+	if err != nil {
+		return err
+	}
+	update(devices)
+
+	data, err := json.MarshalIndent(devices, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(config.PathSessionStore, data, 0600)
+}
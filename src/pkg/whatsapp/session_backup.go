@@ -0,0 +1,485 @@
+package whatsapp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waAdv"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/util/keys"
+)
+
+const (
+	sessionBackupFormat     = "whatsapp-session-backup"
+	sessionBackupVersion    = 1
+	sessionBackupKDF        = "pbkdf2-sha256"
+	sessionBackupIterations = 600000
+)
+
+// insertSessionBackupDevice writes the device row like the whatsmeow store does, within the import transaction
+const insertSessionBackupDevice = `INSERT INTO whatsmeow_device (jid, lid, registration_id, noise_key, identity_key,
+	signed_pre_key, signed_pre_key_id, signed_pre_key_sig,
+	adv_key, adv_details, adv_account_sig, adv_account_sig_key, adv_device_sig,
+	platform, business_name, push_name, facebook_uuid)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
+
+// sessionBackupTables are the whatsmeow tables holding the keys and state of a device, with the column of the
+// device jid. The device row itself is written through the store so its columns can change between versions
+var sessionBackupTables = []struct{ name, column string }{
+	{"whatsmeow_identity_keys", "our_jid"},
+	{"whatsmeow_pre_keys", "jid"},
+	{"whatsmeow_sessions", "our_jid"},
+	{"whatsmeow_sender_keys", "our_jid"},
+	{"whatsmeow_app_state_sync_keys", "jid"},
+	{"whatsmeow_app_state_version", "jid"},
+	{"whatsmeow_app_state_mutation_macs", "jid"},
+	{"whatsmeow_contacts", "our_jid"},
+	{"whatsmeow_chat_settings", "our_jid"},
+	{"whatsmeow_message_secrets", "our_jid"},
+	{"whatsmeow_privacy_tokens", "our_jid"},
+}
+
+// sessionBackupColumnPattern checks the column names of a backup, they are written into the insert statement
+var sessionBackupColumnPattern = regexp.MustCompile(`^[a-z_]+$`)
+
+// sessionBackupEnvelope is the exported file, the backup is encrypted with aes-256-gcm and a key derived from the
+// passphrase
+type sessionBackupEnvelope struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// sessionBackup is the decrypted content of a backup. StoreVersion and StoreCompat are the whatsmeow store schema
+// version of the exporting instance and the oldest schema version able to read its rows
+type sessionBackup struct {
+	AppVersion   string                                     `json:"app_version"`
+	ExportedAt   time.Time                                  `json:"exported_at"`
+	StoreVersion int                                        `json:"store_version"`
+	StoreCompat  int                                        `json:"store_compat"`
+	Device       sessionBackupDevice                        `json:"device"`
+	Tables       map[string][]map[string]sessionBackupValue `json:"tables"`
+}
+
+type sessionBackupDevice struct {
+	JID              string `json:"jid"`
+	LID              string `json:"lid,omitempty"`
+	RegistrationID   uint32 `json:"registration_id"`
+	NoiseKey         []byte `json:"noise_key"`
+	IdentityKey      []byte `json:"identity_key"`
+	SignedPreKey     []byte `json:"signed_pre_key"`
+	SignedPreKeyID   uint32 `json:"signed_pre_key_id"`
+	SignedPreKeySig  []byte `json:"signed_pre_key_sig"`
+	AdvKey           []byte `json:"adv_key"`
+	AdvDetails       []byte `json:"adv_details"`
+	AdvAccountSig    []byte `json:"adv_account_sig"`
+	AdvAccountSigKey []byte `json:"adv_account_sig_key"`
+	AdvDeviceSig     []byte `json:"adv_device_sig"`
+	Platform         string `json:"platform"`
+	BusinessName     string `json:"business_name"`
+	PushName         string `json:"push_name"`
+	FacebookUUID     string `json:"facebook_uuid,omitempty"`
+}
+
+// sessionBackupValue is a column value keeping its type, so rows exported from sqlite can be imported into postgres.
+// A value without any field is null
+type sessionBackupValue struct {
+	Bytes []byte  `json:"bytes,omitempty"`
+	Text  *string `json:"text,omitempty"`
+	Int   *int64  `json:"int,omitempty"`
+	Bool  *bool   `json:"bool,omitempty"`
+}
+
+func (value sessionBackupValue) sqlValue() any {
+	switch {
+	case value.Bytes != nil:
+		return value.Bytes
+	case value.Text != nil:
+		return *value.Text
+	case value.Int != nil:
+		return *value.Int
+	case value.Bool != nil:
+		return *value.Bool
+	}
+	return nil
+}
+
+func newSessionBackupValue(value any) (sessionBackupValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return sessionBackupValue{}, nil
+	case []byte:
+		return sessionBackupValue{Bytes: append([]byte{}, v...)}, nil
+	case string:
+		return sessionBackupValue{Text: &v}, nil
+	case int64:
+		return sessionBackupValue{Int: &v}, nil
+	case bool:
+		return sessionBackupValue{Bool: &v}, nil
+	}
+	return sessionBackupValue{}, fmt.Errorf("unsupported column type %T", value)
+}
+
+// ExportSession returns the credentials and keys of a logged in session encrypted with the passphrase. The backup
+// restores the session with ImportSession without scanning the qr code again
+func ExportSession(client *whatsmeow.Client, passphrase string) ([]byte, error) {
+	device := client.Store
+	if device.ID == nil {
+		return nil, pkgError.ValidationError("session is not logged in, there is nothing to export")
+	}
+
+	version, compat, err := sessionStoreVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	backup := sessionBackup{
+		AppVersion:   config.AppVersion,
+		ExportedAt:   time.Now().UTC(),
+		StoreVersion: version,
+		StoreCompat:  compat,
+		Device: sessionBackupDevice{
+			JID:              device.ID.String(),
+			RegistrationID:   device.RegistrationID,
+			NoiseKey:         device.NoiseKey.Priv[:],
+			IdentityKey:      device.IdentityKey.Priv[:],
+			SignedPreKey:     device.SignedPreKey.Priv[:],
+			SignedPreKeyID:   device.SignedPreKey.KeyID,
+			SignedPreKeySig:  device.SignedPreKey.Signature[:],
+			AdvKey:           device.AdvSecretKey,
+			AdvDetails:       device.Account.GetDetails(),
+			AdvAccountSig:    device.Account.GetAccountSignature(),
+			AdvAccountSigKey: device.Account.GetAccountSignatureKey(),
+			AdvDeviceSig:     device.Account.GetDeviceSignature(),
+			Platform:         device.Platform,
+			BusinessName:     device.BusinessName,
+			PushName:         device.PushName,
+		},
+		Tables: make(map[string][]map[string]sessionBackupValue),
+	}
+	if !device.LID.IsEmpty() {
+		backup.Device.LID = device.LID.String()
+	}
+	if device.FacebookUUID != uuid.Nil {
+		backup.Device.FacebookUUID = device.FacebookUUID.String()
+	}
+
+	for _, table := range sessionBackupTables {
+		rows, err := exportSessionRows(table.name, table.column, device.ID.String())
+		if err != nil {
+			return nil, pkgError.InternalServerError(fmt.Sprintf("failed to export %s: %v", table.name, err))
+		}
+		backup.Tables[table.name] = rows
+	}
+
+	plaintext, err := json.Marshal(backup)
+	if err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to encode session backup: %v", err))
+	}
+	return encryptSessionBackup(plaintext, passphrase)
+}
+
+// ImportSession restores a backup of ExportSession into the session and returns the jid of the restored device.
+// A logged in session is only replaced with force, its local credentials are removed without logging out from
+// whatsapp. The caller connects the client afterwards
+func ImportSession(client *whatsmeow.Client, data []byte, passphrase string, force bool) (types.JID, error) {
+	plaintext, err := decryptSessionBackup(data, passphrase)
+	if err != nil {
+		return types.JID{}, err
+	}
+
+	var backup sessionBackup
+	if err = json.Unmarshal(plaintext, &backup); err != nil {
+		return types.JID{}, pkgError.ValidationError("session backup is corrupted")
+	}
+
+	version, _, err := sessionStoreVersion()
+	if err != nil {
+		return types.JID{}, err
+	}
+	if backup.StoreCompat > version {
+		return types.JID{}, pkgError.ValidationError(fmt.Sprintf(
+			"session backup was exported with whatsmeow store schema v%d which needs at least v%d, this instance has v%d",
+			backup.StoreVersion, backup.StoreCompat, version,
+		))
+	}
+
+	device, err := backup.Device.toDevice()
+	if err != nil {
+		return types.JID{}, err
+	}
+
+	var exists bool
+	if err = storeDB.QueryRow(`SELECT EXISTS(SELECT 1 FROM whatsmeow_device WHERE jid = $1)`, device.ID.String()).Scan(&exists); err != nil {
+		return types.JID{}, pkgError.InternalServerError(fmt.Sprintf("failed to check the device of the backup: %v", err))
+	}
+	if exists && (client.Store.ID == nil || *client.Store.ID != *device.ID) {
+		return types.JID{}, pkgError.ValidationError(fmt.Sprintf("device %s of the backup is already used by another session", device.ID))
+	}
+
+	previous := client.Store.ID
+	if previous != nil && !force {
+		return types.JID{}, pkgError.ValidationError("session is already logged in, set force to replace it")
+	}
+
+	// A session waiting for the qr code is connected as well
+	client.Disconnect()
+
+	if err = importSession(previous, device, backup.Tables); err != nil {
+		return types.JID{}, pkgError.InternalServerError(fmt.Sprintf("failed to import session backup: %v", err))
+	}
+	if previous != nil {
+		WipeSession(client)
+	}
+
+	// The sub stores of the client are bound to its jid, saving an uninitialized device binds them to the new one
+	current := client.Store
+	current.ID, current.LID = device.ID, device.LID
+	current.RegistrationID = device.RegistrationID
+	current.NoiseKey, current.IdentityKey, current.SignedPreKey = device.NoiseKey, device.IdentityKey, device.SignedPreKey
+	current.AdvSecretKey, current.Account = device.AdvSecretKey, device.Account
+	current.Platform, current.BusinessName, current.PushName = device.Platform, device.BusinessName, device.PushName
+	current.FacebookUUID = device.FacebookUUID
+	current.Initialized = false
+	if err = current.Save(); err != nil {
+		return types.JID{}, pkgError.InternalServerError(fmt.Sprintf("failed to load the device of the backup: %v", err))
+	}
+
+	if err = rememberSessionDevice(sessionIDOf(client), *device.ID); err != nil {
+		logrus.Errorf("Failed to remember the device of session %s: %v", sessionIDOf(client), err)
+	}
+	return *device.ID, nil
+}
+
+func (backup sessionBackupDevice) toDevice() (*store.Device, error) {
+	jid, err := types.ParseJID(backup.JID)
+	if err != nil || jid.User == "" {
+		return nil, pkgError.ValidationError("session backup has no valid device jid")
+	}
+	if len(backup.NoiseKey) != 32 || len(backup.IdentityKey) != 32 || len(backup.SignedPreKey) != 32 || len(backup.SignedPreKeySig) != 64 {
+		return nil, pkgError.ValidationError("session backup has invalid device keys")
+	}
+
+	device := &store.Device{
+		ID:             &jid,
+		RegistrationID: backup.RegistrationID,
+		NoiseKey:       keys.NewKeyPairFromPrivateKey([32]byte(backup.NoiseKey)),
+		IdentityKey:    keys.NewKeyPairFromPrivateKey([32]byte(backup.IdentityKey)),
+		SignedPreKey: &keys.PreKey{
+			KeyPair:   *keys.NewKeyPairFromPrivateKey([32]byte(backup.SignedPreKey)),
+			KeyID:     backup.SignedPreKeyID,
+			Signature: (*[64]byte)(backup.SignedPreKeySig),
+		},
+		AdvSecretKey: backup.AdvKey,
+		Account: &waAdv.ADVSignedDeviceIdentity{
+			Details:             backup.AdvDetails,
+			AccountSignature:    backup.AdvAccountSig,
+			AccountSignatureKey: backup.AdvAccountSigKey,
+			DeviceSignature:     backup.AdvDeviceSig,
+		},
+		Platform:     backup.Platform,
+		BusinessName: backup.BusinessName,
+		PushName:     backup.PushName,
+	}
+	if backup.LID != "" {
+		if device.LID, err = types.ParseJID(backup.LID); err != nil {
+			return nil, pkgError.ValidationError("session backup has an invalid device lid")
+		}
+	}
+	if backup.FacebookUUID != "" {
+		if device.FacebookUUID, err = uuid.Parse(backup.FacebookUUID); err != nil {
+			return nil, pkgError.ValidationError("session backup has an invalid facebook uuid")
+		}
+	}
+	return device, nil
+}
+
+// sessionStoreVersion returns the whatsmeow store schema version of the database and its compat version
+func sessionStoreVersion() (version int, compat int, err error) {
+	if err = storeDB.QueryRow(`SELECT version, compat FROM whatsmeow_version LIMIT 1`).Scan(&version, &compat); err != nil {
+		return 0, 0, pkgError.InternalServerError(fmt.Sprintf("failed to read the whatsmeow store version: %v", err))
+	}
+	return version, compat, nil
+}
+
+func exportSessionRows(table, column, jid string) ([]map[string]sessionBackupValue, error) {
+	rows, err := storeDB.Query(fmt.Sprintf(`SELECT * FROM %s WHERE %s = $1`, table, column), jid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]sessionBackupValue, 0)
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]sessionBackupValue, len(columns))
+		for i, name := range columns {
+			if row[name], err = newSessionBackupValue(values[i]); err != nil {
+				return nil, fmt.Errorf("column %s: %w", name, err)
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// importSession replaces the previous device of the session with the device and the rows of the backup in one
+// transaction, a failed import keeps the previous device. Only the known tables are written and every row is
+// bound to the device of the backup, whatever device it names
+func importSession(previous *types.JID, device *store.Device, tables map[string][]map[string]sessionBackupValue) error {
+	tx, err := storeDB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// The rows of the other tables go with the device through their foreign keys
+	if previous != nil {
+		if _, err = tx.Exec(`DELETE FROM whatsmeow_device WHERE jid = $1`, previous.String()); err != nil {
+			return fmt.Errorf("failed to remove the current session: %w", err)
+		}
+	}
+	_, err = tx.Exec(insertSessionBackupDevice,
+		*device.ID, device.LID, device.RegistrationID, device.NoiseKey.Priv[:], device.IdentityKey.Priv[:],
+		device.SignedPreKey.Priv[:], device.SignedPreKey.KeyID, device.SignedPreKey.Signature[:],
+		device.AdvSecretKey, device.Account.Details, device.Account.AccountSignature, device.Account.AccountSignatureKey, device.Account.DeviceSignature,
+		device.Platform, device.BusinessName, device.PushName, uuid.NullUUID{UUID: device.FacebookUUID, Valid: device.FacebookUUID != uuid.Nil})
+	if err != nil {
+		return fmt.Errorf("whatsmeow_device: %w", err)
+	}
+
+	jid := device.ID.String()
+	for _, table := range sessionBackupTables {
+		for _, row := range tables[table.name] {
+			row[table.column] = sessionBackupValue{Text: &jid}
+			columns := make([]string, 0, len(row))
+			for name := range row {
+				if !sessionBackupColumnPattern.MatchString(name) {
+					return fmt.Errorf("invalid column %q of %s", name, table.name)
+				}
+				columns = append(columns, name)
+			}
+			slices.Sort(columns)
+
+			placeholders := make([]string, len(columns))
+			args := make([]any, len(columns))
+			for i, name := range columns {
+				placeholders[i] = fmt.Sprintf("$%d", i+1)
+				args[i] = row[name].sqlValue()
+			}
+			query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, table.name, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+			if _, err = tx.Exec(query, args...); err != nil {
+				return fmt.Errorf("%s: %w", table.name, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func sessionBackupKey(passphrase string, salt []byte, iterations int) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+}
+
+func encryptSessionBackup(plaintext []byte, passphrase string) ([]byte, error) {
+	envelope := sessionBackupEnvelope{
+		Format:     sessionBackupFormat,
+		Version:    sessionBackupVersion,
+		KDF:        sessionBackupKDF,
+		Iterations: sessionBackupIterations,
+		Salt:       make([]byte, 16),
+	}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to generate salt: %v", err))
+	}
+
+	aead, err := sessionBackupCipher(passphrase, envelope.Salt, envelope.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	envelope.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(envelope.Nonce); err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to generate nonce: %v", err))
+	}
+	envelope.Data = aead.Seal(nil, envelope.Nonce, plaintext, []byte(sessionBackupFormat))
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to encode session backup: %v", err))
+	}
+	return data, nil
+}
+
+func decryptSessionBackup(data []byte, passphrase string) ([]byte, error) {
+	var envelope sessionBackupEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil || envelope.Format != sessionBackupFormat {
+		return nil, pkgError.ValidationError("file is not a session backup")
+	}
+	if envelope.Version > sessionBackupVersion {
+		return nil, pkgError.ValidationError(fmt.Sprintf("session backup format v%d is newer than the supported v%d", envelope.Version, sessionBackupVersion))
+	}
+	// The iterations are capped, a crafted backup could otherwise keep the key derivation busy for hours
+	if envelope.KDF != sessionBackupKDF || envelope.Iterations <= 0 || envelope.Iterations > sessionBackupIterations || len(envelope.Salt) == 0 {
+		return nil, pkgError.ValidationError("session backup has an unsupported key derivation")
+	}
+
+	aead, err := sessionBackupCipher(passphrase, envelope.Salt, envelope.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != aead.NonceSize() {
+		return nil, pkgError.ValidationError("session backup is corrupted")
+	}
+	plaintext, err := aead.Open(nil, envelope.Nonce, envelope.Data, []byte(sessionBackupFormat))
+	if err != nil {
+		return nil, pkgError.ValidationError("wrong passphrase or corrupted session backup")
+	}
+	return plaintext, nil
+}
+
+func sessionBackupCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := sessionBackupKey(passphrase, salt, iterations)
+	if err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to derive the backup key: %v", err))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to create the backup cipher: %v", err))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to create the backup cipher: %v", err))
+	}
+	return aead, nil
+}
@@ -0,0 +1,183 @@
+package whatsapp
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waAdv"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+func newTestSessionBackupStore(t *testing.T) (*sql.DB, *sqlstore.Container) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "whatsapp.db")+"?_foreign_keys=on")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	container := sqlstore.NewWithDB(db, "sqlite3", waLog.Noop)
+	require.NoError(t, container.Upgrade())
+	return db, container
+}
+
+func newTestPairedClient(t *testing.T, container *sqlstore.Container, jid types.JID) *whatsmeow.Client {
+	device := container.NewDevice()
+	device.ID = &jid
+	device.PushName = "Backup Test"
+	device.Account = &waAdv.ADVSignedDeviceIdentity{
+		Details:             []byte("details"),
+		AccountSignature:    make([]byte, 64),
+		AccountSignatureKey: make([]byte, 32),
+		DeviceSignature:     make([]byte, 64),
+	}
+	require.NoError(t, device.Save())
+	return whatsmeow.NewClient(device, nil)
+}
+
+func TestSessionBackupRoundTrip(t *testing.T) {
+	previous := config.PathSessionStore
+	config.PathSessionStore = filepath.Join(t.TempDir(), "sessions.json")
+	t.Cleanup(func() {
+		config.PathSessionStore = previous
+		storeDB = nil
+	})
+
+	db, container := newTestSessionBackupStore(t)
+	require.NoError(t, initLocalStores(db))
+	jid := types.NewADJID("628123456789", 0, 7)
+	source := newTestPairedClient(t, container, jid)
+	identity := [32]byte{1, 2, 3}
+	require.NoError(t, source.Store.Identities.PutIdentity("628111111111.0:0", identity))
+
+	backup, err := ExportSession(source, "correct horse")
+	require.NoError(t, err)
+	assert.NotContains(t, string(backup), "Backup Test")
+
+	// A fresh instance
+	freshDB, freshContainer := newTestSessionBackupStore(t)
+	require.NoError(t, initLocalStores(freshDB))
+	target := whatsmeow.NewClient(freshContainer.NewDevice(), nil)
+
+	_, err = ImportSession(target, backup, "wrong horse", false)
+	assert.EqualError(t, err, "wrong passphrase or corrupted session backup")
+	_, err = ImportSession(target, []byte(`{"hello":"world"}`), "correct horse", false)
+	assert.EqualError(t, err, "file is not a session backup")
+
+	restored, err := ImportSession(target, backup, "correct horse", false)
+	require.NoError(t, err)
+	assert.Equal(t, jid, restored)
+	assert.Equal(t, source.Store.NoiseKey.Priv, target.Store.NoiseKey.Priv)
+	assert.Equal(t, source.Store.SignedPreKey.Signature, target.Store.SignedPreKey.Signature)
+	assert.Equal(t, source.Store.Account.GetDetails(), target.Store.Account.GetDetails())
+	trusted, err := target.Store.Identities.IsTrustedIdentity("628111111111.0:0", identity)
+	require.NoError(t, err)
+	assert.True(t, trusted)
+
+	devices, err := freshContainer.GetAllDevices()
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "Backup Test", devices[0].PushName)
+
+	// The restored session is logged in, it's only replaced with force
+	_, err = ImportSession(target, backup, "correct horse", false)
+	assert.EqualError(t, err, "session is already logged in, set force to replace it")
+	_, err = ImportSession(target, backup, "correct horse", true)
+	assert.NoError(t, err)
+}
+
+func TestSessionBackupNewerStore(t *testing.T) {
+	t.Cleanup(func() { storeDB = nil })
+	db, container := newTestSessionBackupStore(t)
+	require.NoError(t, initLocalStores(db))
+	source := newTestPairedClient(t, container, types.NewADJID("628123456789", 0, 7))
+
+	backup, err := ExportSession(source, "correct horse")
+	require.NoError(t, err)
+
+	freshDB, freshContainer := newTestSessionBackupStore(t)
+	require.NoError(t, initLocalStores(freshDB))
+	_, err = freshDB.Exec(`UPDATE whatsmeow_version SET version = version - 1, compat = compat - 1`)
+	require.NoError(t, err)
+	version, _, err := sessionStoreVersion()
+	require.NoError(t, err)
+
+	_, err = ImportSession(whatsmeow.NewClient(freshContainer.NewDevice(), nil), backup, "correct horse", false)
+	assert.ErrorContains(t, err, fmt.Sprintf("this instance has v%d", version))
+}
+
+func TestExportSessionNotLoggedIn(t *testing.T) {
+	_, container := newTestSessionBackupStore(t)
+	_, err := ExportSession(whatsmeow.NewClient(container.NewDevice(), nil), "correct horse")
+	assert.EqualError(t, err, "session is not logged in, there is nothing to export")
+}
+
+func TestSessionBackupIterationsCapped(t *testing.T) {
+	backup, err := encryptSessionBackup([]byte(`{}`), "correct horse")
+	require.NoError(t, err)
+	var envelope sessionBackupEnvelope
+	require.NoError(t, json.Unmarshal(backup, &envelope))
+	envelope.Iterations = sessionBackupIterations * 1000
+	backup, err = json.Marshal(envelope)
+	require.NoError(t, err)
+
+	_, err = decryptSessionBackup(backup, "correct horse")
+	assert.EqualError(t, err, "session backup has an unsupported key derivation")
+}
+
+func newTestBackupDevice(t *testing.T, jid string) *store.Device {
+	device, err := sessionBackupDevice{
+		JID:              jid,
+		NoiseKey:         make([]byte, 32),
+		IdentityKey:      make([]byte, 32),
+		SignedPreKey:     make([]byte, 32),
+		SignedPreKeySig:  make([]byte, 64),
+		AdvKey:           []byte("key"),
+		AdvDetails:       []byte("details"),
+		AdvAccountSig:    make([]byte, 64),
+		AdvAccountSigKey: make([]byte, 32),
+		AdvDeviceSig:     make([]byte, 64),
+	}.toDevice()
+	require.NoError(t, err)
+	return device
+}
+
+func TestImportSessionBindsRowsToDevice(t *testing.T) {
+	t.Cleanup(func() { storeDB = nil })
+	db, container := newTestSessionBackupStore(t)
+	require.NoError(t, initLocalStores(db))
+	previous := newTestPairedClient(t, container, types.NewADJID("628111111111", 0, 2))
+	other := newTestPairedClient(t, container, types.NewADJID("628123456789", 0, 7))
+	require.NoError(t, previous.Store.Identities.PutIdentity("628333333333.0:0", [32]byte{3}))
+
+	// A row naming another device is written for the device of the backup
+	device := newTestBackupDevice(t, "628999999999.0:3@s.whatsapp.net")
+	claimed := other.Store.ID.String()
+	theirID := "628444444444.0:0"
+	tables := map[string][]map[string]sessionBackupValue{"whatsmeow_identity_keys": {{
+		"our_jid":  {Text: &claimed},
+		"their_id": {Text: &theirID},
+		"identity": {Bytes: make([]byte, 32)},
+	}}}
+	require.NoError(t, importSession(previous.Store.ID, device, tables))
+
+	var owner string
+	require.NoError(t, db.QueryRow(`SELECT our_jid FROM whatsmeow_identity_keys WHERE their_id = $1`, theirID).Scan(&owner))
+	assert.Equal(t, device.ID.String(), owner)
+	// The previous device is replaced along with its rows
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM whatsmeow_identity_keys WHERE our_jid = $1`, previous.Store.ID.String()).Scan(&count))
+	assert.Zero(t, count)
+
+	// A failed import keeps the previous device
+	tables["whatsmeow_identity_keys"][0]["identity"] = sessionBackupValue{Bytes: []byte{1}}
+	assert.Error(t, importSession(device.ID, newTestBackupDevice(t, "628777777777.0:3@s.whatsapp.net"), tables))
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM whatsmeow_device WHERE jid = $1`, device.ID.String()).Scan(&count))
+	assert.Equal(t, 1, count)
+}
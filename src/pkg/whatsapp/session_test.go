@@ -0,0 +1,101 @@
+package whatsapp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestRememberSessionDevice(t *testing.T) {
+	previous := config.PathSessionStore
+	config.PathSessionStore = filepath.Join(t.TempDir(), "sessions.json")
+	t.Cleanup(func() { config.PathSessionStore = previous })
+
+	devices, err := loadSessionDevices()
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+
+	require.NoError(t, rememberSessionDevice("sales", types.NewADJID("628123456789", 0, 3)))
+	require.NoError(t, rememberSessionDevice(DefaultSessionID, types.NewADJID("628987654321", 0, 5)))
+
+	devices, err = loadSessionDevices()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"sales":          "628123456789:3@s.whatsapp.net",
+		DefaultSessionID: "628987654321:5@s.whatsapp.net",
+	}, devices)
+
+	require.NoError(t, forgetSessionDevice("sales"))
+	devices, err = loadSessionDevices()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{DefaultSessionID: "628987654321:5@s.whatsapp.net"}, devices)
+}
+
+func TestSessionIDOf(t *testing.T) {
+	client := &whatsmeow.Client{}
+	registerSession("support", client)
+	t.Cleanup(func() {
+		sessionsMu.Lock()
+		delete(sessionClients, "support")
+		sessionsMu.Unlock()
+	})
+
+	assert.Equal(t, "support", sessionIDOf(client))
+	assert.Same(t, client, GetSessionClient("support"))
+	assert.Equal(t, DefaultSessionID, sessionIDOf(&whatsmeow.Client{}))
+	assert.Equal(t, DefaultSessionID, sessionIDOf(nil))
+}
+
+func TestSessionFilePrefix(t *testing.T) {
+	client := &whatsmeow.Client{}
+	registerSession("sales", client)
+	t.Cleanup(func() {
+		sessionsMu.Lock()
+		delete(sessionClients, "sales")
+		sessionsMu.Unlock()
+	})
+
+	assert.Equal(t, "sales.", SessionFilePrefix(client))
+	assert.Equal(t, "default.", SessionFilePrefix(nil))
+	// The files of a session with a longer id don't match the prefix
+	matched, err := filepath.Match(SessionFilePrefix(client)+"*", "sales-eu.3EB0.png")
+	assert.NoError(t, err)
+	assert.False(t, matched)
+}
+
+func TestRemoveSessionDownloads(t *testing.T) {
+	previousMedia, previousStorages := config.PathMedia, config.PathStorages
+	config.PathMedia, config.PathStorages = t.TempDir(), t.TempDir()
+	t.Cleanup(func() { config.PathMedia, config.PathStorages = previousMedia, previousStorages })
+
+	files := map[string]bool{
+		filepath.Join(config.PathMedia, "sales.1700000000-3f2a.jpg"):         false,
+		filepath.Join(config.PathStorages, "sales.1700000000-9b1c.jpg"):      false,
+		filepath.Join(config.PathMedia, "sales-eu.1700000000-77aa.jpg"):      true,
+		filepath.Join(config.PathMedia, "default.1700000000-1d2e.ogg"):       true,
+		filepath.Join(config.PathStorages, "sessions.json"):                  true,
+		filepath.Join(config.PathStorages, "sales.1700000000-9b1c.jpg.part"): false,
+	}
+	for file := range files {
+		require.NoError(t, os.WriteFile(file, []byte("media"), 0600))
+	}
+
+	removeSessionDownloads("sales")
+	for file, kept := range files {
+		_, err := os.Stat(file)
+		assert.Equal(t, kept, err == nil, file)
+	}
+}
+
+func TestValidateSessionIDs(t *testing.T) {
+	assert.NoError(t, ValidateSessionIDs(nil))
+	assert.NoError(t, ValidateSessionIDs([]string{"sales", "support_2", "eu-west"}))
+	assert.Error(t, ValidateSessionIDs([]string{"sales/eu"}))
+	assert.Error(t, ValidateSessionIDs([]string{""}))
+}
@@ -0,0 +1,196 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// StatusLifetime is how long a status is visible after it was posted
+	StatusLifetime = 24 * time.Hour
+)
+
+// statusColor is a #RRGGBB or #AARRGGBB color of a text status
+var statusColor = regexp.MustCompile(`^#([0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+// ReceivedStatus is a status posted by a contact, kept in memory until it expires
+type ReceivedStatus struct {
+	ID              string
+	SenderJID       types.JID
+	SenderAlt       types.JID // The phone number of a sender known by its lid, or the lid of one known by its number
+	PushName        string
+	Type            string
+	Text            string
+	BackgroundColor string
+	MimeType        string
+	PostedAt        time.Time
+}
+
+// ExpiresAt is the time the status stops being visible
+func (status ReceivedStatus) ExpiresAt() time.Time {
+	return status.PostedAt.Add(StatusLifetime)
+}
+
+var (
+	statuses   = make(map[string][]ReceivedStatus)
+	statusesMu sync.Mutex
+)
+
+// StatusColorARGB parses a #RRGGBB or #AARRGGBB color, a color without alpha is opaque
+func StatusColorARGB(color string) (uint32, error) {
+	if !statusColor.MatchString(color) {
+		return 0, pkgError.ValidationError(fmt.Sprintf("color %s must be in the form of #RRGGBB or #AARRGGBB", color))
+	}
+	hex := color[1:]
+	if len(hex) == 6 {
+		hex = "ff" + hex
+	}
+	argb, _ := strconv.ParseUint(hex, 16, 32)
+	return uint32(argb), nil
+}
+
+// statusColorHex formats the ARGB color of a text status as #AARRGGBB, empty when there's none
+func statusColorHex(argb uint32) string {
+	if argb == 0 {
+		return ""
+	}
+	return fmt.Sprintf("#%08x", argb)
+}
+
+// SendStatus posts msg to the status of the session, whatsapp picks the recipients from the status privacy setting
+func SendStatus(ctx context.Context, client *whatsmeow.Client, msg *waE2E.Message) (whatsmeow.SendResponse, error) {
+	return SendMessage(ctx, client, types.StatusBroadcastJID, msg)
+}
+
+// CheckStatusAudience rejects the recipients that won't see a status of the session. The audience is picked by the
+// status privacy setting of the account, like whatsmeow does when sending a status
+func CheckStatusAudience(client *whatsmeow.Client, recipients []types.JID) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+	options, err := client.GetStatusPrivacy()
+	if err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to get the status privacy: %v", err))
+	}
+	isContact := func(jid types.JID) bool {
+		contact, err := client.Store.Contacts.GetContact(jid)
+		return err == nil && contact.Found
+	}
+	for _, recipient := range recipients {
+		if !inStatusAudience(options[0], recipient, isContact) {
+			return pkgError.ValidationError(fmt.Sprintf("recipient %s doesn't see the statuses of the account, change the status privacy setting to share with it", recipient.User))
+		}
+	}
+	return nil
+}
+
+// inStatusAudience reports whether the status privacy lets recipient see a status: the listed contacts of a whitelist,
+// otherwise the saved contacts except the listed ones of a blacklist
+func inStatusAudience(privacy types.StatusPrivacy, recipient types.JID, isContact func(types.JID) bool) bool {
+	listed := slices.ContainsFunc(privacy.List, func(jid types.JID) bool { return jid.ToNonAD() == recipient.ToNonAD() })
+	switch privacy.Type {
+	case types.StatusPrivacyTypeWhitelist:
+		return listed
+	case types.StatusPrivacyTypeBlacklist:
+		return !listed && isContact(recipient)
+	default:
+		return isContact(recipient)
+	}
+}
+
+// recordStatus keeps a status posted by a contact, a status deleted by its sender is removed
+func recordStatus(sessionID string, evt *events.Message) {
+	if evt.Info.Chat != types.StatusBroadcastJID || evt.Info.IsFromMe || evt.Message == nil {
+		return
+	}
+
+	statusesMu.Lock()
+	defer statusesMu.Unlock()
+
+	received := pruneStatuses(statuses[sessionID], time.Now())
+	if protocolMessage := evt.Message.GetProtocolMessage(); protocolMessage != nil {
+		if protocolMessage.GetType() == waE2E.ProtocolMessage_REVOKE {
+			target := protocolMessage.GetKey().GetID()
+			received = slices.DeleteFunc(received, func(status ReceivedStatus) bool { return status.ID == target })
+		}
+	} else if isContentMessage(evt.Message) &&
+		!slices.ContainsFunc(received, func(status ReceivedStatus) bool { return status.ID == evt.Info.ID }) {
+		received = append(received, newReceivedStatus(evt))
+	}
+	statuses[sessionID] = received
+}
+
+func newReceivedStatus(evt *events.Message) ReceivedStatus {
+	status := ReceivedStatus{
+		ID:        evt.Info.ID,
+		SenderJID: evt.Info.Sender.ToNonAD(),
+		SenderAlt: evt.Info.SenderAlt.ToNonAD(),
+		PushName:  evt.Info.PushName,
+		Type:      storedMessageType(evt.Message),
+		Text:      editedMessageText(evt.Message),
+		PostedAt:  evt.Info.Timestamp,
+	}
+	if extendedText := evt.Message.GetExtendedTextMessage(); extendedText != nil {
+		status.BackgroundColor = statusColorHex(extendedText.GetBackgroundArgb())
+	}
+	if media, mimeType, _ := getDownloadableMedia(evt.Message); media != nil {
+		status.MimeType = mimeType
+	}
+	return status
+}
+
+// pruneStatuses drops the expired statuses
+func pruneStatuses(received []ReceivedStatus, now time.Time) []ReceivedStatus {
+	return slices.DeleteFunc(received, func(status ReceivedStatus) bool { return !now.Before(status.ExpiresAt()) })
+}
+
+// ListReceivedStatuses returns the statuses received by the session that haven't expired yet, newest first.
+// A non empty sender only returns the statuses of that contact, whether it posted them with its phone number or its lid
+func ListReceivedStatuses(client *whatsmeow.Client, sender types.JID) []ReceivedStatus {
+	senders := []types.JID{sender.ToNonAD()}
+	if !sender.IsEmpty() && client != nil && client.Store.LIDs != nil {
+		var alt types.JID
+		if sender.Server == types.HiddenUserServer {
+			alt, _ = client.Store.LIDs.GetPNForLID(context.Background(), sender.ToNonAD())
+		} else {
+			alt, _ = client.Store.LIDs.GetLIDForPN(context.Background(), sender.ToNonAD())
+		}
+		if !alt.IsEmpty() {
+			senders = append(senders, alt.ToNonAD())
+		}
+	}
+
+	statusesMu.Lock()
+	defer statusesMu.Unlock()
+
+	sessionID := sessionIDOf(client)
+	statuses[sessionID] = pruneStatuses(statuses[sessionID], time.Now())
+
+	result := make([]ReceivedStatus, 0, len(statuses[sessionID]))
+	for _, status := range statuses[sessionID] {
+		if sender.IsEmpty() || slices.Contains(senders, status.SenderJID) || slices.Contains(senders, status.SenderAlt) {
+			result = append(result, status)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].PostedAt.After(result[j].PostedAt) })
+	return result
+}
+
+// clearSessionStatuses forgets the statuses received by a session that logged out
+func clearSessionStatuses(sessionID string) {
+	statusesMu.Lock()
+	delete(statuses, sessionID)
+	statusesMu.Unlock()
+}
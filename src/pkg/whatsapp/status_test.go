@@ -0,0 +1,121 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStatusColorARGB(t *testing.T) {
+	argb, err := StatusColorARGB("#25D366")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0xff25d366), argb)
+
+	argb, err = StatusColorARGB("#8025d366")
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x8025d366), argb)
+	assert.Equal(t, "#8025d366", statusColorHex(argb))
+	assert.Empty(t, statusColorHex(0))
+
+	for _, color := range []string{"25D366", "#25D36", "#GGGGGG", ""} {
+		_, err = StatusColorARGB(color)
+		assert.Error(t, err, color)
+	}
+}
+
+func newStatusEvent(sender types.JID, id string, postedAt time.Time, msg *waE2E.Message) *events.Message {
+	return &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: types.StatusBroadcastJID, Sender: sender},
+			ID:            id,
+			PushName:      "Alice",
+			Timestamp:     postedAt,
+		},
+		Message: msg,
+	}
+}
+
+func TestRecordStatus(t *testing.T) {
+	t.Cleanup(func() { clearSessionStatuses(DefaultSessionID) })
+	alice := types.NewJID("6289685028129", types.DefaultUserServer)
+	bob := types.NewJID("6289685028130", types.DefaultUserServer)
+	now := time.Now()
+
+	recordStatus(DefaultSessionID, newStatusEvent(alice, "S1", now.Add(-2*time.Hour), &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+		Text:           proto.String("good morning"),
+		BackgroundArgb: proto.Uint32(0xff25d366),
+	}}))
+	recordStatus(DefaultSessionID, newStatusEvent(bob, "S2", now.Add(-time.Hour), &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+		Caption:  proto.String("view"),
+		Mimetype: proto.String("image/jpeg"),
+	}}))
+	// Expired, and a message of a chat instead of a status
+	recordStatus(DefaultSessionID, newStatusEvent(alice, "S0", now.Add(-25*time.Hour), &waE2E.Message{Conversation: proto.String("old")}))
+	chat := newStatusEvent(alice, "M1", now, &waE2E.Message{Conversation: proto.String("hi")})
+	chat.Info.Chat = alice
+	recordStatus(DefaultSessionID, chat)
+
+	received := ListReceivedStatuses(nil, types.JID{})
+	require.Len(t, received, 2)
+	assert.Equal(t, "S2", received[0].ID)
+	assert.Equal(t, "image", received[0].Type)
+	assert.Equal(t, "view", received[0].Text)
+	assert.Equal(t, "image/jpeg", received[0].MimeType)
+	assert.Equal(t, "S1", received[1].ID)
+	assert.Equal(t, "text", received[1].Type)
+	assert.Equal(t, "#ff25d366", received[1].BackgroundColor)
+	assert.Equal(t, received[1].PostedAt.Add(24*time.Hour), received[1].ExpiresAt())
+
+	received = ListReceivedStatuses(nil, alice)
+	require.Len(t, received, 1)
+	assert.Equal(t, "S1", received[0].ID)
+
+	// A sender known by its lid is found by its phone number too
+	carol := types.NewJID("6289685028131", types.DefaultUserServer)
+	carolLID := types.NewJID("123456789", types.HiddenUserServer)
+	fromLID := newStatusEvent(carolLID, "S3", now.Add(-3*time.Hour), &waE2E.Message{Conversation: proto.String("from lid")})
+	fromLID.Info.SenderAlt = carol
+	recordStatus(DefaultSessionID, fromLID)
+	received = ListReceivedStatuses(nil, carol)
+	require.Len(t, received, 1)
+	assert.Equal(t, "S3", received[0].ID)
+	received = ListReceivedStatuses(nil, carolLID)
+	require.Len(t, received, 1)
+	assert.Equal(t, "S3", received[0].ID)
+
+	// A status deleted by its sender is forgotten
+	recordStatus(DefaultSessionID, newStatusEvent(bob, "R1", now, &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{
+		Type: waE2E.ProtocolMessage_REVOKE.Enum(),
+		Key:  &waCommon.MessageKey{ID: proto.String("S2")},
+	}}))
+	received = ListReceivedStatuses(nil, types.JID{})
+	require.Len(t, received, 2)
+	assert.Equal(t, "S1", received[0].ID)
+}
+
+func TestInStatusAudience(t *testing.T) {
+	alice := types.NewJID("6289685028129", types.DefaultUserServer)
+	bob := types.NewJID("6289685028130", types.DefaultUserServer)
+	stranger := types.NewJID("6289685028131", types.DefaultUserServer)
+	isContact := func(jid types.JID) bool { return jid != stranger }
+
+	whitelist := types.StatusPrivacy{Type: types.StatusPrivacyTypeWhitelist, List: []types.JID{alice}}
+	assert.True(t, inStatusAudience(whitelist, alice, isContact))
+	assert.False(t, inStatusAudience(whitelist, bob, isContact))
+
+	blacklist := types.StatusPrivacy{Type: types.StatusPrivacyTypeBlacklist, List: []types.JID{alice}}
+	assert.False(t, inStatusAudience(blacklist, alice, isContact))
+	assert.True(t, inStatusAudience(blacklist, bob, isContact))
+	assert.False(t, inStatusAudience(blacklist, stranger, isContact))
+
+	contacts := types.StatusPrivacy{Type: types.StatusPrivacyTypeContacts}
+	assert.True(t, inStatusAudience(contacts, bob, isContact))
+	assert.False(t, inStatusAudience(contacts, stranger, isContact))
+}
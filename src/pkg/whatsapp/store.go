@@ -0,0 +1,24 @@
+package whatsapp
+
+import "database/sql"
+
+// storeDB is the database of the whatsmeow store. Reactions, chats, messages, statuses and the schedule are kept
+// in tables of their own next to it, the session backup reads and writes the whatsmeow tables directly
+var storeDB *sql.DB
+
+// initLocalStores creates the tables of the local stores in db and makes it their database
+func initLocalStores(db *sql.DB) error {
+	for _, init := range []func(*sql.DB) error{
+		initReactionStore,
+		initChatStore,
+		initMessageStore,
+		initMessageStatusStore,
+		initScheduledMessageStore,
+	} {
+		if err := init(db); err != nil {
+			return err
+		}
+	}
+	storeDB = db
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"mime"
 	"os"
 	"regexp"
@@ -16,30 +17,98 @@ import (
 	"go.mau.fi/whatsmeow/proto/waE2E"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/metrics"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 )
 
-// ExtractMedia is a helper function to extract media from whatsapp
-func ExtractMedia(storageLocation string, mediaFile whatsmeow.DownloadableMessage) (extractedMedia ExtractedMedia, err error) {
+// MediaTooLargeError is returned by ExtractMedia for a media bigger than the download limit of its type
+type MediaTooLargeError struct {
+	Size  uint64
+	Limit int64
+}
+
+func (e *MediaTooLargeError) Error() string {
+	return fmt.Sprintf("file size of %d bytes exceeds the maximum limit of %d bytes", e.Size, e.Limit)
+}
+
+// MaxDownloadSize returns the download limit of the type of a received media, see config.WhatsappMaxImageDownloadSize
+func MaxDownloadSize(mediaFile whatsmeow.DownloadableMessage) int64 {
+	switch mediaFile.(type) {
+	case *waE2E.ImageMessage:
+		return config.WhatsappMaxImageDownloadSize
+	case *waE2E.VideoMessage:
+		return config.WhatsappMaxVideoDownloadSize
+	case *waE2E.DocumentMessage:
+		return config.WhatsappMaxFileDownloadSize
+	}
+	return config.WhatsappSettingMaxDownloadSize
+}
+
+// mediaFileLength returns the size declared by the sender of a media, 0 when unknown
+func mediaFileLength(mediaFile whatsmeow.DownloadableMessage) uint64 {
+	if sized, ok := mediaFile.(interface{ GetFileLength() uint64 }); ok {
+		return sized.GetFileLength()
+	}
+	return 0
+}
+
+// ExtractMedia is a helper function to extract media from whatsapp. A media declared bigger than its download limit
+// isn't downloaded, a *MediaTooLargeError is returned with the metadata of the media
+func ExtractMedia(client *whatsmeow.Client, storageLocation string, mediaFile whatsmeow.DownloadableMessage) (extractedMedia ExtractedMedia, err error) {
 	if mediaFile == nil {
 		logrus.Info("Skip download because data is nil")
 		return extractedMedia, nil
 	}
 
-	data, err := cli.Download(mediaFile)
+	maxFileSize := MaxDownloadSize(mediaFile)
+	if size := mediaFileLength(mediaFile); size > uint64(maxFileSize) {
+		logrus.Warnf("Skip download of a %s of %d bytes, it exceeds the limit of %d bytes", extractMediaMetadata(mediaFile).MimeType, size, maxFileSize)
+		return extractMediaMetadata(mediaFile), &MediaTooLargeError{Size: size, Limit: maxFileSize}
+	}
+	if client == nil {
+		return extractedMedia, pkgError.ErrWaCLI
+	}
+
+	data, err := client.Download(mediaFile)
 	if err != nil {
 		return extractedMedia, err
 	}
+	metrics.MediaDownloadedBytes.Add(float64(len(data)))
 
-	// Validate file size before writing to disk
-	maxFileSize := config.WhatsappSettingMaxDownloadSize
+	// The declared size may be wrong, validate the downloaded one before writing to disk
 	if int64(len(data)) > maxFileSize {
-		return extractedMedia, fmt.Errorf("file size exceeds the maximum limit of %d bytes", maxFileSize)
+		return extractMediaMetadata(mediaFile), &MediaTooLargeError{Size: uint64(len(data)), Limit: maxFileSize}
 	}
 
+	extractedMedia = extractMediaMetadata(mediaFile)
+
+	var extension string
+	if ext, err := mime.ExtensionsByType(extractedMedia.MimeType); err == nil && len(ext) > 0 {
+		extension = ext[0]
+	} else if parts := strings.Split(extractedMedia.MimeType, "/"); len(parts) > 1 {
+		extension = "." + parts[len(parts)-1]
+	}
+
+	// The session prefix lets WipeSession delete the downloads of a logged out session
+	extractedMedia.MediaPath = fmt.Sprintf("%s/%s%d-%s%s", storageLocation, SessionFilePrefix(client), time.Now().Unix(), uuid.NewString(), extension)
+	// Write to a temporary file first so the media janitor and media readers never see a partial file
+	partialPath := extractedMedia.MediaPath + ".part"
+	err = os.WriteFile(partialPath, data, 0600)
+	if err != nil {
+		return extractedMedia, err
+	}
+	if err = os.Rename(partialPath, extractedMedia.MediaPath); err != nil {
+		_ = os.Remove(partialPath)
+		return extractedMedia, err
+	}
+	return extractedMedia, nil
+}
+
+// extractMediaMetadata returns the mime type and caption of a media message without downloading it
+func extractMediaMetadata(mediaFile whatsmeow.DownloadableMessage) (extractedMedia ExtractedMedia) {
 	switch media := mediaFile.(type) {
 	case *waE2E.ImageMessage:
 		extractedMedia.MimeType = media.GetMimetype()
@@ -55,29 +124,17 @@ func ExtractMedia(storageLocation string, mediaFile whatsmeow.DownloadableMessag
 		extractedMedia.MimeType = media.GetMimetype()
 		extractedMedia.Caption = media.GetCaption()
 	}
-
-	var extension string
-	if ext, err := mime.ExtensionsByType(extractedMedia.MimeType); err == nil && len(ext) > 0 {
-		extension = ext[0]
-	} else if parts := strings.Split(extractedMedia.MimeType, "/"); len(parts) > 1 {
-		extension = "." + parts[len(parts)-1]
-	}
-
-	extractedMedia.MediaPath = fmt.Sprintf("%s/%d-%s%s", storageLocation, time.Now().Unix(), uuid.NewString(), extension)
-	err = os.WriteFile(extractedMedia.MediaPath, data, 0600)
-	if err != nil {
-		return extractedMedia, err
-	}
-	return extractedMedia, nil
+	return extractedMedia
 }
 
+// SanitizePhone rewrites a phone number or jid of a request to its canonical jid, see NormalizeJID. An invalid value
+// is kept as is, so the validation or ParseJID rejects it with the reason
 func SanitizePhone(phone *string) {
-	if phone != nil && len(*phone) > 0 && !strings.Contains(*phone, "@") {
-		if len(*phone) <= 15 {
-			*phone = fmt.Sprintf("%s%s", *phone, config.WhatsappTypeUser)
-		} else {
-			*phone = fmt.Sprintf("%s%s", *phone, config.WhatsappTypeGroup)
-		}
+	if phone == nil || len(*phone) == 0 {
+		return
+	}
+	if jid, err := NormalizeJID(*phone); err == nil {
+		*phone = jid.String()
 	}
 }
 
@@ -116,25 +173,9 @@ func GetPlatformName(deviceID int) string {
 	}
 }
 
+// ParseJID parses a phone number or jid of a request, see NormalizeJID
 func ParseJID(arg string) (types.JID, error) {
-	if arg[0] == '+' {
-		arg = arg[1:]
-	}
-	if !strings.ContainsRune(arg, '@') {
-		return types.NewJID(arg, types.DefaultUserServer), nil
-	}
-
-	recipient, err := types.ParseJID(arg)
-	if err != nil {
-		fmt.Printf("invalid JID %s: %v", arg, err)
-		return recipient, pkgError.ErrInvalidJID
-	}
-
-	if recipient.User == "" {
-		fmt.Printf("invalid JID %v: no server specified", arg)
-		return recipient, pkgError.ErrInvalidJID
-	}
-	return recipient, nil
+	return NormalizeJID(arg)
 }
 
 func IsOnWhatsapp(waCli *whatsmeow.Client, jid string) bool {
@@ -159,11 +200,16 @@ func IsOnWhatsapp(waCli *whatsmeow.Client, jid string) bool {
 func ValidateJidWithLogin(waCli *whatsmeow.Client, jid string) (types.JID, error) {
 	MustLogin(waCli)
 
-	if config.WhatsappAccountValidation && !IsOnWhatsapp(waCli, jid) {
+	// A malformed phone is rejected before whatsapp is asked about it
+	recipient, err := ParseJID(jid)
+	if err != nil {
+		return types.JID{}, err
+	}
+	if config.WhatsappAccountValidation && !IsOnWhatsapp(waCli, recipient.String()) {
 		return types.JID{}, pkgError.InvalidJID(fmt.Sprintf("Phone %s is not on whatsapp", jid))
 	}
 
-	return ParseJID(jid)
+	return recipient, nil
 }
 
 func MustLogin(waCli *whatsmeow.Client) {
@@ -195,8 +241,8 @@ func isGroupJid(jid string) bool {
 }
 
 // isFromMySelf is a helper function to check if the message is from my self (logged in account)
-func isFromMySelf(jid string) bool {
-	return extractPhoneNumber(jid) == extractPhoneNumber(cli.Store.ID.String())
+func isFromMySelf(client *whatsmeow.Client, jid string) bool {
+	return extractPhoneNumber(jid) == extractPhoneNumber(client.Store.ID.String())
 }
 
 // extractPhoneNumber is a helper function to extract the phone number from a JID
@@ -212,8 +258,13 @@ func extractPhoneNumber(jid string) string {
 	return ""
 }
 
+// getMessageDigestOrSignature returns the hex hmac sha256 of msg
 func getMessageDigestOrSignature(msg, key []byte) (string, error) {
-	mac := hmac.New(sha256.New, key)
+	return getHMACDigest(sha256.New, msg, key)
+}
+
+func getHMACDigest(newHash func() hash.Hash, msg, key []byte) (string, error) {
+	mac := hmac.New(newHash, key)
 	_, err := mac.Write(msg)
 	if err != nil {
 		return "", err
@@ -246,10 +297,119 @@ func buildEventReaction(evt *events.Message) (waReaction evtReaction) {
 	if reactionMessage := evt.Message.GetReactionMessage(); reactionMessage != nil {
 		waReaction.Message = reactionMessage.GetText()
 		waReaction.ID = reactionMessage.GetKey().GetID()
+		// An empty emoji means the reaction was removed
+		waReaction.Action = "add"
+		if waReaction.Message == "" {
+			waReaction.Action = "remove"
+		}
 	}
 	return waReaction
 }
 
+// getMessageContextInfo returns the context info of any message type that carries one, including edited messages
+func getMessageContextInfo(msg *waE2E.Message) *waE2E.ContextInfo {
+	if editedMessage := msg.GetProtocolMessage().GetEditedMessage(); editedMessage != nil {
+		msg = editedMessage
+	}
+
+	for _, contextInfo := range []*waE2E.ContextInfo{
+		msg.GetExtendedTextMessage().GetContextInfo(),
+		msg.GetImageMessage().GetContextInfo(),
+		msg.GetVideoMessage().GetContextInfo(),
+		msg.GetAudioMessage().GetContextInfo(),
+		msg.GetDocumentMessage().GetContextInfo(),
+		msg.GetStickerMessage().GetContextInfo(),
+		msg.GetContactMessage().GetContextInfo(),
+		msg.GetLocationMessage().GetContextInfo(),
+		msg.GetLiveLocationMessage().GetContextInfo(),
+	} {
+		if contextInfo != nil {
+			return contextInfo
+		}
+	}
+	return nil
+}
+
+// buildEventQuoted returns the message being replied to, or nil when the message is not a reply
+func buildEventQuoted(evt *events.Message) *evtQuoted {
+	contextInfo := getMessageContextInfo(evt.Message)
+	if contextInfo.GetStanzaID() == "" {
+		return nil
+	}
+
+	quotedMessage := contextInfo.GetQuotedMessage()
+	text := quotedMessage.GetConversation()
+	if text == "" {
+		text = quotedMessage.GetExtendedTextMessage().GetText()
+	}
+	if text == "" {
+		text = quotedMessage.GetImageMessage().GetCaption()
+	}
+	if text == "" {
+		text = quotedMessage.GetVideoMessage().GetCaption()
+	}
+	if text == "" {
+		text = quotedMessage.GetDocumentMessage().GetCaption()
+	}
+
+	return &evtQuoted{
+		ID:          contextInfo.GetStanzaID(),
+		Participant: contextInfo.GetParticipant(),
+		Text:        text,
+	}
+}
+
+// buildEventPoll returns the question and options of a poll creation message, or nil when the message is not a poll
+func buildEventPoll(evt *events.Message) *evtPoll {
+	pollMessage := evt.Message.GetPollCreationMessage()
+	if pollMessage == nil {
+		pollMessage = evt.Message.GetPollCreationMessageV2()
+	}
+	if pollMessage == nil {
+		pollMessage = evt.Message.GetPollCreationMessageV3()
+	}
+	if pollMessage == nil {
+		return nil
+	}
+
+	poll := &evtPoll{
+		Question:  pollMessage.GetName(),
+		Options:   make([]string, 0, len(pollMessage.GetOptions())),
+		MaxAnswer: pollMessage.GetSelectableOptionsCount(),
+	}
+	for _, option := range pollMessage.GetOptions() {
+		poll.Options = append(poll.Options, option.GetOptionName())
+	}
+	return poll
+}
+
+// buildEventPollVote returns the selected option hashes (sha256 of the option name, hex encoded) of a poll vote.
+// Decrypting a vote requires the secret of the original poll, which is only known when the poll was sent or received
+// by this device. When the vote can't be decrypted only the poll id is returned.
+func buildEventPollVote(client *whatsmeow.Client, evt *events.Message) *evtPollVote {
+	pollUpdate := evt.Message.GetPollUpdateMessage()
+	if pollUpdate == nil {
+		return nil
+	}
+
+	vote := &evtPollVote{PollID: pollUpdate.GetPollCreationMessageKey().GetID()}
+	if client == nil {
+		return vote
+	}
+
+	decrypted, err := client.DecryptPollVote(evt)
+	if err != nil {
+		logrus.Warnf("Failed to decrypt poll vote from %s: %v", evt.Info.SourceString(), err)
+		return vote
+	}
+
+	vote.Decrypted = true
+	for _, option := range decrypted.GetSelectedOptions() {
+		vote.SelectedOptions = append(vote.SelectedOptions, hex.EncodeToString(option))
+	}
+	return vote
+}
+
 func buildForwarded(evt *events.Message) bool {
 	if extendedText := evt.Message.GetExtendedTextMessage(); extendedText != nil {
 		return extendedText.ContextInfo.GetIsForwarded()
@@ -373,3 +533,22 @@ func ExtractMessageText(evt *events.Message) string {
 	}
 	return messageText
 }
+
+// IsGroupAdmin checks whether the logged in account is an admin of the group
+func IsGroupAdmin(waCli *whatsmeow.Client, groupInfo *types.GroupInfo) bool {
+	if waCli == nil || waCli.Store.ID == nil || groupInfo == nil {
+		return false
+	}
+
+	ownJID := waCli.Store.ID.ToNonAD()
+	ownLID := waCli.Store.LID.ToNonAD()
+	for _, participant := range groupInfo.Participants {
+		isOwn := participant.JID.ToNonAD() == ownJID ||
+			participant.PhoneNumber.ToNonAD() == ownJID ||
+			(!ownLID.IsEmpty() && (participant.JID.ToNonAD() == ownLID || participant.LID.ToNonAD() == ownLID))
+		if isOwn {
+			return participant.IsAdmin || participant.IsSuperAdmin
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBuildEventQuoted(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  *waE2E.Message
+		expected *evtQuoted
+	}{
+		{
+			name:     "should return nil without context info",
+			message:  &waE2E.Message{Conversation: proto.String("hello")},
+			expected: nil,
+		},
+		{
+			name: "should return quoted text reply",
+			message: &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+				Text: proto.String("reply"),
+				ContextInfo: &waE2E.ContextInfo{
+					StanzaID:      proto.String("3EB0C127D7BACC83D6A1"),
+					Participant:   proto.String("628123456789@s.whatsapp.net"),
+					QuotedMessage: &waE2E.Message{Conversation: proto.String("original")},
+				},
+			}},
+			expected: &evtQuoted{ID: "3EB0C127D7BACC83D6A1", Participant: "628123456789@s.whatsapp.net", Text: "original"},
+		},
+		{
+			name: "should return quoted image caption from image reply",
+			message: &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+				ContextInfo: &waE2E.ContextInfo{
+					StanzaID: proto.String("3EB0C127D7BACC83D6A2"),
+					QuotedMessage: &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+						Caption: proto.String("caption"),
+					}},
+				},
+			}},
+			expected: &evtQuoted{ID: "3EB0C127D7BACC83D6A2", Text: "caption"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildEventQuoted(&events.Message{Message: tt.message}))
+		})
+	}
+}
+
+func TestBuildEventPoll(t *testing.T) {
+	assert.Nil(t, buildEventPoll(&events.Message{Message: &waE2E.Message{Conversation: proto.String("hello")}}))
+
+	poll := buildEventPoll(&events.Message{Message: &waE2E.Message{PollCreationMessageV3: &waE2E.PollCreationMessage{
+		Name: proto.String("Lunch?"),
+		Options: []*waE2E.PollCreationMessage_Option{
+			{OptionName: proto.String("Pizza")},
+			{OptionName: proto.String("Sushi")},
+		},
+		SelectableOptionsCount: proto.Uint32(1),
+	}}})
+	assert.Equal(t, &evtPoll{Question: "Lunch?", Options: []string{"Pizza", "Sushi"}, MaxAnswer: 1}, poll)
+}
+
+func TestBuildEventPollVoteWithoutSecret(t *testing.T) {
+	vote := buildEventPollVote(nil, &events.Message{Message: &waE2E.Message{PollUpdateMessage: &waE2E.PollUpdateMessage{
+		PollCreationMessageKey: &waCommon.MessageKey{ID: proto.String("3EB0C127D7BACC83D6A1")},
+	}}})
+	assert.Equal(t, &evtPollVote{PollID: "3EB0C127D7BACC83D6A1"}, vote)
+}
+
+func TestBuildEventReaction(t *testing.T) {
+	newReaction := func(text string) *events.Message {
+		return &events.Message{Message: &waE2E.Message{ReactionMessage: &waE2E.ReactionMessage{
+			Key:  &waCommon.MessageKey{ID: proto.String("3EB0C127D7BACC83D6A1")},
+			Text: proto.String(text),
+		}}}
+	}
+
+	assert.Equal(t, evtReaction{ID: "3EB0C127D7BACC83D6A1", Message: "👍", Action: "add"}, buildEventReaction(newReaction("👍")))
+	assert.Equal(t, evtReaction{ID: "3EB0C127D7BACC83D6A1", Action: "remove"}, buildEventReaction(newReaction("")))
+	assert.Equal(t, evtReaction{}, buildEventReaction(&events.Message{Message: &waE2E.Message{Conversation: proto.String("hello")}}))
+}
@@ -2,57 +2,482 @@ package whatsapp
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"math/rand/v2"
 	"net/http"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/metrics"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
+	"golang.org/x/sync/errgroup"
 )
 
-// forwardToWebhook is a helper function to forward event to webhook url
-func forwardToWebhook(evt any) error {
-	logrus.Info("Forwarding event to webhook:", config.WhatsappWebhook)
+var webhookStatusPattern = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+
+const (
+	WebhookMediaModePath   = "path"
+	WebhookMediaModeBase64 = "base64"
+	WebhookMediaModeURL    = "url"
+	WebhookMediaModeLazy   = "lazy"
+)
+
+const (
+	WebhookSignatureModeBody      = "body"
+	WebhookSignatureModeTimestamp = "timestamp"
+)
+
+const (
+	WebhookSignatureAlgoSHA1   = "sha1"
+	WebhookSignatureAlgoSHA256 = "sha256"
+	WebhookSignatureAlgoSHA512 = "sha512"
+)
+
+var WebhookSignatureAlgos = []string{WebhookSignatureAlgoSHA1, WebhookSignatureAlgoSHA256, WebhookSignatureAlgoSHA512}
+
+const (
+	WebhookRetryJitterNone  = "none"
+	WebhookRetryJitterFull  = "full"
+	WebhookRetryJitterEqual = "equal"
+)
+
+var WebhookRetryJitters = []string{WebhookRetryJitterNone, WebhookRetryJitterFull, WebhookRetryJitterEqual}
+
+// webhookSignatureHashes are the hmac hashes of the signature algorithms
+var webhookSignatureHashes = map[string]func() hash.Hash{
+	WebhookSignatureAlgoSHA1:   sha1.New,
+	WebhookSignatureAlgoSHA256: sha256.New,
+	WebhookSignatureAlgoSHA512: sha512.New,
+}
+
+// webhookSignatureHeader carries the signature whatever the algorithm, receivers tell the algorithm by the prefix
+const webhookSignatureHeader = "X-Hub-Signature-256"
+
+// webhookMedia is the media sent to the webhook, base64, url and download path are only filled in their media mode
+type webhookMedia struct {
+	ExtractedMedia
+	Base64       string `json:"base64,omitempty"`
+	URL          string `json:"url,omitempty"`
+	DownloadPath string `json:"download_path,omitempty"`
+	FileLength   uint64 `json:"file_length,omitempty"`
+	// TooLarge is set when the media exceeds its download limit, only the metadata is sent
+	TooLarge bool `json:"too_large,omitempty"`
+	// Error is the reason the media couldn't be downloaded, only with config.WhatsappWebhookPartialMedia
+	Error string `json:"error,omitempty"`
+}
+
+// webhookReservedHeaders are set by submitWebhook and can't be overridden by config.WhatsappWebhookHeaders
+var webhookReservedHeaders = []string{"Content-Type", "Content-Encoding", webhookSignatureHeader, "X-Hub-Timestamp"}
+
+// WebhookEventTypes lists every event_type that can be forwarded to the webhook
+var WebhookEventTypes = []string{"message", "message_edit", "message_revoke", "receipt", "presence", "chat_presence", "group_participants", "group_info", "connection", "newsletter", "status", "batch", "raw"}
+
+// WebhookSchemaVersion is the version of the payload schema, sent as schema_version in every payload. It's bumped
+// when a field is removed or renamed, or changes its type or meaning. New fields and new event types are compatible
+// and don't bump it. A bump adds the downgrade to the previous version to webhookSchemaDowngrades.
+// Version 0 is the payload sent before schema_version existed
+const WebhookSchemaVersion = 1
+
+// webhookSchemaDowngrades turns a payload of the version into a payload of the previous version, so the consumers
+// pinned with config.WhatsappWebhookSchemaVersion keep receiving the schema they were built for
+var webhookSchemaDowngrades = map[int]func(payload map[string]any){
+	1: downgradeWebhookPayloadToV0,
+}
+
+// webhookMessageFieldsV1 are the fields version 1 added to the message payload
+var webhookMessageFieldsV1 = []string{"id", "chat_jid", "sender_jid", "is_group", "quoted", "mentioned_jid", "poll",
+	"poll_vote", "buttons_response", "list_response"}
+
+// downgradeWebhookPayloadToV0 removes what version 1 added: the session and event ids of every payload, the new
+// fields of a message, the reaction action and the media fields besides media_path, mime_type and caption. Version 0
+// had no reaction removal, so a removed reaction isn't sent. The event types added since are still sent, a version 0
+// consumer leaves them out with config.WhatsappWebhookEvents
+func downgradeWebhookPayloadToV0(payload map[string]any) {
+	delete(payload, "session_id")
+	delete(payload, "event_id")
+	if payload["event_type"] != "message" {
+		return
+	}
+
+	for _, field := range webhookMessageFieldsV1 {
+		delete(payload, field)
+	}
+	if reaction, ok := payload["reaction"].(evtReaction); ok {
+		if reaction.Message == "" {
+			delete(payload, "reaction")
+		} else {
+			payload["reaction"] = evtReaction{ID: reaction.ID, Message: reaction.Message}
+		}
+	}
+	for _, item := range webhookMediaTypes {
+		media, ok := payload[item].(webhookMedia)
+		// The base64, url and lazy media modes are opted into, their media is kept as it is
+		if !ok || media.Base64 != "" || media.URL != "" || media.DownloadPath != "" {
+			continue
+		}
+		payload[item] = media.ExtractedMedia
+	}
+}
+
+// apiLogout is the event of a logout requested through the api, whatsapp doesn't send an event for it
+type apiLogout struct{}
+
+// BatchSent is the event of a batch sent through the api, it carries the result of every recipient so a consumer
+// learns about the partial failures of a batch it didn't send itself
+type BatchSent struct {
+	Sent    int
+	Failed  int
+	Results []BatchSentResult
+}
+
+type BatchSentResult struct {
+	Phone     string `json:"phone"`
+	Success   bool   `json:"success"`
+	MessageID string `json:"message_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// EmitBatchSent forwards the results of a batch sent with the client to the webhook
+func EmitBatchSent(client *whatsmeow.Client, evt *BatchSent) {
+	if isEventForwardingEnabled() {
+		enqueueWebhookEvent(sessionIDOf(client), evt)
+	}
+}
+
+// forwardToWebhook is a helper function to forward event of a session to webhook url and to the /ws/events clients
+func forwardToWebhook(sessionID string, evt any) error {
+	eventType := webhookEventType(evt)
+	if eventType == "" {
+		return fmt.Errorf("unsupported event type: %T", evt)
+	}
+	// The event id is sent in the payloads and logged with them, so an event can be traced from whatsapp to the consumer
+	eventID := uuid.NewString()
+	logger := logrus.WithFields(logrus.Fields{"event_id": eventID, "event_type": eventType, "session_id": sessionID})
+	// A group info event is split into payloads of different event types, those are filtered one by one below
+	_, isGroupInfo := evt.(*events.GroupInfo)
+	if !isGroupInfo && !hasEventStreams() {
+		if !isWebhookEventAllowed(eventType) || len(webhookURLsForEvent(eventType)) == 0 {
+			return nil
+		}
+	}
 
 	var payload map[string]interface{}
+	var payloads []map[string]interface{}
+	var errs []error
 	var err error
 
 	switch e := evt.(type) {
 	case *events.Message:
-		payload, err = createPayload(e)
+		if isDuplicateMessage(sessionID, e) {
+			logger.Infof("Skipping message %s already forwarded", e.Info.ID)
+			metrics.MessagesDeduplicated.WithLabelValues(sessionID).Inc()
+			return nil
+		}
+		switch eventType {
+		case "message_edit":
+			payload, err = createMessageEditPayload(e)
+		case "message_revoke":
+			payload, err = createMessageRevokePayload(e)
+		case "newsletter":
+			payload, err = createNewsletterPayload(GetSessionClient(sessionID), e)
+		case "status":
+			payload, err = createStatusPayload(GetSessionClient(sessionID), e)
+		default:
+			payload, err = createPayload(GetSessionClient(sessionID), e)
+		}
 	case *events.Receipt:
 		payload, err = createReceiptPayload(e)
 	case *events.Presence:
 		payload, err = createPresencePayload(e)
+	case *events.ChatPresence:
+		payload, err = createChatPresencePayload(e)
+	case *events.Connected, *events.Disconnected, *events.LoggedOut, *apiLogout:
+		payload, err = createConnectionPayload(e)
+	case *BatchSent:
+		payload = createBatchPayload(e)
+	case *events.GroupInfo:
+		// A single group info event may carry several participant changes, each one is forwarded on its own
+		payloads = createGroupInfoPayload(e)
+		if infoPayload := createGroupSettingsPayload(e); infoPayload != nil {
+			payloads = append(payloads, infoPayload)
+		}
 	default:
-		return fmt.Errorf("unsupported event type: %T", evt)
+		if eventType != "raw" {
+			return fmt.Errorf("unsupported event type: %T", evt)
+		}
+		payload, err = createRawPayload(evt)
 	}
 
 	if err != nil {
-		return err
+		metrics.WebhooksFailed.WithLabelValues(eventType).Inc()
+		return fmt.Errorf("event %s: %w", eventID, err)
+	}
+	if payload != nil {
+		payloads = append(payloads, payload)
 	}
 
-	for _, url := range config.WhatsappWebhook {
-		if err = submitWebhook(payload, url); err != nil {
-			return err
+	// The streams get every payload before the webhooks, so they never wait for a retrying webhook.
+	// Their clients filter the event types on their own, config.WhatsappWebhookEvents only applies to webhooks
+	for _, payload := range payloads {
+		payload["session_id"] = sessionID
+		payload["event_id"] = eventID
+		downgradeWebhookPayload(payload, WebhookSchemaVersion, webhookSchemaVersion())
+		payloadType, _ := payload["event_type"].(string)
+		publishEvent(payloadType, sessionID, payload)
+	}
+
+	for _, payload := range payloads {
+		payloadType, _ := payload["event_type"].(string)
+		if !isWebhookEventAllowed(payloadType) {
+			continue
+		}
+
+		urls := webhookURLsForEvent(payloadType)
+		if len(urls) > 0 {
+			logger.Info("Forwarding event to webhook:", urls)
+		}
+		// A failing url doesn't hold back the other ones
+		for _, url := range urls {
+			if err = submitWebhook(payload, url); err != nil {
+				errs = append(errs, err)
+			}
 		}
 	}
+	if len(errs) > 0 {
+		return fmt.Errorf("event %s: %w", eventID, errors.Join(errs...))
+	}
 
-	logrus.Info("Event forwarded to webhook")
+	logger.Info("Event forwarded to webhook")
 	return nil
 }
 
-func createPayload(evt *events.Message) (map[string]interface{}, error) {
+// webhookSchemaVersion is the schema version of the payloads sent, config.WhatsappWebhookSchemaVersion or the latest
+func webhookSchemaVersion() int {
+	if config.WhatsappWebhookSchemaVersion < 0 {
+		return WebhookSchemaVersion
+	}
+	return config.WhatsappWebhookSchemaVersion
+}
+
+// downgradeWebhookPayload turns a payload built in the from version into the older to version, one version at a time.
+// A version 0 payload has no schema_version, like the payloads sent before it existed
+func downgradeWebhookPayload(payload map[string]any, from, to int) {
+	for version := from; version > to; version-- {
+		webhookSchemaDowngrades[version](payload)
+	}
+	if to == 0 {
+		delete(payload, "schema_version")
+		return
+	}
+	payload["schema_version"] = to
+}
+
+// webhookEventType maps the whatsapp event to the event_type name used in the payload and in config.WhatsappWebhookEvents
+func webhookEventType(evt any) string {
+	switch e := evt.(type) {
+	case *events.Message:
+		// Every post of a followed channel is a newsletter event, its edits included
+		if e.Info.Chat.Server == types.NewsletterServer {
+			return "newsletter"
+		}
+		// Edits and revokes are protocol messages pointing to an earlier message, they get their own event type.
+		// REVOKE is the zero value of the type, so a message without protocol message must not be read as one
+		if protocolMessage := e.Message.GetProtocolMessage(); protocolMessage != nil {
+			switch protocolMessage.GetType() {
+			case waE2E.ProtocolMessage_MESSAGE_EDIT:
+				return "message_edit"
+			case waE2E.ProtocolMessage_REVOKE:
+				return "message_revoke"
+			}
+		}
+		if e.Info.Chat == types.StatusBroadcastJID {
+			return "status"
+		}
+		return "message"
+	case *events.Receipt:
+		return "receipt"
+	case *events.Presence:
+		return "presence"
+	case *events.ChatPresence:
+		return "chat_presence"
+	case *events.GroupInfo:
+		return "group_participants"
+	case *events.Connected, *events.Disconnected, *events.LoggedOut, *apiLogout:
+		return "connection"
+	case *BatchSent:
+		return "batch"
+	// Never forwarded raw: login qr codes and pairing results hand the account over, history and app state
+	// syncs flood the webhook with the whole account state
+	case *events.QR, *events.PairSuccess, *events.PairError, *events.QRScannedWithoutMultidevice,
+		*events.HistorySync, *events.AppState, *events.AppStateSyncComplete,
+		*events.OfflineSyncPreview, *events.OfflineSyncCompleted:
+		return ""
+	default:
+		// The other events have no payload of their own, they are only forwarded as is when asked to
+		if config.WhatsappWebhookRawEvents {
+			return "raw"
+		}
+		return ""
+	}
+}
+
+// isWebhookEventAllowed checks the event type against config.WhatsappWebhookEvents, an empty list allows every event
+func isWebhookEventAllowed(eventType string) bool {
+	if len(config.WhatsappWebhookEvents) == 0 {
+		return true
+	}
+	for _, allowed := range config.WhatsappWebhookEvents {
+		if strings.TrimSpace(allowed) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// isWebhookEnabled reports whether any webhook url is configured, either globally, through routes or at runtime
+func isWebhookEnabled() bool {
+	return len(config.WhatsappWebhook) > 0 || len(config.WhatsappWebhookRoutes) > 0 || hasRuntimeWebhooks()
+}
+
+// isEventForwardingEnabled reports whether the events have to be turned into payloads, for a webhook or an event stream
+func isEventForwardingEnabled() bool {
+	return isWebhookEnabled() || hasEventStreams()
+}
+
+// parseWebhookRoutes parses config.WhatsappWebhookRoutes entries in the form of event_type=url
+func parseWebhookRoutes() (map[string][]string, error) {
+	routes := make(map[string][]string)
+	for _, route := range config.WhatsappWebhookRoutes {
+		eventType, url, found := strings.Cut(strings.TrimSpace(route), "=")
+		eventType = strings.TrimSpace(eventType)
+		url = strings.TrimSpace(url)
+		if !found || eventType == "" || url == "" {
+			return nil, fmt.Errorf("webhook route %q must be in the form of event_type=url", route)
+		}
+		if !slices.Contains(WebhookEventTypes, eventType) {
+			return nil, fmt.Errorf("webhook route %q uses unsupported event %q, available events: %s", route, eventType, strings.Join(WebhookEventTypes, ","))
+		}
+		routes[eventType] = append(routes[eventType], url)
+	}
+	return routes, nil
+}
+
+// webhookSecrets are the per url secrets of config.WhatsappWebhookSecrets, parsed once by ValidateWebhookConfig
+var webhookSecrets map[string]string
+
+// parseWebhookSecrets parses config.WhatsappWebhookSecrets entries in the form of url=secret. The secret starts at
+// the first "=" after the url, so it may contain "=" like the padding of base64
+func parseWebhookSecrets() (map[string]string, error) {
+	secrets := make(map[string]string)
+	for _, entry := range config.WhatsappWebhookSecrets {
+		index := webhookSecretIndex(entry)
+		if index < 0 {
+			return nil, fmt.Errorf("webhook secret %q must be in the form of url=secret", entry)
+		}
+		url := strings.TrimSpace(entry[:index])
+		secret := strings.TrimSpace(entry[index+1:])
+		if url == "" || secret == "" {
+			return nil, fmt.Errorf("webhook secret %q must be in the form of url=secret", entry)
+		}
+		// The piece of a secret cut at an unquoted comma doesn't start with a url, it must not pass silently
+		if parsed, err := neturl.Parse(url); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("webhook secret %q must start with an http or https url, quote an entry whose secret has a comma", entry)
+		}
+		if _, ok := secrets[url]; ok {
+			return nil, fmt.Errorf("webhook url %s has more than one secret", url)
+		}
+		secrets[url] = secret
+	}
+	return secrets, nil
+}
+
+// webhookSecretIndex returns the index of the "=" ending the url of a url=secret entry, -1 without one. The "=" of
+// the key=value pairs of a query string belong to the url
+func webhookSecretIndex(entry string) int {
+	index := strings.Index(entry, "=")
+	query := strings.Index(entry, "?")
+	if index < 0 || query < 0 || query > index {
+		return index
+	}
+
+	// index is the "=" of the first query parameter, the secret starts at the "=" following a value
+	for i := index + 1; ; {
+		next := strings.IndexAny(entry[i:], "&=")
+		if next < 0 {
+			return -1
+		}
+		i += next
+		if entry[i] == '=' {
+			return i
+		}
+		key := strings.Index(entry[i:], "=")
+		if key < 0 {
+			return -1
+		}
+		i += key + 1
+	}
+}
+
+// webhookSecretForURL returns the secret signing the webhooks of url, config.WhatsappWebhookSecret when the url
+// has no secret of its own
+func webhookSecretForURL(url string) string {
+	if secret, ok := webhookSecrets[url]; ok {
+		return secret
+	}
+	return config.WhatsappWebhookSecret
+}
+
+// webhookURLsForEvent resolves the target urls of an event type.
+// Urls in config.WhatsappWebhook receive every event, routed urls only receive their event type and
+// webhooks added at runtime receive the event types they subscribed to.
+func webhookURLsForEvent(eventType string) []string {
+	urls := slices.Clone(config.WhatsappWebhook)
+
+	routes, err := parseWebhookRoutes()
+	if err != nil {
+		logrus.Errorf("Failed to parse webhook routes: %v", err)
+	}
+	for _, url := range append(routes[eventType], runtimeWebhookURLs(eventType)...) {
+		if !slices.Contains(urls, url) {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+func createPayload(client *whatsmeow.Client, evt *events.Message) (map[string]interface{}, error) {
 	message := buildEventMessage(evt)
 	waReaction := buildEventReaction(evt)
 	forwarded := buildForwarded(evt)
 
 	body := make(map[string]interface{})
 	body["event_type"] = "message"
+	body["id"] = evt.Info.ID
+	body["chat_jid"] = evt.Info.Chat.String()
+	body["sender_jid"] = evt.Info.Sender.String()
+	body["is_group"] = evt.Info.Chat.Server == types.GroupServer
 
 	if from := evt.Info.SourceString(); from != "" {
 		body["from"] = from
@@ -63,7 +488,13 @@ func createPayload(evt *events.Message) (map[string]interface{}, error) {
 	if pushname := evt.Info.PushName; pushname != "" {
 		body["pushname"] = pushname
 	}
-	if waReaction.Message != "" {
+	if quoted := buildEventQuoted(evt); quoted != nil {
+		body["quoted"] = quoted
+	}
+	if mentionedJID := getMessageContextInfo(evt.Message).GetMentionedJID(); len(mentionedJID) > 0 {
+		body["mentioned_jid"] = mentionedJID
+	}
+	if waReaction.Action != "" {
 		body["reaction"] = waReaction
 	}
 	if evt.IsViewOnce {
@@ -76,41 +507,45 @@ func createPayload(evt *events.Message) (map[string]interface{}, error) {
 		body["timestamp"] = timestamp
 	}
 
-	if audioMedia := evt.Message.GetAudioMessage(); audioMedia != nil {
-		path, err := ExtractMedia(config.PathMedia, audioMedia)
-		if err != nil {
-			logrus.Errorf("Failed to download audio from %s: %v", evt.Info.SourceString(), err)
-			return nil, pkgError.WebhookError(fmt.Sprintf("Failed to download audio: %v", err))
-		}
-		body["audio"] = path
-	}
-
 	if contactMessage := evt.Message.GetContactMessage(); contactMessage != nil {
 		body["contact"] = contactMessage
 	}
 
-	if documentMedia := evt.Message.GetDocumentMessage(); documentMedia != nil {
-		path, err := ExtractMedia(config.PathMedia, documentMedia)
-		if err != nil {
-			logrus.Errorf("Failed to download document from %s: %v", evt.Info.SourceString(), err)
-			return nil, pkgError.WebhookError(fmt.Sprintf("Failed to download document: %v", err))
+	if poll := buildEventPoll(evt); poll != nil {
+		body["poll"] = poll
+	}
+
+	if pollVote := buildEventPollVote(client, evt); pollVote != nil {
+		body["poll_vote"] = pollVote
+	}
+
+	if buttonsResponse := evt.Message.GetButtonsResponseMessage(); buttonsResponse != nil {
+		body["buttons_response"] = evtButtonsResponse{
+			SelectedButtonID:    buttonsResponse.GetSelectedButtonID(),
+			SelectedDisplayText: buttonsResponse.GetSelectedDisplayText(),
 		}
-		body["document"] = path
 	}
 
-	if imageMedia := evt.Message.GetImageMessage(); imageMedia != nil {
-		path, err := ExtractMedia(config.PathMedia, imageMedia)
-		if err != nil {
-			logrus.Errorf("Failed to download image from %s: %v", evt.Info.SourceString(), err)
-			return nil, pkgError.WebhookError(fmt.Sprintf("Failed to download image: %v", err))
+	// A quick reply of a template message is answered like the one of a buttons message
+	if templateReply := evt.Message.GetTemplateButtonReplyMessage(); templateReply != nil {
+		body["buttons_response"] = evtButtonsResponse{
+			SelectedButtonID:    templateReply.GetSelectedID(),
+			SelectedDisplayText: templateReply.GetSelectedDisplayText(),
 		}
-		body["image"] = path
 	}
 
 	if listMessage := evt.Message.GetListMessage(); listMessage != nil {
 		body["list"] = listMessage
 	}
 
+	if listResponse := evt.Message.GetListResponseMessage(); listResponse != nil {
+		body["list_response"] = evtListResponse{
+			SelectedRowID: listResponse.GetSingleSelectReply().GetSelectedRowID(),
+			Title:         listResponse.GetTitle(),
+			Description:   listResponse.GetDescription(),
+		}
+	}
+
 	if liveLocationMessage := evt.Message.GetLiveLocationMessage(); liveLocationMessage != nil {
 		body["live_location"] = liveLocationMessage
 	}
@@ -123,25 +558,267 @@ func createPayload(evt *events.Message) (map[string]interface{}, error) {
 		body["order"] = orderMessage
 	}
 
-	if stickerMedia := evt.Message.GetStickerMessage(); stickerMedia != nil {
-		path, err := ExtractMedia(config.PathMedia, stickerMedia)
-		if err != nil {
-			logrus.Errorf("Failed to download sticker from %s: %v", evt.Info.SourceString(), err)
-			return nil, pkgError.WebhookError(fmt.Sprintf("Failed to download sticker: %v", err))
+	medias, err := extractWebhookMedias(client, evt, webhookMediaItems(evt.Message))
+	if err != nil {
+		return nil, err
+	}
+	for mediaType, media := range medias {
+		body[mediaType] = media
+	}
+
+	return body, nil
+}
+
+// webhookMediaItem is a media of a message, the media type is also its key in the payload
+type webhookMediaItem struct {
+	mediaType string
+	file      whatsmeow.DownloadableMessage
+}
+
+// webhookMediaTypes are the payload keys of the media of a message
+var webhookMediaTypes = []string{"audio", "document", "image", "sticker", "video"}
+
+func webhookMediaItems(msg *waE2E.Message) []webhookMediaItem {
+	var items []webhookMediaItem
+	if audio := msg.GetAudioMessage(); audio != nil {
+		items = append(items, webhookMediaItem{"audio", audio})
+	}
+	if document := msg.GetDocumentMessage(); document != nil {
+		items = append(items, webhookMediaItem{"document", document})
+	}
+	if image := msg.GetImageMessage(); image != nil {
+		items = append(items, webhookMediaItem{"image", image})
+	}
+	if sticker := msg.GetStickerMessage(); sticker != nil {
+		items = append(items, webhookMediaItem{"sticker", sticker})
+	}
+	if video := msg.GetVideoMessage(); video != nil {
+		items = append(items, webhookMediaItem{"video", video})
+	}
+	return items
+}
+
+// extractWebhookMedias downloads the media of a message concurrently, at most config.WhatsappWebhookMediaConcurrency
+// at once. A failed download fails the whole payload and removes the media downloaded for it, unless
+// config.WhatsappWebhookPartialMedia is set and the media carries the error instead
+func extractWebhookMedias(client *whatsmeow.Client, evt *events.Message, items []webhookMediaItem) (map[string]webhookMedia, error) {
+	medias := make([]webhookMedia, len(items))
+
+	group, ctx := errgroup.WithContext(context.Background())
+	group.SetLimit(max(config.WhatsappWebhookMediaConcurrency, 1))
+	for i, item := range items {
+		group.Go(func() error {
+			// A failed download skips the ones that didn't start yet
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			media, err := extractWebhookMedia(client, evt, item.mediaType, item.file)
+			if err == nil {
+				medias[i] = media
+				return nil
+			}
+
+			// The file of a media failing after its download isn't sent either
+			if media.MediaPath != "" {
+				_ = os.Remove(media.MediaPath)
+			}
+			if !config.WhatsappWebhookPartialMedia {
+				return err
+			}
+			medias[i] = webhookMedia{
+				ExtractedMedia: extractMediaMetadata(item.file),
+				FileLength:     mediaFileLength(item.file),
+				Error:          err.Error(),
+			}
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		for _, media := range medias {
+			if media.MediaPath != "" {
+				_ = os.Remove(media.MediaPath)
+			}
 		}
-		body["sticker"] = path
+		return nil, err
+	}
+
+	result := make(map[string]webhookMedia, len(items))
+	for i, item := range items {
+		result[item.mediaType] = medias[i]
+	}
+	return result, nil
+}
+
+// createMessageEditPayload builds the payload of a message edited by its sender, target_message_id is the edited message
+func createMessageEditPayload(evt *events.Message) (map[string]any, error) {
+	protocolMessage := evt.Message.GetProtocolMessage()
+
+	body := createProtocolMessageBody(evt, "message_edit")
+	body["target_message_id"] = protocolMessage.GetKey().GetID()
+	body["text"] = editedMessageText(protocolMessage.GetEditedMessage())
+	return body, nil
+}
+
+// createMessageRevokePayload builds the payload of a message deleted for everyone, target_message_id is the deleted message
+func createMessageRevokePayload(evt *events.Message) (map[string]any, error) {
+	body := createProtocolMessageBody(evt, "message_revoke")
+	body["target_message_id"] = evt.Message.GetProtocolMessage().GetKey().GetID()
+	return body, nil
+}
+
+// createNewsletterPayload builds the payload of a channel post, it's the one of a message with the channel details.
+// whatsapp sends the new content of an edited post under the id of the post, edited_at tells it apart
+func createNewsletterPayload(client *whatsmeow.Client, evt *events.Message) (map[string]any, error) {
+	body, err := createPayload(client, evt)
+	if err != nil {
+		return nil, err
+	}
+	body["event_type"] = "newsletter"
+	body["newsletter_jid"] = evt.Info.Chat.String()
+	if evt.NewsletterMeta != nil && !evt.NewsletterMeta.EditTS.IsZero() {
+		body["edited_at"] = evt.NewsletterMeta.EditTS.Format(time.RFC3339)
+	}
+	return body, nil
+}
+
+// createStatusPayload builds the payload of a status posted by a contact, it's the one of a message with the time the
+// status expires and the background color of a text status
+func createStatusPayload(client *whatsmeow.Client, evt *events.Message) (map[string]any, error) {
+	body, err := createPayload(client, evt)
+	if err != nil {
+		return nil, err
+	}
+	body["event_type"] = "status"
+	body["expires_at"] = evt.Info.Timestamp.Add(StatusLifetime).Format(time.RFC3339)
+	if color := statusColorHex(evt.Message.GetExtendedTextMessage().GetBackgroundArgb()); color != "" {
+		body["background_color"] = color
+	}
+	return body, nil
+}
+
+func createProtocolMessageBody(evt *events.Message, eventType string) map[string]any {
+	body := make(map[string]any)
+	body["event_type"] = eventType
+	body["id"] = evt.Info.ID
+	body["chat_jid"] = evt.Info.Chat.String()
+	body["sender_jid"] = evt.Info.Sender.String()
+	body["is_group"] = evt.Info.Chat.Server == types.GroupServer
+	body["timestamp"] = evt.Info.Timestamp.Format(time.RFC3339)
+
+	if from := evt.Info.SourceString(); from != "" {
+		body["from"] = from
+	}
+	if pushname := evt.Info.PushName; pushname != "" {
+		body["pushname"] = pushname
+	}
+	return body
+}
+
+// editedMessageText returns the new text of an edit, the caption when a media message was edited
+func editedMessageText(msg *waE2E.Message) string {
+	switch {
+	case msg.GetConversation() != "":
+		return msg.GetConversation()
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetText()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetCaption()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetCaption()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetCaption()
+	default:
+		return ""
+	}
+}
+
+// autoDownloadMedia tells whether the media type is downloaded for the webhook, media of the other types are
+// fetched on demand as in lazy media mode
+func autoDownloadMedia(mediaType string) bool {
+	switch mediaType {
+	case "audio":
+		return config.WhatsappAutoDownloadAudio
+	case "document":
+		return config.WhatsappAutoDownloadDocument
+	case "image":
+		return config.WhatsappAutoDownloadImage
+	case "sticker":
+		return config.WhatsappAutoDownloadSticker
+	case "video":
+		return config.WhatsappAutoDownloadVideo
+	}
+	return true
+}
+
+// extractWebhookMedia downloads the media and shapes it according to config.WhatsappWebhookMediaMode
+func extractWebhookMedia(client *whatsmeow.Client, evt *events.Message, mediaType string, mediaFile whatsmeow.DownloadableMessage) (webhookMedia, error) {
+	if config.WhatsappWebhookMediaMode == WebhookMediaModeLazy || !autoDownloadMedia(mediaType) {
+		// Only the metadata, the consumer downloads the media with GET /message/:id/media when needed
+		return webhookMedia{
+			ExtractedMedia: extractMediaMetadata(mediaFile),
+			DownloadPath:   MediaDownloadPath(client, evt.Info.ID),
+			FileLength:     mediaFileLength(mediaFile),
+		}, nil
+	}
+
+	extracted, err := ExtractMedia(client, config.PathMedia, mediaFile)
+	var tooLarge *MediaTooLargeError
+	if errors.As(err, &tooLarge) {
+		return webhookMedia{ExtractedMedia: extracted, FileLength: tooLarge.Size, TooLarge: true}, nil
+	}
+	if err != nil {
+		logrus.Errorf("Failed to download %s from %s: %v", mediaType, evt.Info.SourceString(), err)
+		return webhookMedia{}, pkgError.WebhookError(fmt.Sprintf("Failed to download %s: %v", mediaType, err))
+	}
+	return buildWebhookMedia(mediaType, extracted)
+}
+
+// buildWebhookMedia adds the base64 content or the url of a downloaded media based on the media mode
+func buildWebhookMedia(mediaType string, extracted ExtractedMedia) (webhookMedia, error) {
+	media := webhookMedia{ExtractedMedia: extracted}
+	if extracted.MediaPath == "" {
+		return media, nil
 	}
 
-	if videoMedia := evt.Message.GetVideoMessage(); videoMedia != nil {
-		path, err := ExtractMedia(config.PathMedia, videoMedia)
+	switch config.WhatsappWebhookMediaMode {
+	case WebhookMediaModeBase64:
+		info, err := os.Stat(extracted.MediaPath)
+		if err != nil {
+			return media, pkgError.WebhookError(fmt.Sprintf("Failed to read %s: %v", mediaType, err))
+		}
+		if info.Size() > config.WhatsappWebhookMediaMaxBase64Size {
+			logrus.Warnf("Webhook %s is %d bytes which exceeds the base64 limit of %d bytes, sending the path instead",
+				mediaType, info.Size(), config.WhatsappWebhookMediaMaxBase64Size)
+			return media, nil
+		}
+		data, err := os.ReadFile(extracted.MediaPath)
+		if err != nil {
+			return media, pkgError.WebhookError(fmt.Sprintf("Failed to read %s: %v", mediaType, err))
+		}
+		media.Base64 = base64.StdEncoding.EncodeToString(data)
+	case WebhookMediaModeURL:
+		mediaURL, err := SignMediaURL(extracted.MediaPath)
 		if err != nil {
-			logrus.Errorf("Failed to download video from %s: %v", evt.Info.SourceString(), err)
-			return nil, pkgError.WebhookError(fmt.Sprintf("Failed to download video: %v", err))
+			return media, pkgError.WebhookError(fmt.Sprintf("error when create media url %v", err))
 		}
-		body["video"] = path
+		media.URL = mediaURL
 	}
+	return media, nil
+}
 
-	return body, nil
+// createRawPayload forwards an event without a payload of its own as the json of the whatsmeow event, type is its
+// go type like events.CallOffer
+func createRawPayload(evt any) (map[string]any, error) {
+	event, err := json.Marshal(evt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %T event: %w", evt, err)
+	}
+	return map[string]any{
+		"event_type": "raw",
+		"type":       strings.TrimPrefix(fmt.Sprintf("%T", evt), "*"),
+		"event":      json.RawMessage(event),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	}, nil
 }
 
 func createReceiptPayload(evt *events.Receipt) (map[string]any, error) {
@@ -177,41 +854,447 @@ func createPresencePayload(evt *events.Presence) (map[string]any, error) {
 	return body, nil
 }
 
+// createChatPresencePayload reports a participant typing in a chat, whatsapp tells recording apart from typing with
+// the audio media of a composing state
+func createChatPresencePayload(evt *events.ChatPresence) (map[string]any, error) {
+	body := make(map[string]any)
+	body["event_type"] = "chat_presence"
+	body["chat_jid"] = evt.Chat.String()
+	body["from"] = evt.Sender.String()
+	body["is_group"] = evt.IsGroup
+	body["timestamp"] = time.Now().Format(time.RFC3339)
+
+	switch {
+	case evt.State == types.ChatPresencePaused:
+		body["state"] = "paused"
+	case evt.Media == types.ChatPresenceMediaAudio:
+		body["state"] = "recording"
+	default:
+		body["state"] = "composing"
+	}
+
+	return body, nil
+}
+
+// createGroupInfoPayload returns one payload per participant action (add, remove, promote, demote) of the event
+func createGroupInfoPayload(evt *events.GroupInfo) []map[string]any {
+	var payloads []map[string]any
+
+	for _, change := range []struct {
+		action       string
+		participants []types.JID
+	}{
+		{"add", evt.Join},
+		{"remove", evt.Leave},
+		{"promote", evt.Promote},
+		{"demote", evt.Demote},
+	} {
+		if len(change.participants) == 0 {
+			continue
+		}
+
+		participants := make([]string, 0, len(change.participants))
+		for _, participant := range change.participants {
+			participants = append(participants, participant.String())
+		}
+
+		body := make(map[string]any)
+		body["event_type"] = "group_participants"
+		body["group_jid"] = evt.JID.String()
+		body["action"] = change.action
+		body["participants"] = participants
+		body["timestamp"] = evt.Timestamp.Format(time.RFC3339)
+		if evt.Sender != nil {
+			body["actor"] = evt.Sender.String()
+		}
+		if change.action == "add" && evt.JoinReason != "" {
+			body["join_reason"] = evt.JoinReason
+		}
+		payloads = append(payloads, body)
+	}
+
+	return payloads
+}
+
+// createGroupSettingsPayload returns a group_info payload for subject, description, announce or locked changes,
+// nil when the event only carries participant changes
+func createGroupSettingsPayload(evt *events.GroupInfo) map[string]any {
+	body := make(map[string]any)
+	if evt.Name != nil {
+		body["subject"] = evt.Name.Name
+	}
+	if evt.Topic != nil {
+		body["description"] = evt.Topic.Topic
+	}
+	if evt.Announce != nil {
+		body["announce"] = evt.Announce.IsAnnounce
+	}
+	if evt.Locked != nil {
+		body["locked"] = evt.Locked.IsLocked
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	body["event_type"] = "group_info"
+	body["group_jid"] = evt.JID.String()
+	body["timestamp"] = evt.Timestamp.Format(time.RFC3339)
+	if evt.Sender != nil {
+		body["actor"] = evt.Sender.String()
+	}
+	return body
+}
+
+// createConnectionPayload reports the session lifecycle: connected, disconnected or logged_out
+func createConnectionPayload(evt any) (map[string]any, error) {
+	body := make(map[string]any)
+	body["event_type"] = "connection"
+	body["timestamp"] = time.Now().Format(time.RFC3339)
+
+	switch e := evt.(type) {
+	case *events.Connected:
+		body["status"] = "connected"
+	case *events.Disconnected:
+		body["status"] = "disconnected"
+	case *events.LoggedOut:
+		body["status"] = "logged_out"
+		// The reason code is only sent when the logout happened while connecting
+		if e.OnConnect {
+			body["reason"] = e.Reason.String()
+		}
+	case *apiLogout:
+		body["status"] = "logged_out"
+		body["reason"] = "logout"
+	default:
+		return nil, fmt.Errorf("unsupported connection event type: %T", evt)
+	}
+
+	return body, nil
+}
+
+// createBatchPayload reports how many recipients of a batch were sent and the result of each one
+func createBatchPayload(evt *BatchSent) map[string]any {
+	return map[string]any{
+		"event_type": "batch",
+		"timestamp":  time.Now().Format(time.RFC3339),
+		"sent":       evt.Sent,
+		"failed":     evt.Failed,
+		"results":    evt.Results,
+	}
+}
+
+// submitWebhook delivers the payload to url unless its circuit is open, a probe of a half open circuit is
+// attempted once without retries
 func submitWebhook(payload map[string]interface{}, url string) error {
-	client := &http.Client{Timeout: 10 * time.Second}
+	allowed, probe := allowWebhook(url)
+	if !allowed {
+		eventType, _ := payload["event_type"].(string)
+		metrics.WebhookCircuitSkipped.WithLabelValues(webhookCircuitLabel(url)).Inc()
+		logrus.WithFields(logrus.Fields{"event_id": payload["event_id"], "event_type": eventType}).Warnf("Skipping webhook %s, its circuit is open", url)
+		return failWebhook(payload, url, pkgError.WebhookError(fmt.Sprintf("webhook %s is skipped while its circuit is open", url)))
+	}
+
+	maxAttempts := config.WhatsappWebhookMaxRetries + 1
+	if probe {
+		maxAttempts = 1
+	}
+	err := deliverWebhook(payload, url, maxAttempts)
+	recordWebhookResult(url, probe, err)
+	return err
+}
+
+func deliverWebhook(payload map[string]interface{}, url string, maxAttempts int) error {
+	client := getWebhookClient()
+	eventType, _ := payload["event_type"].(string)
+	logger := logrus.WithFields(logrus.Fields{"event_id": payload["event_id"], "event_type": eventType})
 
 	postBody, err := json.Marshal(payload)
 	if err != nil {
 		return pkgError.WebhookError(fmt.Sprintf("Failed to marshal body: %v", err))
 	}
+	// The signature stays computed over the json, the receiver verifies it after decompressing the body
+	requestBody := postBody
+	if config.WhatsappWebhookCompress {
+		if requestBody, err = gzipWebhookBody(postBody); err != nil {
+			return pkgError.WebhookError(fmt.Sprintf("Failed to compress body: %v", err))
+		}
+	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(postBody))
-	if err != nil {
-		return pkgError.WebhookError(fmt.Sprintf("error when create http object %v", err))
+	secret := webhookSecretForURL(url)
+	var attempt int
+
+	for attempt = 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			metrics.WebhooksRetried.WithLabelValues(eventType).Inc()
+		}
+		// Every attempt is signed with a fresh timestamp so retries stay inside the consumer verification window
+		var req *http.Request
+		req, err = newWebhookRequest(context.Background(), url, postBody, requestBody, secret)
+		if err != nil {
+			return err
+		}
+
+		var resp *http.Response
+		startedAt := time.Now()
+		resp, err = client.Do(req)
+		retryAfter := time.Duration(0)
+		if err != nil {
+			metrics.WebhookDuration.WithLabelValues("error").Observe(time.Since(startedAt).Seconds())
+		} else {
+			statusCode := resp.StatusCode
+			metrics.WebhookDuration.WithLabelValues(strconv.Itoa(statusCode)).Observe(time.Since(startedAt).Seconds())
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+
+			if statusCode >= 200 && statusCode < 300 {
+				logger.Infof("Successfully submitted webhook on attempt %d", attempt+1)
+				metrics.WebhooksSent.WithLabelValues(eventType).Inc()
+				return nil
+			}
+
+			err = fmt.Errorf("webhook responded with status %d", statusCode)
+			if !isRetryableWebhookStatus(statusCode) {
+				return failWebhook(payload, url, pkgError.WebhookError(fmt.Sprintf("error when submit webhook on attempt %d: %v", attempt+1, err)))
+			}
+		}
+		logger.Warnf("Attempt %d to submit webhook failed: %v", attempt+1, err)
+		if attempt < maxAttempts-1 {
+			delay := webhookBackoffDelay(attempt)
+			if retryAfter > 0 {
+				delay = min(retryAfter, config.WhatsappWebhookMaxDelay)
+			}
+			time.Sleep(delay)
+		}
 	}
 
-	secretKey := []byte(config.WhatsappWebhookSecret)
-	signature, err := getMessageDigestOrSignature(postBody, secretKey)
+	return failWebhook(payload, url, pkgError.WebhookError(fmt.Sprintf("error when submit webhook after %d attempts: %v", attempt, err)))
+}
+
+// newWebhookRequest builds the signed request posting requestBody to url, postBody is the json the signature is
+// computed over
+func newWebhookRequest(ctx context.Context, url string, postBody, requestBody []byte, secret string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(requestBody))
 	if err != nil {
-		return pkgError.WebhookError(fmt.Sprintf("error when create signature %v", err))
+		return nil, pkgError.WebhookError(fmt.Sprintf("error when create http object %v", err))
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature, err := signWebhookBody(postBody, timestamp, secret)
+	if err != nil {
+		return nil, pkgError.WebhookError(fmt.Sprintf("error when create signature %v", err))
+	}
+	// Custom headers are applied first so they can never replace the content type or the signature headers
+	for name, value := range config.WhatsappWebhookHeaders {
+		req.Header.Set(name, value)
 	}
-
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Hub-Signature-256", fmt.Sprintf("sha256=%s", signature))
+	if config.WhatsappWebhookCompress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	req.Header.Set("X-Hub-Timestamp", timestamp)
+	algo := config.WhatsappWebhookSignatureAlgo
+	req.Header.Set(webhookSignatureHeader, fmt.Sprintf("%s=%s", algo, signature))
+	return req, nil
+}
 
-	var attempt int
-	var maxAttempts = 5
-	var sleepDuration = 1 * time.Second
+// failWebhook records the permanently failed webhook in the dead letter file and returns the original error
+func failWebhook(payload map[string]any, url string, err error) error {
+	eventType, _ := payload["event_type"].(string)
+	metrics.WebhooksFailed.WithLabelValues(eventType).Inc()
+	if errDeadLetter := writeDeadLetter(payload, url, err); errDeadLetter != nil {
+		logrus.WithField("event_id", payload["event_id"]).Errorf("Failed to write webhook dead letter: %v", errDeadLetter)
+	}
+	return err
+}
 
-	for attempt = 0; attempt < maxAttempts; attempt++ {
-		if _, err = client.Do(req); err == nil {
-			logrus.Infof("Successfully submitted webhook on attempt %d", attempt+1)
-			return nil
+// isRetryableWebhookStatus checks the status code against config.WhatsappWebhookRetryOnStatus,
+// entries can be an exact code (429) or a class of codes (5xx)
+func isRetryableWebhookStatus(statusCode int) bool {
+	code := strconv.Itoa(statusCode)
+	for _, pattern := range config.WhatsappWebhookRetryOnStatus {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == code {
+			return true
+		}
+		if len(pattern) == 3 && strings.HasSuffix(pattern, "xx") && pattern[0] == code[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter reads the Retry-After header which can be either delay seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
 		}
-		logrus.Warnf("Attempt %d to submit webhook failed: %v", attempt+1, err)
-		time.Sleep(sleepDuration)
-		sleepDuration *= 2
 	}
+	return 0
+}
+
+// signWebhookBody signs the body, or timestamp + "." + body when config.WhatsappWebhookSignatureMode is timestamp,
+// with the secret of the url and the hmac of config.WhatsappWebhookSignatureAlgo
+func signWebhookBody(postBody []byte, timestamp, secret string) (string, error) {
+	signedContent := postBody
+	if config.WhatsappWebhookSignatureMode == WebhookSignatureModeTimestamp {
+		signedContent = append([]byte(timestamp+"."), postBody...)
+	}
+	newHash, ok := webhookSignatureHashes[config.WhatsappWebhookSignatureAlgo]
+	if !ok {
+		return "", fmt.Errorf("webhook signature algorithm %q is not supported", config.WhatsappWebhookSignatureAlgo)
+	}
+	return getHMACDigest(newHash, signedContent, []byte(secret))
+}
+
+func gzipWebhookBody(body []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
 
-	return pkgError.WebhookError(fmt.Sprintf("error when submit webhook after %d attempts: %v", attempt, err))
+// webhookBackoffDelay returns the delay before the next retry, doubling the base delay
+// on every attempt and never exceeding the configured max delay. The delay is then randomized with
+// config.WhatsappWebhookRetryJitter, so webhooks failing together don't retry in lockstep
+func webhookBackoffDelay(attempt int) time.Duration {
+	delay := config.WhatsappWebhookRetryBaseDelay
+	for i := 0; i < attempt && delay < config.WhatsappWebhookMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > config.WhatsappWebhookMaxDelay {
+		delay = config.WhatsappWebhookMaxDelay
+	}
+	return jitterWebhookDelay(delay)
+}
+
+// jitterWebhookDelay picks a delay between 0 and delay with full jitter, or between half of it and delay with
+// equal jitter
+func jitterWebhookDelay(delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return delay
+	}
+	switch config.WhatsappWebhookRetryJitter {
+	case WebhookRetryJitterFull:
+		return rand.N(delay + 1)
+	case WebhookRetryJitterEqual:
+		return delay/2 + rand.N(delay-delay/2+1)
+	}
+	return delay
+}
+
+// ValidateWebhookConfig makes sure the webhook settings are usable before the app starts
+func ValidateWebhookConfig() error {
+	if config.WhatsappWebhookQueueSize <= 0 {
+		return fmt.Errorf("webhook queue size must be greater than zero, got %d", config.WhatsappWebhookQueueSize)
+	}
+	if config.WhatsappWebhookWorkers <= 0 {
+		return fmt.Errorf("webhook workers must be greater than zero, got %d", config.WhatsappWebhookWorkers)
+	}
+	if config.WhatsappWebhookTimeout <= 0 {
+		return fmt.Errorf("webhook timeout must be greater than zero, got %s", config.WhatsappWebhookTimeout)
+	}
+	if config.WhatsappWebhookMaxRetries < 0 {
+		return fmt.Errorf("webhook max retries must be zero or greater, got %d", config.WhatsappWebhookMaxRetries)
+	}
+	if config.WhatsappWebhookRetryBaseDelay <= 0 {
+		return fmt.Errorf("webhook retry base delay must be greater than zero, got %s", config.WhatsappWebhookRetryBaseDelay)
+	}
+	if config.WhatsappWebhookMaxDelay < config.WhatsappWebhookRetryBaseDelay {
+		return fmt.Errorf("webhook max delay (%s) must not be lower than the retry base delay (%s)", config.WhatsappWebhookMaxDelay, config.WhatsappWebhookRetryBaseDelay)
+	}
+	if config.WhatsappWebhookSchemaVersion < -1 || config.WhatsappWebhookSchemaVersion > WebhookSchemaVersion {
+		return fmt.Errorf("webhook schema version must be between 0 and %d, or -1 for the latest, got %d",
+			WebhookSchemaVersion, config.WhatsappWebhookSchemaVersion)
+	}
+	if !slices.Contains(WebhookRetryJitters, config.WhatsappWebhookRetryJitter) {
+		return fmt.Errorf("webhook retry jitter %q is not supported, available jitters: %s",
+			config.WhatsappWebhookRetryJitter, strings.Join(WebhookRetryJitters, ","))
+	}
+	if _, err := webhookTLSConfig(); err != nil {
+		return err
+	}
+	if config.WhatsappWebhookCircuitThreshold < 0 {
+		return fmt.Errorf("webhook circuit threshold must be zero or greater, got %d", config.WhatsappWebhookCircuitThreshold)
+	}
+	if config.WhatsappWebhookCircuitThreshold > 0 && config.WhatsappWebhookCircuitCooldown <= 0 {
+		return fmt.Errorf("webhook circuit cooldown must be greater than zero, got %s", config.WhatsappWebhookCircuitCooldown)
+	}
+	for _, eventType := range config.WhatsappWebhookEvents {
+		if !slices.Contains(WebhookEventTypes, strings.TrimSpace(eventType)) {
+			return fmt.Errorf("webhook event %q is not supported, available events: %s", eventType, strings.Join(WebhookEventTypes, ","))
+		}
+	}
+	for name := range config.WhatsappWebhookHeaders {
+		// An entry without name, e.g. "=value" or a trailing comma in the env, would send a header net/http rejects
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("webhook header name can't be empty")
+		}
+		if slices.Contains(webhookReservedHeaders, http.CanonicalHeaderKey(strings.TrimSpace(name))) {
+			return fmt.Errorf("webhook header %q can't be overridden", name)
+		}
+	}
+	if config.WhatsappWebhookSignatureMode != WebhookSignatureModeBody && config.WhatsappWebhookSignatureMode != WebhookSignatureModeTimestamp {
+		return fmt.Errorf("webhook signature mode %q is not supported, available modes: %s,%s",
+			config.WhatsappWebhookSignatureMode, WebhookSignatureModeBody, WebhookSignatureModeTimestamp)
+	}
+	if !slices.Contains(WebhookSignatureAlgos, config.WhatsappWebhookSignatureAlgo) {
+		return fmt.Errorf("webhook signature algorithm %q is not supported, available algorithms: %s",
+			config.WhatsappWebhookSignatureAlgo, strings.Join(WebhookSignatureAlgos, ","))
+	}
+	switch config.WhatsappWebhookMediaMode {
+	case WebhookMediaModePath:
+	case WebhookMediaModeBase64:
+		if config.WhatsappWebhookMediaMaxBase64Size <= 0 {
+			return fmt.Errorf("webhook media max base64 size must be greater than 0")
+		}
+	case WebhookMediaModeLazy:
+		if config.WhatsappMediaCacheTTL <= 0 || config.WhatsappMediaCacheSize <= 0 {
+			return fmt.Errorf("media cache ttl and size must be greater than 0 when webhook media mode is %s", WebhookMediaModeLazy)
+		}
+	case WebhookMediaModeURL:
+		if config.AppBaseURL == "" {
+			return fmt.Errorf("base url is required when webhook media mode is %s", WebhookMediaModeURL)
+		}
+		if config.WhatsappWebhookMediaURLExpiry <= 0 {
+			return fmt.Errorf("webhook media url expiry must be greater than 0")
+		}
+		// The webhook secret has a public default, a url signed with it could be forged for any leaked media id
+		if config.WhatsappWebhookMediaURLSecret == "" {
+			return fmt.Errorf("webhook media url secret is required when webhook media mode is %s", WebhookMediaModeURL)
+		}
+	default:
+		return fmt.Errorf("webhook media mode %q is not supported, available modes: %s,%s,%s,%s",
+			config.WhatsappWebhookMediaMode, WebhookMediaModePath, WebhookMediaModeBase64, WebhookMediaModeURL, WebhookMediaModeLazy)
+	}
+	onDemand := !config.WhatsappAutoDownloadAudio || !config.WhatsappAutoDownloadDocument || !config.WhatsappAutoDownloadImage ||
+		!config.WhatsappAutoDownloadSticker || !config.WhatsappAutoDownloadVideo
+	if onDemand && (config.WhatsappMediaCacheTTL <= 0 || config.WhatsappMediaCacheSize <= 0) {
+		return fmt.Errorf("media cache ttl and size must be greater than 0 when a media type isn't auto downloaded")
+	}
+	if _, err := parseWebhookRoutes(); err != nil {
+		return err
+	}
+	secrets, err := parseWebhookSecrets()
+	if err != nil {
+		return err
+	}
+	webhookSecrets = secrets
+	for _, status := range config.WhatsappWebhookRetryOnStatus {
+		if !webhookStatusPattern.MatchString(strings.ToLower(strings.TrimSpace(status))) {
+			return fmt.Errorf("webhook retry on status %q is not valid, use a status code (429) or a class of codes (5xx)", status)
+		}
+	}
+	return nil
 }
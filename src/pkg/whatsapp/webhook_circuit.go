@@ -0,0 +1,104 @@
+package whatsapp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/metrics"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	webhookCircuitClosed   = 0
+	webhookCircuitOpen     = 1
+	webhookCircuitHalfOpen = 2
+)
+
+// webhookCircuit counts the consecutive failed deliveries to a webhook url. Once open the url is skipped until the
+// cooldown passed, then a single probe delivery decides whether it closes again
+type webhookCircuit struct {
+	state    int
+	failures int
+	openedAt time.Time
+}
+
+var (
+	webhookCircuits   = make(map[string]*webhookCircuit)
+	webhookCircuitsMu sync.Mutex
+)
+
+func webhookCircuitLabel(url string) string {
+	return utils.RedactURL(url)
+}
+
+// allowWebhook tells whether a delivery to url may be attempted, probe is true for the single delivery let through
+// a half open circuit
+func allowWebhook(url string) (allowed bool, probe bool) {
+	if config.WhatsappWebhookCircuitThreshold <= 0 {
+		return true, false
+	}
+
+	webhookCircuitsMu.Lock()
+	defer webhookCircuitsMu.Unlock()
+
+	circuit, ok := webhookCircuits[url]
+	if !ok {
+		return true, false
+	}
+	switch circuit.state {
+	case webhookCircuitOpen:
+		if time.Since(circuit.openedAt) < config.WhatsappWebhookCircuitCooldown {
+			return false, false
+		}
+		circuit.state = webhookCircuitHalfOpen
+		metrics.WebhookCircuitState.WithLabelValues(webhookCircuitLabel(url)).Set(webhookCircuitHalfOpen)
+		return true, true
+	case webhookCircuitHalfOpen:
+		// A probe is in flight
+		return false, false
+	default:
+		return true, false
+	}
+}
+
+// recordWebhookResult closes the circuit of url on a delivered webhook and opens it once the failures reach
+// config.WhatsappWebhookCircuitThreshold, a failed probe opens it again right away
+func recordWebhookResult(url string, probe bool, err error) {
+	if config.WhatsappWebhookCircuitThreshold <= 0 {
+		return
+	}
+
+	webhookCircuitsMu.Lock()
+	defer webhookCircuitsMu.Unlock()
+
+	circuit, ok := webhookCircuits[url]
+	if !ok {
+		if err == nil {
+			return
+		}
+		circuit = &webhookCircuit{}
+		webhookCircuits[url] = circuit
+	}
+
+	label := webhookCircuitLabel(url)
+	if err == nil {
+		if circuit.state != webhookCircuitClosed {
+			logrus.Infof("Webhook %s is delivering again, closing its circuit", url)
+		}
+		delete(webhookCircuits, url)
+		metrics.WebhookCircuitState.WithLabelValues(label).Set(webhookCircuitClosed)
+		return
+	}
+
+	circuit.failures++
+	if probe || circuit.failures >= config.WhatsappWebhookCircuitThreshold {
+		if circuit.state != webhookCircuitOpen {
+			logrus.Warnf("Webhook %s failed %d deliveries in a row, skipping it for %s", url, circuit.failures, config.WhatsappWebhookCircuitCooldown)
+		}
+		circuit.state = webhookCircuitOpen
+		circuit.openedAt = time.Now()
+		metrics.WebhookCircuitState.WithLabelValues(label).Set(webhookCircuitOpen)
+	}
+}
@@ -0,0 +1,63 @@
+package whatsapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookCircuit(t *testing.T) {
+	setWebhookRetryConfig(t, 2, time.Millisecond, time.Millisecond)
+	origThreshold := config.WhatsappWebhookCircuitThreshold
+	origCooldown := config.WhatsappWebhookCircuitCooldown
+	t.Cleanup(func() {
+		config.WhatsappWebhookCircuitThreshold = origThreshold
+		config.WhatsappWebhookCircuitCooldown = origCooldown
+	})
+	config.WhatsappWebhookCircuitThreshold = 2
+	config.WhatsappWebhookCircuitCooldown = 50 * time.Millisecond
+
+	var requests atomic.Int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	label := webhookCircuitLabel(server.URL)
+
+	// Two failed deliveries of 3 attempts each open the circuit
+	assert.Error(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+	assert.Error(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+	assert.Equal(t, int32(6), requests.Load())
+	assert.Equal(t, float64(webhookCircuitOpen), testutil.ToFloat64(metrics.WebhookCircuitState.WithLabelValues(label)))
+
+	// The open circuit skips the url without a request
+	assert.Error(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+	assert.Equal(t, int32(6), requests.Load())
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.WebhookCircuitSkipped.WithLabelValues(label)))
+
+	// A failed probe is a single attempt and opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	assert.Error(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+	assert.Equal(t, int32(7), requests.Load())
+	assert.Error(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+	assert.Equal(t, int32(7), requests.Load())
+
+	// A delivered probe closes it
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+	assert.NoError(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+	assert.Equal(t, int32(9), requests.Load())
+	assert.Equal(t, float64(webhookCircuitClosed), testutil.ToFloat64(metrics.WebhookCircuitState.WithLabelValues(label)))
+}
@@ -0,0 +1,106 @@
+package whatsapp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	webhookClient     *http.Client
+	webhookClientOnce sync.Once
+)
+
+// getWebhookClient returns the shared http client used to submit every webhook,
+// the client is created on first use so it picks up the configuration from flags and env
+func getWebhookClient() *http.Client {
+	webhookClientOnce.Do(func() {
+		webhookClient = newWebhookClient()
+	})
+	return webhookClient
+}
+
+// newWebhookClient creates an http client with a transport tuned for keep-alive reuse, presenting the client
+// certificate of config.WhatsappWebhookClientCert to endpoints requiring mutual tls. The requests go through
+// config.WhatsappWebhookProxyURL, or the proxy of the HTTP_PROXY and HTTPS_PROXY env without one
+func newWebhookClient() *http.Client {
+	tlsConfig, err := webhookTLSConfig()
+	if err != nil {
+		// ValidateWebhookConfig stops the app on this error before any webhook is sent
+		logrus.Errorf("Failed to load the webhook tls configuration, continuing without it: %v", err)
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.WhatsappWebhookProxyURL != "" {
+		// ValidateProxyConfig stops the app on an invalid proxy before any webhook is sent
+		if proxyURL, err := parseProxyURL(config.WhatsappWebhookProxyURL); err != nil {
+			logrus.Errorf("Failed to parse the webhook proxy, continuing without it: %v", err)
+		} else {
+			proxy = http.ProxyURL(proxyURL)
+		}
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}
+
+	return &http.Client{
+		Timeout:   config.WhatsappWebhookTimeout,
+		Transport: transport,
+	}
+}
+
+// webhookTLSConfig loads the client certificate and the ca bundle of the webhook endpoints, it is nil when neither
+// is configured. The ca bundle is trusted on top of the system roots
+func webhookTLSConfig() (*tls.Config, error) {
+	certFile, keyFile, caFile := config.WhatsappWebhookClientCert, config.WhatsappWebhookClientKey, config.WhatsappWebhookCACert
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("webhook client certificate and key must be set together")
+		}
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("webhook client certificate %s and key %s can't be used together: %w", certFile, keyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	if caFile != "" {
+		bundle, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read webhook ca bundle %s: %w", caFile, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("webhook ca bundle %s has no pem certificate", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,109 @@
+package whatsapp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate writes a pem certificate and key signed by parent, a nil parent makes a self signed ca
+func writeTestCertificate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(t.TempDir(), name+".pem")
+	keyFile := filepath.Join(t.TempDir(), name+"-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certificate, key, certFile, keyFile
+}
+
+func setWebhookTLSConfig(t *testing.T, certFile, keyFile, caFile string) {
+	origCert, origKey, origCA := config.WhatsappWebhookClientCert, config.WhatsappWebhookClientKey, config.WhatsappWebhookCACert
+	t.Cleanup(func() {
+		config.WhatsappWebhookClientCert, config.WhatsappWebhookClientKey, config.WhatsappWebhookCACert = origCert, origKey, origCA
+	})
+	config.WhatsappWebhookClientCert, config.WhatsappWebhookClientKey, config.WhatsappWebhookCACert = certFile, keyFile, caFile
+}
+
+func TestWebhookMutualTLS(t *testing.T) {
+	ca, caKey, caFile, _ := writeTestCertificate(t, "ca", nil, nil)
+	_, _, serverCertFile, serverKeyFile := writeTestCertificate(t, "server", ca, caKey)
+	_, _, clientCertFile, clientKeyFile := writeTestCertificate(t, "client", ca, caKey)
+
+	serverCertificate, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCertificate},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	setWebhookTLSConfig(t, clientCertFile, clientKeyFile, caFile)
+	assert.NoError(t, ValidateWebhookConfig())
+	resp, err := newWebhookClient().Post(server.URL, "application/json", nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	// Without the client certificate the handshake is refused
+	setWebhookTLSConfig(t, "", "", caFile)
+	_, err = newWebhookClient().Post(server.URL, "application/json", nil)
+	assert.Error(t, err)
+}
+
+func TestValidateWebhookConfigTLS(t *testing.T) {
+	_, _, certFile, _ := writeTestCertificate(t, "client", nil, nil)
+	_, _, _, otherKeyFile := writeTestCertificate(t, "other", nil, nil)
+
+	setWebhookTLSConfig(t, certFile, otherKeyFile, "")
+	assert.ErrorContains(t, ValidateWebhookConfig(), "can't be used together")
+
+	setWebhookTLSConfig(t, certFile, "", "")
+	assert.EqualError(t, ValidateWebhookConfig(), "webhook client certificate and key must be set together")
+
+	setWebhookTLSConfig(t, "", "", otherKeyFile)
+	assert.ErrorContains(t, ValidateWebhookConfig(), "has no pem certificate")
+}
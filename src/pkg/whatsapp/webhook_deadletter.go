@@ -0,0 +1,88 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/sirupsen/logrus"
+)
+
+type deadLetter struct {
+	URL      string         `json:"url"`
+	Payload  map[string]any `json:"payload"`
+	Error    string         `json:"error"`
+	FailedAt string         `json:"failed_at"`
+}
+
+// mutex to prevent concurrent dead-letter file access
+var deadLetterMutex sync.Mutex
+
+// writeDeadLetter appends a permanently failed webhook as a JSON line to config.WhatsappWebhookDeadLetterPath
+func writeDeadLetter(payload map[string]any, url string, reason error) error {
+	if config.WhatsappWebhookDeadLetterPath == "" {
+		return nil
+	}
+
+	line, err := json.Marshal(deadLetter{
+		URL:      url,
+		Payload:  payload,
+		Error:    reason.Error(),
+		FailedAt: time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+	line = append(line, '\n')
+
+	deadLetterMutex.Lock()
+	defer deadLetterMutex.Unlock()
+
+	if err = rotateDeadLetter(int64(len(line))); err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(config.WhatsappWebhookDeadLetterPath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create dead letter folder: %w", err)
+	}
+
+	file, err := os.OpenFile(config.WhatsappWebhookDeadLetterPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open dead letter file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err = file.Write(line); err != nil {
+		return fmt.Errorf("failed to write dead letter: %w", err)
+	}
+	return nil
+}
+
+// rotateDeadLetter moves the current file to <path>.1 when appending the next line would exceed the max size
+func rotateDeadLetter(nextLineSize int64) error {
+	if config.WhatsappWebhookDeadLetterMaxSize <= 0 {
+		return nil
+	}
+
+	info, err := os.Stat(config.WhatsappWebhookDeadLetterPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to stat dead letter file: %w", err)
+	}
+
+	if info.Size() == 0 || info.Size()+nextLineSize <= config.WhatsappWebhookDeadLetterMaxSize {
+		return nil
+	}
+
+	if err = os.Rename(config.WhatsappWebhookDeadLetterPath, config.WhatsappWebhookDeadLetterPath+".1"); err != nil {
+		return fmt.Errorf("failed to rotate dead letter file: %w", err)
+	}
+	logrus.Infof("Rotated webhook dead letter file %s", config.WhatsappWebhookDeadLetterPath)
+	return nil
+}
@@ -0,0 +1,101 @@
+package whatsapp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func setDeadLetterConfig(t *testing.T, maxSize int64) string {
+	origPath := config.WhatsappWebhookDeadLetterPath
+	origMaxSize := config.WhatsappWebhookDeadLetterMaxSize
+	t.Cleanup(func() {
+		config.WhatsappWebhookDeadLetterPath = origPath
+		config.WhatsappWebhookDeadLetterMaxSize = origMaxSize
+	})
+
+	config.WhatsappWebhookDeadLetterPath = filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	config.WhatsappWebhookDeadLetterMaxSize = maxSize
+	return config.WhatsappWebhookDeadLetterPath
+}
+
+func readDeadLetters(t *testing.T, path string) (letters []deadLetter) {
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var letter deadLetter
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &letter))
+		letters = append(letters, letter)
+	}
+	return letters
+}
+
+func TestSubmitWebhookWritesDeadLetterOnce(t *testing.T) {
+	setWebhookRetryConfig(t, 2, time.Millisecond, 2*time.Millisecond)
+	path := setDeadLetterConfig(t, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := submitWebhook(map[string]any{"event_type": "message", "from": "628123456789"}, server.URL)
+	assert.Error(t, err)
+
+	letters := readDeadLetters(t, path)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, server.URL, letters[0].URL)
+	assert.Equal(t, "message", letters[0].Payload["event_type"])
+	assert.Contains(t, letters[0].Error, "500")
+	assert.NotEmpty(t, letters[0].FailedAt)
+}
+
+func TestSubmitWebhookSkipsDeadLetterOnSuccess(t *testing.T) {
+	setWebhookRetryConfig(t, 2, time.Millisecond, 2*time.Millisecond)
+	path := setDeadLetterConfig(t, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWriteDeadLetterRotation(t *testing.T) {
+	path := setDeadLetterConfig(t, 200)
+	payload := map[string]any{"message": strings.Repeat("a", 100)}
+
+	assert.NoError(t, writeDeadLetter(payload, "http://localhost/first", errors.New("failed")))
+	assert.NoError(t, writeDeadLetter(payload, "http://localhost/second", errors.New("failed")))
+
+	current := readDeadLetters(t, path)
+	rotated := readDeadLetters(t, path+".1")
+	assert.Len(t, current, 1)
+	assert.Len(t, rotated, 1)
+	assert.Equal(t, "http://localhost/second", current[0].URL)
+	assert.Equal(t, "http://localhost/first", rotated[0].URL)
+}
+
+func TestWriteDeadLetterDisabled(t *testing.T) {
+	origPath := config.WhatsappWebhookDeadLetterPath
+	defer func() { config.WhatsappWebhookDeadLetterPath = origPath }()
+	config.WhatsappWebhookDeadLetterPath = ""
+
+	assert.NoError(t, writeDeadLetter(map[string]any{}, "http://localhost", errors.New("failed")))
+}
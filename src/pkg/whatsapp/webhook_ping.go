@@ -0,0 +1,64 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/google/uuid"
+)
+
+// webhookPingBodyExcerpt is the number of bytes of the response body returned by a ping
+const webhookPingBodyExcerpt = 1024
+
+// WebhookPingResult is the response of a webhook url to a ping, Body is cut to its first webhookPingBodyExcerpt bytes
+type WebhookPingResult struct {
+	StatusCode int
+	Latency    time.Duration
+	Body       string
+}
+
+// PingWebhook posts a signed ping payload to url once, the same way an event is delivered but without the retries,
+// the circuit and the dead letter file. Any response is a result, only an unreachable url is an error
+func PingWebhook(ctx context.Context, url string) (WebhookPingResult, error) {
+	payload := map[string]any{
+		"event_type": "ping",
+		"event_id":   uuid.NewString(),
+		"timestamp":  time.Now().Format(time.RFC3339),
+	}
+	downgradeWebhookPayload(payload, WebhookSchemaVersion, webhookSchemaVersion())
+	postBody, err := json.Marshal(payload)
+	if err != nil {
+		return WebhookPingResult{}, pkgError.WebhookError(fmt.Sprintf("Failed to marshal body: %v", err))
+	}
+	requestBody := postBody
+	if config.WhatsappWebhookCompress {
+		if requestBody, err = gzipWebhookBody(postBody); err != nil {
+			return WebhookPingResult{}, pkgError.WebhookError(fmt.Sprintf("Failed to compress body: %v", err))
+		}
+	}
+
+	req, err := newWebhookRequest(ctx, url, postBody, requestBody, webhookSecretForURL(url))
+	if err != nil {
+		return WebhookPingResult{}, err
+	}
+
+	startedAt := time.Now()
+	resp, err := getWebhookClient().Do(req)
+	if err != nil {
+		return WebhookPingResult{}, pkgError.WebhookError(fmt.Sprintf("webhook %s is unreachable: %v", url, err))
+	}
+	defer resp.Body.Close()
+
+	excerpt, err := io.ReadAll(io.LimitReader(resp.Body, webhookPingBodyExcerpt))
+	if err != nil {
+		return WebhookPingResult{}, pkgError.WebhookError(fmt.Sprintf("failed to read the response of webhook %s: %v", url, err))
+	}
+	latency := time.Since(startedAt)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return WebhookPingResult{StatusCode: resp.StatusCode, Latency: latency, Body: string(excerpt)}, nil
+}
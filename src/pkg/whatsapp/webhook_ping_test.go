@@ -0,0 +1,68 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPingWebhook(t *testing.T) {
+	origMode := config.WhatsappWebhookSignatureMode
+	defer func() { config.WhatsappWebhookSignatureMode = origMode }()
+	config.WhatsappWebhookSignatureMode = WebhookSignatureModeBody
+
+	var signature string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Hub-Signature-256")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":"invalid signature"}`))
+	}))
+	defer server.Close()
+
+	result, err := PingWebhook(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	assert.Equal(t, `{"error":"invalid signature"}`, result.Body)
+	assert.Positive(t, result.Latency)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "ping", payload["event_type"])
+	assert.NotEmpty(t, payload["event_id"])
+
+	expected, err := getMessageDigestOrSignature(body, []byte(config.WhatsappWebhookSecret))
+	require.NoError(t, err)
+	assert.Equal(t, "sha256="+expected, signature)
+}
+
+func TestPingWebhookBodyExcerpt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", webhookPingBodyExcerpt*3)))
+	}))
+	defer server.Close()
+
+	result, err := PingWebhook(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Len(t, result.Body, webhookPingBodyExcerpt)
+}
+
+func TestPingWebhookUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	_, err := PingWebhook(context.Background(), url)
+	assert.IsType(t, pkgError.WebhookError(""), err)
+}
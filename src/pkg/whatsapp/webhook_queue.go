@@ -0,0 +1,132 @@
+package whatsapp
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/sirupsen/logrus"
+)
+
+// webhookQueue is a bounded buffer of events drained by a fixed pool of workers,
+// so a slow webhook endpoint never blocks the whatsapp event handler
+type webhookQueue struct {
+	events  chan any
+	handle  func(evt any) error
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Uint64
+}
+
+var webhookEventQueue *webhookQueue
+
+// webhookEvent is a queued event together with the session that received it
+type webhookEvent struct {
+	sessionID string
+	evt       any
+}
+
+func newWebhookQueue(size, workers int, handle func(evt any) error) *webhookQueue {
+	queue := &webhookQueue{
+		events: make(chan any, size),
+		handle: handle,
+	}
+
+	for i := 0; i < workers; i++ {
+		queue.wg.Add(1)
+		go queue.work()
+	}
+	return queue
+}
+
+func (queue *webhookQueue) work() {
+	defer queue.wg.Done()
+	for evt := range queue.events {
+		if err := queue.handle(evt); err != nil {
+			logrus.Error("Failed forward to webhook: ", err)
+		}
+	}
+}
+
+// enqueue adds the event to the queue without blocking, the event is dropped when the queue is full
+func (queue *webhookQueue) enqueue(evt any) bool {
+	queue.mu.RLock()
+	defer queue.mu.RUnlock()
+
+	if queue.closed {
+		return false
+	}
+
+	select {
+	case queue.events <- evt:
+		return true
+	default:
+		dropped := queue.dropped.Add(1)
+		logrus.Warnf("Webhook queue is full, dropping %T event (total dropped: %d)", evt, dropped)
+		return false
+	}
+}
+
+// stop closes the queue and waits until the workers flush the in-flight events or the context is done
+func (queue *webhookQueue) stop(ctx context.Context) error {
+	queue.mu.Lock()
+	if !queue.closed {
+		queue.closed = true
+		close(queue.events)
+	}
+	queue.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		queue.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StartWebhookQueue starts the webhook workers based on config.WhatsappWebhookQueueSize and config.WhatsappWebhookWorkers
+func StartWebhookQueue() {
+	webhookEventQueue = newWebhookQueue(config.WhatsappWebhookQueueSize, config.WhatsappWebhookWorkers, func(item any) error {
+		queued := item.(webhookEvent)
+		return forwardToWebhook(queued.sessionID, queued.evt)
+	})
+	logrus.Infof("Webhook queue started with %d workers (queue size: %d)", config.WhatsappWebhookWorkers, config.WhatsappWebhookQueueSize)
+}
+
+// StopWebhookQueue stops accepting new events and flushes the queued ones
+func StopWebhookQueue(ctx context.Context) error {
+	if webhookEventQueue == nil {
+		return nil
+	}
+	return webhookEventQueue.stop(ctx)
+}
+
+// WebhookDroppedEvents returns how many events were dropped because the webhook queue was full
+func WebhookDroppedEvents() uint64 {
+	if webhookEventQueue == nil {
+		return 0
+	}
+	return webhookEventQueue.dropped.Load()
+}
+
+// enqueueWebhookEvent hands the event to the webhook workers, falling back to a goroutine when the queue is not started
+func enqueueWebhookEvent(sessionID string, evt any) {
+	if webhookEventQueue != nil {
+		webhookEventQueue.enqueue(webhookEvent{sessionID: sessionID, evt: evt})
+		return
+	}
+
+	go func() {
+		if err := forwardToWebhook(sessionID, evt); err != nil {
+			logrus.Error("Failed forward to webhook: ", err)
+		}
+	}()
+}
@@ -0,0 +1,65 @@
+package whatsapp
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookQueueFlushOnStop(t *testing.T) {
+	var handled int32
+	queue := newWebhookQueue(10, 2, func(evt any) error {
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, queue.enqueue(i))
+	}
+
+	assert.NoError(t, queue.stop(context.Background()))
+	assert.Equal(t, int32(10), atomic.LoadInt32(&handled))
+
+	// Queue should reject events after being stopped
+	assert.False(t, queue.enqueue(11))
+}
+
+func TestWebhookQueueDropWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	queue := newWebhookQueue(1, 1, func(evt any) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	// First event is picked up by the only worker, second one fills the buffer
+	assert.True(t, queue.enqueue(1))
+	<-started
+	assert.True(t, queue.enqueue(2))
+
+	// Third event should be dropped without blocking
+	assert.False(t, queue.enqueue(3))
+	assert.Equal(t, uint64(1), queue.dropped.Load())
+
+	close(release)
+	assert.NoError(t, queue.stop(context.Background()))
+}
+
+func TestWebhookQueueStopTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	queue := newWebhookQueue(1, 1, func(evt any) error {
+		<-release
+		return nil
+	})
+	queue.enqueue(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, queue.stop(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,164 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+)
+
+const (
+	WebhookSourceConfig = "config"
+	WebhookSourceAPI    = "api"
+)
+
+// WebhookTarget is a webhook url with the event types it receives, empty events means every event
+type WebhookTarget struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+	Source string   `json:"source"`
+}
+
+// webhookStore keeps the webhooks added at runtime, they are persisted to config.PathWebhookStore
+type webhookStore struct {
+	mu      sync.RWMutex
+	targets []WebhookTarget
+}
+
+var runtimeWebhooks = &webhookStore{}
+
+// LoadWebhookStore reads the webhooks added at runtime from config.PathWebhookStore
+func LoadWebhookStore() error {
+	data, err := os.ReadFile(config.PathWebhookStore)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read webhook store: %w", err)
+	}
+
+	var targets []WebhookTarget
+	if err = json.Unmarshal(data, &targets); err != nil {
+		return fmt.Errorf("failed to parse webhook store %s: %w", config.PathWebhookStore, err)
+	}
+
+	runtimeWebhooks.mu.Lock()
+	defer runtimeWebhooks.mu.Unlock()
+	runtimeWebhooks.targets = targets
+	return nil
+}
+
+// ListWebhooks returns the webhooks from the startup configuration followed by the ones added at runtime
+func ListWebhooks() []WebhookTarget {
+	targets := make([]WebhookTarget, 0, len(config.WhatsappWebhook))
+	for _, url := range config.WhatsappWebhook {
+		targets = append(targets, WebhookTarget{URL: url, Source: WebhookSourceConfig})
+	}
+	if routes, err := parseWebhookRoutes(); err == nil {
+		for _, eventType := range WebhookEventTypes {
+			for _, url := range routes[eventType] {
+				targets = append(targets, WebhookTarget{URL: url, Events: []string{eventType}, Source: WebhookSourceConfig})
+			}
+		}
+	}
+
+	runtimeWebhooks.mu.RLock()
+	defer runtimeWebhooks.mu.RUnlock()
+	return append(targets, runtimeWebhooks.targets...)
+}
+
+// AddWebhook adds or replaces a runtime webhook and persists the store
+func AddWebhook(url string, events []string) (WebhookTarget, error) {
+	if slices.Contains(config.WhatsappWebhook, url) {
+		return WebhookTarget{}, pkgError.ValidationError("webhook is already configured at startup")
+	}
+
+	target := WebhookTarget{URL: url, Events: events, Source: WebhookSourceAPI}
+
+	runtimeWebhooks.mu.Lock()
+	defer runtimeWebhooks.mu.Unlock()
+
+	targets := slices.Clone(runtimeWebhooks.targets)
+	if index := slices.IndexFunc(targets, func(t WebhookTarget) bool { return t.URL == url }); index >= 0 {
+		targets[index] = target
+	} else {
+		targets = append(targets, target)
+	}
+
+	if err := saveWebhookStore(targets); err != nil {
+		return WebhookTarget{}, err
+	}
+	runtimeWebhooks.targets = targets
+	return target, nil
+}
+
+// RemoveWebhook removes a runtime webhook and persists the store.
+// Webhooks from the startup configuration can only be removed from that configuration.
+func RemoveWebhook(url string) error {
+	if slices.Contains(config.WhatsappWebhook, url) {
+		return pkgError.ValidationError("webhook is configured at startup, remove it from --webhook instead")
+	}
+
+	runtimeWebhooks.mu.Lock()
+	defer runtimeWebhooks.mu.Unlock()
+
+	index := slices.IndexFunc(runtimeWebhooks.targets, func(t WebhookTarget) bool { return t.URL == url })
+	if index < 0 {
+		return pkgError.ValidationError("webhook not found")
+	}
+
+	targets := slices.Delete(slices.Clone(runtimeWebhooks.targets), index, index+1)
+	if err := saveWebhookStore(targets); err != nil {
+		return err
+	}
+	runtimeWebhooks.targets = targets
+	return nil
+}
+
+// runtimeWebhookURLs returns the runtime webhook urls that receive the event type
+func runtimeWebhookURLs(eventType string) []string {
+	runtimeWebhooks.mu.RLock()
+	defer runtimeWebhooks.mu.RUnlock()
+
+	var urls []string
+	for _, target := range runtimeWebhooks.targets {
+		if len(target.Events) == 0 || slices.Contains(target.Events, eventType) {
+			urls = append(urls, target.URL)
+		}
+	}
+	return urls
+}
+
+func hasRuntimeWebhooks() bool {
+	runtimeWebhooks.mu.RLock()
+	defer runtimeWebhooks.mu.RUnlock()
+	return len(runtimeWebhooks.targets) > 0
+}
+
+// saveWebhookStore writes the store to a temporary file and renames it, so a crash never leaves a partial store
+func saveWebhookStore(targets []WebhookTarget) error {
+	data, err := json.MarshalIndent(targets, "", "  ")
+	if err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to encode webhook store: %v", err))
+	}
+
+	if err = os.MkdirAll(filepath.Dir(config.PathWebhookStore), 0700); err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to create webhook store folder: %v", err))
+	}
+
+	tmpPath := config.PathWebhookStore + ".tmp"
+	if err = os.WriteFile(tmpPath, data, 0600); err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to write webhook store: %v", err))
+	}
+	if err = os.Rename(tmpPath, config.PathWebhookStore); err != nil {
+		_ = os.Remove(tmpPath)
+		return pkgError.InternalServerError(fmt.Sprintf("failed to write webhook store: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package whatsapp
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func setWebhookStoreConfig(t *testing.T) {
+	origPath := config.PathWebhookStore
+	origWebhook := config.WhatsappWebhook
+	origRoutes := config.WhatsappWebhookRoutes
+	t.Cleanup(func() {
+		config.PathWebhookStore = origPath
+		config.WhatsappWebhook = origWebhook
+		config.WhatsappWebhookRoutes = origRoutes
+		runtimeWebhooks.targets = nil
+	})
+
+	config.PathWebhookStore = filepath.Join(t.TempDir(), "webhooks.json")
+	config.WhatsappWebhook = []string{"https://config.example.com"}
+	config.WhatsappWebhookRoutes = nil
+	runtimeWebhooks.targets = nil
+}
+
+func TestWebhookStorePersistence(t *testing.T) {
+	setWebhookStoreConfig(t)
+
+	_, err := AddWebhook("https://receipts.example.com", []string{"receipt"})
+	assert.NoError(t, err)
+	_, err = AddWebhook("https://all.example.com", nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"https://config.example.com", "https://receipts.example.com", "https://all.example.com"}, webhookURLsForEvent("receipt"))
+	assert.Equal(t, []string{"https://config.example.com", "https://all.example.com"}, webhookURLsForEvent("message"))
+
+	// Reload from disk as it happens on reboot
+	runtimeWebhooks.targets = nil
+	assert.NoError(t, LoadWebhookStore())
+	assert.Equal(t, []WebhookTarget{
+		{URL: "https://config.example.com", Source: WebhookSourceConfig},
+		{URL: "https://receipts.example.com", Events: []string{"receipt"}, Source: WebhookSourceAPI},
+		{URL: "https://all.example.com", Source: WebhookSourceAPI},
+	}, ListWebhooks())
+
+	assert.NoError(t, RemoveWebhook("https://receipts.example.com"))
+	runtimeWebhooks.targets = nil
+	assert.NoError(t, LoadWebhookStore())
+	assert.Equal(t, []string{"https://config.example.com", "https://all.example.com"}, webhookURLsForEvent("receipt"))
+}
+
+func TestWebhookStoreConfigWebhooks(t *testing.T) {
+	setWebhookStoreConfig(t)
+
+	_, err := AddWebhook("https://config.example.com", nil)
+	assert.Error(t, err)
+	assert.Error(t, RemoveWebhook("https://config.example.com"))
+	assert.Error(t, RemoveWebhook("https://unknown.example.com"))
+}
+
+func TestLoadWebhookStoreMissingFile(t *testing.T) {
+	setWebhookStoreConfig(t)
+	assert.NoError(t, LoadWebhookStore())
+	assert.False(t, hasRuntimeWebhooks())
+}
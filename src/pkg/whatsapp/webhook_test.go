@@ -0,0 +1,1329 @@
+package whatsapp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func setWebhookRetryConfig(t *testing.T, maxRetries int, baseDelay, maxDelay time.Duration) {
+	origRetries := config.WhatsappWebhookMaxRetries
+	origBaseDelay := config.WhatsappWebhookRetryBaseDelay
+	origMaxDelay := config.WhatsappWebhookMaxDelay
+	t.Cleanup(func() {
+		config.WhatsappWebhookMaxRetries = origRetries
+		config.WhatsappWebhookRetryBaseDelay = origBaseDelay
+		config.WhatsappWebhookMaxDelay = origMaxDelay
+	})
+
+	config.WhatsappWebhookMaxRetries = maxRetries
+	config.WhatsappWebhookRetryBaseDelay = baseDelay
+	config.WhatsappWebhookMaxDelay = maxDelay
+}
+
+func setWebhookRetryJitter(t *testing.T, jitter string) {
+	origJitter := config.WhatsappWebhookRetryJitter
+	t.Cleanup(func() { config.WhatsappWebhookRetryJitter = origJitter })
+	config.WhatsappWebhookRetryJitter = jitter
+}
+
+func TestWebhookBackoffDelay(t *testing.T) {
+	setWebhookRetryJitter(t, WebhookRetryJitterNone)
+
+	tests := []struct {
+		name      string
+		baseDelay time.Duration
+		maxDelay  time.Duration
+		expected  []time.Duration
+	}{
+		{
+			name:      "should double the delay on every attempt",
+			baseDelay: 1 * time.Second,
+			maxDelay:  1 * time.Minute,
+			expected:  []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second},
+		},
+		{
+			name:      "should cap the delay at the max delay",
+			baseDelay: 1 * time.Second,
+			maxDelay:  5 * time.Second,
+			expected:  []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:      "should use the max delay when it equals the base delay",
+			baseDelay: 3 * time.Second,
+			maxDelay:  3 * time.Second,
+			expected:  []time.Duration{3 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setWebhookRetryConfig(t, len(tt.expected), tt.baseDelay, tt.maxDelay)
+			for attempt, expected := range tt.expected {
+				assert.Equal(t, expected, webhookBackoffDelay(attempt), "attempt %d", attempt)
+			}
+		})
+	}
+}
+
+func TestWebhookBackoffDelayJitter(t *testing.T) {
+	tests := []struct {
+		jitter string
+		min    time.Duration
+	}{
+		{WebhookRetryJitterFull, 0},
+		{WebhookRetryJitterEqual, 4 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run("should randomize the delay with "+tt.jitter+" jitter", func(t *testing.T) {
+			setWebhookRetryConfig(t, 4, 1*time.Second, 1*time.Minute)
+			setWebhookRetryJitter(t, tt.jitter)
+
+			delays := make(map[time.Duration]bool)
+			for range 100 {
+				delay := webhookBackoffDelay(3)
+				assert.GreaterOrEqual(t, delay, tt.min)
+				assert.LessOrEqual(t, delay, 8*time.Second)
+				delays[delay] = true
+			}
+			assert.Greater(t, len(delays), 1)
+		})
+	}
+}
+
+func TestValidateWebhookConfigRetryJitter(t *testing.T) {
+	for _, jitter := range WebhookRetryJitters {
+		setWebhookRetryJitter(t, jitter)
+		assert.NoError(t, ValidateWebhookConfig(), jitter)
+	}
+
+	setWebhookRetryJitter(t, "random")
+	assert.Error(t, ValidateWebhookConfig())
+}
+
+func TestValidateWebhookConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		baseDelay  time.Duration
+		maxDelay   time.Duration
+		wantErr    bool
+	}{
+		{"should success with default values", 4, 1 * time.Second, 30 * time.Second, false},
+		{"should success with zero retries", 0, 1 * time.Second, 30 * time.Second, false},
+		{"should error with negative retries", -1, 1 * time.Second, 30 * time.Second, true},
+		{"should error with zero base delay", 4, 0, 30 * time.Second, true},
+		{"should error when max delay lower than base delay", 4, 10 * time.Second, 1 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setWebhookRetryConfig(t, tt.maxRetries, tt.baseDelay, tt.maxDelay)
+			err := ValidateWebhookConfig()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSubmitWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		expected   int32
+	}{
+		{"should try once when max retries is zero", 0, 1},
+		{"should try max retries plus the first attempt", 2, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setWebhookRetryConfig(t, tt.maxRetries, time.Millisecond, 2*time.Millisecond)
+
+			var hits int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&hits, 1)
+				// Hijack and close the connection so the client receives a transport error
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				_ = conn.Close()
+			}))
+			defer server.Close()
+
+			err := submitWebhook(map[string]any{"event_type": "message"}, server.URL)
+			assert.Error(t, err)
+			assert.Equal(t, tt.expected, atomic.LoadInt32(&hits))
+		})
+	}
+}
+
+func TestForwardToWebhookIncludesSessionID(t *testing.T) {
+	previous := config.WhatsappWebhook
+	t.Cleanup(func() { config.WhatsappWebhook = previous })
+
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+	config.WhatsappWebhook = []string{server.URL}
+
+	err := forwardToWebhook("sales", &events.Receipt{
+		MessageSource: types.MessageSource{Chat: types.NewJID("628123456789", types.DefaultUserServer)},
+		MessageIDs:    []types.MessageID{"3EB0C127D7BACC83D6A1"},
+		Type:          types.ReceiptTypeRead,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "sales", body["session_id"])
+	assert.Equal(t, "receipt", body["event_type"])
+	assert.NotEmpty(t, body["event_id"])
+	assert.EqualValues(t, WebhookSchemaVersion, body["schema_version"])
+}
+
+func TestForwardToWebhookRawEvent(t *testing.T) {
+	origWebhook, origRaw := config.WhatsappWebhook, config.WhatsappWebhookRawEvents
+	t.Cleanup(func() { config.WhatsappWebhook, config.WhatsappWebhookRawEvents = origWebhook, origRaw })
+
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+	config.WhatsappWebhook = []string{server.URL}
+	evt := &events.UndecryptableMessage{
+		Info:          types.MessageInfo{ID: "3EB0C127D7BACC83D6A1"},
+		IsUnavailable: true,
+	}
+
+	config.WhatsappWebhookRawEvents = false
+	assert.Empty(t, webhookEventType(evt))
+	assert.Error(t, forwardToWebhook("sales", evt))
+
+	config.WhatsappWebhookRawEvents = true
+	assert.Equal(t, "raw", webhookEventType(evt))
+	assert.NoError(t, forwardToWebhook("sales", evt))
+	assert.Equal(t, "raw", body["event_type"])
+	assert.Equal(t, "events.UndecryptableMessage", body["type"])
+	assert.Equal(t, "sales", body["session_id"])
+	event, _ := body["event"].(map[string]any)
+	assert.Equal(t, true, event["IsUnavailable"])
+	info, _ := event["Info"].(map[string]any)
+	assert.Equal(t, "3EB0C127D7BACC83D6A1", info["ID"])
+}
+
+func TestWebhookEventTypeRawDenied(t *testing.T) {
+	origRaw := config.WhatsappWebhookRawEvents
+	t.Cleanup(func() { config.WhatsappWebhookRawEvents = origRaw })
+	config.WhatsappWebhookRawEvents = true
+
+	for _, evt := range []any{
+		&events.QR{Codes: []string{"2@secret"}},
+		&events.PairSuccess{},
+		&events.HistorySync{},
+		&events.AppState{},
+		&events.AppStateSyncComplete{},
+		&events.OfflineSyncPreview{},
+	} {
+		assert.Empty(t, webhookEventType(evt), "%T", evt)
+	}
+	assert.Equal(t, "raw", webhookEventType(&events.CallOffer{}))
+}
+
+func TestCreateRawPayloadUnmarshalable(t *testing.T) {
+	_, err := createRawPayload(make(chan int))
+	assert.Error(t, err)
+}
+
+func TestSubmitWebhookResponseStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+		expected int32
+	}{
+		{"should success on 2xx response", []int{http.StatusNoContent}, false, 1},
+		{"should retry on 5xx response until success", []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}, false, 3},
+		{"should retry on 429 response", []int{http.StatusTooManyRequests, http.StatusOK}, false, 2},
+		{"should not retry on 404 response", []int{http.StatusNotFound, http.StatusOK}, true, 1},
+		{"should fail after exhausting retries", []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable}, true, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setWebhookRetryConfig(t, 2, time.Millisecond, 2*time.Millisecond)
+
+			var hits int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hit := atomic.AddInt32(&hits, 1)
+				w.WriteHeader(tt.statuses[int(hit)-1])
+			}))
+			defer server.Close()
+
+			err := submitWebhook(map[string]any{"event_type": "message"}, server.URL)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, atomic.LoadInt32(&hits))
+		})
+	}
+}
+
+func TestSubmitWebhookMetrics(t *testing.T) {
+	setWebhookRetryConfig(t, 2, time.Millisecond, 2*time.Millisecond)
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Unique event types keep the counters of this test apart from the other tests
+	assert.NoError(t, submitWebhook(map[string]any{"event_type": "metrics_sent"}, server.URL))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.WebhooksSent.WithLabelValues("metrics_sent")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.WebhooksRetried.WithLabelValues("metrics_sent")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.WebhooksFailed.WithLabelValues("metrics_sent")))
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	assert.Error(t, submitWebhook(map[string]any{"event_type": "metrics_failed"}, failing.URL))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.WebhooksSent.WithLabelValues("metrics_failed")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.WebhooksFailed.WithLabelValues("metrics_failed")))
+	assert.Positive(t, testutil.CollectAndCount(metrics.WebhookDuration))
+}
+
+func TestIsRetryableWebhookStatus(t *testing.T) {
+	origStatus := config.WhatsappWebhookRetryOnStatus
+	defer func() { config.WhatsappWebhookRetryOnStatus = origStatus }()
+	config.WhatsappWebhookRetryOnStatus = []string{"5xx", "429", "408"}
+
+	tests := []struct {
+		status   int
+		expected bool
+	}{
+		{http.StatusInternalServerError, true},
+		{http.StatusGatewayTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusRequestTimeout, true},
+		{http.StatusNotFound, false},
+		{http.StatusBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, isRetryableWebhookStatus(tt.status), "status %d", tt.status)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("invalid"))
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+
+	delay := parseRetryAfter(time.Now().Add(1 * time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, delay > 0 && delay <= time.Minute)
+	assert.Equal(t, time.Duration(0), parseRetryAfter(time.Now().Add(-1*time.Minute).UTC().Format(http.TimeFormat)))
+}
+
+func TestValidateWebhookConfigRetryOnStatus(t *testing.T) {
+	origStatus := config.WhatsappWebhookRetryOnStatus
+	defer func() { config.WhatsappWebhookRetryOnStatus = origStatus }()
+
+	config.WhatsappWebhookRetryOnStatus = []string{"5xx", "429"}
+	assert.NoError(t, ValidateWebhookConfig())
+
+	config.WhatsappWebhookRetryOnStatus = []string{"5xx", "abc"}
+	assert.Error(t, ValidateWebhookConfig())
+}
+
+func benchmarkWebhookPayload(b *testing.B, newClient func() *http.Client) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	payload := []byte(`{"event_type":"message","from":"628123456789@s.whatsapp.net"}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(payload))
+		resp, err := newClient().Do(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+}
+
+// BenchmarkWebhookSharedClient forwards many events through the shared keep-alive client
+func BenchmarkWebhookSharedClient(b *testing.B) {
+	client := newWebhookClient()
+	benchmarkWebhookPayload(b, func() *http.Client { return client })
+}
+
+// BenchmarkWebhookClientPerEvent mirrors the previous behavior of creating a client for every event
+func BenchmarkWebhookClientPerEvent(b *testing.B) {
+	benchmarkWebhookPayload(b, func() *http.Client {
+		return &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{}}
+	})
+}
+
+func TestIsWebhookEventAllowed(t *testing.T) {
+	origEvents := config.WhatsappWebhookEvents
+	defer func() { config.WhatsappWebhookEvents = origEvents }()
+
+	config.WhatsappWebhookEvents = nil
+	assert.True(t, isWebhookEventAllowed("message"))
+	assert.True(t, isWebhookEventAllowed("presence"))
+
+	config.WhatsappWebhookEvents = []string{"message", " receipt"}
+	assert.True(t, isWebhookEventAllowed("message"))
+	assert.True(t, isWebhookEventAllowed("receipt"))
+	assert.False(t, isWebhookEventAllowed("presence"))
+}
+
+func TestValidateWebhookConfigEvents(t *testing.T) {
+	origEvents := config.WhatsappWebhookEvents
+	defer func() { config.WhatsappWebhookEvents = origEvents }()
+
+	config.WhatsappWebhookEvents = []string{"message", "receipt"}
+	assert.NoError(t, ValidateWebhookConfig())
+
+	config.WhatsappWebhookEvents = []string{"message", "unknown"}
+	assert.Error(t, ValidateWebhookConfig())
+}
+
+func TestWebhookURLsForEvent(t *testing.T) {
+	origWebhook := config.WhatsappWebhook
+	origRoutes := config.WhatsappWebhookRoutes
+	defer func() {
+		config.WhatsappWebhook = origWebhook
+		config.WhatsappWebhookRoutes = origRoutes
+	}()
+
+	config.WhatsappWebhook = []string{"https://all.example.com"}
+	config.WhatsappWebhookRoutes = nil
+	assert.Equal(t, []string{"https://all.example.com"}, webhookURLsForEvent("message"))
+	assert.Equal(t, []string{"https://all.example.com"}, webhookURLsForEvent("receipt"))
+
+	config.WhatsappWebhook = nil
+	config.WhatsappWebhookRoutes = []string{"message=https://messages.example.com", "receipt=https://receipts.example.com"}
+	assert.Equal(t, []string{"https://messages.example.com"}, webhookURLsForEvent("message"))
+	assert.Equal(t, []string{"https://receipts.example.com"}, webhookURLsForEvent("receipt"))
+	assert.Empty(t, webhookURLsForEvent("presence"))
+
+	config.WhatsappWebhook = []string{"https://all.example.com"}
+	assert.Equal(t, []string{"https://all.example.com", "https://messages.example.com"}, webhookURLsForEvent("message"))
+}
+
+func TestValidateWebhookConfigRoutes(t *testing.T) {
+	origRoutes := config.WhatsappWebhookRoutes
+	defer func() { config.WhatsappWebhookRoutes = origRoutes }()
+
+	config.WhatsappWebhookRoutes = []string{"message=https://messages.example.com"}
+	assert.NoError(t, ValidateWebhookConfig())
+
+	config.WhatsappWebhookRoutes = []string{"https://messages.example.com"}
+	assert.Error(t, ValidateWebhookConfig())
+
+	config.WhatsappWebhookRoutes = []string{"unknown=https://messages.example.com"}
+	assert.Error(t, ValidateWebhookConfig())
+}
+
+func TestCreatePayloadIdentifiers(t *testing.T) {
+	tests := []struct {
+		name    string
+		chat    types.JID
+		isGroup bool
+	}{
+		{"should mark private chat", types.NewJID("628123456789", types.DefaultUserServer), false},
+		{"should mark group chat", types.NewJID("120363025246125888", types.GroupServer), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evt := &events.Message{
+				Info: types.MessageInfo{
+					MessageSource: types.MessageSource{
+						Chat:   tt.chat,
+						Sender: types.NewJID("628987654321", types.DefaultUserServer),
+					},
+					ID: "3EB0C127D7BACC83D6A1",
+				},
+				Message: &waE2E.Message{},
+			}
+
+			payload, err := createPayload(nil, evt)
+			assert.NoError(t, err)
+			assert.Equal(t, "3EB0C127D7BACC83D6A1", payload["id"])
+			assert.Equal(t, tt.chat.String(), payload["chat_jid"])
+			assert.Equal(t, "628987654321@s.whatsapp.net", payload["sender_jid"])
+			assert.Equal(t, tt.isGroup, payload["is_group"])
+		})
+	}
+}
+
+func TestCreatePayloadMentionedJID(t *testing.T) {
+	chat := types.NewJID("120363025246125888", types.GroupServer)
+	newEvent := func(message *waE2E.Message) *events.Message {
+		return &events.Message{
+			Info:    types.MessageInfo{MessageSource: types.MessageSource{Chat: chat, IsGroup: true}, ID: "3EB0C127D7BACC83D6A1"},
+			Message: message,
+		}
+	}
+
+	payload, err := createPayload(nil, newEvent(&waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+		Text: proto.String("hello @628123456789 and @628987654321"),
+		ContextInfo: &waE2E.ContextInfo{
+			MentionedJID: []string{"628123456789@s.whatsapp.net", "628987654321@s.whatsapp.net"},
+		},
+	}}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"628123456789@s.whatsapp.net", "628987654321@s.whatsapp.net"}, payload["mentioned_jid"])
+
+	payload, err = createPayload(nil, newEvent(&waE2E.Message{Conversation: proto.String("hello")}))
+	assert.NoError(t, err)
+	assert.NotContains(t, payload, "mentioned_jid")
+}
+
+func TestBuildWebhookMedia(t *testing.T) {
+	origMode := config.WhatsappWebhookMediaMode
+	origMaxSize := config.WhatsappWebhookMediaMaxBase64Size
+	origBaseURL := config.AppBaseURL
+	origSecret := config.WhatsappWebhookMediaURLSecret
+	defer func() {
+		config.WhatsappWebhookMediaMode = origMode
+		config.WhatsappWebhookMediaMaxBase64Size = origMaxSize
+		config.AppBaseURL = origBaseURL
+		config.WhatsappWebhookMediaURLSecret = origSecret
+	}()
+
+	path := filepath.Join(t.TempDir(), "image.jpg")
+	assert.NoError(t, os.WriteFile(path, []byte("image-data"), 0600))
+	extracted := ExtractedMedia{MediaPath: path, MimeType: "image/jpeg"}
+
+	config.WhatsappWebhookMediaMode = WebhookMediaModePath
+	media, err := buildWebhookMedia("image", extracted)
+	assert.NoError(t, err)
+	assert.Equal(t, webhookMedia{ExtractedMedia: extracted}, media)
+
+	config.WhatsappWebhookMediaMode = WebhookMediaModeBase64
+	config.WhatsappWebhookMediaMaxBase64Size = 100
+	media, err = buildWebhookMedia("image", extracted)
+	assert.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("image-data")), media.Base64)
+	assert.Equal(t, "image/jpeg", media.MimeType)
+
+	config.WhatsappWebhookMediaMaxBase64Size = 5
+	media, err = buildWebhookMedia("image", extracted)
+	assert.NoError(t, err)
+	assert.Empty(t, media.Base64)
+	assert.Equal(t, path, media.MediaPath)
+
+	config.WhatsappWebhookMediaMode = WebhookMediaModeURL
+	config.AppBaseURL = "https://wa.example.com/"
+	config.WhatsappWebhookMediaURLSecret = "media-secret"
+	media, err = buildWebhookMedia("image", ExtractedMedia{MediaPath: "statics/media/image.jpg"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(media.URL, "https://wa.example.com/media/image.jpg?expires="), media.URL)
+}
+
+func TestCreatePayloadButtonsResponse(t *testing.T) {
+	evt := &events.Message{
+		Info: types.MessageInfo{ID: "3EB0C127D7BACC83D6A1"},
+		Message: &waE2E.Message{ButtonsResponseMessage: &waE2E.ButtonsResponseMessage{
+			SelectedButtonID: proto.String("yes"),
+			Response:         &waE2E.ButtonsResponseMessage_SelectedDisplayText{SelectedDisplayText: "Yes"},
+		}},
+	}
+
+	payload, err := createPayload(nil, evt)
+	assert.NoError(t, err)
+	assert.Equal(t, evtButtonsResponse{SelectedButtonID: "yes", SelectedDisplayText: "Yes"}, payload["buttons_response"])
+
+	evt.Message = &waE2E.Message{TemplateButtonReplyMessage: &waE2E.TemplateButtonReplyMessage{
+		SelectedID:          proto.String("pickup"),
+		SelectedDisplayText: proto.String("Pick up"),
+	}}
+	payload, err = createPayload(nil, evt)
+	assert.NoError(t, err)
+	assert.Equal(t, evtButtonsResponse{SelectedButtonID: "pickup", SelectedDisplayText: "Pick up"}, payload["buttons_response"])
+}
+
+func TestCreatePayloadListResponse(t *testing.T) {
+	evt := &events.Message{
+		Info: types.MessageInfo{ID: "3EB0C127D7BACC83D6A1"},
+		Message: &waE2E.Message{ListResponseMessage: &waE2E.ListResponseMessage{
+			Title:             proto.String("Rice"),
+			ListType:          waE2E.ListResponseMessage_SINGLE_SELECT.Enum(),
+			SingleSelectReply: &waE2E.ListResponseMessage_SingleSelectReply{SelectedRowID: proto.String("rice")},
+		}},
+	}
+
+	payload, err := createPayload(nil, evt)
+	assert.NoError(t, err)
+	assert.Equal(t, evtListResponse{SelectedRowID: "rice", Title: "Rice"}, payload["list_response"])
+}
+
+func TestCreatePayloadLazyMedia(t *testing.T) {
+	origMode := config.WhatsappWebhookMediaMode
+	defer func() { config.WhatsappWebhookMediaMode = origMode }()
+	config.WhatsappWebhookMediaMode = WebhookMediaModeLazy
+
+	evt := &events.Message{
+		Info: types.MessageInfo{ID: "3EB0C127D7BACC83D6A1"},
+		Message: &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+			Mimetype:   proto.String("image/jpeg"),
+			Caption:    proto.String("caption"),
+			FileLength: proto.Uint64(1234),
+		}},
+	}
+
+	payload, err := createPayload(nil, evt)
+	assert.NoError(t, err)
+	assert.Equal(t, webhookMedia{
+		ExtractedMedia: ExtractedMedia{MimeType: "image/jpeg", Caption: "caption"},
+		DownloadPath:   "/message/3EB0C127D7BACC83D6A1/media",
+		FileLength:     1234,
+	}, payload["image"])
+}
+
+func TestCreatePayloadAutoDownloadOff(t *testing.T) {
+	origMode, origAudio := config.WhatsappWebhookMediaMode, config.WhatsappAutoDownloadAudio
+	defer func() { config.WhatsappWebhookMediaMode, config.WhatsappAutoDownloadAudio = origMode, origAudio }()
+	config.WhatsappWebhookMediaMode = WebhookMediaModePath
+	config.WhatsappAutoDownloadAudio = false
+
+	evt := &events.Message{
+		Info: types.MessageInfo{ID: "3EB0C127D7BACC83D6A1"},
+		Message: &waE2E.Message{AudioMessage: &waE2E.AudioMessage{
+			Mimetype:   proto.String("audio/ogg; codecs=opus"),
+			FileLength: proto.Uint64(4321),
+		}},
+	}
+
+	// The audio isn't downloaded, a nil client would fail the download
+	payload, err := createPayload(nil, evt)
+	assert.NoError(t, err)
+	assert.Equal(t, webhookMedia{
+		ExtractedMedia: ExtractedMedia{MimeType: "audio/ogg; codecs=opus"},
+		DownloadPath:   "/message/3EB0C127D7BACC83D6A1/media",
+		FileLength:     4321,
+	}, payload["audio"])
+}
+
+func TestAutoDownloadMedia(t *testing.T) {
+	origImage, origVideo := config.WhatsappAutoDownloadImage, config.WhatsappAutoDownloadVideo
+	defer func() { config.WhatsappAutoDownloadImage, config.WhatsappAutoDownloadVideo = origImage, origVideo }()
+	config.WhatsappAutoDownloadImage = true
+	config.WhatsappAutoDownloadVideo = false
+
+	assert.True(t, autoDownloadMedia("image"))
+	assert.False(t, autoDownloadMedia("video"))
+	assert.True(t, autoDownloadMedia("audio"))
+}
+
+func TestValidateWebhookConfigAutoDownload(t *testing.T) {
+	origVideo, origTTL := config.WhatsappAutoDownloadVideo, config.WhatsappMediaCacheTTL
+	defer func() { config.WhatsappAutoDownloadVideo, config.WhatsappMediaCacheTTL = origVideo, origTTL }()
+
+	config.WhatsappAutoDownloadVideo = false
+	assert.NoError(t, ValidateWebhookConfig())
+
+	config.WhatsappMediaCacheTTL = 0
+	assert.Error(t, ValidateWebhookConfig())
+
+	config.WhatsappAutoDownloadVideo = true
+	assert.NoError(t, ValidateWebhookConfig())
+}
+
+func TestValidateWebhookConfigMediaMode(t *testing.T) {
+	origMode := config.WhatsappWebhookMediaMode
+	origBaseURL := config.AppBaseURL
+	origSecret := config.WhatsappWebhookMediaURLSecret
+	defer func() {
+		config.WhatsappWebhookMediaMode = origMode
+		config.AppBaseURL = origBaseURL
+		config.WhatsappWebhookMediaURLSecret = origSecret
+	}()
+
+	config.WhatsappWebhookMediaMode = WebhookMediaModeBase64
+	assert.NoError(t, ValidateWebhookConfig())
+
+	config.WhatsappWebhookMediaMode = WebhookMediaModeURL
+	config.AppBaseURL = ""
+	assert.Error(t, ValidateWebhookConfig())
+
+	config.AppBaseURL = "https://wa.example.com"
+	config.WhatsappWebhookMediaURLSecret = ""
+	assert.Error(t, ValidateWebhookConfig())
+
+	config.WhatsappWebhookMediaURLSecret = "media-secret"
+	assert.NoError(t, ValidateWebhookConfig())
+
+	config.WhatsappWebhookMediaMode = WebhookMediaModeLazy
+	assert.NoError(t, ValidateWebhookConfig())
+
+	config.WhatsappWebhookMediaMode = "ftp"
+	assert.Error(t, ValidateWebhookConfig())
+}
+
+func TestSubmitWebhookSignature(t *testing.T) {
+	origMode := config.WhatsappWebhookSignatureMode
+	defer func() { config.WhatsappWebhookSignatureMode = origMode }()
+
+	tests := []struct {
+		name   string
+		mode   string
+		signed func(timestamp string, body []byte) []byte
+	}{
+		{"should sign body only", WebhookSignatureModeBody, func(_ string, body []byte) []byte { return body }},
+		{"should sign timestamp and body", WebhookSignatureModeTimestamp, func(timestamp string, body []byte) []byte {
+			return append([]byte(timestamp+"."), body...)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.WhatsappWebhookSignatureMode = tt.mode
+
+			var timestamp, signature string
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				timestamp = r.Header.Get("X-Hub-Timestamp")
+				signature = r.Header.Get("X-Hub-Signature-256")
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			assert.NoError(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+			assert.NotEmpty(t, timestamp)
+
+			expected, err := getMessageDigestOrSignature(tt.signed(timestamp, body), []byte(config.WhatsappWebhookSecret))
+			assert.NoError(t, err)
+			assert.Equal(t, "sha256="+expected, signature)
+		})
+	}
+}
+
+func TestSubmitWebhookSignatureAlgo(t *testing.T) {
+	origAlgo, origMode, origSecret := config.WhatsappWebhookSignatureAlgo, config.WhatsappWebhookSignatureMode, config.WhatsappWebhookSecret
+	defer func() {
+		config.WhatsappWebhookSignatureAlgo = origAlgo
+		config.WhatsappWebhookSignatureMode = origMode
+		config.WhatsappWebhookSecret = origSecret
+	}()
+	config.WhatsappWebhookSignatureMode = WebhookSignatureModeBody
+	config.WhatsappWebhookSecret = "secret"
+
+	tests := []struct {
+		algo     string
+		expected string
+	}{
+		{WebhookSignatureAlgoSHA1, "sha1=b1cb44b33b69059d22f6f194f8fee226a947da3d"},
+		{WebhookSignatureAlgoSHA256, "sha256=d79b7f149cfa0fab1cb23ec067f3ecdfb3ed0f9e6178610709ef97d1a1327aba"},
+		{WebhookSignatureAlgoSHA512, "sha512=20c61b309e91776c4bc658471ffc0b0b70dfd3df9cfab0962e4c83de110008096e28fcefdeca5eca72aad2fab9836ac440e8ed26296b50663723453892d9c7ea"},
+	}
+
+	for _, tt := range tests {
+		t.Run("should sign with "+tt.algo, func(t *testing.T) {
+			config.WhatsappWebhookSignatureAlgo = tt.algo
+
+			var headers http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header.Clone()
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			assert.NoError(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+			// The header stays the same for every algorithm, only the prefix changes
+			assert.Equal(t, tt.expected, headers.Get("X-Hub-Signature-256"))
+			assert.Empty(t, headers.Get("X-Hub-Signature"))
+			assert.Empty(t, headers.Get("X-Hub-Signature-512"))
+		})
+	}
+}
+
+func TestValidateWebhookConfigSignatureAlgo(t *testing.T) {
+	origAlgo := config.WhatsappWebhookSignatureAlgo
+	defer func() { config.WhatsappWebhookSignatureAlgo = origAlgo }()
+
+	for _, algo := range WebhookSignatureAlgos {
+		config.WhatsappWebhookSignatureAlgo = algo
+		assert.NoError(t, ValidateWebhookConfig(), algo)
+	}
+
+	config.WhatsappWebhookSignatureAlgo = "md5"
+	assert.Error(t, ValidateWebhookConfig())
+}
+
+func TestParseWebhookSecrets(t *testing.T) {
+	origSecrets := config.WhatsappWebhookSecrets
+	defer func() { config.WhatsappWebhookSecrets = origSecrets }()
+
+	config.WhatsappWebhookSecrets = []string{
+		"https://a.example.com/hook=secret-a",
+		" https://b.example.com/hook?token=abc = secret-b",
+		"https://c.example.com/hook?a=1&flag&b=2=c2VjcmV0LWM=",
+		"https://d.example.com/hook=c2VjcmV0LWQ==",
+	}
+	secrets, err := parseWebhookSecrets()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"https://a.example.com/hook":              "secret-a",
+		"https://b.example.com/hook?token=abc":    "secret-b",
+		"https://c.example.com/hook?a=1&flag&b=2": "c2VjcmV0LWM=",
+		"https://d.example.com/hook":              "c2VjcmV0LWQ==",
+	}, secrets)
+	assert.NoError(t, ValidateWebhookConfig())
+
+	for _, entries := range [][]string{
+		{"https://a.example.com/hook"},
+		{"=secret-a"},
+		{"https://a.example.com/hook="},
+		{"https://a.example.com/hook?token=abc"},
+		{"https://a.example.com/hook=secret-a", "https://a.example.com/hook=secret-b"},
+		// A secret cut at an unquoted comma, "https://a.example.com/hook=se,cr=et"
+		{"https://a.example.com/hook=se", "cr=et"},
+		{"ftp://a.example.com/hook=secret-a"},
+	} {
+		config.WhatsappWebhookSecrets = entries
+		_, err = parseWebhookSecrets()
+		assert.Error(t, err, entries)
+		assert.Error(t, ValidateWebhookConfig(), entries)
+	}
+}
+
+func TestSubmitWebhookURLSecret(t *testing.T) {
+	origSecret, origSecrets, origMode := config.WhatsappWebhookSecret, config.WhatsappWebhookSecrets, config.WhatsappWebhookSignatureMode
+	defer func() {
+		config.WhatsappWebhookSecret = origSecret
+		config.WhatsappWebhookSecrets = origSecrets
+		config.WhatsappWebhookSignatureMode = origMode
+	}()
+	config.WhatsappWebhookSecret = "global-secret"
+	config.WhatsappWebhookSignatureMode = WebhookSignatureModeBody
+
+	newServer := func(signature *string, body *[]byte) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*signature = r.Header.Get("X-Hub-Signature-256")
+			*body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	var ownSignature, globalSignature string
+	var ownBody, globalBody []byte
+	own := newServer(&ownSignature, &ownBody)
+	defer own.Close()
+	global := newServer(&globalSignature, &globalBody)
+	defer global.Close()
+	config.WhatsappWebhookSecrets = []string{own.URL + "=own-secret"}
+	assert.NoError(t, ValidateWebhookConfig())
+
+	assert.NoError(t, submitWebhook(map[string]any{"event_type": "message"}, own.URL))
+	assert.NoError(t, submitWebhook(map[string]any{"event_type": "message"}, global.URL))
+
+	expected, err := getMessageDigestOrSignature(ownBody, []byte("own-secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256="+expected, ownSignature)
+
+	expected, err = getMessageDigestOrSignature(globalBody, []byte("global-secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256="+expected, globalSignature)
+}
+
+func TestSubmitWebhookHeaders(t *testing.T) {
+	origHeaders := config.WhatsappWebhookHeaders
+	defer func() { config.WhatsappWebhookHeaders = origHeaders }()
+	config.WhatsappWebhookHeaders = map[string]string{"Authorization": "Bearer token", "X-Tenant-Id": "tenant-1"}
+
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, submitWebhook(map[string]any{"event_type": "message"}, server.URL))
+	assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+	assert.Equal(t, "tenant-1", headers.Get("X-Tenant-Id"))
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+}
+
+func TestSubmitWebhookCompress(t *testing.T) {
+	origCompress := config.WhatsappWebhookCompress
+	defer func() { config.WhatsappWebhookCompress = origCompress }()
+	config.WhatsappWebhookCompress = true
+
+	var encoding, signature string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		signature = r.Header.Get("X-Hub-Signature-256")
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ = io.ReadAll(reader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, submitWebhook(map[string]any{"event_type": "message", "message": map[string]any{"text": "hello"}}, server.URL))
+	assert.Equal(t, "gzip", encoding)
+	assert.JSONEq(t, `{"event_type":"message","message":{"text":"hello"}}`, string(body))
+
+	// The signature is verified on the decompressed body
+	expected, err := getMessageDigestOrSignature(body, []byte(config.WhatsappWebhookSecret))
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256="+expected, signature)
+}
+
+func TestValidateWebhookConfigHeaders(t *testing.T) {
+	origHeaders := config.WhatsappWebhookHeaders
+	defer func() { config.WhatsappWebhookHeaders = origHeaders }()
+
+	config.WhatsappWebhookHeaders = map[string]string{"Authorization": "Bearer token"}
+	assert.NoError(t, ValidateWebhookConfig())
+
+	for _, name := range []string{"content-type", "X-Hub-Signature-256", "x-hub-signature-256", "x-hub-timestamp"} {
+		config.WhatsappWebhookHeaders = map[string]string{name: "value"}
+		assert.Error(t, ValidateWebhookConfig(), name)
+	}
+
+	for _, name := range []string{"", "  "} {
+		config.WhatsappWebhookHeaders = map[string]string{name: "value"}
+		assert.Error(t, ValidateWebhookConfig(), "empty header name %q", name)
+	}
+}
+
+func TestCreateGroupInfoPayload(t *testing.T) {
+	group := types.NewJID("120363025246125888", types.GroupServer)
+	actor := types.NewJID("628123456789", types.DefaultUserServer)
+	member := types.NewJID("628987654321", types.DefaultUserServer)
+
+	payloads := createGroupInfoPayload(&events.GroupInfo{
+		JID:     group,
+		Sender:  &actor,
+		Join:    []types.JID{member},
+		Promote: []types.JID{member},
+	})
+	assert.Len(t, payloads, 2)
+
+	assert.Equal(t, "group_participants", payloads[0]["event_type"])
+	assert.Equal(t, group.String(), payloads[0]["group_jid"])
+	assert.Equal(t, "add", payloads[0]["action"])
+	assert.Equal(t, []string{member.String()}, payloads[0]["participants"])
+	assert.Equal(t, actor.String(), payloads[0]["actor"])
+	assert.Equal(t, "promote", payloads[1]["action"])
+
+	assert.Empty(t, createGroupInfoPayload(&events.GroupInfo{JID: group, Name: &types.GroupName{Name: "New name"}}))
+}
+
+func TestCreateGroupSettingsPayload(t *testing.T) {
+	group := types.NewJID("120363025246125888", types.GroupServer)
+	actor := types.NewJID("628123456789", types.DefaultUserServer)
+
+	payload := createGroupSettingsPayload(&events.GroupInfo{
+		JID:    group,
+		Sender: &actor,
+		Name:   &types.GroupName{Name: "New name"},
+		Topic:  &types.GroupTopic{Topic: "New description"},
+	})
+	assert.Equal(t, "group_info", payload["event_type"])
+	assert.Equal(t, group.String(), payload["group_jid"])
+	assert.Equal(t, "New name", payload["subject"])
+	assert.Equal(t, "New description", payload["description"])
+	assert.Equal(t, actor.String(), payload["actor"])
+	assert.NotContains(t, payload, "announce")
+
+	assert.Nil(t, createGroupSettingsPayload(&events.GroupInfo{JID: group, Join: []types.JID{actor}}))
+}
+
+func TestCreateConnectionPayload(t *testing.T) {
+	tests := []struct {
+		name   string
+		evt    any
+		status string
+		reason any
+	}{
+		{"should report connected", &events.Connected{}, "connected", nil},
+		{"should report disconnected", &events.Disconnected{}, "disconnected", nil},
+		{"should report logged out with reason", &events.LoggedOut{OnConnect: true, Reason: events.ConnectFailureLoggedOut}, "logged_out", events.ConnectFailureLoggedOut.String()},
+		{"should report logged out without reason", &events.LoggedOut{}, "logged_out", nil},
+		{"should report logout through the api", &apiLogout{}, "logged_out", "logout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := createConnectionPayload(tt.evt)
+			assert.NoError(t, err)
+			assert.Equal(t, "connection", payload["event_type"])
+			assert.Equal(t, tt.status, payload["status"])
+			assert.Equal(t, tt.reason, payload["reason"])
+		})
+	}
+}
+
+func TestCreateBatchPayload(t *testing.T) {
+	results := []BatchSentResult{
+		{Phone: "6289685028129", Success: true, MessageID: "3EB0BATCH"},
+		{Phone: "6289685028130", Error: "recipient is not on whatsapp"},
+	}
+
+	payload := createBatchPayload(&BatchSent{Sent: 1, Failed: 1, Results: results})
+	assert.Equal(t, "batch", payload["event_type"])
+	assert.Equal(t, 1, payload["sent"])
+	assert.Equal(t, 1, payload["failed"])
+	assert.Equal(t, results, payload["results"])
+	assert.Equal(t, "batch", webhookEventType(&BatchSent{}))
+}
+
+func TestCreateMessageEditAndRevokePayload(t *testing.T) {
+	info := types.MessageInfo{
+		MessageSource: types.MessageSource{
+			Chat:   types.NewJID("628123456789", types.DefaultUserServer),
+			Sender: types.NewJID("628123456789", types.DefaultUserServer),
+		},
+		ID: "3EB0EDIT",
+	}
+	target := &waCommon.MessageKey{ID: proto.String("3EB0C127D7BACC83D6A1")}
+
+	edit := &events.Message{Info: info, Message: &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{
+		Type:          waE2E.ProtocolMessage_MESSAGE_EDIT.Enum(),
+		Key:           target,
+		EditedMessage: &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{Text: proto.String("fixed typo")}},
+	}}}
+	assert.Equal(t, "message_edit", webhookEventType(edit))
+
+	payload, err := createMessageEditPayload(edit)
+	assert.NoError(t, err)
+	assert.Equal(t, "message_edit", payload["event_type"])
+	assert.Equal(t, "3EB0EDIT", payload["id"])
+	assert.Equal(t, "3EB0C127D7BACC83D6A1", payload["target_message_id"])
+	assert.Equal(t, "fixed typo", payload["text"])
+
+	revoke := &events.Message{Info: info, Message: &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{
+		Type: waE2E.ProtocolMessage_REVOKE.Enum(),
+		Key:  target,
+	}}}
+	assert.Equal(t, "message_revoke", webhookEventType(revoke))
+
+	payload, err = createMessageRevokePayload(revoke)
+	assert.NoError(t, err)
+	assert.Equal(t, "message_revoke", payload["event_type"])
+	assert.Equal(t, "3EB0C127D7BACC83D6A1", payload["target_message_id"])
+	assert.NotContains(t, payload, "text")
+
+	assert.Equal(t, "message", webhookEventType(&events.Message{Info: info, Message: &waE2E.Message{Conversation: proto.String("hi")}}))
+}
+
+func TestCreateNewsletterPayload(t *testing.T) {
+	newsletter := types.NewJID("120363024512399999", types.NewsletterServer)
+	evt := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: newsletter, Sender: newsletter},
+			ID:            "184",
+			Timestamp:     time.Date(2025, 4, 20, 10, 0, 0, 0, time.UTC),
+		},
+		Message: &waE2E.Message{Conversation: proto.String("release notes")},
+	}
+	assert.Equal(t, "newsletter", webhookEventType(evt))
+
+	payload, err := createNewsletterPayload(nil, evt)
+	assert.NoError(t, err)
+	assert.Equal(t, "newsletter", payload["event_type"])
+	assert.Equal(t, newsletter.String(), payload["newsletter_jid"])
+	assert.Equal(t, false, payload["is_group"])
+	assert.Equal(t, "release notes", payload["message"].(evtMessage).Text)
+	assert.NotContains(t, payload, "edited_at")
+
+	evt.NewsletterMeta = &events.NewsletterMessageMeta{EditTS: time.Date(2025, 4, 20, 11, 0, 0, 0, time.UTC)}
+	payload, err = createNewsletterPayload(nil, evt)
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-04-20T11:00:00Z", payload["edited_at"])
+}
+
+func TestCreateStatusPayload(t *testing.T) {
+	sender := types.NewJID("628123456789", types.DefaultUserServer)
+	evt := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: types.StatusBroadcastJID, Sender: sender},
+			ID:            "3EB0STATUS",
+			Timestamp:     time.Date(2025, 4, 20, 10, 0, 0, 0, time.UTC),
+		},
+		Message: &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text:           proto.String("good morning"),
+			BackgroundArgb: proto.Uint32(0xff25d366),
+		}},
+	}
+	assert.Equal(t, "status", webhookEventType(evt))
+
+	payload, err := createStatusPayload(nil, evt)
+	assert.NoError(t, err)
+	assert.Equal(t, "status", payload["event_type"])
+	assert.Equal(t, "status@broadcast", payload["chat_jid"])
+	assert.Equal(t, sender.String(), payload["sender_jid"])
+	assert.Equal(t, "2025-04-21T10:00:00Z", payload["expires_at"])
+	assert.Equal(t, "#ff25d366", payload["background_color"])
+
+	revoke := &events.Message{Info: evt.Info, Message: &waE2E.Message{ProtocolMessage: &waE2E.ProtocolMessage{
+		Type: waE2E.ProtocolMessage_REVOKE.Enum(),
+		Key:  &waCommon.MessageKey{ID: proto.String("3EB0STATUS")},
+	}}}
+	assert.Equal(t, "message_revoke", webhookEventType(revoke))
+}
+
+func TestEditedMessageText(t *testing.T) {
+	assert.Equal(t, "hello", editedMessageText(&waE2E.Message{Conversation: proto.String("hello")}))
+	assert.Equal(t, "new caption", editedMessageText(&waE2E.Message{ImageMessage: &waE2E.ImageMessage{Caption: proto.String("new caption")}}))
+	assert.Equal(t, "", editedMessageText(nil))
+}
+
+func TestCreateChatPresencePayload(t *testing.T) {
+	chat := types.NewJID("120363025246125888", types.GroupServer)
+	sender := types.NewJID("628123456789", types.DefaultUserServer)
+	source := types.MessageSource{Chat: chat, Sender: sender, IsGroup: true}
+
+	tests := []struct {
+		state types.ChatPresence
+		media types.ChatPresenceMedia
+		want  string
+	}{
+		{types.ChatPresenceComposing, types.ChatPresenceMediaText, "composing"},
+		{types.ChatPresenceComposing, types.ChatPresenceMediaAudio, "recording"},
+		{types.ChatPresencePaused, types.ChatPresenceMediaText, "paused"},
+	}
+	for _, tt := range tests {
+		payload, err := createChatPresencePayload(&events.ChatPresence{MessageSource: source, State: tt.state, Media: tt.media})
+		assert.NoError(t, err)
+		assert.Equal(t, "chat_presence", payload["event_type"])
+		assert.Equal(t, chat.String(), payload["chat_jid"])
+		assert.Equal(t, sender.String(), payload["from"])
+		assert.Equal(t, true, payload["is_group"])
+		assert.Equal(t, tt.want, payload["state"])
+	}
+	assert.Equal(t, "chat_presence", webhookEventType(&events.ChatPresence{}))
+}
+
+func TestCreatePayloadOversizedMedia(t *testing.T) {
+	origMode, origLimit := config.WhatsappWebhookMediaMode, config.WhatsappMaxVideoDownloadSize
+	defer func() { config.WhatsappWebhookMediaMode, config.WhatsappMaxVideoDownloadSize = origMode, origLimit }()
+	config.WhatsappWebhookMediaMode = WebhookMediaModePath
+	config.WhatsappMaxVideoDownloadSize = 1000
+
+	evt := &events.Message{
+		Info: types.MessageInfo{ID: "3EB0C127D7BACC83D6A2"},
+		Message: &waE2E.Message{VideoMessage: &waE2E.VideoMessage{
+			Mimetype:   proto.String("video/mp4"),
+			Caption:    proto.String("huge"),
+			FileLength: proto.Uint64(5000),
+		}},
+	}
+
+	// The client is nil, the video must be skipped before any download is attempted
+	payload, err := createPayload(nil, evt)
+	assert.NoError(t, err)
+	assert.Equal(t, webhookMedia{
+		ExtractedMedia: ExtractedMedia{MimeType: "video/mp4", Caption: "huge"},
+		FileLength:     5000,
+		TooLarge:       true,
+	}, payload["video"])
+}
+
+func TestMaxDownloadSize(t *testing.T) {
+	orig := []int64{config.WhatsappSettingMaxDownloadSize, config.WhatsappMaxImageDownloadSize, config.WhatsappMaxVideoDownloadSize, config.WhatsappMaxFileDownloadSize}
+	defer func() {
+		config.WhatsappSettingMaxDownloadSize, config.WhatsappMaxImageDownloadSize = orig[0], orig[1]
+		config.WhatsappMaxVideoDownloadSize, config.WhatsappMaxFileDownloadSize = orig[2], orig[3]
+	}()
+	config.WhatsappSettingMaxDownloadSize, config.WhatsappMaxImageDownloadSize = 1, 2
+	config.WhatsappMaxVideoDownloadSize, config.WhatsappMaxFileDownloadSize = 3, 4
+
+	assert.Equal(t, int64(2), MaxDownloadSize(&waE2E.ImageMessage{}))
+	assert.Equal(t, int64(3), MaxDownloadSize(&waE2E.VideoMessage{}))
+	assert.Equal(t, int64(4), MaxDownloadSize(&waE2E.DocumentMessage{}))
+	assert.Equal(t, int64(1), MaxDownloadSize(&waE2E.AudioMessage{}))
+	assert.Equal(t, int64(1), MaxDownloadSize(&waE2E.StickerMessage{}))
+
+	_, err := ExtractMedia(nil, t.TempDir(), &waE2E.DocumentMessage{FileLength: proto.Uint64(5)})
+	var tooLarge *MediaTooLargeError
+	assert.ErrorAs(t, err, &tooLarge)
+	assert.Equal(t, &MediaTooLargeError{Size: 5, Limit: 4}, tooLarge)
+}
+
+func TestCreatePayloadMediaFailure(t *testing.T) {
+	origMode, origPartial := config.WhatsappWebhookMediaMode, config.WhatsappWebhookPartialMedia
+	defer func() { config.WhatsappWebhookMediaMode, config.WhatsappWebhookPartialMedia = origMode, origPartial }()
+	config.WhatsappWebhookMediaMode = WebhookMediaModePath
+
+	evt := &events.Message{
+		Info: types.MessageInfo{ID: "3EB0C127D7BACC83D6A3"},
+		Message: &waE2E.Message{
+			ImageMessage:    &waE2E.ImageMessage{Mimetype: proto.String("image/jpeg"), FileLength: proto.Uint64(10)},
+			DocumentMessage: &waE2E.DocumentMessage{Mimetype: proto.String("application/pdf"), FileLength: proto.Uint64(20)},
+		},
+	}
+
+	// Without a client every download fails
+	config.WhatsappWebhookPartialMedia = false
+	_, err := createPayload(nil, evt)
+	assert.Error(t, err)
+
+	config.WhatsappWebhookPartialMedia = true
+	payload, err := createPayload(nil, evt)
+	assert.NoError(t, err)
+	image, ok := payload["image"].(webhookMedia)
+	assert.True(t, ok)
+	assert.Equal(t, "image/jpeg", image.MimeType)
+	assert.Equal(t, uint64(10), image.FileLength)
+	assert.NotEmpty(t, image.Error)
+	document, ok := payload["document"].(webhookMedia)
+	assert.True(t, ok)
+	assert.Equal(t, "application/pdf", document.MimeType)
+	assert.NotEmpty(t, document.Error)
+}
+
+func TestExtractWebhookMediasConcurrency(t *testing.T) {
+	origMode, origConcurrency := config.WhatsappWebhookMediaMode, config.WhatsappWebhookMediaConcurrency
+	defer func() {
+		config.WhatsappWebhookMediaMode, config.WhatsappWebhookMediaConcurrency = origMode, origConcurrency
+	}()
+	config.WhatsappWebhookMediaMode = WebhookMediaModeLazy
+	config.WhatsappWebhookMediaConcurrency = 1
+
+	evt := &events.Message{
+		Info: types.MessageInfo{ID: "3EB0C127D7BACC83D6A4"},
+		Message: &waE2E.Message{
+			AudioMessage:   &waE2E.AudioMessage{Mimetype: proto.String("audio/ogg")},
+			StickerMessage: &waE2E.StickerMessage{Mimetype: proto.String("image/webp")},
+			VideoMessage:   &waE2E.VideoMessage{Mimetype: proto.String("video/mp4")},
+		},
+	}
+
+	medias, err := extractWebhookMedias(nil, evt, webhookMediaItems(evt.Message))
+	assert.NoError(t, err)
+	assert.Len(t, medias, 3)
+	assert.Equal(t, "audio/ogg", medias["audio"].MimeType)
+	assert.Equal(t, "image/webp", medias["sticker"].MimeType)
+	assert.Equal(t, "video/mp4", medias["video"].MimeType)
+
+	medias, err = extractWebhookMedias(nil, evt, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, medias)
+}
+
+func TestDowngradeWebhookPayload(t *testing.T) {
+	origDowngrades := webhookSchemaDowngrades
+	defer func() { webhookSchemaDowngrades = origDowngrades }()
+	// Version 3 renamed from_jid to sender, version 2 moved the text into a message object
+	webhookSchemaDowngrades = map[int]func(payload map[string]any){
+		3: func(payload map[string]any) {
+			payload["from_jid"] = payload["sender"]
+			delete(payload, "sender")
+		},
+		2: func(payload map[string]any) {
+			payload["text"] = payload["message"].(map[string]any)["text"]
+			delete(payload, "message")
+		},
+	}
+
+	payload := map[string]any{"sender": "628123456789@s.whatsapp.net", "message": map[string]any{"text": "hi"}}
+	downgradeWebhookPayload(payload, 3, 1)
+	assert.Equal(t, map[string]any{"from_jid": "628123456789@s.whatsapp.net", "text": "hi", "schema_version": 1}, payload)
+
+	latest := map[string]any{"sender": "628123456789@s.whatsapp.net"}
+	downgradeWebhookPayload(latest, 3, 3)
+	assert.Equal(t, map[string]any{"sender": "628123456789@s.whatsapp.net", "schema_version": 3}, latest)
+}
+
+func TestDowngradeWebhookPayloadToV0(t *testing.T) {
+	image := webhookMedia{ExtractedMedia: ExtractedMedia{MediaPath: "statics/media/image.jpg", MimeType: "image/jpeg"}, FileLength: 10}
+	payload := map[string]any{
+		"event_type": "message",
+		"session_id": "sales",
+		"event_id":   "0b7f",
+		"id":         "3EB0A1",
+		"chat_jid":   "628123456789@s.whatsapp.net",
+		"sender_jid": "628123456789@s.whatsapp.net",
+		"is_group":   false,
+		"from":       "628123456789@s.whatsapp.net",
+		"timestamp":  "2025-01-01T00:00:00Z",
+		"reaction":   evtReaction{ID: "3EB0A0", Message: "👍", Action: "add"},
+		"image":      image,
+		"video":      webhookMedia{ExtractedMedia: ExtractedMedia{MimeType: "video/mp4"}, URL: "https://wa.example.com/media/video.mp4"},
+	}
+	downgradeWebhookPayload(payload, WebhookSchemaVersion, 0)
+	assert.Equal(t, map[string]any{
+		"event_type": "message",
+		"from":       "628123456789@s.whatsapp.net",
+		"timestamp":  "2025-01-01T00:00:00Z",
+		"reaction":   evtReaction{ID: "3EB0A0", Message: "👍"},
+		"image":      image.ExtractedMedia,
+		"video":      payload["video"],
+	}, payload)
+
+	// Version 0 had no reaction removals
+	removed := map[string]any{"event_type": "message", "reaction": evtReaction{ID: "3EB0A0", Action: "remove"}}
+	downgradeWebhookPayload(removed, WebhookSchemaVersion, 0)
+	assert.NotContains(t, removed, "reaction")
+
+	receipt := map[string]any{"event_type": "receipt", "session_id": "sales", "event_id": "0b7f", "message_ids": []string{"3EB0A1"}}
+	downgradeWebhookPayload(receipt, WebhookSchemaVersion, 0)
+	assert.Equal(t, map[string]any{"event_type": "receipt", "message_ids": []string{"3EB0A1"}}, receipt)
+}
+
+func TestValidateWebhookConfigSchemaVersion(t *testing.T) {
+	origVersion := config.WhatsappWebhookSchemaVersion
+	defer func() { config.WhatsappWebhookSchemaVersion = origVersion }()
+
+	for _, version := range []int{-1, WebhookSchemaVersion} {
+		config.WhatsappWebhookSchemaVersion = version
+		assert.NoError(t, ValidateWebhookConfig(), version)
+		assert.Equal(t, WebhookSchemaVersion, webhookSchemaVersion(), version)
+	}
+
+	config.WhatsappWebhookSchemaVersion = 0
+	assert.NoError(t, ValidateWebhookConfig())
+	assert.Equal(t, 0, webhookSchemaVersion())
+
+	for _, version := range []int{-2, WebhookSchemaVersion + 1} {
+		config.WhatsappWebhookSchemaVersion = version
+		assert.Error(t, ValidateWebhookConfig(), version)
+	}
+}
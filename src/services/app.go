@@ -4,14 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	domainApp "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/app"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
 	fiberUtils "github.com/gofiber/fiber/v2/utils"
 	"github.com/sirupsen/logrus"
@@ -26,6 +27,9 @@ type serviceApp struct {
 	db    *sqlstore.Container
 }
 
+// qrStreamBuffer is the number of login events buffered for a /qr/stream client
+const qrStreamBuffer = 8
+
 func NewAppService(waCli *whatsmeow.Client, db *sqlstore.Container) domainApp.IAppService {
 	return &serviceApp{
 		WaCli: waCli,
@@ -41,7 +45,8 @@ func (service serviceApp) Login(_ context.Context) (response domainApp.LoginResp
 	// Disconnect for reconnecting
 	service.WaCli.Disconnect()
 
-	chImage := make(chan string)
+	// Only the first QR code is returned by the login, the rotated codes are served by QR
+	chImage := make(chan string, 1)
 
 	ch, err := service.WaCli.GetQRChannel(context.Background())
 	if err != nil {
@@ -61,8 +66,12 @@ func (service serviceApp) Login(_ context.Context) (response domainApp.LoginResp
 			for evt := range ch {
 				response.Code = evt.Code
 				response.Duration = evt.Timeout / time.Second / 2
+				if evt.Event == "timeout" {
+					whatsapp.SetLoginState(service.WaCli, whatsapp.LoginStateLoggedOut, time.Time{})
+				}
 				if evt.Event == "code" {
-					qrPath := fmt.Sprintf("%s/scan-qr-%s.png", config.PathQrCode, fiberUtils.UUIDv4())
+					whatsapp.SetLoginQR(service.WaCli, evt.Code, time.Now().Add(evt.Timeout))
+					qrPath := fmt.Sprintf("%s/scan-qr-%s%s.png", config.PathQrCode, whatsapp.SessionFilePrefix(service.WaCli), fiberUtils.UUIDv4())
 					err = qrcode.WriteFile(evt.Code, qrcode.Medium, 512, qrPath)
 					if err != nil {
 						logrus.Error("Error when write qr code to file: ", err)
@@ -74,7 +83,10 @@ func (service serviceApp) Login(_ context.Context) (response domainApp.LoginResp
 							logrus.Error("error when remove qrImage file", err.Error())
 						}
 					}()
-					chImage <- qrPath
+					select {
+					case chImage <- qrPath:
+					default:
+					}
 				} else {
 					logrus.Error("error when get qrCode", evt.Event)
 				}
@@ -92,74 +104,176 @@ func (service serviceApp) Login(_ context.Context) (response domainApp.LoginResp
 	return response, nil
 }
 
-func (service serviceApp) LoginWithCode(ctx context.Context, phoneNumber string) (loginCode string, err error) {
+func (service serviceApp) LoginWithCode(ctx context.Context, phoneNumber string) (response domainApp.LoginWithCodeResponse, err error) {
 	if err = validations.ValidateLoginWithCode(ctx, phoneNumber); err != nil {
 		logrus.Errorf("Error when validate login with code: %s", err.Error())
-		return loginCode, err
+		return response, err
 	}
 
 	// detect is already logged in
 	if service.WaCli.Store.ID != nil {
 		logrus.Warn("User is already logged in")
-		return loginCode, pkgError.ErrAlreadyLoggedIn
+		return response, pkgError.ErrAlreadyLoggedIn
+	}
+
+	// The qr channel has to be opened before connecting, its first code tells the websocket is ready for pairing
+	service.WaCli.Disconnect()
+	ch, err := service.WaCli.GetQRChannel(context.Background())
+	if err != nil {
+		logrus.Errorf("Error when get qr channel: %s", err.Error())
+		return response, pkgError.ErrQrChannel
+	}
+	if err = service.WaCli.Connect(); err != nil {
+		logrus.Errorf("Error when connect to whatsapp: %s", err.Error())
+		return response, pkgError.ErrReconnect
+	}
+
+	select {
+	case evt := <-ch:
+		if evt.Event != "code" {
+			return response, pkgError.InternalServerError(fmt.Sprintf("unexpected login event %s before pairing", evt.Event))
+		}
+	case <-time.After(10 * time.Second):
+		return response, pkgError.ErrReconnect
 	}
 
-	// reconnect first
-	_ = service.Reconnect(ctx)
+	go func() {
+		for evt := range ch {
+			if evt.Event == "timeout" {
+				whatsapp.SetLoginState(service.WaCli, whatsapp.LoginStateLoggedOut, time.Time{})
+			}
+		}
+	}()
 
-	loginCode, err = service.WaCli.PairPhone(phoneNumber, true, whatsmeow.PairClientChrome, "Chrome (Linux)")
+	response.PairCode, err = service.WaCli.PairPhone(phoneNumber, true, whatsmeow.PairClientChrome, "Chrome (Linux)")
 	if err != nil {
 		logrus.Errorf("Error when pairing phone: %s", err.Error())
-		return loginCode, err
+		return response, err
 	}
 
-	logrus.Infof("Successfully paired phone with code: %s", loginCode)
-	return loginCode, nil
+	response.ExpiresAt = time.Now().Add(whatsapp.PairCodeTimeout)
+	whatsapp.SetLoginState(service.WaCli, whatsapp.LoginStateWaitingPairCode, response.ExpiresAt)
+
+	logrus.Infof("Successfully paired phone with code: %s", response.PairCode)
+	return response, nil
 }
 
-func (service serviceApp) Logout(_ context.Context) (err error) {
-	// delete history
-	files, err := filepath.Glob(fmt.Sprintf("./%s/history-*", config.PathStorages))
-	if err != nil {
-		return err
+func (service serviceApp) Status(_ context.Context) (response domainApp.StatusResponse, err error) {
+	if service.WaCli == nil {
+		return response, pkgError.ErrWaCLI
 	}
 
-	for _, f := range files {
-		err = os.Remove(f)
-		if err != nil {
-			return err
+	response.IsConnected = service.WaCli.IsConnected()
+	response.IsLoggedIn = service.WaCli.IsLoggedIn()
+
+	state, expiresAt := whatsapp.GetLoginState(service.WaCli)
+	if response.IsLoggedIn {
+		state, expiresAt = whatsapp.LoginStateLoggedIn, time.Time{}
+	}
+	response.LoginState = string(state)
+	if !expiresAt.IsZero() {
+		response.ExpiresAt = &expiresAt
+	}
+
+	return response, nil
+}
+
+func (service serviceApp) QR(ctx context.Context, request domainApp.QRRequest) (response domainApp.QRResponse, err error) {
+	if err = validations.ValidateQR(ctx, request); err != nil {
+		return response, err
+	}
+	if service.WaCli == nil {
+		return response, pkgError.ErrWaCLI
+	}
+	if service.WaCli.IsLoggedIn() {
+		return response, pkgError.ErrAlreadyLoggedIn
+	}
+
+	code, expiresAt, ok := whatsapp.GetLoginQR(service.WaCli)
+	if !ok {
+		// No login is in progress, an empty code tells there's nothing to scan
+		return response, nil
+	}
+	response.Code = code
+	response.ExpiresAt = expiresAt
+	if request.Format != domainApp.QRFormatJSON {
+		if response.Image, err = qrcode.Encode(code, qrcode.Medium, 512); err != nil {
+			return response, pkgError.InternalServerError(fmt.Sprintf("failed to render the qr code: %v", err))
 		}
 	}
-	// delete qr images
-	qrImages, err := filepath.Glob(fmt.Sprintf("./%s/scan-*", config.PathQrCode))
-	if err != nil {
-		return err
+
+	return response, nil
+}
+
+func (service serviceApp) QRStream(ctx context.Context) (events <-chan domainApp.QREvent, err error) {
+	if service.WaCli == nil {
+		return nil, pkgError.ErrWaCLI
 	}
 
-	for _, f := range qrImages {
-		err = os.Remove(f)
-		if err != nil {
-			return err
+	ch := make(chan domainApp.QREvent, qrStreamBuffer)
+	push := func(evt whatsapp.LoginEvent) {
+		qrEvent := domainApp.QREvent{Event: evt.Event, Code: evt.Code}
+		if !evt.ExpiresAt.IsZero() {
+			qrEvent.ExpiresAt = &evt.ExpiresAt
 		}
+		// A client that can't keep up misses the event, the next QR code replaces it anyway
+		select {
+		case ch <- qrEvent:
+		default:
+		}
+	}
+	// Listening before reading the current state, so a code rotated meanwhile isn't missed
+	stop := whatsapp.OnLoginEvent(service.WaCli, push)
+	context.AfterFunc(ctx, stop)
+	if service.WaCli.IsLoggedIn() {
+		push(whatsapp.LoginEvent{Event: whatsapp.LoginEventSuccess})
+	} else if code, expiresAt, ok := whatsapp.GetLoginQR(service.WaCli); ok {
+		push(whatsapp.LoginEvent{Event: whatsapp.LoginEventQR, Code: code, ExpiresAt: expiresAt})
 	}
 
-	// delete senditems
-	qrItems, err := filepath.Glob(fmt.Sprintf("./%s/*", config.PathSendItems))
-	if err != nil {
-		return err
+	return ch, nil
+}
+
+func (service serviceApp) Logout(_ context.Context) (err error) {
+	// Only the files of this session are deleted, the other sessions keep theirs
+	prefix := whatsapp.SessionFilePrefix(service.WaCli)
+	patterns := []string{
+		// qr images
+		fmt.Sprintf("./%s/scan-qr-%s*", config.PathQrCode, prefix),
+		// senditems
+		fmt.Sprintf("./%s/%s*", config.PathSendItems, prefix),
+	}
+	// history, its files are named after the device
+	if service.WaCli.Store.ID != nil {
+		patterns = append(patterns, fmt.Sprintf("./%s/history-*-%s-*", config.PathStorages, service.WaCli.Store.ID.String()))
+	}
+
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if err = os.Remove(f); err != nil {
+				return err
+			}
+		}
 	}
 
-	for _, f := range qrItems {
-		if !strings.Contains(f, ".gitignore") {
-			err = os.Remove(f)
-			if err != nil {
+	// Logging out is idempotent, a session without device is already logged out
+	if service.WaCli.Store.ID != nil {
+		if err = service.WaCli.Logout(); err != nil {
+			// Whatsapp can't be told without a connection, the local credentials and keys are removed anyway
+			logrus.Warnf("Failed to logout from whatsapp, removing the local session only: %v", err)
+			if err = service.WaCli.Store.Delete(); err != nil {
 				return err
 			}
+			service.WaCli.Disconnect()
 		}
 	}
 
-	err = service.WaCli.Logout()
-	return
+	whatsapp.WipeSession(service.WaCli)
+	return nil
 }
 
 func (service serviceApp) Reconnect(_ context.Context) (err error) {
@@ -211,3 +325,68 @@ func (service serviceApp) FetchDevices(_ context.Context) (response []domainApp.
 
 	return response, nil
 }
+
+func (service serviceApp) DeviceInfo(_ context.Context) (response domainApp.DeviceInfoResponse, err error) {
+	if service.WaCli == nil {
+		return response, pkgError.ErrWaCLI
+	}
+
+	info := whatsapp.GetDeviceInfo(service.WaCli)
+	response = domainApp.DeviceInfoResponse{
+		JID:              info.JID,
+		LID:              info.LID,
+		Platform:         info.Platform,
+		PushName:         info.PushName,
+		IsBusiness:       info.BusinessName != "",
+		BusinessName:     info.BusinessName,
+		IsConnected:      info.IsConnected,
+		IsLoggedIn:       info.IsLoggedIn,
+		WhatsmeowVersion: info.WhatsmeowVersion,
+	}
+	if !info.ConnectedAt.IsZero() {
+		response.ConnectedAt = &info.ConnectedAt
+		response.UptimeSeconds = int64(time.Since(info.ConnectedAt) / time.Second)
+	}
+
+	return response, nil
+}
+
+func (service serviceApp) ExportSession(ctx context.Context, request domainApp.ExportSessionRequest) (response domainApp.ExportSessionResponse, err error) {
+	if err = validations.ValidateExportSession(ctx, request); err != nil {
+		return response, err
+	}
+
+	response.Data, err = whatsapp.ExportSession(service.WaCli, request.Passphrase)
+	if err != nil {
+		return response, err
+	}
+	response.FileName = fmt.Sprintf("session-%s-%s.json", service.WaCli.Store.ID.User, time.Now().Format("20060102-150405"))
+	return response, nil
+}
+
+func (service serviceApp) ImportSession(ctx context.Context, request domainApp.ImportSessionRequest) (response domainApp.ImportSessionResponse, err error) {
+	if err = validations.ValidateImportSession(ctx, request); err != nil {
+		return response, err
+	}
+
+	file, err := request.Backup.Open()
+	if err != nil {
+		return response, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return response, err
+	}
+
+	device, err := whatsapp.ImportSession(service.WaCli, data, request.Passphrase, request.Force)
+	if err != nil {
+		return response, err
+	}
+	if err = service.WaCli.Connect(); err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("session is restored but failed to connect: %v", err))
+	}
+	response.Device = device.String()
+	return response, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+
+	domainAutoReply "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/autoreply"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+)
+
+type autoReplyService struct{}
+
+func NewAutoReplyService() domainAutoReply.IAutoReplyService {
+	return &autoReplyService{}
+}
+
+func (service autoReplyService) List(_ context.Context) (response domainAutoReply.AutoReplyRulesResponse, err error) {
+	enabled, rules := whatsapp.ListAutoReplyRules()
+	response.Enabled = enabled
+	response.Rules = make([]domainAutoReply.AutoReplyRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		response.Rules = append(response.Rules, toAutoReplyRuleResponse(rule))
+	}
+	return response, nil
+}
+
+func (service autoReplyService) Add(ctx context.Context, request domainAutoReply.AddAutoReplyRequest) (response domainAutoReply.AutoReplyRuleResponse, err error) {
+	if err = validations.ValidateAddAutoReply(ctx, request); err != nil {
+		return response, err
+	}
+
+	rule, err := whatsapp.AddAutoReplyRule(whatsapp.AutoReplyRule{
+		ID:            request.ID,
+		Match:         request.Match,
+		Pattern:       request.Pattern,
+		CaseSensitive: request.CaseSensitive,
+		Chat:          request.Chat,
+		Reply:         request.Reply,
+	})
+	if err != nil {
+		return response, err
+	}
+	return toAutoReplyRuleResponse(rule), nil
+}
+
+func (service autoReplyService) Delete(ctx context.Context, request domainAutoReply.DeleteAutoReplyRequest) (err error) {
+	if err = validations.ValidateDeleteAutoReply(ctx, request); err != nil {
+		return err
+	}
+	return whatsapp.RemoveAutoReplyRule(request.ID)
+}
+
+func (service autoReplyService) SetEnabled(_ context.Context, request domainAutoReply.SetAutoReplyEnabledRequest) (err error) {
+	return whatsapp.SetAutoReplyEnabled(request.Enabled)
+}
+
+func toAutoReplyRuleResponse(rule whatsapp.AutoReplyRule) domainAutoReply.AutoReplyRuleResponse {
+	return domainAutoReply.AutoReplyRuleResponse{
+		ID:            rule.ID,
+		Match:         rule.Match,
+		Pattern:       rule.Pattern,
+		CaseSensitive: rule.CaseSensitive,
+		Chat:          rule.Chat,
+		Reply:         rule.Reply,
+		Source:        rule.Source,
+	}
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+type chatService struct {
+	WaCli *whatsmeow.Client
+}
+
+func NewChatService(waCli *whatsmeow.Client) domainChat.IChatService {
+	return &chatService{
+		WaCli: waCli,
+	}
+}
+
+func (service chatService) SetEphemeral(ctx context.Context, request domainChat.SetEphemeralRequest) (response domainChat.SetEphemeralResponse, err error) {
+	if err = validations.ValidateSetEphemeral(ctx, request); err != nil {
+		return response, err
+	}
+	timer, _ := whatsmeow.ParseDisappearingTimerString(request.Duration)
+
+	JID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.ChatJID)
+	if err != nil {
+		return response, err
+	}
+
+	// Only admins can change the group settings when the group info is locked
+	if JID.Server == types.GroupServer {
+		groupInfo, err := service.WaCli.GetGroupInfo(JID)
+		if err != nil {
+			return response, err
+		}
+		if groupInfo.IsLocked && !whatsapp.IsGroupAdmin(service.WaCli, groupInfo) {
+			return response, pkgError.ForbiddenError("only group admins can change the disappearing messages of this group")
+		}
+	}
+
+	if err = service.WaCli.SetDisappearingTimer(JID, timer); err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to set disappearing messages: %v", err))
+	}
+	whatsapp.SetChatEphemeralTimer(service.WaCli, JID, timer)
+
+	response.ChatJID = JID.String()
+	response.Duration = uint32(timer.Seconds())
+	return response, nil
+}
+
+// ListChats returns the chats recorded from the events of the session, whatsapp can't list them on demand
+func (service chatService) ListChats(ctx context.Context, request domainChat.ListChatsRequest) (response domainChat.ListChatsResponse, err error) {
+	if request.Limit == 0 {
+		request.Limit = domainChat.ChatListDefaultLimit
+	}
+	if request.Sort == "" {
+		request.Sort = domainChat.ChatSortRecent
+	}
+	if err = validations.ValidateListChats(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	chats, total, err := whatsapp.ListChats(service.WaCli, request.Sort == domainChat.ChatSortRecent, request.Limit, request.Offset)
+	if err != nil {
+		return response, err
+	}
+
+	response.Data = []domainChat.ChatData{}
+	for _, chat := range chats {
+		data := domainChat.ChatData{
+			JID:           chat.JID,
+			Name:          chat.Name,
+			LastMessageID: chat.LastMessageID,
+			UnreadCount:   chat.UnreadCount,
+		}
+		if !chat.LastMessageAt.IsZero() {
+			data.LastMessageAt = chat.LastMessageAt.Format(time.RFC3339)
+		}
+		response.Data = append(response.Data, data)
+	}
+	response.Total = total
+	response.Limit = request.Limit
+	response.Offset = request.Offset
+	return response, nil
+}
+
+// ListMessages returns the stored history of a chat, it only covers the messages seen since they started being stored
+func (service chatService) ListMessages(ctx context.Context, request domainChat.ListMessagesRequest) (response domainChat.ListMessagesResponse, err error) {
+	if request.Limit == 0 {
+		request.Limit = domainChat.MessageListDefaultLimit
+	}
+	if err = validations.ValidateListMessages(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	JID, err := whatsapp.ParseJID(request.ChatJID)
+	if err != nil {
+		return response, err
+	}
+
+	messages, hasMore, err := whatsapp.GetChatMessages(service.WaCli, JID, messageCursor(request.Before), messageCursor(request.After), request.Limit)
+	if err != nil {
+		return response, err
+	}
+
+	response.Data = []domainChat.MessageData{}
+	for _, msg := range messages {
+		response.Data = append(response.Data, newMessageData(service.WaCli, msg))
+	}
+	response.HasMore = hasMore
+	return response, nil
+}
+
+// messageCursor tells a timestamp from a message id, a message id never parses as RFC3339
+func messageCursor(value string) whatsapp.MessageCursor {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return whatsapp.MessageCursor{Time: at}
+	}
+	return whatsapp.MessageCursor{ID: value}
+}
+
+func newMessageData(client *whatsmeow.Client, msg whatsapp.StoredMessage) domainChat.MessageData {
+	data := domainChat.MessageData{
+		ID:        msg.ID,
+		ChatJID:   msg.ChatJID,
+		SenderJID: msg.SenderJID,
+		IsFromMe:  msg.IsFromMe,
+		PushName:  msg.PushName,
+		Type:      msg.Type,
+		Text:      msg.Text,
+		Timestamp: msg.SentAt.Format(time.RFC3339),
+	}
+	if msg.MediaType != "" {
+		data.Media = &domainChat.MessageMedia{
+			MimeType:     msg.MimeType,
+			FileName:     msg.FileName,
+			DownloadPath: whatsapp.MediaDownloadPath(client, msg.ID),
+		}
+	}
+	return data
+}
+
+func (service chatService) MarkAsRead(ctx context.Context, request domainChat.MarkAsReadRequest) (response domainChat.MarkAsReadResponse, err error) {
+	if err = validations.ValidateMarkChatAsRead(ctx, request); err != nil {
+		return response, err
+	}
+
+	JID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.ChatJID)
+	if err != nil {
+		return response, err
+	}
+
+	ids := request.MessageIDs
+	if request.UpToMessageID != "" && !slices.Contains(ids, request.UpToMessageID) {
+		ids = append(ids, request.UpToMessageID)
+	}
+
+	// In groups the receipt must name the participant who sent the messages
+	sender := types.EmptyJID
+	if request.Sender != "" {
+		if sender, err = whatsapp.ParseJID(request.Sender); err != nil {
+			return response, err
+		}
+	} else {
+		sender = storedMessageSender(service.WaCli, JID, ids[len(ids)-1])
+	}
+
+	if err = markMessagesRead(service.WaCli, ids, JID, sender); err != nil {
+		var validationErr pkgError.ValidationError
+		if errors.As(err, &validationErr) {
+			return response, err
+		}
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to mark as read: %v", err))
+	}
+
+	// Clear the unread badge of the chat on all linked devices, the range ends at the timestamp of the last message
+	if request.UpToMessageID != "" {
+		lastMessageAt := time.Now()
+		if msg, err := whatsapp.GetStoredMessage(service.WaCli, JID, request.UpToMessageID); err == nil {
+			lastMessageAt = msg.SentAt
+		}
+
+		key := &waCommon.MessageKey{
+			RemoteJID: proto.String(JID.String()),
+			FromMe:    proto.Bool(false),
+			ID:        proto.String(request.UpToMessageID),
+		}
+		if !sender.IsEmpty() {
+			key.Participant = proto.String(sender.String())
+		}
+
+		if err = whatsapp.SendChatSettings(service.WaCli, buildMarkChatAsRead(JID, true, time.Now(), lastMessageAt, key)); err != nil {
+			return response, err
+		}
+		whatsapp.RecordChatRead(service.WaCli, JID, true)
+	}
+
+	response.ChatJID = JID.String()
+	response.MessageIDs = ids
+	response.ReadReceipts = service.WaCli.GetPrivacySettings().ReadReceipts != types.PrivacySettingNone
+	return response, nil
+}
+
+func (service chatService) MarkAsUnread(ctx context.Context, request domainChat.MarkAsUnreadRequest) (response domainChat.MarkAsUnreadResponse, err error) {
+	if err = validations.ValidateMarkChatAsUnread(ctx, request); err != nil {
+		return response, err
+	}
+	JID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.ChatJID)
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now()
+	if err = whatsapp.SendChatSettings(service.WaCli, buildMarkChatAsRead(JID, !request.Unread, now, now, nil)); err != nil {
+		return response, err
+	}
+	whatsapp.RecordChatRead(service.WaCli, JID, !request.Unread)
+
+	response.ChatJID = JID.String()
+	response.Unread = request.Unread
+	return response, nil
+}
+
+func (service chatService) ArchiveChat(ctx context.Context, request domainChat.ArchiveChatRequest) (response domainChat.ChatSettingsResponse, err error) {
+	if err = validations.ValidateArchiveChat(ctx, request); err != nil {
+		return response, err
+	}
+	JID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.ChatJID)
+	if err != nil {
+		return response, err
+	}
+
+	// Archiving a chat unpins it as well
+	if err = whatsapp.SendChatSettings(service.WaCli, appstate.BuildArchive(JID, request.Archived, time.Time{}, nil)); err != nil {
+		return response, err
+	}
+	return service.chatSettings(JID)
+}
+
+func (service chatService) PinChat(ctx context.Context, request domainChat.PinChatRequest) (response domainChat.ChatSettingsResponse, err error) {
+	if err = validations.ValidatePinChat(ctx, request); err != nil {
+		return response, err
+	}
+	JID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.ChatJID)
+	if err != nil {
+		return response, err
+	}
+
+	if err = whatsapp.SendChatSettings(service.WaCli, appstate.BuildPin(JID, request.Pinned)); err != nil {
+		return response, err
+	}
+	return service.chatSettings(JID)
+}
+
+func (service chatService) MuteChat(ctx context.Context, request domainChat.MuteChatRequest) (response domainChat.ChatSettingsResponse, err error) {
+	if err = validations.ValidateMuteChat(ctx, request); err != nil {
+		return response, err
+	}
+	JID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.ChatJID)
+	if err != nil {
+		return response, err
+	}
+
+	mute, duration := muteDuration(request.Duration)
+	if err = whatsapp.SendChatSettings(service.WaCli, appstate.BuildMute(JID, mute, duration)); err != nil {
+		return response, err
+	}
+	return service.chatSettings(JID)
+}
+
+// chatSettings returns the chat settings after a patch, whatsmeow stores the patch once whatsapp accepted it
+func (service chatService) chatSettings(JID types.JID) (response domainChat.ChatSettingsResponse, err error) {
+	settings, err := whatsapp.GetChatSettings(service.WaCli, JID)
+	if err != nil {
+		return response, err
+	}
+
+	response.ChatJID = JID.String()
+	response.Archived = settings.Archived
+	response.Pinned = settings.Pinned
+	response.Muted = settings.Muted
+	if !settings.MutedUntil.IsZero() {
+		response.MutedUntil = settings.MutedUntil.Format(time.RFC3339)
+	}
+	return response, nil
+}
+
+// muteDuration maps the mute duration of the api to the whatsmeow mute patch, a zero duration mutes forever
+func muteDuration(duration string) (mute bool, muteDuration time.Duration) {
+	switch duration {
+	case "8h":
+		return true, 8 * time.Hour
+	case "1w":
+		return true, 7 * 24 * time.Hour
+	case "always":
+		return true, 0
+	default:
+		return false, 0
+	}
+}
+
+// buildMarkChatAsRead builds the app state patch of the unread marker of a chat, marking a chat read clears it.
+// Both directions share this patch so the latest call wins, whatsapp orders them by the timestamp
+func buildMarkChatAsRead(JID types.JID, read bool, now, lastMessageAt time.Time, lastMessage *waCommon.MessageKey) appstate.PatchInfo {
+	messageRange := &waSyncAction.SyncActionMessageRange{
+		LastMessageTimestamp: proto.Int64(lastMessageAt.Unix()),
+	}
+	if lastMessage != nil {
+		messageRange.Messages = []*waSyncAction.SyncActionMessage{{
+			Key:       lastMessage,
+			Timestamp: proto.Int64(lastMessageAt.Unix()),
+		}}
+	}
+
+	return appstate.PatchInfo{
+		Timestamp: now,
+		Type:      appstate.WAPatchRegularLow,
+		Mutations: []appstate.MutationInfo{{
+			Index:   []string{appstate.IndexMarkChatAsRead, JID.String()},
+			Version: 3,
+			Value: &waSyncAction.SyncActionValue{
+				MarkChatAsReadAction: &waSyncAction.MarkChatAsReadAction{
+					Read:         proto.Bool(read),
+					MessageRange: messageRange,
+				},
+			},
+		}},
+	}
+}
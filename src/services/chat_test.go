@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waCommon"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBuildMarkChatAsRead(t *testing.T) {
+	jid := types.NewJID("6289685028129", types.DefaultUserServer)
+	now := time.Unix(1735689600, 0)
+
+	unread := buildMarkChatAsRead(jid, false, now, now, nil)
+	assert.Equal(t, appstate.WAPatchRegularLow, unread.Type)
+	assert.Equal(t, []string{appstate.IndexMarkChatAsRead, jid.String()}, unread.Mutations[0].Index)
+	action := unread.Mutations[0].Value.GetMarkChatAsReadAction()
+	assert.False(t, action.GetRead())
+	assert.Equal(t, now.Unix(), action.GetMessageRange().GetLastMessageTimestamp())
+	assert.Empty(t, action.GetMessageRange().GetMessages())
+
+	// Marking read goes through the same mutation index, so it replaces the unread marker
+	key := &waCommon.MessageKey{ID: proto.String("3EB0C127D7BACC83D6A1")}
+	read := buildMarkChatAsRead(jid, true, now.Add(time.Minute), now, key)
+	assert.Equal(t, unread.Mutations[0].Index, read.Mutations[0].Index)
+	assert.True(t, read.Mutations[0].Value.GetMarkChatAsReadAction().GetRead())
+	readRange := read.Mutations[0].Value.GetMarkChatAsReadAction().GetMessageRange()
+	assert.Equal(t, "3EB0C127D7BACC83D6A1", readRange.GetMessages()[0].GetKey().GetID())
+	// The range ends at the last message, not at the time it was read
+	assert.Equal(t, now.Unix(), readRange.GetLastMessageTimestamp())
+	assert.Equal(t, now.Unix(), readRange.GetMessages()[0].GetTimestamp())
+	assert.Equal(t, now.Add(time.Minute), read.Timestamp)
+}
+
+func TestMuteDuration(t *testing.T) {
+	tests := []struct {
+		duration string
+		mute     bool
+		length   time.Duration
+	}{
+		{"8h", true, 8 * time.Hour},
+		{"1w", true, 7 * 24 * time.Hour},
+		{"always", true, 0},
+		{"off", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.duration, func(t *testing.T) {
+			mute, length := muteDuration(tt.duration)
+			assert.Equal(t, tt.mute, mute)
+			assert.Equal(t, tt.length, length)
+		})
+	}
+}
+
+func TestMessageCursor(t *testing.T) {
+	assert.Equal(t, whatsapp.MessageCursor{ID: "3EB0C127D7BACC83D6A1"}, messageCursor("3EB0C127D7BACC83D6A1"))
+	assert.Equal(t, time.Unix(1735689600, 0).Unix(), messageCursor("2025-01-01T00:00:00Z").Time.Unix())
+	assert.True(t, messageCursor("").IsEmpty())
+}
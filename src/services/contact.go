@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+
+	domainContact "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/contact"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+type contactService struct {
+	WaCli *whatsmeow.Client
+}
+
+func NewContactService(waCli *whatsmeow.Client) domainContact.IContactService {
+	return &contactService{
+		WaCli: waCli,
+	}
+}
+
+func (service contactService) Check(ctx context.Context, request domainContact.CheckRequest) (response domainContact.CheckResponse, err error) {
+	if err = validations.ValidateCheckContacts(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	results, err := whatsapp.CheckOnWhatsapp(service.WaCli, request.Phones)
+	if err != nil {
+		return response, err
+	}
+
+	for _, result := range results {
+		data := domainContact.CheckResponseData{
+			Phone:        result.Phone,
+			IsOnWhatsapp: result.IsIn,
+			VerifiedName: result.VerifiedName,
+		}
+		if result.IsIn {
+			data.JID = result.JID.String()
+		}
+		response.Data = append(response.Data, data)
+	}
+	return response, nil
+}
+
+func (service contactService) Block(ctx context.Context, request domainContact.BlockRequest) (response domainContact.BlockResponse, err error) {
+	return service.updateBlocklist(ctx, request, true)
+}
+
+func (service contactService) Unblock(ctx context.Context, request domainContact.BlockRequest) (response domainContact.BlockResponse, err error) {
+	return service.updateBlocklist(ctx, request, false)
+}
+
+func (service contactService) Blocklist(_ context.Context) (response domainContact.BlocklistResponse, err error) {
+	whatsapp.MustLogin(service.WaCli)
+
+	blocklist, err := service.WaCli.GetBlocklist()
+	if err != nil {
+		return response, err
+	}
+
+	response.Data = []string{}
+	for _, jid := range blocklist.JIDs {
+		response.Data = append(response.Data, jid.String())
+	}
+	return response, nil
+}
+
+func (service contactService) updateBlocklist(ctx context.Context, request domainContact.BlockRequest, blocked bool) (response domainContact.BlockResponse, err error) {
+	if err = validations.ValidateBlockContact(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	// a spammer may already have left whatsapp, so the account validation is skipped here
+	jid, err := whatsapp.ParseJID(request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	isBlocked, err := setBlocked(service.WaCli, jid, blocked)
+	if err != nil {
+		return response, err
+	}
+
+	response.JID = jid.String()
+	response.IsBlocked = isBlocked
+	return response, nil
+}
+
+// blocklistUpdater is the part of the whatsmeow client used to read and change the block list
+type blocklistUpdater interface {
+	GetBlocklist() (*types.Blocklist, error)
+	UpdateBlocklist(jid types.JID, action events.BlocklistChangeAction) (*types.Blocklist, error)
+}
+
+// setBlocked brings jid into the wanted block state, a jid already in that state is left untouched
+// so blocking or unblocking twice succeeds. It returns the block state reported by whatsapp.
+func setBlocked(cli blocklistUpdater, jid types.JID, blocked bool) (bool, error) {
+	current, err := cli.GetBlocklist()
+	if err != nil {
+		return false, err
+	}
+	if isInBlocklist(current, jid) == blocked {
+		return blocked, nil
+	}
+
+	action := events.BlocklistChangeActionBlock
+	if !blocked {
+		action = events.BlocklistChangeActionUnblock
+	}
+	updated, err := cli.UpdateBlocklist(jid, action)
+	if err != nil {
+		return false, err
+	}
+	return isInBlocklist(updated, jid), nil
+}
+
+func isInBlocklist(blocklist *types.Blocklist, jid types.JID) bool {
+	if blocklist == nil {
+		return false
+	}
+	for _, blocked := range blocklist.JIDs {
+		if blocked.ToNonAD() == jid.ToNonAD() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+type fakeBlocklist struct {
+	jids    []types.JID
+	updates []events.BlocklistChangeAction
+}
+
+func (f *fakeBlocklist) GetBlocklist() (*types.Blocklist, error) {
+	return &types.Blocklist{JIDs: f.jids}, nil
+}
+
+func (f *fakeBlocklist) UpdateBlocklist(jid types.JID, action events.BlocklistChangeAction) (*types.Blocklist, error) {
+	f.updates = append(f.updates, action)
+	if action == events.BlocklistChangeActionBlock {
+		f.jids = append(f.jids, jid)
+	} else {
+		var jids []types.JID
+		for _, blocked := range f.jids {
+			if blocked != jid {
+				jids = append(jids, blocked)
+			}
+		}
+		f.jids = jids
+	}
+	return &types.Blocklist{JIDs: f.jids}, nil
+}
+
+func TestSetBlocked(t *testing.T) {
+	jid := types.NewJID("6289685028129", types.DefaultUserServer)
+	cli := &fakeBlocklist{}
+
+	blocked, err := setBlocked(cli, jid, true)
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+
+	// blocking again is a no-op
+	blocked, err = setBlocked(cli, jid, true)
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+	assert.Equal(t, []events.BlocklistChangeAction{events.BlocklistChangeActionBlock}, cli.updates)
+
+	blocked, err = setBlocked(cli, jid, false)
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+
+	blocked, err = setBlocked(cli, jid, false)
+	assert.NoError(t, err)
+	assert.False(t, blocked)
+	assert.Equal(t, []events.BlocklistChangeAction{events.BlocklistChangeActionBlock, events.BlocklistChangeActionUnblock}, cli.updates)
+}
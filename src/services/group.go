@@ -2,15 +2,19 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
-	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	domainGroup "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/group"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"github.com/sirupsen/logrus"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
 )
 
 type groupService struct {
@@ -29,13 +33,178 @@ func (service groupService) JoinGroupWithLink(ctx context.Context, request domai
 	}
 	whatsapp.MustLogin(service.WaCli)
 
-	jid, err := service.WaCli.JoinGroupWithLink(request.Link)
-	if err != nil {
+	jid, err := service.WaCli.JoinGroupWithLink(normalizeInviteCode(request.Link))
+	if errors.Is(err, whatsmeow.ErrInviteLinkRevoked) || errors.Is(err, whatsmeow.ErrInviteLinkInvalid) {
+		return groupID, pkgError.ValidationError(err.Error())
+	} else if err != nil {
 		return
 	}
 	return jid.String(), nil
 }
 
+func (service groupService) SetGroupInfo(ctx context.Context, request domainGroup.GroupInfoRequest) (response domainGroup.GroupInfoResponse, err error) {
+	if err = validations.ValidateSetGroupInfo(ctx, request); err != nil {
+		return response, err
+	}
+
+	groupJID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.GroupID)
+	if err != nil {
+		return response, err
+	}
+
+	if err = service.ensureGroupAdmin(groupJID); err != nil {
+		return response, err
+	}
+
+	sender := service.WaCli.Store.ID.ToNonAD()
+	change := &events.GroupInfo{JID: groupJID, Sender: &sender, Timestamp: time.Now()}
+	response.GroupID = groupJID.String()
+
+	if request.Subject != nil {
+		if err = service.WaCli.SetGroupName(groupJID, *request.Subject); err != nil {
+			return response, err
+		}
+		response.Subject = request.Subject
+		change.Name = &types.GroupName{Name: *request.Subject, NameSetAt: change.Timestamp}
+	}
+
+	if request.Description != nil {
+		if err = service.WaCli.SetGroupTopic(groupJID, "", "", *request.Description); err != nil {
+			// The subject may be applied already, the caller and the consumers still have to learn about it
+			if change.Name != nil {
+				whatsapp.EmitGroupInfoChange(service.WaCli, change)
+				return response, fmt.Errorf("subject was changed to %q, but changing the description failed: %w", *request.Subject, err)
+			}
+			return response, err
+		}
+		response.Description = request.Description
+		change.Topic = &types.GroupTopic{Topic: *request.Description, TopicSetAt: change.Timestamp, TopicDeleted: *request.Description == ""}
+	}
+
+	whatsapp.EmitGroupInfoChange(service.WaCli, change)
+	return response, nil
+}
+
+func (service groupService) SetGroupSetting(ctx context.Context, request domainGroup.GroupSettingRequest) (response domainGroup.GroupSettingResponse, err error) {
+	if err = validations.ValidateSetGroupSetting(ctx, request); err != nil {
+		return response, err
+	}
+
+	groupJID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.GroupID)
+	if err != nil {
+		return response, err
+	}
+
+	if err = service.ensureGroupAdmin(groupJID); err != nil {
+		return response, err
+	}
+
+	enabled := *request.Enabled
+	if err = applyGroupSetting(service.WaCli, groupJID, request.Setting, enabled); err != nil {
+		return response, err
+	}
+
+	sender := service.WaCli.Store.ID.ToNonAD()
+	change := &events.GroupInfo{JID: groupJID, Sender: &sender, Timestamp: time.Now()}
+	if request.Setting == domainGroup.GroupSettingAnnounce {
+		change.Announce = &types.GroupAnnounce{IsAnnounce: enabled}
+	} else {
+		change.Locked = &types.GroupLocked{IsLocked: enabled}
+	}
+	whatsapp.EmitGroupInfoChange(service.WaCli, change)
+
+	return domainGroup.GroupSettingResponse{
+		GroupID: groupJID.String(),
+		Setting: request.Setting,
+		Enabled: enabled,
+	}, nil
+}
+
+// groupSettingSetter is the part of the whatsmeow client used to toggle the group settings
+type groupSettingSetter interface {
+	SetGroupAnnounce(jid types.JID, announce bool) error
+	SetGroupLocked(jid types.JID, locked bool) error
+}
+
+func applyGroupSetting(cli groupSettingSetter, groupJID types.JID, setting string, enabled bool) error {
+	switch setting {
+	case domainGroup.GroupSettingAnnounce:
+		return cli.SetGroupAnnounce(groupJID, enabled)
+	case domainGroup.GroupSettingLocked:
+		return cli.SetGroupLocked(groupJID, enabled)
+	default:
+		return pkgError.ValidationError(fmt.Sprintf("unknown group setting %s", setting))
+	}
+}
+
+func (service groupService) GetInviteLink(ctx context.Context, request domainGroup.GroupInviteLinkRequest) (response domainGroup.GroupInviteLinkResponse, err error) {
+	if err = validations.ValidateGroupInviteLink(ctx, request); err != nil {
+		return response, err
+	}
+
+	groupJID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.GroupID)
+	if err != nil {
+		return response, err
+	}
+
+	if err = service.ensureGroupAdmin(groupJID); err != nil {
+		return response, err
+	}
+
+	link, err := service.WaCli.GetGroupInviteLink(groupJID, request.Reset)
+	if errors.Is(err, whatsmeow.ErrGroupInviteLinkUnauthorized) {
+		return response, pkgError.ForbiddenError(err.Error())
+	} else if err != nil {
+		return response, err
+	}
+
+	return domainGroup.GroupInviteLinkResponse{
+		GroupID:    groupJID.String(),
+		InviteLink: link,
+		InviteCode: normalizeInviteCode(link),
+	}, nil
+}
+
+// GetInviteInfo resolves an invite code to the group it opens, without joining the group
+func (service groupService) GetInviteInfo(ctx context.Context, request domainGroup.GroupInviteInfoRequest) (response domainGroup.GroupInviteInfoResponse, err error) {
+	if err = validations.ValidateGroupInviteInfo(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	code := normalizeInviteCode(request.Code)
+	info, err := service.WaCli.GetGroupInfoFromLink(code)
+	if errors.Is(err, whatsmeow.ErrInviteLinkRevoked) {
+		return response, pkgError.ValidationError(fmt.Sprintf("invite code %s was revoked or has expired", code))
+	} else if errors.Is(err, whatsmeow.ErrInviteLinkInvalid) {
+		return response, pkgError.ValidationError(fmt.Sprintf("invite code %s is not valid", code))
+	} else if err != nil {
+		return response, err
+	}
+	return newGroupInviteInfo(code, info), nil
+}
+
+func newGroupInviteInfo(code string, info *types.GroupInfo) domainGroup.GroupInviteInfoResponse {
+	response := domainGroup.GroupInviteInfoResponse{
+		GroupID:                info.JID.String(),
+		InviteCode:             code,
+		Subject:                info.Name,
+		Description:            info.Topic,
+		Size:                   len(info.Participants),
+		IsJoinApprovalRequired: info.IsJoinApprovalRequired,
+	}
+	// The phone number of the creator is preferred, newer groups only tell its lid
+	if !info.OwnerPN.IsEmpty() {
+		response.CreatorJID = info.OwnerPN.String()
+	} else if !info.OwnerJID.IsEmpty() {
+		response.CreatorJID = info.OwnerJID.String()
+	}
+	if !info.GroupCreated.IsZero() {
+		response.CreatedAt = info.GroupCreated.Format(time.RFC3339)
+	}
+	return response
+}
+
 func (service groupService) LeaveGroup(ctx context.Context, request domainGroup.LeaveGroupRequest) (err error) {
 	if err = validations.ValidateLeaveGroup(ctx, request); err != nil {
 		return err
@@ -46,12 +215,25 @@ func (service groupService) LeaveGroup(ctx context.Context, request domainGroup.
 		return err
 	}
 
-	return service.WaCli.LeaveGroup(JID)
+	// Leaving is idempotent, a group we are not part of (anymore) is already left
+	if _, err = service.WaCli.GetGroupInfo(JID); errors.Is(err, whatsmeow.ErrNotInGroup) || errors.Is(err, whatsmeow.ErrGroupNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err = service.WaCli.LeaveGroup(JID); err != nil {
+		return err
+	}
+
+	self := service.WaCli.Store.ID.ToNonAD()
+	whatsapp.EmitGroupInfoChange(service.WaCli, &events.GroupInfo{JID: JID, Sender: &self, Timestamp: time.Now(), Leave: []types.JID{self}})
+	return nil
 }
 
-func (service groupService) CreateGroup(ctx context.Context, request domainGroup.CreateGroupRequest) (groupID string, err error) {
+func (service groupService) CreateGroup(ctx context.Context, request domainGroup.CreateGroupRequest) (response domainGroup.CreateGroupResponse, err error) {
 	if err = validations.ValidateCreateGroup(ctx, request); err != nil {
-		return groupID, err
+		return response, err
 	}
 	whatsapp.MustLogin(service.WaCli)
 
@@ -72,7 +254,23 @@ func (service groupService) CreateGroup(ctx context.Context, request domainGroup
 		return
 	}
 
-	return groupInfo.JID.String(), nil
+	response.GroupID = groupInfo.JID.String()
+	response.Participants = []domainGroup.ParticipantStatus{}
+	for _, participant := range groupInfo.Participants {
+		if service.WaCli.Store.ID != nil && participant.JID.User == service.WaCli.Store.ID.User {
+			continue
+		}
+		response.Participants = append(response.Participants, newParticipantStatus(participant, whatsmeow.ParticipantChangeAdd))
+	}
+
+	// The group is already created at this point, a missing invite link must not fail the request
+	if link, err := service.WaCli.GetGroupInviteLink(groupInfo.JID, false); err != nil {
+		logrus.Warnf("failed to get invite link of new group %s: %v", groupInfo.JID, err)
+	} else {
+		response.InviteLink = link
+	}
+
+	return response, nil
 }
 
 func (service groupService) ManageParticipant(ctx context.Context, request domainGroup.ParticipantRequest) (result []domainGroup.ParticipantStatus, err error) {
@@ -91,25 +289,19 @@ func (service groupService) ManageParticipant(ctx context.Context, request domai
 		return result, err
 	}
 
+	if err = service.ensureGroupAdmin(groupJID); err != nil {
+		return result, err
+	}
+
 	participants, err := service.WaCli.UpdateGroupParticipants(groupJID, participantsJID, request.Action)
-	if err != nil {
+	if errors.Is(err, whatsmeow.ErrIQForbidden) || errors.Is(err, whatsmeow.ErrIQNotAuthorized) {
+		return result, pkgError.ForbiddenError(err.Error())
+	} else if err != nil {
 		return result, err
 	}
 
 	for _, participant := range participants {
-		if participant.Error == 403 && participant.AddRequest != nil {
-			result = append(result, domainGroup.ParticipantStatus{
-				Participant: participant.JID.String(),
-				Status:      "error",
-				Message:     "Failed to add participant",
-			})
-		} else {
-			result = append(result, domainGroup.ParticipantStatus{
-				Participant: participant.JID.String(),
-				Status:      "success",
-				Message:     "Action success",
-			})
-		}
+		result = append(result, newParticipantStatus(participant, request.Action))
 	}
 
 	return result, nil
@@ -179,18 +371,85 @@ func (service groupService) ManageGroupRequestParticipants(ctx context.Context,
 	return result, nil
 }
 
+// ensureGroupAdmin returns a forbidden error when the logged in account is not an admin of the group
+func (service groupService) ensureGroupAdmin(groupJID types.JID) error {
+	groupInfo, err := service.WaCli.GetGroupInfo(groupJID)
+	if errors.Is(err, whatsmeow.ErrNotInGroup) {
+		return pkgError.ForbiddenError(err.Error())
+	} else if errors.Is(err, whatsmeow.ErrGroupNotFound) {
+		return pkgError.ValidationError(err.Error())
+	} else if err != nil {
+		return err
+	}
+
+	if !whatsapp.IsGroupAdmin(service.WaCli, groupInfo) {
+		return pkgError.ForbiddenError("you need to be an admin of the group")
+	}
+	return nil
+}
+
+// normalizeInviteCode accepts a full invite url (with or without scheme) or a bare code and returns the code
+func normalizeInviteCode(link string) string {
+	code := strings.TrimSpace(link)
+	code = strings.TrimPrefix(code, "https://")
+	code = strings.TrimPrefix(code, "http://")
+	code = strings.TrimPrefix(code, "chat.whatsapp.com/")
+	code = strings.TrimPrefix(code, "invite/")
+	if idx := strings.IndexAny(code, "?#"); idx >= 0 {
+		code = code[:idx]
+	}
+	return strings.TrimSuffix(code, "/")
+}
+
+// newParticipantStatus converts the outcome whatsapp returned for a single participant of an action
+func newParticipantStatus(participant types.GroupParticipant, action whatsmeow.ParticipantChange) domainGroup.ParticipantStatus {
+	status := domainGroup.ParticipantStatus{
+		Participant: participant.JID.String(),
+		Status:      "success",
+		Message:     fmt.Sprintf("Action %s success", action),
+	}
+
+	switch {
+	case participant.Error == 0:
+	case participant.Error == 403 && participant.AddRequest != nil:
+		status.Status = "invite_required"
+		status.Message = "Participant privacy settings only allow joining through an invite"
+		status.InviteCode = participant.AddRequest.Code
+		status.InviteExpiration = participant.AddRequest.Expiration
+	case participant.Error == 403:
+		status.Status = "error"
+		status.Message = "Participant privacy settings do not allow being added"
+	case participant.Error == 408:
+		status.Status = "error"
+		status.Message = "Participant recently left the group and can not be added yet"
+	case participant.Error == 404:
+		status.Status = "error"
+		status.Message = "Participant is not in the group"
+	case participant.Error == 409:
+		status.Status = "error"
+		status.Message = "Participant is already in the group"
+	default:
+		status.Status = "error"
+		status.Message = fmt.Sprintf("Action %s failed (code %d)", action, participant.Error)
+	}
+
+	return status
+}
+
+// participantToJID normalizes the participants like every other phone of the api, a single invalid one fails the
+// whole request instead of being left out of it
 func (service groupService) participantToJID(participants []string) ([]types.JID, error) {
 	var participantsJID []types.JID
 	for _, participant := range participants {
-		formattedParticipant := participant + config.WhatsappTypeUser
-
-		if !whatsapp.IsOnWhatsapp(service.WaCli, formattedParticipant) {
-			return nil, pkgError.ErrUserNotRegistered
+		participantJID, err := whatsapp.NormalizeJID(participant)
+		if err != nil {
+			return nil, pkgError.ValidationError(fmt.Sprintf("participant %s: %v", participant, err))
 		}
 
-		if participantJID, err := types.ParseJID(formattedParticipant); err == nil {
-			participantsJID = append(participantsJID, participantJID)
+		if !whatsapp.IsOnWhatsapp(service.WaCli, participantJID.String()) {
+			return nil, pkgError.ErrUserNotRegistered
 		}
+		participantsJID = append(participantsJID, participantJID)
 	}
 	return participantsJID, nil
 }
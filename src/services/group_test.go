@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	domainGroup "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/group"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestNewParticipantStatus(t *testing.T) {
+	jid := types.NewJID("6289685028129", types.DefaultUserServer)
+	expiration := time.Unix(1735689600, 0)
+
+	tests := []struct {
+		name        string
+		participant types.GroupParticipant
+		status      string
+		inviteCode  string
+	}{
+		{name: "added", participant: types.GroupParticipant{JID: jid}, status: "success"},
+		{
+			name: "privacy settings require an invite",
+			participant: types.GroupParticipant{JID: jid, Error: 403, AddRequest: &types.GroupParticipantAddRequest{
+				Code: "AbCdEf", Expiration: expiration,
+			}},
+			status:     "invite_required",
+			inviteCode: "AbCdEf",
+		},
+		{name: "already in group", participant: types.GroupParticipant{JID: jid, Error: 409}, status: "error"},
+		{name: "not in group", participant: types.GroupParticipant{JID: jid, Error: 404}, status: "error"},
+		{name: "unknown error", participant: types.GroupParticipant{JID: jid, Error: 500}, status: "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := newParticipantStatus(tt.participant, whatsmeow.ParticipantChangeAdd)
+			assert.Equal(t, jid.String(), status.Participant)
+			assert.Equal(t, tt.status, status.Status)
+			assert.Equal(t, tt.inviteCode, status.InviteCode)
+			assert.NotEmpty(t, status.Message)
+		})
+	}
+}
+
+func TestNewParticipantStatusMessageNamesAction(t *testing.T) {
+	jid := types.NewJID("6289685028129", types.DefaultUserServer)
+
+	assert.Equal(t, "Action promote success", newParticipantStatus(types.GroupParticipant{JID: jid}, whatsmeow.ParticipantChangePromote).Message)
+	assert.Equal(t, "Action demote failed (code 500)", newParticipantStatus(types.GroupParticipant{JID: jid, Error: 500}, whatsmeow.ParticipantChangeDemote).Message)
+}
+
+func TestNormalizeInviteCode(t *testing.T) {
+	for _, link := range []string{
+		"AbCdEfGhIjKlMnOpQrStUv",
+		" AbCdEfGhIjKlMnOpQrStUv ",
+		"https://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv",
+		"http://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv/",
+		"chat.whatsapp.com/invite/AbCdEfGhIjKlMnOpQrStUv",
+		"https://chat.whatsapp.com/AbCdEfGhIjKlMnOpQrStUv?mode=ac_t",
+	} {
+		assert.Equal(t, "AbCdEfGhIjKlMnOpQrStUv", normalizeInviteCode(link), link)
+	}
+}
+
+func TestNewGroupInviteInfo(t *testing.T) {
+	info := &types.GroupInfo{
+		JID:                         types.NewJID("120363025246125888", types.GroupServer),
+		OwnerJID:                    types.NewJID("123456789", types.HiddenUserServer),
+		OwnerPN:                     types.NewJID("6289685028129", types.DefaultUserServer),
+		GroupName:                   types.GroupName{Name: "Weekend hike"},
+		GroupTopic:                  types.GroupTopic{Topic: "Saturday 7am"},
+		GroupCreated:                time.Unix(1735689600, 0).UTC(),
+		Participants:                []types.GroupParticipant{{}, {}, {}},
+		GroupMembershipApprovalMode: types.GroupMembershipApprovalMode{IsJoinApprovalRequired: true},
+	}
+
+	response := newGroupInviteInfo("AbCdEfGhIjKlMnOpQrStUv", info)
+	assert.Equal(t, "120363025246125888@g.us", response.GroupID)
+	assert.Equal(t, "Weekend hike", response.Subject)
+	assert.Equal(t, "Saturday 7am", response.Description)
+	assert.Equal(t, 3, response.Size)
+	assert.Equal(t, "6289685028129@s.whatsapp.net", response.CreatorJID)
+	assert.Equal(t, "2025-01-01T00:00:00Z", response.CreatedAt)
+	assert.True(t, response.IsJoinApprovalRequired)
+
+	// Without the phone number the lid of the creator is returned
+	info.OwnerPN = types.EmptyJID
+	assert.Equal(t, "123456789@lid", newGroupInviteInfo("AbCdEfGhIjKlMnOpQrStUv", info).CreatorJID)
+}
+
+type fakeGroupSettingSetter struct {
+	calls []string
+}
+
+func (f *fakeGroupSettingSetter) SetGroupAnnounce(jid types.JID, announce bool) error {
+	f.calls = append(f.calls, fmt.Sprintf("SetGroupAnnounce(%s, %t)", jid, announce))
+	return nil
+}
+
+func (f *fakeGroupSettingSetter) SetGroupLocked(jid types.JID, locked bool) error {
+	f.calls = append(f.calls, fmt.Sprintf("SetGroupLocked(%s, %t)", jid, locked))
+	return nil
+}
+
+func TestApplyGroupSetting(t *testing.T) {
+	group := types.NewJID("120363025246125888", types.GroupServer)
+
+	tests := []struct {
+		setting string
+		enabled bool
+		call    string
+	}{
+		{domainGroup.GroupSettingAnnounce, true, "SetGroupAnnounce(120363025246125888@g.us, true)"},
+		{domainGroup.GroupSettingAnnounce, false, "SetGroupAnnounce(120363025246125888@g.us, false)"},
+		{domainGroup.GroupSettingLocked, true, "SetGroupLocked(120363025246125888@g.us, true)"},
+		{domainGroup.GroupSettingLocked, false, "SetGroupLocked(120363025246125888@g.us, false)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.call, func(t *testing.T) {
+			setter := &fakeGroupSettingSetter{}
+			assert.NoError(t, applyGroupSetting(setter, group, tt.setting, tt.enabled))
+			assert.Equal(t, []string{tt.call}, setter.calls)
+		})
+	}
+
+	setter := &fakeGroupSettingSetter{}
+	assert.Error(t, applyGroupSetting(setter, group, "ephemeral", true))
+	assert.Empty(t, setter.calls)
+}
+
+func TestParticipantToJIDRejectsInvalid(t *testing.T) {
+	_, err := groupService{}.participantToJID([]string{"not-a-phone"})
+	assert.IsType(t, pkgError.ValidationError(""), err)
+	assert.Contains(t, err.Error(), "participant not-a-phone")
+}
@@ -2,10 +2,14 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	domainMessage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/message"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/metrics"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
 	"github.com/sirupsen/logrus"
@@ -38,7 +42,8 @@ func (service serviceMessage) MarkAsRead(ctx context.Context, request domainMess
 	}
 
 	ids := []types.MessageID{request.MessageID}
-	if err = service.WaCli.MarkRead(ids, time.Now(), dataWaRecipient, *service.WaCli.Store.ID); err != nil {
+	sender := storedMessageSender(service.WaCli, dataWaRecipient, request.MessageID)
+	if err = markMessagesRead(service.WaCli, ids, dataWaRecipient, sender); err != nil {
 		return response, err
 	}
 
@@ -46,7 +51,7 @@ func (service serviceMessage) MarkAsRead(ctx context.Context, request domainMess
 		"phone":      request.Phone,
 		"message_id": request.MessageID,
 		"chat":       dataWaRecipient.String(),
-		"sender":     service.WaCli.Store.ID.String(),
+		"sender":     sender.String(),
 	})
 
 	response.MessageID = request.MessageID
@@ -54,6 +59,32 @@ func (service serviceMessage) MarkAsRead(ctx context.Context, request domainMess
 	return response, nil
 }
 
+// markMessagesRead sends the read receipts of the messages. The sender is the participant who sent them in groups, it
+// stays empty in the other chats where the chat itself is the sender
+func markMessagesRead(client *whatsmeow.Client, ids []types.MessageID, chat, sender types.JID) error {
+	if chat.Server == types.GroupServer && sender.IsEmpty() {
+		return pkgError.ValidationError("sender: is required in group chats.")
+	}
+	return client.MarkRead(ids, time.Now(), chat, sender)
+}
+
+// storedMessageSender looks the sender of a group message up in the message history, it is empty for the other chats
+// and the messages which aren't stored
+func storedMessageSender(client *whatsmeow.Client, chat types.JID, messageID string) types.JID {
+	if chat.Server != types.GroupServer {
+		return types.EmptyJID
+	}
+	msg, err := whatsapp.GetStoredMessage(client, chat, messageID)
+	if err != nil || msg.IsFromMe {
+		return types.EmptyJID
+	}
+	sender, err := types.ParseJID(msg.SenderJID)
+	if err != nil {
+		return types.EmptyJID
+	}
+	return sender
+}
+
 func (service serviceMessage) ReactMessage(ctx context.Context, request domainMessage.ReactionRequest) (response domainMessage.GenericResponse, err error) {
 	if err = validations.ValidateReactMessage(ctx, request); err != nil {
 		return response, err
@@ -74,10 +105,11 @@ func (service serviceMessage) ReactMessage(ctx context.Context, request domainMe
 			SenderTimestampMS: proto.Int64(time.Now().UnixMilli()),
 		},
 	}
-	ts, err := service.WaCli.SendMessage(ctx, dataWaRecipient, msg)
+	ts, err := whatsapp.SendMessage(ctx, service.WaCli, dataWaRecipient, msg)
 	if err != nil {
 		return response, err
 	}
+	whatsapp.RecordSentReaction(service.WaCli, dataWaRecipient, request.MessageID, request.Emoji, time.UnixMilli(msg.GetReactionMessage().GetSenderTimestampMS()))
 
 	response.MessageID = ts.ID
 	response.Status = fmt.Sprintf("Reaction sent to %s (server timestamp: %s)", request.Phone, ts.Timestamp)
@@ -93,7 +125,7 @@ func (service serviceMessage) RevokeMessage(ctx context.Context, request domainM
 		return response, err
 	}
 
-	ts, err := service.WaCli.SendMessage(context.Background(), dataWaRecipient, service.WaCli.BuildRevoke(dataWaRecipient, types.EmptyJID, request.MessageID))
+	ts, err := service.revokeMessage(ctx, dataWaRecipient, request.MessageID)
 	if err != nil {
 		return response, err
 	}
@@ -112,6 +144,11 @@ func (service serviceMessage) DeleteMessage(ctx context.Context, request domainM
 		return err
 	}
 
+	if request.ForEveryone {
+		_, err = service.revokeMessage(ctx, dataWaRecipient, request.MessageID)
+		return err
+	}
+
 	isFromMe := "1"
 	if len(request.MessageID) > 22 {
 		isFromMe = "0"
@@ -147,8 +184,16 @@ func (service serviceMessage) UpdateMessage(ctx context.Context, request domainM
 		return response, err
 	}
 
+	sent, err := service.findOwnMessage(request.MessageID, dataWaRecipient)
+	if err != nil {
+		return response, err
+	}
+	if time.Since(sent.SentAt) > whatsapp.MessageEditWindow {
+		return response, pkgError.ValidationError(fmt.Sprintf("message %s is older than the %s edit window", request.MessageID, whatsapp.MessageEditWindow))
+	}
+
 	msg := &waE2E.Message{Conversation: proto.String(request.Message)}
-	ts, err := service.WaCli.SendMessage(context.Background(), dataWaRecipient, service.WaCli.BuildEdit(dataWaRecipient, request.MessageID, msg))
+	ts, err := whatsapp.SendMessage(ctx, service.WaCli, dataWaRecipient, service.WaCli.BuildEdit(dataWaRecipient, request.MessageID, msg))
 	if err != nil {
 		return response, err
 	}
@@ -158,6 +203,125 @@ func (service serviceMessage) UpdateMessage(ctx context.Context, request domainM
 	return response, nil
 }
 
+func (service serviceMessage) DownloadMedia(_ context.Context, request domainMessage.DownloadMediaRequest) (response domainMessage.DownloadMediaResponse, err error) {
+	cached, err := whatsapp.GetCachedMedia(service.WaCli, request.MessageID)
+	if err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	data, err := service.WaCli.Download(cached.Media)
+	if err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to download media of message %s: %v", request.MessageID, err))
+	}
+	metrics.MediaDownloadedBytes.Add(float64(len(data)))
+	if maxSize := whatsapp.MaxDownloadSize(cached.Media); int64(len(data)) > maxSize {
+		return response, pkgError.ValidationError(fmt.Sprintf("media size exceeds the maximum limit of %d bytes", maxSize))
+	}
+
+	response.Data = data
+	response.MimeType = cached.MimeType
+	response.FileName = cached.FileName
+	return response, nil
+}
+
+// GetMessageStatus returns the delivery state of a message sent by this account, tracked from its receipts
+func (service serviceMessage) GetMessageStatus(ctx context.Context, request domainMessage.StatusRequest) (response domainMessage.StatusResponse, err error) {
+	if err = validations.ValidateMessageStatus(ctx, request); err != nil {
+		return response, err
+	}
+
+	chat, err := whatsapp.ParseJID(request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	status, err := whatsapp.GetMessageStatus(service.WaCli, chat, request.MessageID)
+	if err != nil {
+		return response, err
+	}
+
+	response.MessageID = status.MessageID
+	response.ChatJID = status.ChatJID
+	response.Status = status.Status
+	response.SentAt = status.SentAt.Format(time.RFC3339)
+	if !status.DeliveredAt.IsZero() {
+		response.DeliveredAt = status.DeliveredAt.Format(time.RFC3339)
+	}
+	if !status.ReadAt.IsZero() {
+		response.ReadAt = status.ReadAt.Format(time.RFC3339)
+	}
+	return response, nil
+}
+
+// GetReactions returns the reactions recorded for a message, whatsapp has no query for them
+func (service serviceMessage) GetReactions(ctx context.Context, request domainMessage.ReactionsRequest) (response domainMessage.ReactionsResponse, err error) {
+	if err = validations.ValidateMessageReactions(ctx, request); err != nil {
+		return response, err
+	}
+	chat, err := whatsapp.ParseJID(request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	reactions, err := whatsapp.GetMessageReactions(service.WaCli, chat, request.MessageID)
+	if err != nil {
+		return response, err
+	}
+
+	response.MessageID = request.MessageID
+	response.Data = []domainMessage.ReactionData{}
+	for _, reaction := range reactions {
+		response.Data = append(response.Data, domainMessage.ReactionData{
+			SenderJID: reaction.SenderJID,
+			Emoji:     reaction.Emoji,
+			Timestamp: reaction.ReactedAt.Format(time.RFC3339),
+		})
+	}
+	return response, nil
+}
+
+// revokeMessage deletes a message sent by this account for everyone in the chat
+func (service serviceMessage) revokeMessage(ctx context.Context, chat types.JID, messageID string) (whatsmeow.SendResponse, error) {
+	sent, err := service.findOwnMessage(messageID, chat)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	if time.Since(sent.SentAt) > whatsapp.MessageRevokeWindow {
+		return whatsmeow.SendResponse{}, pkgError.ValidationError(fmt.Sprintf("message %s is older than the %s delete for everyone window", messageID, whatsapp.MessageRevokeWindow))
+	}
+
+	ts, err := whatsapp.SendMessage(ctx, service.WaCli, chat, service.WaCli.BuildRevoke(chat, types.EmptyJID, messageID))
+	if errors.Is(err, whatsmeow.ErrServerReturnedError) {
+		return ts, pkgError.ValidationError(fmt.Sprintf("WhatsApp rejected deleting message %s for everyone, it is probably past the time limit: %v", messageID, err))
+	}
+	return ts, err
+}
+
+// findOwnMessage returns the delivery state of the message when it was sent by this account to the chat, only
+// the messages of this account have one
+func (service serviceMessage) findOwnMessage(messageID string, chat types.JID) (whatsapp.MessageStatus, error) {
+	sent, err := whatsapp.GetMessageStatus(service.WaCli, chat, messageID)
+	var notFound pkgError.NotFoundError
+	if errors.As(err, &notFound) {
+		// Not tracked, e.g. a status store added after it was sent, the message history knows who sent it
+		if stored, err := whatsapp.GetStoredMessage(service.WaCli, chat, messageID); err == nil {
+			if !stored.IsFromMe {
+				return sent, pkgError.ForbiddenError(fmt.Sprintf("message %s was not sent by this account", messageID))
+			}
+			return whatsapp.MessageStatus{MessageID: stored.ID, ChatJID: stored.ChatJID, Status: whatsapp.MessageStatusSent, SentAt: stored.SentAt}, nil
+		}
+		// Sent before the session was running, the chat storage still tells whether someone else sent it
+		if record, err := utils.FindRecordFromStorage(messageID); err == nil {
+			if sender, err := types.ParseJID(record.JID); err == nil && sender.User != service.WaCli.Store.ID.User {
+				return sent, pkgError.ForbiddenError(fmt.Sprintf("message %s was not sent by this account", messageID))
+			}
+		}
+		return sent, pkgError.ValidationError(fmt.Sprintf("message %s is unknown in %s, only messages this account sent since the session was running are accepted", messageID, chat.String()))
+	}
+	return sent, err
+}
+
 // StarMessage implements message.IMessageService.
 func (service serviceMessage) StarMessage(ctx context.Context, request domainMessage.StarRequest) (err error) {
 	if err = validations.ValidateStarMessage(ctx, request); err != nil {
@@ -181,3 +345,162 @@ func (service serviceMessage) StarMessage(ctx context.Context, request domainMes
 	}
 	return nil
 }
+
+// ForwardMessage sends a received message again to other chats, flagged as forwarded like the phone app does
+func (service serviceMessage) ForwardMessage(ctx context.Context, request domainMessage.ForwardRequest) (response domainMessage.ForwardResponse, err error) {
+	if err = validations.ValidateForwardMessage(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	source, err := whatsapp.ParseJID(request.Phone)
+	if err != nil {
+		return response, err
+	}
+	original, err := whatsapp.GetCachedMessage(service.WaCli, source, request.MessageID)
+	if err != nil {
+		return response, err
+	}
+	msg, err := newForwardedMessage(original.Message)
+	if err != nil {
+		return response, err
+	}
+	if err = service.reuploadForwardedMedia(ctx, msg); err != nil {
+		return response, err
+	}
+
+	response.Results = make([]domainMessage.ForwardResult, len(request.Phones))
+	for i, phone := range request.Phones {
+		result := domainMessage.ForwardResult{Phone: phone}
+		if sent, err := service.forwardTo(ctx, phone, msg); err != nil {
+			result.Error = err.Error()
+			response.Failed++
+		} else {
+			result.Success = true
+			result.MessageID = sent.ID
+			response.Sent++
+		}
+		response.Results[i] = result
+	}
+
+	response.Status = fmt.Sprintf("Message %s forwarded to %d of %d chats", request.MessageID, response.Sent, len(request.Phones))
+	return response, nil
+}
+
+func (service serviceMessage) forwardTo(ctx context.Context, phone string, msg *waE2E.Message) (whatsmeow.SendResponse, error) {
+	recipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, phone)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+	return whatsapp.SendMessage(ctx, service.WaCli, recipient, msg)
+}
+
+// newForwardedMessage copies the content of a message with the forwarded flag set, the context of the original
+// (quote, mentions) is dropped since it means nothing in another chat
+func newForwardedMessage(original *waE2E.Message) (*waE2E.Message, error) {
+	forwarded := func(previous *waE2E.ContextInfo) *waE2E.ContextInfo {
+		return &waE2E.ContextInfo{
+			IsForwarded:     proto.Bool(true),
+			ForwardingScore: proto.Uint32(previous.GetForwardingScore() + 1),
+		}
+	}
+
+	msg := &waE2E.Message{}
+	switch {
+	case original.GetConversation() != "":
+		// A plain conversation has no context, it has to become an extended text to carry the flag
+		msg.ExtendedTextMessage = &waE2E.ExtendedTextMessage{
+			Text:        proto.String(original.GetConversation()),
+			ContextInfo: forwarded(nil),
+		}
+	case original.GetExtendedTextMessage() != nil:
+		msg.ExtendedTextMessage = proto.Clone(original.GetExtendedTextMessage()).(*waE2E.ExtendedTextMessage)
+		msg.ExtendedTextMessage.ContextInfo = forwarded(original.GetExtendedTextMessage().GetContextInfo())
+	case original.GetImageMessage() != nil:
+		msg.ImageMessage = proto.Clone(original.GetImageMessage()).(*waE2E.ImageMessage)
+		msg.ImageMessage.ContextInfo = forwarded(original.GetImageMessage().GetContextInfo())
+	case original.GetVideoMessage() != nil:
+		msg.VideoMessage = proto.Clone(original.GetVideoMessage()).(*waE2E.VideoMessage)
+		msg.VideoMessage.ContextInfo = forwarded(original.GetVideoMessage().GetContextInfo())
+	case original.GetAudioMessage() != nil:
+		msg.AudioMessage = proto.Clone(original.GetAudioMessage()).(*waE2E.AudioMessage)
+		msg.AudioMessage.ContextInfo = forwarded(original.GetAudioMessage().GetContextInfo())
+	case original.GetDocumentMessage() != nil:
+		msg.DocumentMessage = proto.Clone(original.GetDocumentMessage()).(*waE2E.DocumentMessage)
+		msg.DocumentMessage.ContextInfo = forwarded(original.GetDocumentMessage().GetContextInfo())
+	case original.GetStickerMessage() != nil:
+		msg.StickerMessage = proto.Clone(original.GetStickerMessage()).(*waE2E.StickerMessage)
+		msg.StickerMessage.ContextInfo = forwarded(original.GetStickerMessage().GetContextInfo())
+	case original.GetContactMessage() != nil:
+		msg.ContactMessage = proto.Clone(original.GetContactMessage()).(*waE2E.ContactMessage)
+		msg.ContactMessage.ContextInfo = forwarded(original.GetContactMessage().GetContextInfo())
+	case original.GetContactsArrayMessage() != nil:
+		msg.ContactsArrayMessage = proto.Clone(original.GetContactsArrayMessage()).(*waE2E.ContactsArrayMessage)
+		msg.ContactsArrayMessage.ContextInfo = forwarded(original.GetContactsArrayMessage().GetContextInfo())
+	case original.GetLocationMessage() != nil:
+		msg.LocationMessage = proto.Clone(original.GetLocationMessage()).(*waE2E.LocationMessage)
+		msg.LocationMessage.ContextInfo = forwarded(original.GetLocationMessage().GetContextInfo())
+	default:
+		return nil, pkgError.ValidationError("message type can't be forwarded, only text, media, contact and location messages are supported")
+	}
+	return msg, nil
+}
+
+// reuploadForwardedMedia uploads the media of a forwarded message again, the links of the original expire
+// and may not be reachable by the receivers
+func (service serviceMessage) reuploadForwardedMedia(ctx context.Context, msg *waE2E.Message) error {
+	var media whatsmeow.DownloadableMessage
+	var mediaType whatsmeow.MediaType
+	switch {
+	case msg.GetImageMessage() != nil:
+		media, mediaType = msg.GetImageMessage(), whatsmeow.MediaImage
+	case msg.GetVideoMessage() != nil:
+		media, mediaType = msg.GetVideoMessage(), whatsmeow.MediaVideo
+	case msg.GetAudioMessage() != nil:
+		media, mediaType = msg.GetAudioMessage(), whatsmeow.MediaAudio
+	case msg.GetDocumentMessage() != nil:
+		media, mediaType = msg.GetDocumentMessage(), whatsmeow.MediaDocument
+	case msg.GetStickerMessage() != nil:
+		media, mediaType = msg.GetStickerMessage(), whatsmeow.MediaImage
+	default:
+		return nil
+	}
+
+	data, err := service.WaCli.Download(media)
+	if err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to download media to forward: %v", err))
+	}
+	metrics.MediaDownloadedBytes.Add(float64(len(data)))
+	uploaded, err := service.WaCli.Upload(ctx, data, mediaType)
+	if err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to upload media to forward: %v", err))
+	}
+	setForwardedMedia(msg, uploaded)
+	return nil
+}
+
+// setForwardedMedia points the media of the message to the uploaded copy, the caption and the other details are kept
+func setForwardedMedia(msg *waE2E.Message, uploaded whatsmeow.UploadResponse) {
+	switch {
+	case msg.GetImageMessage() != nil:
+		m := msg.ImageMessage
+		m.URL, m.DirectPath, m.MediaKey = proto.String(uploaded.URL), proto.String(uploaded.DirectPath), uploaded.MediaKey
+		m.FileEncSHA256, m.FileSHA256, m.FileLength = uploaded.FileEncSHA256, uploaded.FileSHA256, proto.Uint64(uploaded.FileLength)
+	case msg.GetVideoMessage() != nil:
+		m := msg.VideoMessage
+		m.URL, m.DirectPath, m.MediaKey = proto.String(uploaded.URL), proto.String(uploaded.DirectPath), uploaded.MediaKey
+		m.FileEncSHA256, m.FileSHA256, m.FileLength = uploaded.FileEncSHA256, uploaded.FileSHA256, proto.Uint64(uploaded.FileLength)
+	case msg.GetAudioMessage() != nil:
+		m := msg.AudioMessage
+		m.URL, m.DirectPath, m.MediaKey = proto.String(uploaded.URL), proto.String(uploaded.DirectPath), uploaded.MediaKey
+		m.FileEncSHA256, m.FileSHA256, m.FileLength = uploaded.FileEncSHA256, uploaded.FileSHA256, proto.Uint64(uploaded.FileLength)
+	case msg.GetDocumentMessage() != nil:
+		m := msg.DocumentMessage
+		m.URL, m.DirectPath, m.MediaKey = proto.String(uploaded.URL), proto.String(uploaded.DirectPath), uploaded.MediaKey
+		m.FileEncSHA256, m.FileSHA256, m.FileLength = uploaded.FileEncSHA256, uploaded.FileSHA256, proto.Uint64(uploaded.FileLength)
+	case msg.GetStickerMessage() != nil:
+		m := msg.StickerMessage
+		m.URL, m.DirectPath, m.MediaKey = proto.String(uploaded.URL), proto.String(uploaded.DirectPath), uploaded.MediaKey
+		m.FileEncSHA256, m.FileSHA256, m.FileLength = uploaded.FileEncSHA256, uploaded.FileSHA256, proto.Uint64(uploaded.FileLength)
+	}
+}
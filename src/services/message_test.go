@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewForwardedMessage(t *testing.T) {
+	text, err := newForwardedMessage(&waE2E.Message{Conversation: proto.String("hello")})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", text.GetExtendedTextMessage().GetText())
+	assert.True(t, text.GetExtendedTextMessage().GetContextInfo().GetIsForwarded())
+	assert.Equal(t, uint32(1), text.GetExtendedTextMessage().GetContextInfo().GetForwardingScore())
+
+	original := &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+		Caption:     proto.String("holiday"),
+		Mimetype:    proto.String("image/jpeg"),
+		ContextInfo: &waE2E.ContextInfo{ForwardingScore: proto.Uint32(3), StanzaID: proto.String("3EB0QUOTED")},
+	}}
+	image, err := newForwardedMessage(original)
+	assert.NoError(t, err)
+	assert.Equal(t, "holiday", image.GetImageMessage().GetCaption())
+	assert.Equal(t, uint32(4), image.GetImageMessage().GetContextInfo().GetForwardingScore())
+	assert.Empty(t, image.GetImageMessage().GetContextInfo().GetStanzaID())
+	// The original is left untouched
+	assert.Equal(t, uint32(3), original.GetImageMessage().GetContextInfo().GetForwardingScore())
+
+	_, err = newForwardedMessage(&waE2E.Message{PollCreationMessage: &waE2E.PollCreationMessage{Name: proto.String("lunch?")}})
+	assert.Error(t, err)
+}
+
+func TestSetForwardedMedia(t *testing.T) {
+	msg := &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+		URL:      proto.String("https://mmg.whatsapp.net/old"),
+		FileName: proto.String("invoice.pdf"),
+		Caption:  proto.String("march"),
+	}}
+	setForwardedMedia(msg, whatsmeow.UploadResponse{
+		URL:        "https://mmg.whatsapp.net/new",
+		DirectPath: "/v/new",
+		MediaKey:   []byte{1},
+		FileLength: 42,
+	})
+
+	assert.Equal(t, "https://mmg.whatsapp.net/new", msg.GetDocumentMessage().GetURL())
+	assert.Equal(t, "/v/new", msg.GetDocumentMessage().GetDirectPath())
+	assert.Equal(t, uint64(42), msg.GetDocumentMessage().GetFileLength())
+	assert.Equal(t, "invoice.pdf", msg.GetDocumentMessage().GetFileName())
+	assert.Equal(t, "march", msg.GetDocumentMessage().GetCaption())
+}
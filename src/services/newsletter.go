@@ -6,6 +6,7 @@ import (
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
 )
 
 type newsletterService struct {
@@ -18,15 +19,67 @@ func NewNewsletterService(waCli *whatsmeow.Client) domainNewsletter.INewsletterS
 	}
 }
 
+func (service newsletterService) Follow(ctx context.Context, request domainNewsletter.FollowRequest) (response domainNewsletter.Newsletter, err error) {
+	if err = validations.ValidateFollowNewsletter(ctx, request); err != nil {
+		return response, err
+	}
+
+	metadata, err := whatsapp.ResolveNewsletter(service.WaCli, request.NewsletterID, request.InviteLink)
+	if err != nil {
+		return response, err
+	}
+
+	if err = service.WaCli.FollowNewsletter(metadata.ID); err != nil {
+		return response, err
+	}
+
+	response = newNewsletter(metadata)
+	if response.Role == "" {
+		response.Role = string(types.NewsletterRoleSubscriber)
+	}
+	return response, nil
+}
+
 func (service newsletterService) Unfollow(ctx context.Context, request domainNewsletter.UnfollowRequest) (err error) {
 	if err = validations.ValidateUnfollowNewsletter(ctx, request); err != nil {
 		return err
 	}
 
-	JID, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.NewsletterID)
+	metadata, err := whatsapp.ResolveNewsletter(service.WaCli, request.NewsletterID, request.InviteLink)
 	if err != nil {
 		return err
 	}
 
-	return service.WaCli.UnfollowNewsletter(JID)
+	return service.WaCli.UnfollowNewsletter(metadata.ID)
+}
+
+func (service newsletterService) List(_ context.Context) (response domainNewsletter.ListResponse, err error) {
+	whatsapp.MustLogin(service.WaCli)
+
+	newsletters, err := service.WaCli.GetSubscribedNewsletters()
+	if err != nil {
+		return response, err
+	}
+
+	response.Data = make([]domainNewsletter.Newsletter, 0, len(newsletters))
+	for _, metadata := range newsletters {
+		response.Data = append(response.Data, newNewsletter(metadata))
+	}
+	return response, nil
+}
+
+// newNewsletter maps the metadata of a channel, the viewer details are missing when it was looked up by invite link
+func newNewsletter(metadata *types.NewsletterMetadata) domainNewsletter.Newsletter {
+	newsletter := domainNewsletter.Newsletter{
+		JID:         metadata.ID.String(),
+		Name:        metadata.ThreadMeta.Name.Text,
+		Description: metadata.ThreadMeta.Description.Text,
+		InviteCode:  metadata.ThreadMeta.InviteCode,
+		Subscribers: metadata.ThreadMeta.SubscriberCount,
+	}
+	if metadata.ViewerMeta != nil {
+		newsletter.Role = string(metadata.ViewerMeta.Role)
+		newsletter.Muted = metadata.ViewerMeta.Mute == types.NewsletterMuteOn
+	}
+	return newsletter
 }
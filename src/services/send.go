@@ -2,10 +2,19 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/domains/app"
@@ -23,6 +32,7 @@ import (
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type serviceSend struct {
@@ -37,9 +47,16 @@ func NewSendService(waCli *whatsmeow.Client, appService app.IAppService) domainS
 	}
 }
 
-// wrapSendMessage wraps the message sending process with message ID saving
-func (service serviceSend) wrapSendMessage(ctx context.Context, recipient types.JID, msg *waE2E.Message, content string) (whatsmeow.SendResponse, error) {
-	ts, err := service.WaCli.SendMessage(ctx, recipient, msg)
+// wrapSendMessage wraps the message sending process with message ID saving, a message to a newsletter is checked
+// against what the newsletter accepts first
+func (service serviceSend) wrapSendMessage(ctx context.Context, recipient types.JID, msg *waE2E.Message, content string, extra ...whatsmeow.SendRequestExtra) (whatsmeow.SendResponse, error) {
+	if recipient.Server == types.NewsletterServer {
+		if err := whatsapp.CheckNewsletterMessage(service.WaCli, recipient, msg); err != nil {
+			return whatsmeow.SendResponse{}, err
+		}
+	}
+
+	ts, err := whatsapp.SendMessage(ctx, service.WaCli, recipient, msg, extra...)
 	if err != nil {
 		return whatsmeow.SendResponse{}, err
 	}
@@ -49,6 +66,68 @@ func (service serviceSend) wrapSendMessage(ctx context.Context, recipient types.
 	return ts, nil
 }
 
+// replyContext returns the context quoting the reply_to message of the chat, the quote is rebuilt from the cached
+// message so the receivers see its preview
+func (service serviceSend) replyContext(chat types.JID, replyTo *domainSend.ReplyTo) (*waE2E.ContextInfo, error) {
+	if replyTo == nil {
+		return nil, nil
+	}
+	quoted, err := whatsapp.GetCachedMessage(service.WaCli, chat, replyTo.MessageID)
+	if err != nil {
+		return nil, pkgError.ValidationError(fmt.Sprintf("reply_to message %s is not a known message of %s, only messages received or sent within %s can be quoted", replyTo.MessageID, chat.String(), config.WhatsappMessageCacheTTL))
+	}
+	if replyTo.Participant != "" {
+		participant, err := whatsapp.ParseJID(replyTo.Participant)
+		if err != nil {
+			return nil, err
+		}
+		if participant.User != quoted.Sender.User {
+			return nil, pkgError.ValidationError(fmt.Sprintf("reply_to message %s was sent by %s, not %s", replyTo.MessageID, quoted.Sender.String(), participant.String()))
+		}
+	}
+	return newReplyContext(replyTo.MessageID, quoted), nil
+}
+
+func newReplyContext(messageID string, quoted whatsapp.CachedMessage) *waE2E.ContextInfo {
+	return &waE2E.ContextInfo{
+		StanzaID:      proto.String(messageID),
+		Participant:   proto.String(quoted.Sender.String()),
+		QuotedMessage: quotedContent(quoted.Message),
+	}
+}
+
+// quotedContent copies the content of a message without its own context, a quote doesn't carry the quote,
+// mentions or forward flag of the quoted message
+func quotedContent(msg *waE2E.Message) *waE2E.Message {
+	quoted := proto.Clone(msg).(*waE2E.Message)
+	quoted.MessageContextInfo = nil
+	quoted.ProtoReflect().Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if field.Kind() != protoreflect.MessageKind || field.IsList() || field.IsMap() {
+			return true
+		}
+		content := value.Message()
+		if contextInfo := content.Descriptor().Fields().ByName("contextInfo"); contextInfo != nil {
+			content.Clear(contextInfo)
+		}
+		return true
+	})
+	return quoted
+}
+
+// withReply adds the quote of reply to the context of a message, the other details of the context are kept
+func withReply(info *waE2E.ContextInfo, reply *waE2E.ContextInfo) *waE2E.ContextInfo {
+	if reply == nil {
+		return info
+	}
+	if info == nil {
+		info = &waE2E.ContextInfo{}
+	}
+	info.StanzaID = reply.StanzaID
+	info.Participant = reply.Participant
+	info.QuotedMessage = reply.QuotedMessage
+	return info
+}
+
 func (service serviceSend) SendText(ctx context.Context, request domainSend.MessageRequest) (response domainSend.GenericResponse, err error) {
 	err = validations.ValidateSendMessage(ctx, request)
 	if err != nil {
@@ -58,11 +137,19 @@ func (service serviceSend) SendText(ctx context.Context, request domainSend.Mess
 	if err != nil {
 		return response, err
 	}
+	reply, err := service.replyContext(dataWaRecipient, request.ReplyTo)
+	if err != nil {
+		return response, err
+	}
+	text, mentions, err := service.resolveMentions(ctx, dataWaRecipient, request.Message, request.Mentions)
+	if err != nil {
+		return response, err
+	}
 
 	// Create base message
 	msg := &waE2E.Message{
 		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
-			Text:        proto.String(request.Message),
+			Text:        proto.String(text),
 			ContextInfo: &waE2E.ContextInfo{},
 		},
 	}
@@ -73,17 +160,22 @@ func (service serviceSend) SendText(ctx context.Context, request domainSend.Mess
 		msg.ExtendedTextMessage.ContextInfo.ForwardingScore = proto.Uint32(100)
 	}
 
-	parsedMentions := service.getMentionFromText(ctx, request.Message)
+	var parsedMentions []string
+	for _, mention := range mentions {
+		parsedMentions = append(parsedMentions, mention.String())
+	}
 	if len(parsedMentions) > 0 {
 		msg.ExtendedTextMessage.ContextInfo.MentionedJID = parsedMentions
 	}
 
-	// Reply message
-	if request.ReplyMessageID != nil && *request.ReplyMessageID != "" {
+	// Reply message, reply_message_id is the older form quoting only the text recorded in the chat storage
+	if reply != nil {
+		msg.ExtendedTextMessage.ContextInfo = withReply(msg.ExtendedTextMessage.ContextInfo, reply)
+	} else if request.ReplyMessageID != nil && *request.ReplyMessageID != "" {
 		record, err := utils.FindRecordFromStorage(*request.ReplyMessageID)
 		if err == nil { // Only set reply context if we found the message ID
 			msg.ExtendedTextMessage = &waE2E.ExtendedTextMessage{
-				Text: proto.String(request.Message),
+				Text: proto.String(text),
 				ContextInfo: &waE2E.ContextInfo{
 					StanzaID:    request.ReplyMessageID,
 					Participant: proto.String(record.JID),
@@ -101,7 +193,28 @@ func (service serviceSend) SendText(ctx context.Context, request domainSend.Mess
 		}
 	}
 
-	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, msg, request.Message)
+	// Link preview is opt-in, a slow or broken site only skips the preview
+	if request.LinkPreview {
+		if link := utils.ExtractFirstURL(request.Message); link != "" {
+			metadata, err := utils.GetLinkPreview(link)
+			if err != nil {
+				logrus.Warnf("Failed to fetch link preview of %s: %v, continue without preview", link, err)
+			} else {
+				service.attachLinkPreview(ctx, msg.ExtendedTextMessage, link, metadata, dataWaRecipient)
+			}
+		}
+	}
+
+	// Disappearing message, inherit the chat setting when not requested
+	disappearingDuration := whatsapp.GetChatEphemeralTimer(service.WaCli, dataWaRecipient)
+	if request.DisappearingDuration != nil {
+		disappearingDuration = *request.DisappearingDuration
+	}
+	if disappearingDuration > 0 {
+		msg.ExtendedTextMessage.ContextInfo.Expiration = proto.Uint32(disappearingDuration)
+	}
+
+	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, msg, text)
 	if err != nil {
 		return response, err
 	}
@@ -111,6 +224,95 @@ func (service serviceSend) SendText(ctx context.Context, request domainSend.Mess
 	return response, nil
 }
 
+// resolveMentions resolves the explicit mentions of a request and rewrites their placeholders in the message, the
+// @numbers of the message are mentioned as well. In a group every mentioned number must be a member
+func (service serviceSend) resolveMentions(ctx context.Context, recipient types.JID, message string, phones []string) (string, []types.JID, error) {
+	mentions := make([]types.JID, 0, len(phones))
+	for _, phone := range phones {
+		mention, err := whatsapp.ValidateJidWithLogin(service.WaCli, phone)
+		if err != nil {
+			return "", nil, err
+		}
+		if mention.Server != types.DefaultUserServer {
+			return "", nil, pkgError.ValidationError(fmt.Sprintf("mention %s must be a phone number", phone))
+		}
+		mentions = append(mentions, mention)
+	}
+
+	text := message
+	if len(mentions) > 0 {
+		var err error
+		if text, err = buildMentionText(message, mentions); err != nil {
+			return "", nil, err
+		}
+	}
+	for _, mention := range service.getMentionFromText(ctx, text) {
+		if !slices.Contains(mentions, mention) {
+			mentions = append(mentions, mention)
+		}
+	}
+
+	if len(mentions) > 0 && recipient.Server == types.GroupServer {
+		groupInfo, err := service.WaCli.GetGroupInfo(recipient)
+		if err != nil {
+			return "", nil, err
+		}
+		if err = checkGroupMentions(groupInfo.Participants, mentions); err != nil {
+			return "", nil, err
+		}
+	}
+	return text, mentions, nil
+}
+
+// mentionPlaceholder matches a @number of a text, the number may have the leading + of the international format
+var mentionPlaceholder = regexp.MustCompile(`@\+?\d+`)
+
+// buildMentionText rewrites the @number placeholders of the mentions to @<user of the jid>, the form whatsapp renders
+// as a mention. Every mention needs a placeholder, other @numbers are kept as they are
+func buildMentionText(message string, mentions []types.JID) (string, error) {
+	found := make(map[string]bool, len(mentions))
+	text := mentionPlaceholder.ReplaceAllStringFunc(message, func(placeholder string) string {
+		jid, err := whatsapp.NormalizeJID(placeholder[1:])
+		if err != nil {
+			return placeholder
+		}
+		for _, mention := range mentions {
+			if mention.User == jid.User {
+				found[mention.User] = true
+				return "@" + mention.User
+			}
+		}
+		return placeholder
+	})
+
+	for _, mention := range mentions {
+		if !found[mention.User] {
+			return "", pkgError.ValidationError(fmt.Sprintf("mention %s has no @%s placeholder in the message", mention.User, mention.User))
+		}
+	}
+	return text, nil
+}
+
+// checkGroupMentions rejects the mentions that aren't members of the group, a member of a lid group is matched by
+// its phone number
+func checkGroupMentions(participants []types.GroupParticipant, mentions []types.JID) error {
+	members := make(map[string]bool, len(participants))
+	for _, participant := range participants {
+		if participant.JID.Server == types.DefaultUserServer {
+			members[participant.JID.User] = true
+		}
+		if !participant.PhoneNumber.IsEmpty() {
+			members[participant.PhoneNumber.User] = true
+		}
+	}
+	for _, mention := range mentions {
+		if !members[mention.User] {
+			return pkgError.ValidationError(fmt.Sprintf("mention %s is not a member of the group", mention.User))
+		}
+	}
+	return nil
+}
+
 func (service serviceSend) SendImage(ctx context.Context, request domainSend.ImageRequest) (response domainSend.GenericResponse, err error) {
 	err = validations.ValidateSendImage(ctx, request)
 	if err != nil {
@@ -120,6 +322,10 @@ func (service serviceSend) SendImage(ctx context.Context, request domainSend.Ima
 	if err != nil {
 		return response, err
 	}
+	reply, err := service.replyContext(dataWaRecipient, request.ReplyTo)
+	if err != nil {
+		return response, err
+	}
 
 	var (
 		imagePath      string
@@ -132,7 +338,7 @@ func (service serviceSend) SendImage(ctx context.Context, request domainSend.Ima
 	if request.ImageURL != nil && *request.ImageURL != "" {
 		// Download image from URL
 		imageData, fileName, err := utils.DownloadImageFromURL(*request.ImageURL)
-		oriImagePath = fmt.Sprintf("%s/%s", config.PathSendItems, fileName)
+		oriImagePath = sendItemPath(service.WaCli, fileName)
 		if err != nil {
 			return response, pkgError.InternalServerError(fmt.Sprintf("failed to download image from URL %v", err))
 		}
@@ -143,7 +349,7 @@ func (service serviceSend) SendImage(ctx context.Context, request domainSend.Ima
 		}
 	} else if request.Image != nil {
 		// Save image to server
-		oriImagePath = fmt.Sprintf("%s/%s", config.PathSendItems, request.Image.Filename)
+		oriImagePath = sendItemPath(service.WaCli, request.Image.Filename)
 		err = fasthttp.SaveMultipartFile(request.Image, oriImagePath)
 		if err != nil {
 			return response, err
@@ -160,7 +366,7 @@ func (service serviceSend) SendImage(ctx context.Context, request domainSend.Ima
 
 	// Resize Thumbnail
 	resizedImage := imaging.Resize(srcImage, 100, 0, imaging.Lanczos)
-	imageThumbnail = fmt.Sprintf("%s/thumbnails-%s", config.PathSendItems, imageName)
+	imageThumbnail = sendItemPath(service.WaCli, "thumbnails-"+imageName)
 	if err = imaging.Save(resizedImage, imageThumbnail); err != nil {
 		return response, pkgError.InternalServerError(fmt.Sprintf("failed to save thumbnail %v", err))
 	}
@@ -173,7 +379,7 @@ func (service serviceSend) SendImage(ctx context.Context, request domainSend.Ima
 			return response, pkgError.InternalServerError(fmt.Sprintf("failed to open image %v", err))
 		}
 		newImage := imaging.Resize(openImageBuffer, 600, 0, imaging.Lanczos)
-		newImagePath := fmt.Sprintf("%s/new-%s", config.PathSendItems, imageName)
+		newImagePath := sendItemPath(service.WaCli, "new-"+imageName)
 		if err = imaging.Save(newImage, newImagePath); err != nil {
 			return response, pkgError.InternalServerError(fmt.Sprintf("failed to save image %v", err))
 		}
@@ -218,12 +424,14 @@ func (service serviceSend) SendImage(ctx context.Context, request domainSend.Ima
 			ForwardingScore: proto.Uint32(100),
 		}
 	}
+	msg.ImageMessage.ContextInfo = withReply(msg.ImageMessage.ContextInfo, reply)
 
 	caption := "🖼️ Image"
 	if request.Caption != "" {
 		caption = "🖼️ " + request.Caption
 	}
-	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, msg, caption)
+	// Newsletter media isn't encrypted, it's referenced by the handle of its upload instead
+	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, msg, caption, whatsmeow.SendRequestExtra{MediaHandle: uploadedImage.Handle})
 	go func() {
 		errDelete := utils.RemoveFile(0, deletedItems...)
 		if errDelete != nil {
@@ -248,9 +456,12 @@ func (service serviceSend) SendFile(ctx context.Context, request domainSend.File
 	if err != nil {
 		return response, err
 	}
+	reply, err := service.replyContext(dataWaRecipient, request.ReplyTo)
+	if err != nil {
+		return response, err
+	}
 
 	fileBytes := helpers.MultipartFormFileHeaderToBytes(request.File)
-	fileMimeType := http.DetectContentType(fileBytes)
 
 	// Send to WA server
 	uploadedFile, err := service.uploadMedia(ctx, whatsmeow.MediaDocument, fileBytes, dataWaRecipient)
@@ -259,25 +470,8 @@ func (service serviceSend) SendFile(ctx context.Context, request domainSend.File
 		return response, err
 	}
 
-	msg := &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
-		URL:           proto.String(uploadedFile.URL),
-		Mimetype:      proto.String(fileMimeType),
-		Title:         proto.String(request.File.Filename),
-		FileSHA256:    uploadedFile.FileSHA256,
-		FileLength:    proto.Uint64(uploadedFile.FileLength),
-		MediaKey:      uploadedFile.MediaKey,
-		FileName:      proto.String(request.File.Filename),
-		FileEncSHA256: uploadedFile.FileEncSHA256,
-		DirectPath:    proto.String(uploadedFile.DirectPath),
-		Caption:       proto.String(request.Caption),
-	}}
-
-	if request.IsForwarded {
-		msg.DocumentMessage.ContextInfo = &waE2E.ContextInfo{
-			IsForwarded:     proto.Bool(true),
-			ForwardingScore: proto.Uint32(100),
-		}
-	}
+	msg := newDocumentMessage(request, uploadedFile, fileBytes)
+	msg.DocumentMessage.ContextInfo = withReply(msg.DocumentMessage.ContextInfo, reply)
 
 	caption := "📄 Document"
 	if request.Caption != "" {
@@ -302,6 +496,10 @@ func (service serviceSend) SendVideo(ctx context.Context, request domainSend.Vid
 	if err != nil {
 		return response, err
 	}
+	reply, err := service.replyContext(dataWaRecipient, request.ReplyTo)
+	if err != nil {
+		return response, err
+	}
 
 	var (
 		videoPath      string
@@ -311,23 +509,18 @@ func (service serviceSend) SendVideo(ctx context.Context, request domainSend.Vid
 
 	generateUUID := fiberUtils.UUIDv4()
 	// Save video to server
-	oriVideoPath := fmt.Sprintf("%s/%s", config.PathSendItems, generateUUID+request.Video.Filename)
+	oriVideoPath := sendItemPath(service.WaCli, generateUUID+request.Video.Filename)
 	err = fasthttp.SaveMultipartFile(request.Video, oriVideoPath)
 	if err != nil {
 		return response, pkgError.InternalServerError(fmt.Sprintf("failed to store video in server %v", err))
 	}
 
-	// Check if ffmpeg is installed
-	_, err = exec.LookPath("ffmpeg")
-	if err != nil {
-		return response, pkgError.InternalServerError("ffmpeg not installed")
-	}
-
 	// Get thumbnail video with ffmpeg
-	thumbnailVideoPath := fmt.Sprintf("%s/%s", config.PathSendItems, generateUUID+".png")
-	cmdThumbnail := exec.Command("ffmpeg", "-i", oriVideoPath, "-ss", "00:00:01.000", "-vframes", "1", thumbnailVideoPath)
-	err = cmdThumbnail.Run()
-	if err != nil {
+	thumbnailVideoPath := sendItemPath(service.WaCli, generateUUID+".png")
+	err = helpers.RunFFmpeg(ctx, nil, "-i", oriVideoPath, "-ss", "00:00:01.000", "-vframes", "1", thumbnailVideoPath)
+	if errors.Is(err, helpers.ErrFFmpegNotInstalled) {
+		return response, pkgError.InternalServerError(err.Error())
+	} else if err != nil {
 		return response, pkgError.InternalServerError(fmt.Sprintf("failed to create thumbnail %v", err))
 	}
 
@@ -337,7 +530,7 @@ func (service serviceSend) SendVideo(ctx context.Context, request domainSend.Vid
 		return response, pkgError.InternalServerError(fmt.Sprintf("failed to open image %v", err))
 	}
 	resizedImage := imaging.Resize(srcImage, 100, 0, imaging.Lanczos)
-	thumbnailResizeVideoPath := fmt.Sprintf("%s/thumbnails-%s", config.PathSendItems, generateUUID+".png")
+	thumbnailResizeVideoPath := sendItemPath(service.WaCli, "thumbnails-"+generateUUID+".png")
 	if err = imaging.Save(resizedImage, thumbnailResizeVideoPath); err != nil {
 		return response, pkgError.InternalServerError(fmt.Sprintf("failed to save thumbnail %v", err))
 	}
@@ -347,12 +540,10 @@ func (service serviceSend) SendVideo(ctx context.Context, request domainSend.Vid
 	videoThumbnail = thumbnailResizeVideoPath
 
 	if request.Compress {
-		compresVideoPath := fmt.Sprintf("%s/%s", config.PathSendItems, generateUUID+".mp4")
+		compresVideoPath := sendItemPath(service.WaCli, generateUUID+".mp4")
 
-		cmdCompress := exec.Command("ffmpeg", "-i", oriVideoPath, "-strict", "-2", compresVideoPath)
-		err = cmdCompress.Run()
-		if err != nil {
-			return response, pkgError.InternalServerError("failed to compress video")
+		if err = helpers.RunFFmpeg(ctx, nil, "-i", oriVideoPath, "-strict", "-2", compresVideoPath); err != nil {
+			return response, pkgError.InternalServerError(fmt.Sprintf("failed to compress video %v", err))
 		}
 
 		videoPath = compresVideoPath
@@ -398,6 +589,7 @@ func (service serviceSend) SendVideo(ctx context.Context, request domainSend.Vid
 			ForwardingScore: proto.Uint32(100),
 		}
 	}
+	msg.VideoMessage.ContextInfo = withReply(msg.VideoMessage.ContextInfo, reply)
 
 	caption := "🎥 Video"
 	if request.Caption != "" {
@@ -429,22 +621,27 @@ func (service serviceSend) SendContact(ctx context.Context, request domainSend.C
 		return response, err
 	}
 
-	msgVCard := fmt.Sprintf("BEGIN:VCARD\nVERSION:3.0\nN:;%v;;;\nFN:%v\nTEL;type=CELL;waid=%v:+%v\nEND:VCARD",
-		request.ContactName, request.ContactName, request.ContactPhone, request.ContactPhone)
-	msg := &waE2E.Message{ContactMessage: &waE2E.ContactMessage{
-		DisplayName: proto.String(request.ContactName),
-		Vcard:       proto.String(msgVCard),
-	}}
+	cards := request.Contacts
+	if len(cards) == 0 {
+		cards = []domainSend.ContactCard{{Name: request.ContactName, Phone: request.ContactPhone, Vcard: request.Vcard}}
+	}
+	msg, content, err := newContactMessage(cards)
+	if err != nil {
+		return response, err
+	}
 
 	if request.IsForwarded {
-		msg.ContactMessage.ContextInfo = &waE2E.ContextInfo{
+		contextInfo := &waE2E.ContextInfo{
 			IsForwarded:     proto.Bool(true),
 			ForwardingScore: proto.Uint32(100),
 		}
+		if msg.ContactMessage != nil {
+			msg.ContactMessage.ContextInfo = contextInfo
+		} else {
+			msg.ContactsArrayMessage.ContextInfo = contextInfo
+		}
 	}
 
-	content := "👤 " + request.ContactName
-
 	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, msg, content)
 	if err != nil {
 		return response, err
@@ -455,6 +652,87 @@ func (service serviceSend) SendContact(ctx context.Context, request domainSend.C
 	return response, nil
 }
 
+// newContactMessage builds a contact message of a single card or a contacts array message of several cards,
+// content is the text kept in the chat storage
+func newContactMessage(cards []domainSend.ContactCard) (msg *waE2E.Message, content string, err error) {
+	contacts := make([]*waE2E.ContactMessage, 0, len(cards))
+	for _, card := range cards {
+		vcard := card.Vcard
+		if vcard == "" {
+			if vcard, err = buildVCard(card.Name, card.Phone); err != nil {
+				return nil, "", err
+			}
+		}
+		name := card.Name
+		if name == "" {
+			name = vcardDisplayName(vcard)
+		}
+		contacts = append(contacts, &waE2E.ContactMessage{
+			DisplayName: proto.String(name),
+			Vcard:       proto.String(vcard),
+		})
+	}
+
+	if len(contacts) == 1 {
+		return &waE2E.Message{ContactMessage: contacts[0]}, "👤 " + contacts[0].GetDisplayName(), nil
+	}
+	displayName := fmt.Sprintf("%d contacts", len(contacts))
+	return &waE2E.Message{ContactsArrayMessage: &waE2E.ContactsArrayMessage{
+		DisplayName: proto.String(displayName),
+		Contacts:    contacts,
+	}}, "👤 " + displayName, nil
+}
+
+// buildVCard returns a vCard 3.0 of the contact, waid lets whatsapp link the card to the account of the phone
+func buildVCard(name, phone string) (string, error) {
+	jid, err := whatsapp.NormalizeJID(phone)
+	if err != nil {
+		return "", err
+	}
+	if jid.Server != types.DefaultUserServer {
+		return "", pkgError.InvalidJID(fmt.Sprintf("%s is not a phone number", phone))
+	}
+	name = vcardEscaper.Replace(name)
+	return fmt.Sprintf("BEGIN:VCARD\nVERSION:3.0\nN:;%s;;;\nFN:%s\nTEL;type=CELL;waid=%s:+%s\nEND:VCARD", name, name, jid.User, jid.User), nil
+}
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+// vcardDisplayName reads the formatted name of a raw vCard
+func vcardDisplayName(vcard string) string {
+	for _, line := range strings.Split(strings.ReplaceAll(vcard, "\r\n", "\n"), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if found && strings.EqualFold(strings.Split(key, ";")[0], "FN") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// attachLinkPreview adds the link metadata to the message and uploads the thumbnail when its dimensions are known
+func (service serviceSend) attachLinkPreview(ctx context.Context, extendedText *waE2E.ExtendedTextMessage, link string, metadata utils.Metadata, recipient types.JID) {
+	extendedText.Title = proto.String(metadata.Title)
+	extendedText.MatchedText = proto.String(link)
+	extendedText.Description = proto.String(metadata.Description)
+	extendedText.JPEGThumbnail = metadata.ImageThumb
+
+	// If we have a thumbnail image, upload it to WhatsApp's servers
+	if len(metadata.ImageThumb) > 0 && metadata.Height != nil && metadata.Width != nil {
+		uploadedThumb, err := service.uploadMedia(ctx, whatsmeow.MediaLinkThumbnail, metadata.ImageThumb, recipient)
+		if err == nil {
+			// Update the message with the uploaded thumbnail information
+			extendedText.ThumbnailDirectPath = proto.String(uploadedThumb.DirectPath)
+			extendedText.ThumbnailSHA256 = uploadedThumb.FileSHA256
+			extendedText.ThumbnailEncSHA256 = uploadedThumb.FileEncSHA256
+			extendedText.MediaKey = uploadedThumb.MediaKey
+			extendedText.ThumbnailHeight = metadata.Height
+			extendedText.ThumbnailWidth = metadata.Width
+		} else {
+			logrus.Warnf("Failed to upload thumbnail: %v, continue without uploaded thumbnail", err)
+		}
+	}
+}
+
 func (service serviceSend) SendLink(ctx context.Context, request domainSend.LinkRequest) (response domainSend.GenericResponse, err error) {
 	err = validations.ValidateSendLink(ctx, request)
 	if err != nil {
@@ -479,11 +757,7 @@ func (service serviceSend) SendLink(ctx context.Context, request domainSend.Link
 
 	// Create the message
 	msg := &waE2E.Message{ExtendedTextMessage: &waE2E.ExtendedTextMessage{
-		Text:          proto.String(fmt.Sprintf("%s\n%s", request.Caption, request.Link)),
-		Title:         proto.String(metadata.Title),
-		MatchedText:   proto.String(request.Link),
-		Description:   proto.String(metadata.Description),
-		JPEGThumbnail: metadata.ImageThumb,
+		Text: proto.String(fmt.Sprintf("%s\n%s", request.Caption, request.Link)),
 	}}
 
 	if request.IsForwarded {
@@ -493,21 +767,7 @@ func (service serviceSend) SendLink(ctx context.Context, request domainSend.Link
 		}
 	}
 
-	// If we have a thumbnail image, upload it to WhatsApp's servers
-	if len(metadata.ImageThumb) > 0 && metadata.Height != nil && metadata.Width != nil {
-		uploadedThumb, err := service.uploadMedia(ctx, whatsmeow.MediaLinkThumbnail, metadata.ImageThumb, dataWaRecipient)
-		if err == nil {
-			// Update the message with the uploaded thumbnail information
-			msg.ExtendedTextMessage.ThumbnailDirectPath = proto.String(uploadedThumb.DirectPath)
-			msg.ExtendedTextMessage.ThumbnailSHA256 = uploadedThumb.FileSHA256
-			msg.ExtendedTextMessage.ThumbnailEncSHA256 = uploadedThumb.FileEncSHA256
-			msg.ExtendedTextMessage.MediaKey = uploadedThumb.MediaKey
-			msg.ExtendedTextMessage.ThumbnailHeight = metadata.Height
-			msg.ExtendedTextMessage.ThumbnailWidth = metadata.Width
-		} else {
-			logrus.Warnf("Failed to upload thumbnail: %v, continue without uploaded thumbnail", err)
-		}
-	}
+	service.attachLinkPreview(ctx, msg.ExtendedTextMessage, request.Link, metadata, dataWaRecipient)
 
 	content := "🔗 " + request.Link
 	if request.Caption != "" {
@@ -540,6 +800,12 @@ func (service serviceSend) SendLocation(ctx context.Context, request domainSend.
 			DegreesLongitude: proto.Float64(utils.StrToFloat64(request.Longitude)),
 		},
 	}
+	if request.Name != "" {
+		msg.LocationMessage.Name = proto.String(request.Name)
+	}
+	if request.Address != "" {
+		msg.LocationMessage.Address = proto.String(request.Address)
+	}
 
 	if request.IsForwarded {
 		msg.LocationMessage.ContextInfo = &waE2E.ContextInfo{
@@ -561,6 +827,61 @@ func (service serviceSend) SendLocation(ctx context.Context, request domainSend.
 	return response, nil
 }
 
+func (service serviceSend) SendLiveLocation(ctx context.Context, request domainSend.LiveLocationRequest) (response domainSend.GenericResponse, err error) {
+	err = validations.ValidateSendLiveLocation(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	location := whatsapp.LiveLocation{
+		Latitude:  utils.StrToFloat64(request.Latitude),
+		Longitude: utils.StrToFloat64(request.Longitude),
+		Caption:   request.Caption,
+	}
+	ts, endsAt, err := whatsapp.StartLiveLocation(ctx, service.WaCli, dataWaRecipient, location, time.Duration(request.Duration)*time.Second)
+	if err != nil {
+		return response, err
+	}
+	utils.RecordMessage(ts.ID, service.WaCli.Store.ID.String(), "📍 live "+request.Latitude+", "+request.Longitude)
+
+	response.MessageID = ts.ID
+	response.Status = fmt.Sprintf("Live location shared with %s until %s (server timestamp: %s)", request.Phone, endsAt.Format(time.RFC3339), ts.Timestamp.String())
+	return response, nil
+}
+
+func (service serviceSend) UpdateLiveLocation(ctx context.Context, request domainSend.UpdateLiveLocationRequest) (response domainSend.GenericResponse, err error) {
+	if err = validations.ValidateUpdateLiveLocation(ctx, request); err != nil {
+		return response, err
+	}
+
+	location := whatsapp.LiveLocation{
+		Latitude:  utils.StrToFloat64(request.Latitude),
+		Longitude: utils.StrToFloat64(request.Longitude),
+		Caption:   request.Caption,
+	}
+	if err = whatsapp.UpdateLiveLocation(service.WaCli, request.MessageID, location); err != nil {
+		return response, err
+	}
+
+	response.MessageID = request.MessageID
+	response.Status = fmt.Sprintf("Live location %s moved to %s, %s", request.MessageID, request.Latitude, request.Longitude)
+	return response, nil
+}
+
+func (service serviceSend) StopLiveLocation(_ context.Context, request domainSend.StopLiveLocationRequest) (response domainSend.GenericResponse, err error) {
+	if err = whatsapp.StopLiveLocation(service.WaCli, request.MessageID); err != nil {
+		return response, err
+	}
+
+	response.MessageID = request.MessageID
+	response.Status = fmt.Sprintf("Live location %s stopped", request.MessageID)
+	return response, nil
+}
+
 func (service serviceSend) SendAudio(ctx context.Context, request domainSend.AudioRequest) (response domainSend.GenericResponse, err error) {
 	err = validations.ValidateSendAudio(ctx, request)
 	if err != nil {
@@ -570,9 +891,23 @@ func (service serviceSend) SendAudio(ctx context.Context, request domainSend.Aud
 	if err != nil {
 		return response, err
 	}
+	reply, err := service.replyContext(dataWaRecipient, request.ReplyTo)
+	if err != nil {
+		return response, err
+	}
 
 	autioBytes := helpers.MultipartFormFileHeaderToBytes(request.Audio)
 	audioMimeType := http.DetectContentType(autioBytes)
+	var (
+		waveform []byte
+		seconds  uint32
+	)
+	if request.PTT {
+		if autioBytes, waveform, seconds, err = voiceNote(ctx, service.WaCli, request.Audio); err != nil {
+			return response, err
+		}
+		audioMimeType = helpers.VoiceNoteMimeType
+	}
 
 	audioUploaded, err := service.uploadMedia(ctx, whatsmeow.MediaAudio, autioBytes, dataWaRecipient)
 	if err != nil {
@@ -580,15 +915,184 @@ func (service serviceSend) SendAudio(ctx context.Context, request domainSend.Aud
 		return response, err
 	}
 
+	msg := newAudioMessage(request, audioUploaded, audioMimeType)
+	msg.AudioMessage.ContextInfo = withReply(msg.AudioMessage.ContextInfo, reply)
+	content := "🎵 Audio"
+	if request.PTT {
+		msg.AudioMessage.Waveform = waveform
+		msg.AudioMessage.Seconds = proto.Uint32(seconds)
+		content = "🎤 Voice note"
+	}
+
+	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, msg, content)
+	if err != nil {
+		return response, err
+	}
+
+	response.MessageID = ts.ID
+	response.Status = fmt.Sprintf("Send audio success %s (server timestamp: %s)", request.Phone, ts.Timestamp.String())
+	return response, nil
+}
+
+// sendItemPath is the path of a file stored while a message of the session of the client is sent
+func sendItemPath(client *whatsmeow.Client, name string) string {
+	return fmt.Sprintf("%s/%s%s", config.PathSendItems, whatsapp.SessionFilePrefix(client), name)
+}
+
+// voiceNote transcodes the audio to the opus voice note format and draws its waveform
+func voiceNote(ctx context.Context, client *whatsmeow.Client, audio *multipart.FileHeader) (data []byte, waveform []byte, seconds uint32, err error) {
+	generateUUID := fiberUtils.UUIDv4()
+	oriAudioPath := sendItemPath(client, generateUUID+filepath.Base(audio.Filename))
+	voiceNotePath := sendItemPath(client, generateUUID+".ogg")
+	defer func() {
+		if errDelete := utils.RemoveFile(0, oriAudioPath, voiceNotePath); errDelete != nil {
+			logrus.Infof("error when deleting voice note: %v", errDelete)
+		}
+	}()
+	if err = fasthttp.SaveMultipartFile(audio, oriAudioPath); err != nil {
+		return nil, nil, 0, pkgError.InternalServerError(fmt.Sprintf("failed to store audio in server %v", err))
+	}
+
+	err = helpers.ConvertToVoiceNote(ctx, oriAudioPath, voiceNotePath)
+	if errors.Is(err, helpers.ErrFFmpegNotInstalled) {
+		return nil, nil, 0, pkgError.InternalServerError(err.Error())
+	} else if err != nil {
+		return nil, nil, 0, pkgError.ValidationError(fmt.Sprintf("audio can't be converted to a voice note, please send a mp3, ogg, wav, m4a or aac file (%v)", err))
+	}
+	if waveform, seconds, err = helpers.VoiceNoteWaveform(ctx, voiceNotePath); err != nil {
+		return nil, nil, 0, pkgError.InternalServerError(fmt.Sprintf("failed to draw the voice note waveform: %v", err))
+	}
+	if data, err = os.ReadFile(voiceNotePath); err != nil {
+		return nil, nil, 0, pkgError.InternalServerError(fmt.Sprintf("failed to read the voice note: %v", err))
+	}
+	return data, waveform, seconds, nil
+}
+
+func (service serviceSend) SendSticker(ctx context.Context, request domainSend.StickerRequest) (response domainSend.GenericResponse, err error) {
+	err = validations.ValidateSendSticker(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	generateUUID := fiberUtils.UUIDv4()
+	oriStickerPath := sendItemPath(service.WaCli, generateUUID+request.Sticker.Filename)
+	if err = fasthttp.SaveMultipartFile(request.Sticker, oriStickerPath); err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to store sticker in server %v", err))
+	}
+	stickerPath := sendItemPath(service.WaCli, generateUUID+".webp")
+	defer func() {
+		if errDelete := utils.RemoveFile(0, oriStickerPath, stickerPath); errDelete != nil {
+			logrus.Infof("error when deleting sticker: %v", errDelete)
+		}
+	}()
+
+	animated := request.Sticker.Header.Get("Content-Type") == "image/gif"
+	err = helpers.ConvertToWebpSticker(ctx, oriStickerPath, stickerPath, animated)
+	if errors.Is(err, helpers.ErrFFmpegNotInstalled) {
+		return response, pkgError.InternalServerError(err.Error())
+	} else if err != nil {
+		return response, pkgError.ValidationError(fmt.Sprintf("failed to convert sticker to webp: %v", err))
+	}
+
+	dataWaSticker, err := os.ReadFile(stickerPath)
+	if err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to read sticker %v", err))
+	}
+	uploaded, err := service.uploadMedia(ctx, whatsmeow.MediaImage, dataWaSticker, dataWaRecipient)
+	if err != nil {
+		return response, pkgError.WaUploadMediaError(fmt.Sprintf("Failed to upload sticker: %v", err))
+	}
+
+	msg := &waE2E.Message{StickerMessage: &waE2E.StickerMessage{
+		URL:           proto.String(uploaded.URL),
+		DirectPath:    proto.String(uploaded.DirectPath),
+		MediaKey:      uploaded.MediaKey,
+		Mimetype:      proto.String("image/webp"),
+		FileEncSHA256: uploaded.FileEncSHA256,
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    proto.Uint64(uint64(len(dataWaSticker))),
+		Width:         proto.Uint32(helpers.StickerSize),
+		Height:        proto.Uint32(helpers.StickerSize),
+		IsAnimated:    proto.Bool(animated),
+	}}
+
+	if request.IsForwarded {
+		msg.StickerMessage.ContextInfo = &waE2E.ContextInfo{
+			IsForwarded:     proto.Bool(true),
+			ForwardingScore: proto.Uint32(100),
+		}
+	}
+
+	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, msg, "🎨 Sticker")
+	if err != nil {
+		return response, err
+	}
+
+	response.MessageID = ts.ID
+	response.Status = fmt.Sprintf("Send sticker success %s (server timestamp: %s)", request.Phone, ts.Timestamp.String())
+	return response, nil
+}
+
+// newDocumentMessage builds the document with the file name of the request, the recipient sees that exact name
+func newDocumentMessage(request domainSend.FileRequest, uploaded whatsmeow.UploadResponse, fileBytes []byte) *waE2E.Message {
+	fileName := request.FileName
+	if fileName == "" {
+		fileName = request.File.Filename
+	}
+
+	msg := &waE2E.Message{DocumentMessage: &waE2E.DocumentMessage{
+		URL:           proto.String(uploaded.URL),
+		Mimetype:      proto.String(documentMimeType(fileName, request.MimeType, fileBytes)),
+		Title:         proto.String(fileName),
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    proto.Uint64(uploaded.FileLength),
+		MediaKey:      uploaded.MediaKey,
+		FileName:      proto.String(fileName),
+		FileEncSHA256: uploaded.FileEncSHA256,
+		DirectPath:    proto.String(uploaded.DirectPath),
+		Caption:       proto.String(request.Caption),
+	}}
+
+	if request.IsForwarded {
+		msg.DocumentMessage.ContextInfo = &waE2E.ContextInfo{
+			IsForwarded:     proto.Bool(true),
+			ForwardingScore: proto.Uint32(100),
+		}
+	}
+	return msg
+}
+
+// documentMimeType returns the mime type of the request, or the one of the file extension, or the one sniffed from
+// the content. Office files are zip archives to the sniffer, so the extension goes first
+func documentMimeType(fileName, mimeType string, fileBytes []byte) string {
+	if mimeType != "" {
+		return mimeType
+	}
+	if byExtension := mime.TypeByExtension(filepath.Ext(fileName)); byExtension != "" {
+		if mediaType, _, err := mime.ParseMediaType(byExtension); err == nil {
+			return mediaType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(fileBytes))
+	return mediaType
+}
+
+func newAudioMessage(request domainSend.AudioRequest, uploaded whatsmeow.UploadResponse, mimeType string) *waE2E.Message {
 	msg := &waE2E.Message{
 		AudioMessage: &waE2E.AudioMessage{
-			URL:           proto.String(audioUploaded.URL),
-			DirectPath:    proto.String(audioUploaded.DirectPath),
-			Mimetype:      proto.String(audioMimeType),
-			FileLength:    proto.Uint64(audioUploaded.FileLength),
-			FileSHA256:    audioUploaded.FileSHA256,
-			FileEncSHA256: audioUploaded.FileEncSHA256,
-			MediaKey:      audioUploaded.MediaKey,
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			Mimetype:      proto.String(mimeType),
+			FileLength:    proto.Uint64(uploaded.FileLength),
+			FileSHA256:    uploaded.FileSHA256,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			MediaKey:      uploaded.MediaKey,
+			ViewOnce:      proto.Bool(request.ViewOnce),
+			PTT:           proto.Bool(request.PTT),
 		},
 	}
 
@@ -598,8 +1102,22 @@ func (service serviceSend) SendAudio(ctx context.Context, request domainSend.Aud
 			ForwardingScore: proto.Uint32(100),
 		}
 	}
+	return msg
+}
 
-	content := "🎵 Audio"
+func (service serviceSend) SendPoll(ctx context.Context, request domainSend.PollRequest) (response domainSend.GenericResponse, err error) {
+	err = validations.ValidateSendPoll(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	content := "📊 " + request.Question
+
+	msg := service.WaCli.BuildPollCreation(request.Question, request.Options, pollSelectableCount(request))
 
 	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, msg, content)
 	if err != nil {
@@ -607,12 +1125,12 @@ func (service serviceSend) SendAudio(ctx context.Context, request domainSend.Aud
 	}
 
 	response.MessageID = ts.ID
-	response.Status = fmt.Sprintf("Send audio success %s (server timestamp: %s)", request.Phone, ts.Timestamp.String())
+	response.Status = fmt.Sprintf("Send poll success %s (server timestamp: %s)", request.Phone, ts.Timestamp.String())
 	return response, nil
 }
 
-func (service serviceSend) SendPoll(ctx context.Context, request domainSend.PollRequest) (response domainSend.GenericResponse, err error) {
-	err = validations.ValidateSendPoll(ctx, request)
+func (service serviceSend) SendButtons(ctx context.Context, request domainSend.ButtonsRequest) (response domainSend.GenericResponse, err error) {
+	err = validations.ValidateSendButtons(ctx, request)
 	if err != nil {
 		return response, err
 	}
@@ -621,27 +1139,212 @@ func (service serviceSend) SendPoll(ctx context.Context, request domainSend.Poll
 		return response, err
 	}
 
-	content := "📊 " + request.Question
+	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, newButtonsMessage(request), request.Body)
+	if errors.Is(err, whatsmeow.ErrServerReturnedError) {
+		// Buttons are restricted to the business api and may be refused for this account
+		return response, pkgError.ValidationError(fmt.Sprintf("WhatsApp rejected the buttons message, buttons may not be allowed for this account: %v", err))
+	}
+	if err != nil {
+		return response, err
+	}
 
-	msg := service.WaCli.BuildPollCreation(request.Question, request.Options, request.MaxAnswer)
+	response.MessageID = ts.ID
+	response.Status = fmt.Sprintf("Send buttons success %s (server timestamp: %s)", request.Phone, ts.Timestamp.String())
+	return response, nil
+}
 
-	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, msg, content)
+func newButtonsMessage(request domainSend.ButtonsRequest) *waE2E.Message {
+	buttons := make([]*waE2E.ButtonsMessage_Button, len(request.Buttons))
+	for i, button := range request.Buttons {
+		buttons[i] = &waE2E.ButtonsMessage_Button{
+			ButtonID:   proto.String(button.ID),
+			ButtonText: &waE2E.ButtonsMessage_Button_ButtonText{DisplayText: proto.String(button.Text)},
+			Type:       waE2E.ButtonsMessage_Button_RESPONSE.Enum(),
+		}
+	}
+
+	buttonsMessage := &waE2E.ButtonsMessage{
+		ContentText: proto.String(request.Body),
+		Buttons:     buttons,
+		HeaderType:  waE2E.ButtonsMessage_EMPTY.Enum(),
+	}
+	if request.Footer != "" {
+		buttonsMessage.FooterText = proto.String(request.Footer)
+	}
+	return &waE2E.Message{ButtonsMessage: buttonsMessage}
+}
+
+// SendTemplateButtons sends a template message with quick reply, url and call buttons. Template messages are meant for
+// the business api, with Fallback a rejected template is sent again as plain text. WhatsApp may also accept a template
+// and never show it, that silent drop can't be told apart from a delivery so it doesn't trigger the fallback
+func (service serviceSend) SendTemplateButtons(ctx context.Context, request domainSend.TemplateButtonsRequest) (response domainSend.GenericResponse, err error) {
+	err = validations.ValidateSendTemplateButtons(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, newTemplateButtonsMessage(request), request.Body)
+	if errors.Is(err, whatsmeow.ErrServerReturnedError) {
+		if !request.Fallback {
+			return response, pkgError.ValidationError(fmt.Sprintf("WhatsApp rejected the template message, template buttons may not be allowed for this account: %v", err))
+		}
+		logrus.Warnf("WhatsApp rejected the template message to %s, sending it as text: %v", request.Phone, err)
+		text := templateButtonsFallbackText(request)
+		ts, err = service.wrapSendMessage(ctx, dataWaRecipient, &waE2E.Message{Conversation: proto.String(text)}, text)
+		if err != nil {
+			return response, err
+		}
+		response.MessageID = ts.ID
+		response.Status = fmt.Sprintf("Template buttons rejected, sent as text to %s (server timestamp: %s)", request.Phone, ts.Timestamp.String())
+		return response, nil
+	}
 	if err != nil {
 		return response, err
 	}
 
 	response.MessageID = ts.ID
-	response.Status = fmt.Sprintf("Send poll success %s (server timestamp: %s)", request.Phone, ts.Timestamp.String())
+	response.Status = fmt.Sprintf("Send template buttons success %s (server timestamp: %s)", request.Phone, ts.Timestamp.String())
 	return response, nil
 }
 
+func newTemplateButtonsMessage(request domainSend.TemplateButtonsRequest) *waE2E.Message {
+	buttons := make([]*waE2E.HydratedTemplateButton, len(request.Buttons))
+	for i, button := range request.Buttons {
+		buttons[i] = &waE2E.HydratedTemplateButton{Index: proto.Uint32(uint32(i))}
+		switch button.Type {
+		case domainSend.TemplateButtonURL:
+			buttons[i].HydratedButton = &waE2E.HydratedTemplateButton_UrlButton{UrlButton: &waE2E.HydratedTemplateButton_HydratedURLButton{
+				DisplayText: proto.String(button.Text),
+				URL:         proto.String(button.URL),
+			}}
+		case domainSend.TemplateButtonCall:
+			buttons[i].HydratedButton = &waE2E.HydratedTemplateButton_CallButton{CallButton: &waE2E.HydratedTemplateButton_HydratedCallButton{
+				DisplayText: proto.String(button.Text),
+				PhoneNumber: proto.String(button.PhoneNumber),
+			}}
+		default:
+			buttons[i].HydratedButton = &waE2E.HydratedTemplateButton_QuickReplyButton{QuickReplyButton: &waE2E.HydratedTemplateButton_HydratedQuickReplyButton{
+				DisplayText: proto.String(button.Text),
+				ID:          proto.String(button.ID),
+			}}
+		}
+	}
+
+	template := &waE2E.TemplateMessage_HydratedFourRowTemplate{
+		HydratedContentText: proto.String(request.Body),
+		HydratedButtons:     buttons,
+	}
+	if request.Title != "" {
+		template.Title = &waE2E.TemplateMessage_HydratedFourRowTemplate_HydratedTitleText{HydratedTitleText: request.Title}
+	}
+	if request.Footer != "" {
+		template.HydratedFooterText = proto.String(request.Footer)
+	}
+	// Older clients read the template from the format, newer ones from hydratedTemplate
+	return &waE2E.Message{TemplateMessage: &waE2E.TemplateMessage{
+		Format:           &waE2E.TemplateMessage_HydratedFourRowTemplate_{HydratedFourRowTemplate: template},
+		HydratedTemplate: template,
+	}}
+}
+
+// templateButtonsFallbackText writes the template as plain text, the url and call buttons keep their target
+func templateButtonsFallbackText(request domainSend.TemplateButtonsRequest) string {
+	var text strings.Builder
+	if request.Title != "" {
+		text.WriteString("*" + request.Title + "*\n\n")
+	}
+	text.WriteString(request.Body)
+	text.WriteString("\n")
+	for _, button := range request.Buttons {
+		switch button.Type {
+		case domainSend.TemplateButtonURL:
+			text.WriteString(fmt.Sprintf("\n%s: %s", button.Text, button.URL))
+		case domainSend.TemplateButtonCall:
+			text.WriteString(fmt.Sprintf("\n%s: %s", button.Text, button.PhoneNumber))
+		default:
+			text.WriteString(fmt.Sprintf("\n- %s", button.Text))
+		}
+	}
+	if request.Footer != "" {
+		text.WriteString("\n\n_" + request.Footer + "_")
+	}
+	return text.String()
+}
+
+func (service serviceSend) SendList(ctx context.Context, request domainSend.ListRequest) (response domainSend.GenericResponse, err error) {
+	err = validations.ValidateSendList(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	ts, err := service.wrapSendMessage(ctx, dataWaRecipient, newListMessage(request), "📋 "+request.Title)
+	if errors.Is(err, whatsmeow.ErrServerReturnedError) {
+		// Lists are restricted to the business api like buttons
+		return response, pkgError.ValidationError(fmt.Sprintf("WhatsApp rejected the list message, lists may not be allowed for this account: %v", err))
+	}
+	if err != nil {
+		return response, err
+	}
+
+	response.MessageID = ts.ID
+	response.Status = fmt.Sprintf("Send list success %s (server timestamp: %s)", request.Phone, ts.Timestamp.String())
+	return response, nil
+}
+
+func newListMessage(request domainSend.ListRequest) *waE2E.Message {
+	sections := make([]*waE2E.ListMessage_Section, len(request.Sections))
+	for i, section := range request.Sections {
+		rows := make([]*waE2E.ListMessage_Row, len(section.Rows))
+		for j, row := range section.Rows {
+			rows[j] = &waE2E.ListMessage_Row{
+				RowID: proto.String(row.ID),
+				Title: proto.String(row.Title),
+			}
+			if row.Description != "" {
+				rows[j].Description = proto.String(row.Description)
+			}
+		}
+		sections[i] = &waE2E.ListMessage_Section{Title: proto.String(section.Title), Rows: rows}
+	}
+
+	listMessage := &waE2E.ListMessage{
+		Title:      proto.String(request.Title),
+		ButtonText: proto.String(request.ButtonText),
+		ListType:   waE2E.ListMessage_SINGLE_SELECT.Enum(),
+		Sections:   sections,
+	}
+	if request.Description != "" {
+		listMessage.Description = proto.String(request.Description)
+	}
+	if request.Footer != "" {
+		listMessage.FooterText = proto.String(request.Footer)
+	}
+	return &waE2E.Message{ListMessage: listMessage}
+}
+
+// pollSelectableCount returns the selectable options of the poll, 0 lets voters select any number of options
+func pollSelectableCount(request domainSend.PollRequest) int {
+	if request.MultipleAnswers && request.MaxAnswer == len(request.Options) {
+		return 0
+	}
+	return request.MaxAnswer
+}
+
 func (service serviceSend) SendPresence(ctx context.Context, request domainSend.PresenceRequest) (response domainSend.GenericResponse, err error) {
 	err = validations.ValidateSendPresence(ctx, request)
 	if err != nil {
 		return response, err
 	}
 
-	err = service.WaCli.SendPresence(types.Presence(request.Type))
+	err = whatsapp.SendPresence(service.WaCli, types.Presence(request.Type))
 	if err != nil {
 		return response, err
 	}
@@ -651,12 +1354,192 @@ func (service serviceSend) SendPresence(ctx context.Context, request domainSend.
 	return response, nil
 }
 
-func (service serviceSend) getMentionFromText(_ context.Context, messages string) (result []string) {
+func (service serviceSend) SendBatch(ctx context.Context, request domainSend.BatchRequest) (response domainSend.BatchResponse, err error) {
+	if err = validations.ValidateSendBatch(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	concurrency := config.WhatsappBatchConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	limiter := getBatchRateLimiter(service.WaCli)
+	response.Results = make([]domainSend.BatchResult, len(request.Phones))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := domainSend.BatchResult{Phone: request.Phones[i]}
+				if err := limiter.Wait(ctx); err != nil {
+					result.Error = err.Error()
+					response.Results[i] = result
+					continue
+				}
+
+				sent, err := service.sendBatchItem(ctx, domainSend.MessageRequest{
+					Phone:       request.Phones[i],
+					Message:     request.Message,
+					IsForwarded: request.IsForwarded,
+				})
+				if err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Success = true
+					result.MessageID = sent.MessageID
+				}
+				response.Results[i] = result
+
+				// Throttle each worker so recipients are not hit in a burst, a cancelled batch stops waiting
+				delay := time.NewTimer(config.WhatsappBatchDelay)
+				select {
+				case <-delay.C:
+				case <-ctx.Done():
+					delay.Stop()
+				}
+			}
+		}()
+	}
+	for i := range request.Phones {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	results := make([]whatsapp.BatchSentResult, len(response.Results))
+	for i, result := range response.Results {
+		if result.Success {
+			response.Sent++
+		} else {
+			response.Failed++
+		}
+		results[i] = whatsapp.BatchSentResult(result)
+	}
+	// The webhook gets the per recipient results as well, so the other consumers see the partial failures too
+	whatsapp.EmitBatchSent(service.WaCli, &whatsapp.BatchSent{Sent: response.Sent, Failed: response.Failed, Results: results})
+	response.Status = fmt.Sprintf("Batch sent to %d of %d recipients", response.Sent, len(request.Phones))
+	return response, nil
+}
+
+// sendBatchItem sends to one recipient, the login checks panic so it is recovered here instead of crashing the worker
+func (service serviceSend) sendBatchItem(ctx context.Context, request domainSend.MessageRequest) (response domainSend.GenericResponse, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return service.SendText(ctx, request)
+}
+
+var (
+	batchRateLimiters   = make(map[*whatsmeow.Client]*utils.RateLimiter)
+	batchRateLimitersMu sync.Mutex
+)
+
+// getBatchRateLimiter returns the limiter shared by every batch of the session of the client, so concurrent
+// batches can't exceed the rate together. The default session is served on two routes with the same client
+func getBatchRateLimiter(client *whatsmeow.Client) *utils.RateLimiter {
+	batchRateLimitersMu.Lock()
+	defer batchRateLimitersMu.Unlock()
+
+	limiter, ok := batchRateLimiters[client]
+	if !ok {
+		limiter = utils.NewRateLimiter(config.WhatsappBatchRateLimit)
+		batchRateLimiters[client] = limiter
+	}
+	return limiter
+}
+
+func (service serviceSend) SendChatPresence(ctx context.Context, request domainSend.ChatPresenceRequest) (response domainSend.GenericResponse, err error) {
+	if err = validations.ValidateSendChatPresence(ctx, request); err != nil {
+		return response, err
+	}
+	dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	state := types.ChatPresenceComposing
+	media := types.ChatPresenceMediaText
+	switch request.State {
+	case "recording":
+		media = types.ChatPresenceMediaAudio
+	case "paused":
+		state = types.ChatPresencePaused
+	}
+
+	// A new call replaces the pending auto pause of the same chat, the lock isn't held while sending
+	chat := chatPresenceKey{client: service.WaCli, chat: dataWaRecipient.String()}
+	call := &chatPresenceCall{}
+	chatPresenceMu.Lock()
+	if previous, ok := chatPresenceCalls[chat]; ok && previous.timer != nil {
+		previous.timer.Stop()
+	}
+	chatPresenceCalls[chat] = call
+	chatPresenceMu.Unlock()
+
+	err = service.WaCli.SendChatPresence(dataWaRecipient, state, media)
+
+	chatPresenceMu.Lock()
+	defer chatPresenceMu.Unlock()
+	if chatPresenceCalls[chat] != call {
+		// Replaced by a newer call while sending, the auto pause is the newer one's
+		return response, err
+	}
+	if err != nil {
+		delete(chatPresenceCalls, chat)
+		return response, err
+	}
+	if state != types.ChatPresenceComposing || request.Duration <= 0 {
+		delete(chatPresenceCalls, chat)
+	} else {
+		call.timer = time.AfterFunc(time.Duration(request.Duration)*time.Second, func() {
+			chatPresenceMu.Lock()
+			if chatPresenceCalls[chat] != call {
+				// Replaced by a newer call in the meantime
+				chatPresenceMu.Unlock()
+				return
+			}
+			delete(chatPresenceCalls, chat)
+			chatPresenceMu.Unlock()
+
+			if err := service.WaCli.SendChatPresence(dataWaRecipient, types.ChatPresencePaused, types.ChatPresenceMediaText); err != nil {
+				logrus.Warnf("Failed to pause chat presence of %s: %v", chat.chat, err)
+			}
+		})
+	}
+
+	response.MessageID = "chat-presence"
+	response.Status = fmt.Sprintf("Send chat presence %s to %s success", request.State, request.Phone)
+	return response, nil
+}
+
+// chatPresenceKey identifies a chat of a session, each session has its own auto pause
+type chatPresenceKey struct {
+	client *whatsmeow.Client
+	chat   string
+}
+
+// chatPresenceCall is the latest chat presence sent to a chat, with the timer of its auto pause
+type chatPresenceCall struct {
+	timer *time.Timer
+}
+
+var (
+	chatPresenceCalls = make(map[chatPresenceKey]*chatPresenceCall)
+	chatPresenceMu    sync.Mutex
+)
+
+func (service serviceSend) getMentionFromText(_ context.Context, messages string) (result []types.JID) {
 	mentions := utils.ContainsMention(messages)
 	for _, mention := range mentions {
 		// Get JID from phone number
 		if dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, mention); err == nil {
-			result = append(result, dataWaRecipient.String())
+			result = append(result, dataWaRecipient)
 		}
 	}
 	return result
@@ -664,9 +1547,100 @@ func (service serviceSend) getMentionFromText(_ context.Context, messages string
 
 func (service serviceSend) uploadMedia(ctx context.Context, mediaType whatsmeow.MediaType, media []byte, recipient types.JID) (uploaded whatsmeow.UploadResponse, err error) {
 	if recipient.Server == types.NewsletterServer {
+		// A message the session can't post is rejected before its media is uploaded
+		if err = whatsapp.CheckNewsletterRole(service.WaCli, recipient); err != nil {
+			return uploaded, err
+		}
 		uploaded, err = service.WaCli.UploadNewsletter(ctx, media, mediaType)
 	} else {
 		uploaded, err = service.WaCli.Upload(ctx, media, mediaType)
 	}
 	return uploaded, err
 }
+
+// ScheduleMessage stores a text message to be sent at send_at by the scheduler, it survives restarts
+func (service serviceSend) ScheduleMessage(ctx context.Context, request domainSend.ScheduleRequest) (response domainSend.ScheduledMessage, err error) {
+	if err = validations.ValidateScheduleMessage(ctx, request); err != nil {
+		return response, err
+	}
+	recipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	request.Phone = recipient.String()
+	payload, err := json.Marshal(request.MessageRequest)
+	if err != nil {
+		return response, err
+	}
+	sendAt, _ := time.Parse(time.RFC3339, request.SendAt)
+	scheduled, err := whatsapp.ScheduleMessage(service.WaCli, request.Phone, string(payload), sendAt)
+	if err != nil {
+		return response, err
+	}
+	return newScheduledMessage(scheduled)
+}
+
+func (service serviceSend) ListScheduledMessages(ctx context.Context, request domainSend.ListScheduledRequest) (response domainSend.ListScheduledResponse, err error) {
+	if err = validations.ValidateListScheduled(ctx, request); err != nil {
+		return response, err
+	}
+
+	messages, err := whatsapp.ListScheduledMessages(service.WaCli, request.Status)
+	if err != nil {
+		return response, err
+	}
+	response.Data = make([]domainSend.ScheduledMessage, 0, len(messages))
+	for _, message := range messages {
+		scheduled, err := newScheduledMessage(message)
+		if err != nil {
+			return response, err
+		}
+		response.Data = append(response.Data, scheduled)
+	}
+	return response, nil
+}
+
+func (service serviceSend) CancelScheduledMessage(ctx context.Context, request domainSend.CancelScheduledRequest) (response domainSend.ScheduledMessage, err error) {
+	if err = validations.ValidateCancelScheduled(ctx, request); err != nil {
+		return response, err
+	}
+
+	message, err := whatsapp.CancelScheduledMessage(service.WaCli, request.ID)
+	if err != nil {
+		return response, err
+	}
+	return newScheduledMessage(message)
+}
+
+// SendScheduledMessage sends the stored request of a due message, the login checks panic so they are recovered here
+// instead of stopping the scheduler
+func (service serviceSend) SendScheduledMessage(ctx context.Context, payload string) (response domainSend.GenericResponse, err error) {
+	var request domainSend.MessageRequest
+	if err = json.Unmarshal([]byte(payload), &request); err != nil {
+		return response, fmt.Errorf("invalid scheduled message: %w", err)
+	}
+	return service.sendBatchItem(ctx, request)
+}
+
+func newScheduledMessage(message whatsapp.ScheduledMessage) (domainSend.ScheduledMessage, error) {
+	var request domainSend.MessageRequest
+	if err := json.Unmarshal([]byte(message.Payload), &request); err != nil {
+		return domainSend.ScheduledMessage{}, fmt.Errorf("invalid scheduled message %s: %w", message.ID, err)
+	}
+
+	scheduled := domainSend.ScheduledMessage{
+		ID:        message.ID,
+		Phone:     message.Phone,
+		Message:   request.Message,
+		SendAt:    message.SendAt,
+		Status:    message.Status,
+		MessageID: message.MessageID,
+		Error:     message.Error,
+		CreatedAt: message.CreatedAt,
+	}
+	if !message.SentAt.IsZero() {
+		scheduled.SentAt = &message.SentAt
+	}
+	return scheduled, nil
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"mime/multipart"
+	"testing"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/stretchr/testify/assert"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNewAudioMessageViewOnceRoundTrip(t *testing.T) {
+	uploaded := whatsmeow.UploadResponse{URL: "https://mmg.whatsapp.net/audio", DirectPath: "/audio", FileLength: 10}
+
+	for _, viewOnce := range []bool{true, false} {
+		msg := newAudioMessage(domainSend.AudioRequest{ViewOnce: viewOnce}, uploaded, "audio/ogg")
+
+		// Encode and decode like the message goes over the wire
+		data, err := proto.Marshal(msg)
+		assert.NoError(t, err)
+		var received waE2E.Message
+		assert.NoError(t, proto.Unmarshal(data, &received))
+
+		assert.Equal(t, viewOnce, received.GetAudioMessage().GetViewOnce())
+		assert.Equal(t, "audio/ogg", received.GetAudioMessage().GetMimetype())
+	}
+}
+
+func TestNewAudioMessageVoiceNote(t *testing.T) {
+	msg := newAudioMessage(domainSend.AudioRequest{PTT: true}, whatsmeow.UploadResponse{}, "audio/ogg; codecs=opus")
+	assert.True(t, msg.GetAudioMessage().GetPTT())
+
+	msg = newAudioMessage(domainSend.AudioRequest{}, whatsmeow.UploadResponse{}, "audio/mpeg")
+	assert.False(t, msg.GetAudioMessage().GetPTT())
+}
+
+func TestPollCreationRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    domainSend.PollRequest
+		selectable uint32
+	}{
+		{"single answer", domainSend.PollRequest{Question: "Lunch?", Options: []string{"Rice", "Noodle"}, MaxAnswer: 1}, 1},
+		{"multiple answers", domainSend.PollRequest{Question: "Lunch?", Options: []string{"Rice", "Noodle"}, MultipleAnswers: true}, 0},
+		{"multiple answers with all options", domainSend.PollRequest{Question: "Lunch?", Options: []string{"Rice", "Noodle"}, MaxAnswer: 2, MultipleAnswers: true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := (&whatsmeow.Client{}).BuildPollCreation(tt.request.Question, tt.request.Options, pollSelectableCount(tt.request))
+
+			data, err := proto.Marshal(msg)
+			assert.NoError(t, err)
+			var received waE2E.Message
+			assert.NoError(t, proto.Unmarshal(data, &received))
+
+			poll := received.GetPollCreationMessage()
+			assert.Equal(t, "Lunch?", poll.GetName())
+			assert.Len(t, poll.GetOptions(), 2)
+			assert.Equal(t, "Noodle", poll.GetOptions()[1].GetOptionName())
+			assert.Equal(t, tt.selectable, poll.GetSelectableOptionsCount())
+			// The secret is needed to decrypt the votes of this poll
+			assert.Len(t, received.GetMessageContextInfo().GetMessageSecret(), 32)
+		})
+	}
+}
+
+func TestNewButtonsMessage(t *testing.T) {
+	msg := newButtonsMessage(domainSend.ButtonsRequest{
+		Body:    "Continue?",
+		Buttons: []domainSend.Button{{ID: "yes", Text: "Yes"}, {ID: "no", Text: "No"}},
+	})
+
+	buttons := msg.GetButtonsMessage()
+	assert.Equal(t, "Continue?", buttons.GetContentText())
+	assert.Nil(t, buttons.FooterText)
+	assert.Len(t, buttons.GetButtons(), 2)
+	assert.Equal(t, "no", buttons.GetButtons()[1].GetButtonID())
+	assert.Equal(t, "No", buttons.GetButtons()[1].GetButtonText().GetDisplayText())
+	assert.Equal(t, waE2E.ButtonsMessage_Button_RESPONSE, buttons.GetButtons()[1].GetType())
+}
+
+func TestNewListMessage(t *testing.T) {
+	msg := newListMessage(domainSend.ListRequest{
+		Title:      "Menu",
+		ButtonText: "Open menu",
+		Sections: []domainSend.ListSection{{Title: "Food", Rows: []domainSend.ListRow{
+			{ID: "rice", Title: "Rice", Description: "Fried rice"},
+		}}},
+	})
+
+	list := msg.GetListMessage()
+	assert.Equal(t, "Menu", list.GetTitle())
+	assert.Equal(t, "Open menu", list.GetButtonText())
+	assert.Equal(t, waE2E.ListMessage_SINGLE_SELECT, list.GetListType())
+	assert.Equal(t, "rice", list.GetSections()[0].GetRows()[0].GetRowID())
+	assert.Equal(t, "Fried rice", list.GetSections()[0].GetRows()[0].GetDescription())
+}
+
+func TestBuildVCard(t *testing.T) {
+	vcard, err := buildVCard("Doe, John", "+62 896-8502-4992")
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"BEGIN:VCARD\nVERSION:3.0\nN:;Doe\\, John;;;\nFN:Doe\\, John\nTEL;type=CELL;waid=6289685024992:+6289685024992\nEND:VCARD",
+		vcard,
+	)
+
+	_, err = buildVCard("Team", "120363025246125888@g.us")
+	assert.Error(t, err)
+}
+
+func TestNewContactMessage(t *testing.T) {
+	rawVCard := "BEGIN:VCARD\r\nVERSION:3.0\r\nFN;CHARSET=UTF-8:Jane Roe\r\nTEL:+6289685024993\r\nEND:VCARD"
+
+	msg, content, err := newContactMessage([]domainSend.ContactCard{{Name: "Aldino", Phone: "6289685024992"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Aldino", msg.GetContactMessage().GetDisplayName())
+	assert.Contains(t, msg.GetContactMessage().GetVcard(), "waid=6289685024992")
+	assert.Equal(t, "👤 Aldino", content)
+
+	msg, content, err = newContactMessage([]domainSend.ContactCard{{Name: "Aldino", Phone: "6289685024992"}, {Vcard: rawVCard}})
+	assert.NoError(t, err)
+	assert.Nil(t, msg.GetContactMessage())
+	assert.Equal(t, "2 contacts", msg.GetContactsArrayMessage().GetDisplayName())
+	assert.Len(t, msg.GetContactsArrayMessage().GetContacts(), 2)
+	assert.Equal(t, "Jane Roe", msg.GetContactsArrayMessage().GetContacts()[1].GetDisplayName())
+	assert.Equal(t, rawVCard, msg.GetContactsArrayMessage().GetContacts()[1].GetVcard())
+	assert.Equal(t, "👤 2 contacts", content)
+}
+
+func TestNewReplyContext(t *testing.T) {
+	sender := types.NewJID("6289685028129", types.DefaultUserServer)
+	original := &waE2E.Message{ImageMessage: &waE2E.ImageMessage{
+		Caption:       proto.String("holiday"),
+		JPEGThumbnail: []byte{0xff, 0xd8},
+		ContextInfo:   &waE2E.ContextInfo{StanzaID: proto.String("3EB0OLDER"), IsForwarded: proto.Bool(true)},
+	}}
+
+	reply := newReplyContext("3EB0QUOTED", whatsapp.CachedMessage{Message: original, Sender: sender})
+	assert.Equal(t, "3EB0QUOTED", reply.GetStanzaID())
+	assert.Equal(t, "6289685028129@s.whatsapp.net", reply.GetParticipant())
+	assert.Equal(t, "holiday", reply.GetQuotedMessage().GetImageMessage().GetCaption())
+	assert.Equal(t, []byte{0xff, 0xd8}, reply.GetQuotedMessage().GetImageMessage().GetJPEGThumbnail())
+	// The quote doesn't carry the context of the quoted message, the original keeps it
+	assert.Nil(t, reply.GetQuotedMessage().GetImageMessage().GetContextInfo())
+	assert.Equal(t, "3EB0OLDER", original.GetImageMessage().GetContextInfo().GetStanzaID())
+
+	// The other details of the context are kept
+	info := withReply(&waE2E.ContextInfo{IsForwarded: proto.Bool(true), MentionedJID: []string{sender.String()}}, reply)
+	assert.True(t, info.GetIsForwarded())
+	assert.Equal(t, []string{sender.String()}, info.GetMentionedJID())
+	assert.Equal(t, "3EB0QUOTED", info.GetStanzaID())
+
+	assert.Equal(t, "3EB0QUOTED", withReply(nil, reply).GetStanzaID())
+	assert.Nil(t, withReply(nil, nil))
+}
+
+func TestNewDocumentMessage(t *testing.T) {
+	file := &multipart.FileHeader{Filename: "upload-3f2a.bin"}
+	pdf := []byte("%PDF-1.7\n")
+
+	msg := newDocumentMessage(domainSend.FileRequest{File: file, FileName: "Invoice January.pdf", Caption: "your invoice"}, whatsmeow.UploadResponse{}, pdf)
+	assert.Equal(t, "Invoice January.pdf", msg.GetDocumentMessage().GetFileName())
+	assert.Equal(t, "Invoice January.pdf", msg.GetDocumentMessage().GetTitle())
+	assert.Equal(t, "application/pdf", msg.GetDocumentMessage().GetMimetype())
+	assert.Equal(t, "your invoice", msg.GetDocumentMessage().GetCaption())
+
+	// The uploaded name is used without a filename, an explicit mimetype wins
+	msg = newDocumentMessage(domainSend.FileRequest{File: file, MimeType: "application/x-custom"}, whatsmeow.UploadResponse{}, pdf)
+	assert.Equal(t, "upload-3f2a.bin", msg.GetDocumentMessage().GetFileName())
+	assert.Equal(t, "application/x-custom", msg.GetDocumentMessage().GetMimetype())
+}
+
+func TestDocumentMimeType(t *testing.T) {
+	zip := []byte("PK\x03\x04")
+	// The extension wins over the sniffed content, the charset parameter is dropped
+	assert.Equal(t, "application/pdf", documentMimeType("report.pdf", "", zip))
+	assert.Equal(t, "text/html", documentMimeType("page.html", "", []byte("hello")))
+	assert.Equal(t, "application/zip", documentMimeType("archive", "", zip))
+}
+
+func TestBuildMentionText(t *testing.T) {
+	origCountryCode := config.WhatsappDefaultCountryCode
+	defer func() { config.WhatsappDefaultCountryCode = origCountryCode }()
+	config.WhatsappDefaultCountryCode = "62"
+
+	alice := types.NewJID("6281234567890", types.DefaultUserServer)
+	bob := types.NewJID("628987654321", types.DefaultUserServer)
+
+	text, err := buildMentionText("Hi @+6281234567890 and @0898765432 1, ping @628111 too", []types.JID{alice, types.NewJID("62898765432", types.DefaultUserServer)})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hi @6281234567890 and @62898765432 1, ping @628111 too", text)
+
+	text, err = buildMentionText("@6281234567890 meet @628987654321", []types.JID{alice, bob})
+	assert.NoError(t, err)
+	assert.Equal(t, "@6281234567890 meet @628987654321", text)
+
+	_, err = buildMentionText("Hi @6281234567890", []types.JID{alice, bob})
+	assert.Equal(t, pkgError.ValidationError("mention 628987654321 has no @628987654321 placeholder in the message"), err)
+}
+
+func TestCheckGroupMentions(t *testing.T) {
+	alice := types.NewJID("6281234567890", types.DefaultUserServer)
+	bob := types.NewJID("628987654321", types.DefaultUserServer)
+	participants := []types.GroupParticipant{
+		{JID: alice},
+		// A member of a lid group is known by its lid and its phone number
+		{JID: types.NewJID("123456789", types.HiddenUserServer), PhoneNumber: bob},
+	}
+
+	assert.NoError(t, checkGroupMentions(participants, []types.JID{alice, bob}))
+	assert.Equal(t, pkgError.ValidationError("mention 628555 is not a member of the group"),
+		checkGroupMentions(participants, []types.JID{alice, types.NewJID("628555", types.DefaultUserServer)}))
+}
+
+func TestNewTemplateButtonsMessage(t *testing.T) {
+	msg := newTemplateButtonsMessage(domainSend.TemplateButtonsRequest{
+		Title:  "Order #42",
+		Body:   "Your order is ready",
+		Footer: "Thanks",
+		Buttons: []domainSend.TemplateButton{
+			{Type: domainSend.TemplateButtonQuickReply, Text: "Pick up", ID: "pickup"},
+			{Type: domainSend.TemplateButtonURL, Text: "Track", URL: "https://example.com/42"},
+			{Type: domainSend.TemplateButtonCall, Text: "Call us", PhoneNumber: "+6281234567890"},
+		},
+	})
+
+	// Encode and decode like the message goes over the wire
+	data, err := proto.Marshal(msg)
+	assert.NoError(t, err)
+	var received waE2E.Message
+	assert.NoError(t, proto.Unmarshal(data, &received))
+
+	template := received.GetTemplateMessage().GetHydratedFourRowTemplate()
+	assert.Equal(t, "Order #42", template.GetHydratedTitleText())
+	assert.Equal(t, "Your order is ready", template.GetHydratedContentText())
+	assert.Equal(t, "Thanks", template.GetHydratedFooterText())
+	assert.Equal(t, template.GetHydratedContentText(), received.GetTemplateMessage().GetHydratedTemplate().GetHydratedContentText())
+
+	buttons := template.GetHydratedButtons()
+	assert.Len(t, buttons, 3)
+	assert.Equal(t, "pickup", buttons[0].GetQuickReplyButton().GetID())
+	assert.Equal(t, "https://example.com/42", buttons[1].GetUrlButton().GetURL())
+	assert.Equal(t, "Track", buttons[1].GetUrlButton().GetDisplayText())
+	assert.Equal(t, "+6281234567890", buttons[2].GetCallButton().GetPhoneNumber())
+	assert.Equal(t, uint32(2), buttons[2].GetIndex())
+}
+
+func TestTemplateButtonsFallbackText(t *testing.T) {
+	text := templateButtonsFallbackText(domainSend.TemplateButtonsRequest{
+		Body: "Your order is ready",
+		Buttons: []domainSend.TemplateButton{
+			{Type: domainSend.TemplateButtonQuickReply, Text: "Pick up", ID: "pickup"},
+			{Type: domainSend.TemplateButtonURL, Text: "Track", URL: "https://example.com/42"},
+		},
+	})
+	assert.Equal(t, "Your order is ready\n\n- Pick up\nTrack: https://example.com/42", text)
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	domainStatus "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/status"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/utils"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+	"github.com/disintegration/imaging"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+type statusService struct {
+	WaCli *whatsmeow.Client
+}
+
+func NewStatusService(waCli *whatsmeow.Client) domainStatus.IStatusService {
+	return &statusService{
+		WaCli: waCli,
+	}
+}
+
+// PostStatus posts a text, image or video status to the contacts of the status privacy setting, the post is rejected
+// when one of the given recipients wouldn't see it
+func (service statusService) PostStatus(ctx context.Context, request domainStatus.PostStatusRequest) (response domainStatus.PostStatusResponse, err error) {
+	if err = validations.ValidatePostStatus(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	// The recipients are checked in a single query and must be in the audience of the status privacy setting
+	results, err := whatsapp.CheckOnWhatsapp(service.WaCli, request.Recipients)
+	if err != nil {
+		return response, err
+	}
+	recipients := make([]types.JID, 0, len(results))
+	for _, result := range results {
+		if !result.IsIn {
+			return response, pkgError.InvalidJID(fmt.Sprintf("Phone %s is not on whatsapp", result.Phone))
+		}
+		recipients = append(recipients, result.JID)
+	}
+	if err = whatsapp.CheckStatusAudience(service.WaCli, recipients); err != nil {
+		return response, err
+	}
+
+	var msg *waE2E.Message
+	content := request.Text
+	switch request.Type {
+	case domainStatus.StatusTypeText:
+		msg, err = newTextStatusMessage(request)
+	case domainStatus.StatusTypeImage:
+		msg, err = service.newImageStatusMessage(ctx, request)
+		content = "🖼️ " + request.Text
+	case domainStatus.StatusTypeVideo:
+		msg, err = service.newVideoStatusMessage(ctx, request)
+		content = "🎥 " + request.Text
+	}
+	if err != nil {
+		return response, err
+	}
+
+	ts, err := whatsapp.SendStatus(ctx, service.WaCli, msg)
+	if err != nil {
+		return response, err
+	}
+	utils.RecordMessage(ts.ID, service.WaCli.Store.ID.String(), content)
+
+	response.MessageID = ts.ID
+	response.Status = fmt.Sprintf("Status posted (server timestamp: %s)", ts.Timestamp.String())
+	return response, nil
+}
+
+func newTextStatusMessage(request domainStatus.PostStatusRequest) (*waE2E.Message, error) {
+	text := &waE2E.ExtendedTextMessage{Text: proto.String(request.Text)}
+	if request.BackgroundColor != "" {
+		argb, err := whatsapp.StatusColorARGB(request.BackgroundColor)
+		if err != nil {
+			return nil, err
+		}
+		text.BackgroundArgb = proto.Uint32(argb)
+		// White text stays readable on the colors of the app
+		text.TextArgb = proto.Uint32(0xffffffff)
+	}
+	return &waE2E.Message{ExtendedTextMessage: text}, nil
+}
+
+func (service statusService) newImageStatusMessage(ctx context.Context, request domainStatus.PostStatusRequest) (*waE2E.Message, error) {
+	data, err := readStatusMedia(request)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := service.WaCli.Upload(ctx, data, whatsmeow.MediaImage)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &waE2E.ImageMessage{
+		URL:           proto.String(uploaded.URL),
+		DirectPath:    proto.String(uploaded.DirectPath),
+		MediaKey:      uploaded.MediaKey,
+		Mimetype:      proto.String(http.DetectContentType(data)),
+		FileEncSHA256: uploaded.FileEncSHA256,
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    proto.Uint64(uploaded.FileLength),
+	}
+	if request.Text != "" {
+		image.Caption = proto.String(request.Text)
+	}
+	// The thumbnail is only a preview, a status is posted without one when it can't be made
+	if src, err := imaging.Decode(bytes.NewReader(data)); err == nil {
+		var thumbnail bytes.Buffer
+		if err = imaging.Encode(&thumbnail, imaging.Resize(src, 100, 0, imaging.Lanczos), imaging.JPEG); err == nil {
+			image.JPEGThumbnail = thumbnail.Bytes()
+		}
+	}
+	return &waE2E.Message{ImageMessage: image}, nil
+}
+
+func (service statusService) newVideoStatusMessage(ctx context.Context, request domainStatus.PostStatusRequest) (*waE2E.Message, error) {
+	data, err := readStatusMedia(request)
+	if err != nil {
+		return nil, err
+	}
+	uploaded, err := service.WaCli.Upload(ctx, data, whatsmeow.MediaVideo)
+	if err != nil {
+		return nil, err
+	}
+
+	video := &waE2E.VideoMessage{
+		URL:           proto.String(uploaded.URL),
+		DirectPath:    proto.String(uploaded.DirectPath),
+		MediaKey:      uploaded.MediaKey,
+		Mimetype:      proto.String(http.DetectContentType(data)),
+		FileEncSHA256: uploaded.FileEncSHA256,
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    proto.Uint64(uploaded.FileLength),
+	}
+	if request.Text != "" {
+		video.Caption = proto.String(request.Text)
+	}
+	return &waE2E.Message{VideoMessage: video}, nil
+}
+
+func readStatusMedia(request domainStatus.PostStatusRequest) ([]byte, error) {
+	file, err := request.Media.Open()
+	if err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to open %s %v", request.Type, err))
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, pkgError.InternalServerError(fmt.Sprintf("failed to read %s %v", request.Type, err))
+	}
+	return data, nil
+}
+
+// ListStatuses returns the statuses of contacts received in the last 24 hours, newest first
+func (service statusService) ListStatuses(_ context.Context, request domainStatus.ListStatusesRequest) (response domainStatus.ListStatusesResponse, err error) {
+	whatsapp.MustLogin(service.WaCli)
+
+	var sender types.JID
+	if request.Phone != "" {
+		if sender, err = whatsapp.ParseJID(request.Phone); err != nil {
+			return response, err
+		}
+	}
+
+	received := whatsapp.ListReceivedStatuses(service.WaCli, sender)
+	response.Data = make([]domainStatus.StatusData, 0, len(received))
+	for _, status := range received {
+		response.Data = append(response.Data, newStatusData(status))
+	}
+	return response, nil
+}
+
+func newStatusData(status whatsapp.ReceivedStatus) domainStatus.StatusData {
+	return domainStatus.StatusData{
+		ID:              status.ID,
+		SenderJID:       status.SenderJID.String(),
+		PushName:        status.PushName,
+		Type:            status.Type,
+		Text:            status.Text,
+		BackgroundColor: status.BackgroundColor,
+		MimeType:        status.MimeType,
+		PostedAt:        status.PostedAt.Format(time.RFC3339),
+		ExpiresAt:       status.ExpiresAt().Format(time.RFC3339),
+	}
+}
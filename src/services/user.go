@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"image"
+	"io"
+	"strings"
 	"time"
 
 	domainUser "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/user"
@@ -118,6 +120,51 @@ func (service userService) Avatar(ctx context.Context, request domainUser.Avatar
 
 }
 
+func (service userService) ContactInfo(ctx context.Context, request domainUser.ContactInfoRequest) (response domainUser.ContactInfoResponse, err error) {
+	err = validations.ValidateContactInfo(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+	response.JID = dataWaRecipient.String()
+
+	// Names come from the local contact store, filled by the app state sync and the push names of received messages
+	contact, err := service.WaCli.Store.Contacts.GetContact(dataWaRecipient)
+	if err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to read contact %s: %v", dataWaRecipient, err))
+	}
+	response.PushName = contact.PushName
+	response.FullName = contact.FullName
+	response.BusinessName = contact.BusinessName
+
+	users, err := service.WaCli.GetUserInfo([]types.JID{dataWaRecipient})
+	if err != nil {
+		return response, err
+	}
+	if userInfo, ok := users[dataWaRecipient]; ok && userInfo.VerifiedName != nil {
+		response.IsVerified = true
+		response.VerifiedName = userInfo.VerifiedName.Details.GetVerifiedName()
+		// The verified name of a business account is its business name
+		if response.BusinessName == "" {
+			response.BusinessName = response.VerifiedName
+		}
+	}
+
+	picture, err := whatsapp.GetProfilePicture(service.WaCli, dataWaRecipient)
+	if err != nil {
+		return response, err
+	}
+	response.ProfilePicture = domainUser.ContactProfilePicture{
+		Status: picture.Status,
+		URL:    picture.URL,
+		ID:     picture.ID,
+	}
+	return response, nil
+}
+
 func (service userService) MyListGroups(_ context.Context) (response domainUser.MyListGroupsResponse, err error) {
 	whatsapp.MustLogin(service.WaCli)
 
@@ -179,66 +226,266 @@ func (service userService) MyListContacts(ctx context.Context) (response domainU
 	return response, nil
 }
 
-func (service userService) ChangeAvatar(ctx context.Context, request domainUser.ChangeAvatarRequest) (err error) {
+func (service userService) ChangeAvatar(ctx context.Context, request domainUser.ChangeAvatarRequest) (response domainUser.ChangeAvatarResponse, err error) {
+	if err = validations.ValidateChangeAvatar(ctx, request); err != nil {
+		return response, err
+	}
 	whatsapp.MustLogin(service.WaCli)
 
 	file, err := request.Avatar.Open()
 	if err != nil {
-		return err
+		return response, err
 	}
 	defer file.Close()
 
-	// Read original image
-	srcImage, err := imaging.Decode(file)
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return response, err
+	}
+	avatar, err := squareAvatar(data)
+	if err != nil {
+		return response, err
+	}
+
+	// WhatsApp sets the own profile picture when the target jid is empty
+	response.PictureID, err = service.WaCli.SetGroupPhoto(types.JID{}, avatar)
 	if err != nil {
-		return fmt.Errorf("failed to decode image: %v", err)
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to set the profile picture: %v", err))
 	}
+	return response, nil
+}
 
-	// Get original dimensions
-	bounds := srcImage.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
+// RemoveAvatar deletes the profile picture, the account falls back to the default one. There is no new picture, so
+// the picture id of the response is empty
+func (service userService) RemoveAvatar(_ context.Context) (response domainUser.ChangeAvatarResponse, err error) {
+	whatsapp.MustLogin(service.WaCli)
 
-	// Calculate new dimensions for 1:1 aspect ratio
-	size := width
-	if height < width {
-		size = height
+	if _, err = service.WaCli.SetGroupPhoto(types.JID{}, nil); err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to remove the profile picture: %v", err))
+	}
+	return response, nil
+}
+
+// squareAvatar crops the center square of a jpeg or png image and scales it down to domainUser.AvatarMaxSize,
+// the result is the jpeg whatsapp expects for a profile picture
+func squareAvatar(data []byte) ([]byte, error) {
+	// The header is enough to reject an unsupported format or an oversized image before decoding it
+	imageConfig, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, pkgError.ValidationError("avatar must be a jpg or png image")
+	}
+	size := min(imageConfig.Width, imageConfig.Height)
+	if size < domainUser.AvatarMinSize {
+		return nil, pkgError.ValidationError(fmt.Sprintf("avatar must be at least %dx%d pixels", domainUser.AvatarMinSize, domainUser.AvatarMinSize))
 	}
-	if size > 640 {
-		size = 640
+	if max(imageConfig.Width, imageConfig.Height) > domainUser.AvatarMaxUploadSize {
+		return nil, pkgError.ValidationError(fmt.Sprintf("avatar must be at most %d pixels wide and high", domainUser.AvatarMaxUploadSize))
 	}
 
-	// Create a square crop from the center
-	left := (width - size) / 2
-	top := (height - size) / 2
-	croppedImage := imaging.Crop(srcImage, image.Rect(left, top, left+size, top+size))
+	srcImage, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
 
-	// Resize if needed
-	if size > 640 {
-		croppedImage = imaging.Resize(croppedImage, 640, 640, imaging.Lanczos)
+	// The orientation may have swapped the sides, so crop from the decoded bounds
+	avatar := imaging.CropCenter(srcImage, size, size)
+	if size > domainUser.AvatarMaxSize {
+		avatar = imaging.Resize(avatar, domainUser.AvatarMaxSize, domainUser.AvatarMaxSize, imaging.Lanczos)
 	}
 
-	// Convert to bytes
 	var buf bytes.Buffer
-	err = imaging.Encode(&buf, croppedImage, imaging.JPEG, imaging.JPEGQuality(80))
+	if err = imaging.Encode(&buf, avatar, imaging.JPEG, imaging.JPEGQuality(80)); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (service userService) ChangePushName(ctx context.Context, request domainUser.ChangePushNameRequest) (response domainUser.ChangePushNameResponse, err error) {
+	request.PushName = strings.TrimSpace(request.PushName)
+	if err = validations.ValidateChangePushName(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
+
+	err = service.WaCli.SendAppState(appstate.BuildSettingPushName(request.PushName))
 	if err != nil {
-		return fmt.Errorf("failed to encode image: %v", err)
+		return response, err
 	}
+	response.PushName = request.PushName
+	return response, nil
+}
+
+// ChangeStatus sets the about text of the account, shown in its profile
+func (service userService) ChangeStatus(ctx context.Context, request domainUser.ChangeStatusRequest) (response domainUser.ChangeStatusResponse, err error) {
+	request.Status = strings.TrimSpace(request.Status)
+	if err = validations.ValidateChangeStatus(ctx, request); err != nil {
+		return response, err
+	}
+	whatsapp.MustLogin(service.WaCli)
 
-	_, err = service.WaCli.SetGroupPhoto(types.JID{}, buf.Bytes())
+	if err = service.WaCli.SetStatusMessage(request.Status); err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to set the about text: %v", err))
+	}
+	response.Status = request.Status
+	return response, nil
+}
+
+// SubscribePresence subscribes to the online and last seen state of a contact, whatsapp only sends it while the own
+// presence is available
+func (service userService) SubscribePresence(ctx context.Context, request domainUser.PresenceSubscriptionRequest) (response domainUser.PresenceSubscriptionData, err error) {
+	if err = validations.ValidatePresenceSubscription(ctx, request); err != nil {
+		return response, err
+	}
+	jid, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	subscription, err := whatsapp.SubscribePresence(service.WaCli, jid)
+	if err != nil {
+		return response, pkgError.InternalServerError(fmt.Sprintf("failed to subscribe to the presence of %s: %v", jid, err))
+	}
+	return newPresenceSubscriptionData(subscription), nil
+}
+
+func (service userService) UnsubscribePresence(ctx context.Context, request domainUser.PresenceSubscriptionRequest) (err error) {
+	if err = validations.ValidatePresenceSubscription(ctx, request); err != nil {
+		return err
+	}
+	whatsapp.MustLogin(service.WaCli)
+	jid, err := whatsapp.ParseJID(request.Phone)
 	if err != nil {
 		return err
 	}
 
+	subscribed, err := whatsapp.UnsubscribePresence(service.WaCli, jid)
+	if !subscribed {
+		return pkgError.NotFoundError(fmt.Sprintf("no presence subscription to %s", jid))
+	}
+	if err != nil {
+		return pkgError.InternalServerError(fmt.Sprintf("failed to unsubscribe from the presence of %s: %v", jid, err))
+	}
 	return nil
 }
 
-func (service userService) ChangePushName(ctx context.Context, request domainUser.ChangePushNameRequest) (err error) {
+func (service userService) PresenceSubscriptions(_ context.Context) (response domainUser.PresenceSubscriptionsResponse, err error) {
 	whatsapp.MustLogin(service.WaCli)
 
-	err = service.WaCli.SendAppState(appstate.BuildSettingPushName(request.PushName))
+	own, subscriptions := whatsapp.GetPresenceSubscriptions(service.WaCli)
+	response.OwnPresence = string(own)
+	response.Subscriptions = make([]domainUser.PresenceSubscriptionData, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		response.Subscriptions = append(response.Subscriptions, newPresenceSubscriptionData(subscription))
+	}
+	return response, nil
+}
+
+func newPresenceSubscriptionData(subscription whatsapp.PresenceSubscription) domainUser.PresenceSubscriptionData {
+	data := domainUser.PresenceSubscriptionData{
+		JID:          subscription.JID.String(),
+		Available:    subscription.Available,
+		SubscribedAt: subscription.SubscribedAt,
+	}
+	if !subscription.LastSeen.IsZero() {
+		data.LastSeen = &subscription.LastSeen
+	}
+	if !subscription.UpdatedAt.IsZero() {
+		data.UpdatedAt = &subscription.UpdatedAt
+	}
+	return data
+}
+
+func (service userService) BusinessCatalog(ctx context.Context, request domainUser.BusinessCatalogRequest) (response domainUser.BusinessCatalogResponse, err error) {
+	err = validations.ValidateBusinessCatalog(ctx, request)
 	if err != nil {
-		return err
+		return response, err
+	}
+	dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	limit := request.Limit
+	if limit == 0 {
+		limit = domainUser.BusinessCatalogDefaultLimit
+	}
+	catalog, err := whatsapp.GetBusinessCatalog(ctx, service.WaCli, dataWaRecipient, limit, request.After)
+	if err != nil {
+		return response, err
+	}
+
+	response.JID = dataWaRecipient.String()
+	response.NextCursor = catalog.NextCursor
+	response.Products = make([]domainUser.BusinessProduct, 0, len(catalog.Products))
+	for _, product := range catalog.Products {
+		response.Products = append(response.Products, newBusinessProduct(product))
+	}
+	return response, nil
+}
+
+func (service userService) BusinessProduct(ctx context.Context, request domainUser.BusinessProductRequest) (response domainUser.BusinessProduct, err error) {
+	err = validations.ValidateBusinessProduct(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	dataWaRecipient, err := whatsapp.ValidateJidWithLogin(service.WaCli, request.Phone)
+	if err != nil {
+		return response, err
+	}
+
+	product, err := whatsapp.GetBusinessProduct(ctx, service.WaCli, dataWaRecipient, request.ProductID)
+	if err != nil {
+		return response, err
+	}
+	return newBusinessProduct(product), nil
+}
+
+func (service userService) JIDInfo(ctx context.Context, request domainUser.JIDInfoRequest) (response domainUser.JIDInfoResponse, err error) {
+	if err = validations.ValidateJIDInfo(ctx, request); err != nil {
+		return response, err
+	}
+	jid, err := whatsapp.ParseJID(request.JID)
+	if err != nil {
+		return response, err
+	}
+	jidType := whatsapp.JIDType(jid)
+	if jidType == "" {
+		return response, pkgError.InvalidJID(fmt.Sprintf("%s is not a user, group, broadcast or newsletter jid", request.JID))
+	}
+
+	response = domainUser.JIDInfoResponse{JID: jid.String(), Type: jidType}
+	if jidType != whatsapp.JIDTypeGroup {
+		return response, nil
+	}
+
+	whatsapp.MustLogin(service.WaCli)
+	isMember, isAdmin := false, false
+	groupInfo, err := service.WaCli.GetGroupInfo(jid)
+	switch {
+	case errors.Is(err, whatsmeow.ErrNotInGroup):
+	case errors.Is(err, whatsmeow.ErrGroupNotFound):
+		return domainUser.JIDInfoResponse{}, pkgError.NotFoundError(fmt.Sprintf("group %s not found", jid.String()))
+	case err != nil:
+		return domainUser.JIDInfoResponse{}, err
+	default:
+		isMember, isAdmin = true, whatsapp.IsGroupAdmin(service.WaCli, groupInfo)
+	}
+	response.IsMember, response.IsAdmin = &isMember, &isAdmin
+	return response, nil
+}
+
+func newBusinessProduct(product whatsapp.BusinessProduct) domainUser.BusinessProduct {
+	return domainUser.BusinessProduct{
+		ID:               product.ID,
+		RetailerID:       product.RetailerID,
+		Name:             product.Name,
+		Description:      product.Description,
+		URL:              product.URL,
+		Price:            float64(product.PriceAmount1000) / 1000,
+		PriceAmount1000:  product.PriceAmount1000,
+		Currency:         product.Currency,
+		ImageURL:         product.ImageURL,
+		OriginalImageURL: product.OriginalImageURL,
+		Hidden:           product.Hidden,
 	}
-	return nil
 }
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	domainUser "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/user"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTestImage(t *testing.T, format string, width, height int) []byte {
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			src.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "png":
+		require.NoError(t, png.Encode(&buf, src))
+	case "gif":
+		require.NoError(t, gif.Encode(&buf, src, nil))
+	default:
+		require.NoError(t, jpeg.Encode(&buf, src, nil))
+	}
+	return buf.Bytes()
+}
+
+func TestSquareAvatar(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		width  int
+		height int
+		size   int
+	}{
+		{name: "crops a wide png", format: "png", width: 400, height: 300, size: 300},
+		{name: "crops a tall jpeg", format: "jpeg", width: 300, height: 500, size: 300},
+		{name: "scales down a large image", format: "jpeg", width: 1600, height: 1200, size: domainUser.AvatarMaxSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			avatar, err := squareAvatar(encodeTestImage(t, tt.format, tt.width, tt.height))
+			require.NoError(t, err)
+
+			imageConfig, format, err := image.DecodeConfig(bytes.NewReader(avatar))
+			require.NoError(t, err)
+			assert.Equal(t, "jpeg", format)
+			assert.Equal(t, tt.size, imageConfig.Width)
+			assert.Equal(t, tt.size, imageConfig.Height)
+		})
+	}
+}
+
+func TestSquareAvatarInvalid(t *testing.T) {
+	_, err := squareAvatar(encodeTestImage(t, "gif", 300, 300))
+	assert.Equal(t, pkgError.ValidationError("avatar must be a jpg or png image"), err)
+
+	_, err = squareAvatar([]byte("not an image"))
+	assert.Equal(t, pkgError.ValidationError("avatar must be a jpg or png image"), err)
+
+	_, err = squareAvatar(encodeTestImage(t, "png", 800, 100))
+	assert.Equal(t, pkgError.ValidationError("avatar must be at least 192x192 pixels"), err)
+}
+
+func TestJIDInfo(t *testing.T) {
+	service := userService{}
+	tests := []struct {
+		jid      string
+		expected domainUser.JIDInfoResponse
+	}{
+		{"+62 812-3456-7890", domainUser.JIDInfoResponse{JID: "6281234567890@s.whatsapp.net", Type: "user"}},
+		{"status@broadcast", domainUser.JIDInfoResponse{JID: "status@broadcast", Type: "broadcast"}},
+		{"120363144038483540@newsletter", domainUser.JIDInfoResponse{JID: "120363144038483540@newsletter", Type: "newsletter"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.jid, func(t *testing.T) {
+			response, err := service.JIDInfo(context.Background(), domainUser.JIDInfoRequest{JID: tt.jid})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, response)
+		})
+	}
+
+	for _, jid := range []string{"62812abc", "group@g.us", "867051314767696@bot"} {
+		_, err := service.JIDInfo(context.Background(), domainUser.JIDInfoRequest{JID: jid})
+		assert.IsType(t, pkgError.InvalidJID(""), err, jid)
+	}
+}
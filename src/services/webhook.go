@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+
+	domainWebhook "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/webhook"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/validations"
+)
+
+type webhookService struct{}
+
+func NewWebhookService() domainWebhook.IWebhookService {
+	return &webhookService{}
+}
+
+func (service webhookService) List(_ context.Context) (response []domainWebhook.WebhookResponse, err error) {
+	response = make([]domainWebhook.WebhookResponse, 0)
+	for _, target := range whatsapp.ListWebhooks() {
+		response = append(response, toWebhookResponse(target))
+	}
+	return response, nil
+}
+
+func (service webhookService) Add(ctx context.Context, request domainWebhook.AddWebhookRequest) (response domainWebhook.WebhookResponse, err error) {
+	if err = validations.ValidateAddWebhook(ctx, request); err != nil {
+		return response, err
+	}
+
+	target, err := whatsapp.AddWebhook(request.URL, request.Events)
+	if err != nil {
+		return response, err
+	}
+	return toWebhookResponse(target), nil
+}
+
+func (service webhookService) Delete(ctx context.Context, request domainWebhook.DeleteWebhookRequest) (err error) {
+	if err = validations.ValidateDeleteWebhook(ctx, request); err != nil {
+		return err
+	}
+	return whatsapp.RemoveWebhook(request.URL)
+}
+
+func (service webhookService) Test(ctx context.Context, request domainWebhook.TestWebhookRequest) (response domainWebhook.TestWebhookResponse, err error) {
+	if err = validations.ValidateTestWebhook(ctx, request); err != nil {
+		return response, err
+	}
+
+	result, err := whatsapp.PingWebhook(ctx, request.URL)
+	if err != nil {
+		return response, err
+	}
+	return domainWebhook.TestWebhookResponse{
+		URL:        request.URL,
+		StatusCode: result.StatusCode,
+		LatencyMs:  result.Latency.Milliseconds(),
+		Body:       result.Body,
+	}, nil
+}
+
+func toWebhookResponse(target whatsapp.WebhookTarget) domainWebhook.WebhookResponse {
+	events := target.Events
+	if len(events) == 0 {
+		events = whatsapp.WebhookEventTypes
+	}
+	return domainWebhook.WebhookResponse{
+		URL:    target.URL,
+		Events: events,
+		Source: target.Source,
+	}
+}
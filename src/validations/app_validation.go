@@ -3,6 +3,7 @@ package validations
 import (
 	"context"
 	"fmt"
+	domainApp "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/app"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"regexp"
@@ -19,3 +20,40 @@ func ValidateLoginWithCode(ctx context.Context, phoneNumber string) error {
 	}
 	return nil
 }
+
+func ValidateQR(ctx context.Context, request domainApp.QRRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Format, validation.In(domainApp.QRFormatPNG, domainApp.QRFormatJSON)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateExportSession(ctx context.Context, request domainApp.ExportSessionRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Passphrase, validation.Required, validation.RuneLength(domainApp.SessionBackupMinPassphrase, 0)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateImportSession(ctx context.Context, request domainApp.ImportSessionRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Backup, validation.Required),
+		validation.Field(&request.Passphrase, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package validations
 import (
 	"context"
 	"testing"
+
+	domainApp "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/app"
 )
 
 func TestValidateLoginWithCode(t *testing.T) {
@@ -59,3 +61,24 @@ func TestValidateLoginWithCode(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateQR(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		wantErr bool
+	}{
+		{name: "Default format", format: "", wantErr: false},
+		{name: "Png format", format: domainApp.QRFormatPNG, wantErr: false},
+		{name: "Json format", format: domainApp.QRFormatJSON, wantErr: false},
+		{name: "Unknown format", format: "svg", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateQR(context.Background(), domainApp.QRRequest{Format: tt.format}); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateQR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package validations
+
+import (
+	"context"
+	"regexp"
+
+	domainAutoReply "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/autoreply"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+func ValidateAddAutoReply(ctx context.Context, request domainAutoReply.AddAutoReplyRequest) error {
+	matchTypes := make([]any, 0, len(whatsapp.AutoReplyMatchTypes))
+	for _, matchType := range whatsapp.AutoReplyMatchTypes {
+		matchTypes = append(matchTypes, matchType)
+	}
+
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Match, validation.Required, validation.In(matchTypes...)),
+		validation.Field(&request.Pattern, validation.Required, validation.When(request.Match == whatsapp.AutoReplyMatchRegex, validation.By(func(value any) error {
+			if _, err := regexp.Compile(value.(string)); err != nil {
+				return validation.NewError("validation_regex", "must be a valid regex")
+			}
+			return nil
+		}))),
+		validation.Field(&request.Reply, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateDeleteAutoReply(ctx context.Context, request domainAutoReply.DeleteAutoReplyRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.ID, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
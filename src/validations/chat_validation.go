@@ -0,0 +1,116 @@
+package validations
+
+import (
+	"context"
+
+	domainChat "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/chat"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"go.mau.fi/whatsmeow"
+)
+
+func ValidateListChats(ctx context.Context, request domainChat.ListChatsRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Limit, validation.Min(1), validation.Max(domainChat.ChatListMaxLimit)),
+		validation.Field(&request.Offset, validation.Min(0)),
+		validation.Field(&request.Sort, validation.In(domainChat.ChatSortRecent, domainChat.ChatSortOldest)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateListMessages(ctx context.Context, request domainChat.ListMessagesRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.ChatJID, validation.Required),
+		validation.Field(&request.Limit, validation.Min(1), validation.Max(domainChat.MessageListMaxLimit)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateSetEphemeral(ctx context.Context, request domainChat.SetEphemeralRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.ChatJID, validation.Required),
+		validation.Field(&request.Duration, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	if _, ok := whatsmeow.ParseDisappearingTimerString(request.Duration); !ok {
+		return pkgError.ValidationError("duration: must be one of off, 24h, 7d or 90d.")
+	}
+
+	return nil
+}
+
+func ValidateMarkChatAsRead(ctx context.Context, request domainChat.MarkAsReadRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.ChatJID, validation.Required),
+		validation.Field(&request.MessageIDs, validation.Required.When(request.UpToMessageID == "").Error("cannot be blank without up_to_message_id"), validation.Each(validation.Required)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateMarkChatAsUnread(ctx context.Context, request domainChat.MarkAsUnreadRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.ChatJID, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateArchiveChat(ctx context.Context, request domainChat.ArchiveChatRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.ChatJID, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidatePinChat(ctx context.Context, request domainChat.PinChatRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.ChatJID, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateMuteChat(ctx context.Context, request domainChat.MuteChatRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.ChatJID, validation.Required),
+		validation.Field(&request.Duration, validation.Required, validation.In("8h", "1w", "always", "off").Error("must be one of 8h, 1w, always or off")),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package validations
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainContact "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/contact"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// phonePattern accepts international numbers with or without +, spaces and dashes
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 \-]{4,20}$`)
+
+func ValidateCheckContacts(ctx context.Context, request domainContact.CheckRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phones,
+			validation.Required,
+			validation.Length(1, config.WhatsappContactCheckMaxPhones),
+			validation.Each(validation.Required, validation.Match(phonePattern)),
+		),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateBlockContact(ctx context.Context, request domainContact.BlockRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package validations
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	domainContact "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/contact"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCheckContacts(t *testing.T) {
+	tooMany := make([]string, 101)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("62812%04d", i)
+	}
+
+	tests := []struct {
+		name    string
+		request domainContact.CheckRequest
+		err     any
+	}{
+		{
+			name:    "should success",
+			request: domainContact.CheckRequest{Phones: []string{"6281234567890", "+62 812-3456-7891"}},
+			err:     nil,
+		},
+		{
+			name:    "should error with empty phones",
+			request: domainContact.CheckRequest{},
+			err:     pkgError.ValidationError("phones: cannot be blank."),
+		},
+		{
+			name:    "should error with invalid phone",
+			request: domainContact.CheckRequest{Phones: []string{"6281234567890", "john"}},
+			err:     pkgError.ValidationError("phones: (1: must be in a valid format.)."),
+		},
+		{
+			name:    "should error with too many phones",
+			request: domainContact.CheckRequest{Phones: tooMany},
+			err:     pkgError.ValidationError("phones: the length must be between 1 and 100."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCheckContacts(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateBlockContact(t *testing.T) {
+	tests := []struct {
+		name    string
+		request domainContact.BlockRequest
+		err     any
+	}{
+		{
+			name:    "should success",
+			request: domainContact.BlockRequest{Phone: "6281234567890@s.whatsapp.net"},
+			err:     nil,
+		},
+		{
+			name:    "should error with empty phone",
+			request: domainContact.BlockRequest{},
+			err:     pkgError.ValidationError("phone: cannot be blank."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBlockContact(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
@@ -21,6 +21,62 @@ func ValidateJoinGroupWithLink(ctx context.Context, request domainGroup.JoinGrou
 	return nil
 }
 
+func ValidateSetGroupInfo(ctx context.Context, request domainGroup.GroupInfoRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.GroupID, validation.Required),
+		validation.Field(&request.Subject,
+			validation.When(request.Description == nil, validation.Required.Error("cannot be blank without description")),
+			validation.NilOrNotEmpty,
+			validation.RuneLength(1, domainGroup.GroupSubjectMaxLength),
+		),
+		validation.Field(&request.Description, validation.RuneLength(0, domainGroup.GroupDescriptionMaxLength)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateSetGroupSetting(ctx context.Context, request domainGroup.GroupSettingRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.GroupID, validation.Required),
+		validation.Field(&request.Setting, validation.Required, validation.In(domainGroup.GroupSettingAnnounce, domainGroup.GroupSettingLocked)),
+		validation.Field(&request.Enabled, validation.NotNil),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateGroupInviteLink(ctx context.Context, request domainGroup.GroupInviteLinkRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.GroupID, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateGroupInviteInfo(ctx context.Context, request domainGroup.GroupInviteInfoRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Code, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
 func ValidateLeaveGroup(ctx context.Context, request domainGroup.LeaveGroupRequest) error {
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.GroupID, validation.Required),
@@ -52,6 +108,12 @@ func ValidateParticipant(ctx context.Context, request domainGroup.ParticipantReq
 		validation.Field(&request.GroupID, validation.Required),
 		validation.Field(&request.Participants, validation.Required),
 		validation.Field(&request.Participants, validation.Each(validation.Required)),
+		validation.Field(&request.Action, validation.Required, validation.In(
+			whatsmeow.ParticipantChangeAdd,
+			whatsmeow.ParticipantChangeRemove,
+			whatsmeow.ParticipantChangePromote,
+			whatsmeow.ParticipantChangeDemote,
+		)),
 	)
 
 	if err != nil {
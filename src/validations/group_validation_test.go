@@ -0,0 +1,101 @@
+package validations
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	domainGroup "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/group"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSetGroupInfo(t *testing.T) {
+	subject := "Weekend trip"
+	emptyString := ""
+	longSubject := strings.Repeat("a", domainGroup.GroupSubjectMaxLength+1)
+	longDescription := strings.Repeat("a", domainGroup.GroupDescriptionMaxLength+1)
+
+	type args struct {
+		request domainGroup.GroupInfoRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success with subject only",
+			args: args{request: domainGroup.GroupInfoRequest{GroupID: "120363025246125888@g.us", Subject: &subject}},
+			err:  nil,
+		},
+		{
+			name: "should success removing the description",
+			args: args{request: domainGroup.GroupInfoRequest{GroupID: "120363025246125888@g.us", Description: &emptyString}},
+			err:  nil,
+		},
+		{
+			name: "should error without subject and description",
+			args: args{request: domainGroup.GroupInfoRequest{GroupID: "120363025246125888@g.us"}},
+			err:  pkgError.ValidationError("subject: cannot be blank without description."),
+		},
+		{
+			name: "should error with empty subject",
+			args: args{request: domainGroup.GroupInfoRequest{GroupID: "120363025246125888@g.us", Subject: &emptyString, Description: &subject}},
+			err:  pkgError.ValidationError("subject: cannot be blank."),
+		},
+		{
+			name: "should error with too long subject",
+			args: args{request: domainGroup.GroupInfoRequest{GroupID: "120363025246125888@g.us", Subject: &longSubject}},
+			err:  pkgError.ValidationError("subject: the length must be between 1 and 100."),
+		},
+		{
+			name: "should error with too long description",
+			args: args{request: domainGroup.GroupInfoRequest{GroupID: "120363025246125888@g.us", Description: &longDescription}},
+			err:  pkgError.ValidationError("description: the length must be no more than 2048."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSetGroupInfo(context.Background(), tt.args.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateSetGroupSetting(t *testing.T) {
+	enabled := true
+
+	type args struct {
+		request domainGroup.GroupSettingRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success",
+			args: args{request: domainGroup.GroupSettingRequest{GroupID: "120363025246125888@g.us", Setting: domainGroup.GroupSettingLocked, Enabled: &enabled}},
+			err:  nil,
+		},
+		{
+			name: "should error without enabled",
+			args: args{request: domainGroup.GroupSettingRequest{GroupID: "120363025246125888@g.us", Setting: domainGroup.GroupSettingAnnounce}},
+			err:  pkgError.ValidationError("enabled: is required."),
+		},
+		{
+			name: "should error with unknown setting",
+			args: args{request: domainGroup.GroupSettingRequest{GroupID: "120363025246125888@g.us", Setting: "ephemeral", Enabled: &enabled}},
+			err:  pkgError.ValidationError("Setting: must be a valid value."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSetGroupSetting(context.Background(), tt.args.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
@@ -7,9 +7,24 @@ import (
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
 
+// A channel is picked by its jid or by its invite link, never by both
+func ValidateFollowNewsletter(ctx context.Context, request domainNewsletter.FollowRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.NewsletterID, validation.When(request.InviteLink == "", validation.Required.Error("newsletter_id or invite_link is required"))),
+		validation.Field(&request.InviteLink, validation.When(request.NewsletterID != "", validation.Empty.Error("can't be used with newsletter_id"))),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
 func ValidateUnfollowNewsletter(ctx context.Context, request domainNewsletter.UnfollowRequest) error {
 	err := validation.ValidateStructWithContext(ctx, &request,
-		validation.Field(&request.NewsletterID, validation.Required),
+		validation.Field(&request.NewsletterID, validation.When(request.InviteLink == "", validation.Required.Error("newsletter_id or invite_link is required"))),
+		validation.Field(&request.InviteLink, validation.When(request.NewsletterID != "", validation.Empty.Error("can't be used with newsletter_id"))),
 	)
 
 	if err != nil {
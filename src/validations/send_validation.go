@@ -2,12 +2,19 @@ package validations
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"mime"
+	"regexp"
 	"sort"
+	"strings"
+	"time"
+	"unicode"
 
 	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
 	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
 	"github.com/dustin/go-humanize"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
@@ -17,6 +24,9 @@ func ValidateSendMessage(ctx context.Context, request domainSend.MessageRequest)
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Phone, validation.Required),
 		validation.Field(&request.Message, validation.Required),
+		validation.Field(&request.Mentions, validation.Each(validation.Required)),
+		validation.Field(&request.ReplyTo, validation.By(validateReplyTo)),
+		validation.Field(&request.DisappearingDuration, validation.In(disappearingTimers()...).Error("must be one of 0, 86400, 604800 or 7776000 seconds")),
 	)
 
 	if err != nil {
@@ -25,9 +35,96 @@ func ValidateSendMessage(ctx context.Context, request domainSend.MessageRequest)
 	return nil
 }
 
+// ValidateScheduleMessage checks the message like ValidateSendMessage, send_at must be in the future
+func ValidateScheduleMessage(ctx context.Context, request domainSend.ScheduleRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Type, validation.In(domainSend.ScheduleTypeText).Error("only text messages can be scheduled")),
+	)
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	if err := ValidateSendMessage(ctx, request.MessageRequest); err != nil {
+		return err
+	}
+
+	err = validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.SendAt, validation.Required, validation.Date(time.RFC3339).Error("must be an RFC3339 timestamp"), validation.By(validateFutureTime)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+	return nil
+}
+
+func validateFutureTime(value any) error {
+	at, err := time.Parse(time.RFC3339, value.(string))
+	if err != nil {
+		return nil
+	}
+	if !at.After(time.Now()) {
+		return errors.New("must be in the future")
+	}
+	return nil
+}
+
+func ValidateListScheduled(ctx context.Context, request domainSend.ListScheduledRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Status, validation.In(
+			whatsapp.ScheduledMessagePending,
+			whatsapp.ScheduledMessageSending,
+			whatsapp.ScheduledMessageSent,
+			whatsapp.ScheduledMessageFailed,
+			whatsapp.ScheduledMessageCancelled,
+			whatsapp.ScheduledMessageExpired,
+		)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+	return nil
+}
+
+func ValidateCancelScheduled(ctx context.Context, request domainSend.CancelScheduledRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.ID, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+	return nil
+}
+
+func ValidateSendBatch(ctx context.Context, request domainSend.BatchRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phones, validation.Required, validation.Length(1, config.WhatsappBatchMaxRecipients), validation.Each(validation.Required)),
+		validation.Field(&request.Message, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+	return nil
+}
+
+// validateReplyTo checks the quoted message of a send request, it is optional
+func validateReplyTo(value interface{}) error {
+	replyTo, _ := value.(*domainSend.ReplyTo)
+	if replyTo == nil {
+		return nil
+	}
+	return validation.Errors{
+		"message_id": validation.Validate(replyTo.MessageID, validation.Required),
+	}.Filter()
+}
+
 func ValidateSendImage(ctx context.Context, request domainSend.ImageRequest) error {
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.ReplyTo, validation.By(validateReplyTo)),
 	)
 
 	if err != nil {
@@ -68,6 +165,10 @@ func ValidateSendFile(ctx context.Context, request domainSend.FileRequest) error
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Phone, validation.Required),
 		validation.Field(&request.File, validation.Required),
+		validation.Field(&request.FileName, validation.RuneLength(0, 255), validation.By(validateFileName)),
+		validation.Field(&request.MimeType, validation.By(validateMimeType)),
+		validation.Field(&request.ViewOnce, validation.Empty.Error("is only supported for image, video and audio")),
+		validation.Field(&request.ReplyTo, validation.By(validateReplyTo)),
 	)
 
 	if err != nil {
@@ -82,10 +183,66 @@ func ValidateSendFile(ctx context.Context, request domainSend.FileRequest) error
 	return nil
 }
 
+// validateFileName rejects the names the recipient device could read as a path
+func validateFileName(value any) error {
+	fileName, _ := value.(string)
+	if fileName == "" {
+		return nil
+	}
+	if strings.ContainsAny(fileName, `/\`) || fileName == "." || fileName == ".." {
+		return validation.NewError("validation_file_name", "must not contain path separators")
+	}
+	if strings.TrimSpace(fileName) != fileName || strings.IndexFunc(fileName, unicode.IsControl) >= 0 {
+		return validation.NewError("validation_file_name", "must not start or end with spaces or contain control characters")
+	}
+	return nil
+}
+
+func validateMimeType(value any) error {
+	mimeType, _ := value.(string)
+	if mimeType == "" {
+		return nil
+	}
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err != nil || !strings.Contains(mediaType, "/") {
+		return validation.NewError("validation_mime_type", "must be a mime type like application/pdf")
+	}
+	return nil
+}
+
+func ValidateSendSticker(ctx context.Context, request domainSend.StickerRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Sticker, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	availableMimes := map[string]bool{
+		"image/jpeg": true,
+		"image/jpg":  true,
+		"image/png":  true,
+		"image/webp": true,
+		"image/gif":  true,
+	}
+	if !availableMimes[request.Sticker.Header.Get("Content-Type")] {
+		return pkgError.ValidationError("your sticker is not allowed. please use png/jpg/jpeg/webp/gif")
+	}
+
+	if request.Sticker.Size > config.WhatsappSettingMaxImageSize {
+		maxSizeString := humanize.Bytes(uint64(config.WhatsappSettingMaxImageSize))
+		return pkgError.ValidationError(fmt.Sprintf("max sticker upload is %s", maxSizeString))
+	}
+
+	return nil
+}
+
 func ValidateSendVideo(ctx context.Context, request domainSend.VideoRequest) error {
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Phone, validation.Required),
 		validation.Field(&request.Video, validation.Required),
+		validation.Field(&request.ReplyTo, validation.By(validateReplyTo)),
 	)
 
 	if err != nil {
@@ -111,11 +268,27 @@ func ValidateSendVideo(ctx context.Context, request domainSend.VideoRequest) err
 }
 
 func ValidateSendContact(ctx context.Context, request domainSend.ContactRequest) error {
+	if len(request.Contacts) > 0 {
+		err := validation.ValidateStructWithContext(ctx, &request,
+			validation.Field(&request.Phone, validation.Required),
+		)
+		if err != nil {
+			return pkgError.ValidationError(err.Error())
+		}
+		for i, card := range request.Contacts {
+			if err = validateContactCard(card.Name, card.Phone, card.Vcard, "name", "phone"); err != nil {
+				return pkgError.ValidationError(fmt.Sprintf("contacts.%d: %s", i, err.Error()))
+			}
+		}
+		return nil
+	}
+
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Phone, validation.Required),
-		validation.Field(&request.ContactPhone, validation.Required),
-		validation.Field(&request.ContactName, validation.Required),
 	)
+	if err == nil {
+		err = validateContactCard(request.ContactName, request.ContactPhone, request.Vcard, "contact_name", "contact_phone")
+	}
 
 	if err != nil {
 		return pkgError.ValidationError(err.Error())
@@ -124,6 +297,27 @@ func ValidateSendContact(ctx context.Context, request domainSend.ContactRequest)
 	return nil
 }
 
+// validateContactCard requires a name and a phone unless a raw vcard is given
+func validateContactCard(name, phone, vcard, nameField, phoneField string) error {
+	return validation.Errors{
+		nameField:  validation.Validate(name, validation.When(vcard == "", validation.Required)),
+		phoneField: validation.Validate(phone, validation.When(vcard == "", validation.Required), validation.Match(phonePattern)),
+		"vcard":    validation.Validate(vcard, validation.By(isVCard)),
+	}.Filter()
+}
+
+func isVCard(value any) error {
+	vcard, _ := value.(string)
+	if vcard == "" {
+		return nil
+	}
+	vcard = strings.TrimSpace(vcard)
+	if !strings.HasPrefix(strings.ToUpper(vcard), "BEGIN:VCARD") || !strings.HasSuffix(strings.ToUpper(vcard), "END:VCARD") {
+		return errors.New("must start with BEGIN:VCARD and end with END:VCARD")
+	}
+	return nil
+}
+
 func ValidateSendLink(ctx context.Context, request domainSend.LinkRequest) error {
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Phone, validation.Required),
@@ -152,10 +346,40 @@ func ValidateSendLocation(ctx context.Context, request domainSend.LocationReques
 	return nil
 }
 
+func ValidateSendLiveLocation(ctx context.Context, request domainSend.LiveLocationRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Latitude, validation.Required, is.Latitude),
+		validation.Field(&request.Longitude, validation.Required, is.Longitude),
+		validation.Field(&request.Duration, validation.Required, validation.Min(60), validation.Max(int(whatsapp.LiveLocationMaxDuration.Seconds()))),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateUpdateLiveLocation(ctx context.Context, request domainSend.UpdateLiveLocationRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.MessageID, validation.Required),
+		validation.Field(&request.Latitude, validation.Required, is.Latitude),
+		validation.Field(&request.Longitude, validation.Required, is.Longitude),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
 func ValidateSendAudio(ctx context.Context, request domainSend.AudioRequest) error {
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Phone, validation.Required),
 		validation.Field(&request.Audio, validation.Required),
+		validation.Field(&request.ReplyTo, validation.By(validateReplyTo)),
 	)
 
 	if err != nil {
@@ -180,6 +404,12 @@ func ValidateSendAudio(ctx context.Context, request domainSend.AudioRequest) err
 		"audio/x-pn-wav": true,
 		"audio/x-wav":    true,
 	}
+	if request.PTT {
+		// Voice notes are transcoded, so the formats of browser and phone recordings are accepted too
+		availableMimes["audio/mp4"] = true
+		availableMimes["audio/opus"] = true
+		availableMimes["audio/webm"] = true
+	}
 	availableMimesStr := ""
 
 	// Sort MIME types for consistent error message order
@@ -210,11 +440,13 @@ func ValidateSendPoll(ctx context.Context, request domainSend.PollRequest) error
 		validation.Field(&request.Phone, validation.Required),
 		validation.Field(&request.Question, validation.Required),
 
-		validation.Field(&request.Options, validation.Each(validation.Required)),
+		validation.Field(&request.Options, validation.Length(1, domainSend.PollMaxOptions), validation.Each(validation.Required)),
 
-		validation.Field(&request.MaxAnswer, validation.Required),
-		validation.Field(&request.MaxAnswer, validation.Min(1)),
+		validation.Field(&request.MaxAnswer, validation.Required.When(!request.MultipleAnswers)),
+		validation.Field(&request.MaxAnswer, validation.Min(0)),
 		validation.Field(&request.MaxAnswer, validation.Max(len(request.Options))),
+		// A single answer is the single choice poll, it contradicts multiple answers
+		validation.Field(&request.MaxAnswer, validation.When(request.MultipleAnswers, validation.NotIn(1).Error("must be unset or at least 2 with multiple_answers"))),
 	)
 
 	if err != nil {
@@ -233,6 +465,132 @@ func ValidateSendPoll(ctx context.Context, request domainSend.PollRequest) error
 	return nil
 }
 
+func ValidateSendButtons(ctx context.Context, request domainSend.ButtonsRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Body, validation.Required, validation.Length(0, 1024)),
+		validation.Field(&request.Footer, validation.Length(0, 60)),
+		validation.Field(&request.Buttons, validation.Required, validation.Length(1, domainSend.ButtonsMaxButtons)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	uniqueIDs := make(map[string]bool)
+	for i, button := range request.Buttons {
+		err = validation.ValidateStructWithContext(ctx, &button,
+			validation.Field(&button.ID, validation.Required, validation.Length(0, 256)),
+			validation.Field(&button.Text, validation.Required, validation.Length(0, 20)),
+		)
+		if err != nil {
+			return pkgError.ValidationError(fmt.Sprintf("buttons[%d]: %s", i, err.Error()))
+		}
+		if uniqueIDs[button.ID] {
+			return pkgError.ValidationError("button ids should be unique")
+		}
+		uniqueIDs[button.ID] = true
+	}
+
+	return nil
+}
+
+// callButtonPhone is the international number dialed by a call button
+var callButtonPhone = regexp.MustCompile(`^\+?\d{7,15}$`)
+
+func ValidateSendTemplateButtons(ctx context.Context, request domainSend.TemplateButtonsRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Title, validation.Length(0, 60)),
+		validation.Field(&request.Body, validation.Required, validation.Length(0, 1024)),
+		validation.Field(&request.Footer, validation.Length(0, 60)),
+		validation.Field(&request.Buttons, validation.Required, validation.Length(1, domainSend.TemplateMaxButtons)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	uniqueIDs := make(map[string]bool)
+	count := make(map[string]int)
+	for i, button := range request.Buttons {
+		err = validation.ValidateStructWithContext(ctx, &button,
+			validation.Field(&button.Type, validation.Required, validation.In(domainSend.TemplateButtonQuickReply, domainSend.TemplateButtonURL, domainSend.TemplateButtonCall)),
+			validation.Field(&button.Text, validation.Required, validation.Length(0, 20)),
+			validation.Field(&button.ID, validation.When(button.Type == domainSend.TemplateButtonQuickReply, validation.Required, validation.Length(0, 256))),
+			validation.Field(&button.URL, validation.When(button.Type == domainSend.TemplateButtonURL, validation.Required, is.URL)),
+			validation.Field(&button.PhoneNumber, validation.When(button.Type == domainSend.TemplateButtonCall, validation.Required, validation.Match(callButtonPhone).Error("must be an international phone number like +6281234567890"))),
+		)
+		if err != nil {
+			return pkgError.ValidationError(fmt.Sprintf("buttons[%d]: %s", i, err.Error()))
+		}
+		if button.Type == domainSend.TemplateButtonQuickReply {
+			if uniqueIDs[button.ID] {
+				return pkgError.ValidationError("button ids should be unique")
+			}
+			uniqueIDs[button.ID] = true
+		}
+		count[button.Type]++
+	}
+
+	if count[domainSend.TemplateButtonURL] > domainSend.TemplateMaxURLButtons {
+		return pkgError.ValidationError(fmt.Sprintf("at most %d url buttons are allowed", domainSend.TemplateMaxURLButtons))
+	}
+	if count[domainSend.TemplateButtonCall] > domainSend.TemplateMaxCallButtons {
+		return pkgError.ValidationError(fmt.Sprintf("at most %d call button is allowed", domainSend.TemplateMaxCallButtons))
+	}
+	return nil
+}
+
+func ValidateSendList(ctx context.Context, request domainSend.ListRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Title, validation.Required, validation.Length(0, 60)),
+		validation.Field(&request.Description, validation.Length(0, 1024)),
+		validation.Field(&request.Footer, validation.Length(0, 60)),
+		validation.Field(&request.ButtonText, validation.Required, validation.Length(0, 20)),
+		validation.Field(&request.Sections, validation.Required, validation.Length(1, domainSend.ListMaxSections)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	totalRows := 0
+	uniqueIDs := make(map[string]bool)
+	for i, section := range request.Sections {
+		err = validation.ValidateStructWithContext(ctx, &section,
+			// WhatsApp needs a title for each section when there is more than one
+			validation.Field(&section.Title, validation.Required.When(len(request.Sections) > 1), validation.Length(0, 24)),
+			validation.Field(&section.Rows, validation.Required),
+		)
+		if err != nil {
+			return pkgError.ValidationError(fmt.Sprintf("sections[%d]: %s", i, err.Error()))
+		}
+
+		for j, row := range section.Rows {
+			err = validation.ValidateStructWithContext(ctx, &row,
+				validation.Field(&row.ID, validation.Required, validation.Length(0, 200)),
+				validation.Field(&row.Title, validation.Required, validation.Length(0, 24)),
+				validation.Field(&row.Description, validation.Length(0, 72)),
+			)
+			if err != nil {
+				return pkgError.ValidationError(fmt.Sprintf("sections[%d].rows[%d]: %s", i, j, err.Error()))
+			}
+			if uniqueIDs[row.ID] {
+				return pkgError.ValidationError("row ids should be unique")
+			}
+			uniqueIDs[row.ID] = true
+		}
+		totalRows += len(section.Rows)
+	}
+	if totalRows > domainSend.ListMaxRows {
+		return pkgError.ValidationError(fmt.Sprintf("a list can have at most %d rows over all sections", domainSend.ListMaxRows))
+	}
+
+	return nil
+}
+
 func ValidateSendPresence(ctx context.Context, request domainSend.PresenceRequest) error {
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Type, validation.In("available", "unavailable")),
@@ -244,3 +602,26 @@ func ValidateSendPresence(ctx context.Context, request domainSend.PresenceReques
 
 	return nil
 }
+
+func ValidateSendChatPresence(ctx context.Context, request domainSend.ChatPresenceRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.State, validation.Required, validation.In("composing", "recording", "paused")),
+		validation.Field(&request.Duration, validation.Min(0), validation.Max(60)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+// disappearingTimers returns the allowed disappearing durations for validation.In
+func disappearingTimers() []any {
+	timers := make([]any, 0, len(whatsapp.DisappearingTimers))
+	for _, timer := range whatsapp.DisappearingTimers {
+		timers = append(timers, timer)
+	}
+	return timers
+}
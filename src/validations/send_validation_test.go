@@ -2,13 +2,16 @@ package validations
 
 import (
 	"context"
+	"fmt"
 	"mime/multipart"
 	"testing"
+	"time"
 
 	domainMessage "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/message"
 	domainSend "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/send"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestValidateSendMessage(t *testing.T) {
@@ -44,6 +47,69 @@ func TestValidateSendMessage(t *testing.T) {
 			}},
 			err: pkgError.ValidationError("message: cannot be blank."),
 		},
+		{
+			name: "should success with mentions",
+			args: args{request: domainSend.MessageRequest{
+				Phone:    "120363024512399999@g.us",
+				Message:  "Hi @6281234567890",
+				Mentions: []string{"6281234567890"},
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with empty mention",
+			args: args{request: domainSend.MessageRequest{
+				Phone:    "120363024512399999@g.us",
+				Message:  "Hi @6281234567890",
+				Mentions: []string{"6281234567890", ""},
+			}},
+			err: pkgError.ValidationError("mentions: (1: cannot be blank.)."),
+		},
+		{
+			name: "should success with reply to",
+			args: args{request: domainSend.MessageRequest{
+				Phone:   "1728937129312@s.whatsapp.net",
+				Message: "Hello this is testing",
+				ReplyTo: &domainSend.ReplyTo{MessageID: "3EB0C127D7BACC83D6A1"},
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with reply to without message id",
+			args: args{request: domainSend.MessageRequest{
+				Phone:   "1728937129312@s.whatsapp.net",
+				Message: "Hello this is testing",
+				ReplyTo: &domainSend.ReplyTo{Participant: "6289685028129@s.whatsapp.net"},
+			}},
+			err: pkgError.ValidationError("reply_to: (message_id: cannot be blank.)."),
+		},
+		{
+			name: "should success with disappearing duration preset",
+			args: args{request: domainSend.MessageRequest{
+				Phone:                "1728937129312@s.whatsapp.net",
+				Message:              "Hello this is testing",
+				DisappearingDuration: proto.Uint32(604800),
+			}},
+			err: nil,
+		},
+		{
+			name: "should success with disappearing duration off",
+			args: args{request: domainSend.MessageRequest{
+				Phone:                "1728937129312@s.whatsapp.net",
+				Message:              "Hello this is testing",
+				DisappearingDuration: proto.Uint32(0),
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with arbitrary disappearing duration",
+			args: args{request: domainSend.MessageRequest{
+				Phone:                "1728937129312@s.whatsapp.net",
+				Message:              "Hello this is testing",
+				DisappearingDuration: proto.Uint32(3600),
+			}},
+			err: pkgError.ValidationError("disappearing_duration: must be one of 0, 86400, 604800 or 7776000 seconds."),
+		},
 	}
 
 	for _, tt := range tests {
@@ -54,6 +120,113 @@ func TestValidateSendMessage(t *testing.T) {
 	}
 }
 
+func TestValidateSendSticker(t *testing.T) {
+	newSticker := func(contentType string) *multipart.FileHeader {
+		return &multipart.FileHeader{
+			Filename: "sticker",
+			Size:     100,
+			Header:   map[string][]string{"Content-Type": {contentType}},
+		}
+	}
+
+	type args struct {
+		request domainSend.StickerRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success with png",
+			args: args{request: domainSend.StickerRequest{
+				Phone:   "1728937129312@s.whatsapp.net",
+				Sticker: newSticker("image/png"),
+			}},
+			err: nil,
+		},
+		{
+			name: "should success with animated gif",
+			args: args{request: domainSend.StickerRequest{
+				Phone:   "1728937129312@s.whatsapp.net",
+				Sticker: newSticker("image/gif"),
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with empty sticker",
+			args: args{request: domainSend.StickerRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+			}},
+			err: pkgError.ValidationError("sticker: cannot be blank."),
+		},
+		{
+			name: "should error with unsupported type",
+			args: args{request: domainSend.StickerRequest{
+				Phone:   "1728937129312@s.whatsapp.net",
+				Sticker: newSticker("application/pdf"),
+			}},
+			err: pkgError.ValidationError("your sticker is not allowed. please use png/jpg/jpeg/webp/gif"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendSticker(context.Background(), tt.args.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateSendBatch(t *testing.T) {
+	type args struct {
+		request domainSend.BatchRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success with phones and message",
+			args: args{request: domainSend.BatchRequest{
+				Phones:  []string{"1728937129312@s.whatsapp.net", "1728937129313@s.whatsapp.net"},
+				Message: "Hello this is testing",
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with empty phones",
+			args: args{request: domainSend.BatchRequest{
+				Message: "Hello this is testing",
+			}},
+			err: pkgError.ValidationError("phones: cannot be blank."),
+		},
+		{
+			name: "should error with blank phone",
+			args: args{request: domainSend.BatchRequest{
+				Phones:  []string{"1728937129312@s.whatsapp.net", ""},
+				Message: "Hello this is testing",
+			}},
+			err: pkgError.ValidationError("phones: (1: cannot be blank.)."),
+		},
+		{
+			name: "should error with empty message",
+			args: args{request: domainSend.BatchRequest{
+				Phones: []string{"1728937129312@s.whatsapp.net"},
+			}},
+			err: pkgError.ValidationError("message: cannot be blank."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendBatch(context.Background(), tt.args.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
 func TestValidateSendImage(t *testing.T) {
 	image := &multipart.FileHeader{
 		Filename: "sample-image.png",
@@ -155,6 +328,52 @@ func TestValidateSendFile(t *testing.T) {
 			}},
 			err: pkgError.ValidationError("file: cannot be blank."),
 		},
+		{
+			name: "should error with view once document",
+			args: args{request: domainSend.FileRequest{
+				Phone:    "1728937129312@s.whatsapp.net",
+				File:     file,
+				ViewOnce: true,
+			}},
+			err: pkgError.ValidationError("view_once: is only supported for image, video and audio."),
+		},
+		{
+			name: "should success with custom filename and mimetype",
+			args: args{request: domainSend.FileRequest{
+				Phone:    "1728937129312@s.whatsapp.net",
+				File:     file,
+				FileName: "Invoice 2025-01 (final).pdf",
+				MimeType: "application/pdf",
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with path in filename",
+			args: args{request: domainSend.FileRequest{
+				Phone:    "1728937129312@s.whatsapp.net",
+				File:     file,
+				FileName: "../invoice.pdf",
+			}},
+			err: pkgError.ValidationError("filename: must not contain path separators."),
+		},
+		{
+			name: "should error with windows path in filename",
+			args: args{request: domainSend.FileRequest{
+				Phone:    "1728937129312@s.whatsapp.net",
+				File:     file,
+				FileName: `C:\invoice.pdf`,
+			}},
+			err: pkgError.ValidationError("filename: must not contain path separators."),
+		},
+		{
+			name: "should error with invalid mimetype",
+			args: args{request: domainSend.FileRequest{
+				Phone:    "1728937129312@s.whatsapp.net",
+				File:     file,
+				MimeType: "pdf",
+			}},
+			err: pkgError.ValidationError("mimetype: must be a mime type like application/pdf."),
+		},
 	}
 
 	for _, tt := range tests {
@@ -447,6 +666,53 @@ func TestValidateSendContact(t *testing.T) {
 			}},
 			err: pkgError.ValidationError("contact_phone: cannot be blank."),
 		},
+		{
+			name: "should error with invalid contact phone",
+			args: args{request: domainSend.ContactRequest{
+				Phone:        "1728937129312@s.whatsapp.net",
+				ContactName:  "Aldino",
+				ContactPhone: "call me",
+			}},
+			err: pkgError.ValidationError("contact_phone: must be in a valid format."),
+		},
+		{
+			name: "should success with raw vcard",
+			args: args{request: domainSend.ContactRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				Vcard: "BEGIN:VCARD\nVERSION:3.0\nFN:Aldino\nTEL:+62788712738123\nEND:VCARD",
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with malformed vcard",
+			args: args{request: domainSend.ContactRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				Vcard: "FN:Aldino",
+			}},
+			err: pkgError.ValidationError("vcard: must start with BEGIN:VCARD and end with END:VCARD."),
+		},
+		{
+			name: "should success with multiple contacts",
+			args: args{request: domainSend.ContactRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				Contacts: []domainSend.ContactCard{
+					{Name: "Aldino", Phone: "+62 788-7127-38123"},
+					{Vcard: "BEGIN:VCARD\nVERSION:3.0\nFN:Kemal\nEND:VCARD"},
+				},
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with invalid contact of multiple contacts",
+			args: args{request: domainSend.ContactRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				Contacts: []domainSend.ContactCard{
+					{Name: "Aldino", Phone: "62788712738123"},
+					{Name: "Kemal"},
+				},
+			}},
+			err: pkgError.ValidationError("contacts.1: phone: cannot be blank."),
+		},
 	}
 
 	for _, tt := range tests {
@@ -530,6 +796,61 @@ func TestValidateSendLocation(t *testing.T) {
 	}
 }
 
+func TestValidateSendLiveLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		request domainSend.LiveLocationRequest
+		err     any
+	}{
+		{
+			name: "should success normal condition",
+			request: domainSend.LiveLocationRequest{
+				Phone:     "1728937129312@s.whatsapp.net",
+				Latitude:  "-7.797068",
+				Longitude: "110.370529",
+				Duration:  900,
+			},
+			err: nil,
+		},
+		{
+			name: "should error with out of range latitude",
+			request: domainSend.LiveLocationRequest{
+				Phone:     "1728937129312@s.whatsapp.net",
+				Latitude:  "97.797068",
+				Longitude: "110.370529",
+				Duration:  900,
+			},
+			err: pkgError.ValidationError("latitude: must be a valid latitude."),
+		},
+		{
+			name: "should error with empty duration",
+			request: domainSend.LiveLocationRequest{
+				Phone:     "1728937129312@s.whatsapp.net",
+				Latitude:  "-7.797068",
+				Longitude: "110.370529",
+			},
+			err: pkgError.ValidationError("duration: cannot be blank."),
+		},
+		{
+			name: "should error with too long duration",
+			request: domainSend.LiveLocationRequest{
+				Phone:     "1728937129312@s.whatsapp.net",
+				Latitude:  "-7.797068",
+				Longitude: "110.370529",
+				Duration:  86400,
+			},
+			err: pkgError.ValidationError("duration: must be no greater than 900."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendLiveLocation(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
 func TestValidateSendAudio(t *testing.T) {
 	audio := &multipart.FileHeader{
 		Filename: "sample-audio.mp3",
@@ -581,6 +902,31 @@ func TestValidateSendAudio(t *testing.T) {
 			}},
 			err: pkgError.ValidationError("your audio type is not allowed. please use (audio/aac,audio/amr,audio/flac,audio/m4a,audio/m4r,audio/mp3,audio/mpeg,audio/ogg,audio/vnd.wav,audio/vnd.wave,audio/wav,audio/wave,audio/wma,audio/x-ms-wma,audio/x-pn-wav,audio/x-wav,)"),
 		},
+		{
+			name: "should success with webm voice note",
+			args: args{request: domainSend.AudioRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				PTT:   true,
+				Audio: &multipart.FileHeader{
+					Filename: "recording.webm",
+					Size:     100,
+					Header:   map[string][]string{"Content-Type": {"audio/webm"}},
+				},
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with webm audio that is not a voice note",
+			args: args{request: domainSend.AudioRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				Audio: &multipart.FileHeader{
+					Filename: "recording.webm",
+					Size:     100,
+					Header:   map[string][]string{"Content-Type": {"audio/webm"}},
+				},
+			}},
+			err: pkgError.ValidationError("your audio type is not allowed. please use (audio/aac,audio/amr,audio/flac,audio/m4a,audio/m4r,audio/mp3,audio/mpeg,audio/ogg,audio/vnd.wav,audio/vnd.wave,audio/wav,audio/wave,audio/wma,audio/x-ms-wma,audio/x-pn-wav,audio/x-wav,)"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -660,6 +1006,57 @@ func TestValidateSendPoll(t *testing.T) {
 			}},
 			err: pkgError.ValidationError("max_answer: must be no greater than 3."),
 		},
+		{
+			name: "should success with multiple answers without max answer",
+			args: args{request: domainSend.PollRequest{
+				Phone:           "1728937129312@s.whatsapp.net",
+				Question:        "What is your favorite color?",
+				Options:         []string{"Red", "Blue", "Green"},
+				MultipleAnswers: true,
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with multiple answers limited to one answer",
+			args: args{request: domainSend.PollRequest{
+				Phone:           "1728937129312@s.whatsapp.net",
+				Question:        "What is your favorite color?",
+				Options:         []string{"Red", "Blue", "Green"},
+				MaxAnswer:       1,
+				MultipleAnswers: true,
+			}},
+			err: pkgError.ValidationError("max_answer: must be unset or at least 2 with multiple_answers."),
+		},
+		{
+			name: "should success with multiple answers limited to two answers",
+			args: args{request: domainSend.PollRequest{
+				Phone:           "1728937129312@s.whatsapp.net",
+				Question:        "What is your favorite color?",
+				Options:         []string{"Red", "Blue", "Green"},
+				MaxAnswer:       2,
+				MultipleAnswers: true,
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with single answer without max answer",
+			args: args{request: domainSend.PollRequest{
+				Phone:    "1728937129312@s.whatsapp.net",
+				Question: "What is your favorite color?",
+				Options:  []string{"Red", "Blue", "Green"},
+			}},
+			err: pkgError.ValidationError("max_answer: cannot be blank."),
+		},
+		{
+			name: "should error with more than 12 options",
+			args: args{request: domainSend.PollRequest{
+				Phone:     "1728937129312@s.whatsapp.net",
+				Question:  "Pick a number",
+				Options:   []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13"},
+				MaxAnswer: 1,
+			}},
+			err: pkgError.ValidationError("options: the length must be between 1 and 12."),
+		},
 	}
 
 	for _, tt := range tests {
@@ -709,3 +1106,329 @@ func TestValidateSendPresence(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSendChatPresence(t *testing.T) {
+	type args struct {
+		request domainSend.ChatPresenceRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success with composing for a duration",
+			args: args{request: domainSend.ChatPresenceRequest{
+				Phone:    "1728937129312@s.whatsapp.net",
+				State:    "composing",
+				Duration: 5,
+			}},
+			err: nil,
+		},
+		{
+			name: "should success with paused",
+			args: args{request: domainSend.ChatPresenceRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				State: "paused",
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with invalid state",
+			args: args{request: domainSend.ChatPresenceRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				State: "typing",
+			}},
+			err: pkgError.ValidationError("state: must be a valid value."),
+		},
+		{
+			name: "should error with too long duration",
+			args: args{request: domainSend.ChatPresenceRequest{
+				Phone:    "1728937129312@s.whatsapp.net",
+				State:    "recording",
+				Duration: 120,
+			}},
+			err: pkgError.ValidationError("duration: must be no greater than 60."),
+		},
+		{
+			name: "should error with empty phone",
+			args: args{request: domainSend.ChatPresenceRequest{
+				State: "composing",
+			}},
+			err: pkgError.ValidationError("phone: cannot be blank."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendChatPresence(context.Background(), tt.args.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateSendButtons(t *testing.T) {
+	type args struct {
+		request domainSend.ButtonsRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success with buttons",
+			args: args{request: domainSend.ButtonsRequest{
+				Phone:   "1728937129312@s.whatsapp.net",
+				Body:    "Do you want to continue?",
+				Footer:  "Powered by bot",
+				Buttons: []domainSend.Button{{ID: "yes", Text: "Yes"}, {ID: "no", Text: "No"}},
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with empty buttons",
+			args: args{request: domainSend.ButtonsRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				Body:  "Do you want to continue?",
+			}},
+			err: pkgError.ValidationError("buttons: cannot be blank."),
+		},
+		{
+			name: "should error with more than 3 buttons",
+			args: args{request: domainSend.ButtonsRequest{
+				Phone: "1728937129312@s.whatsapp.net",
+				Body:  "Pick one",
+				Buttons: []domainSend.Button{
+					{ID: "1", Text: "One"}, {ID: "2", Text: "Two"}, {ID: "3", Text: "Three"}, {ID: "4", Text: "Four"},
+				},
+			}},
+			err: pkgError.ValidationError("buttons: the length must be between 1 and 3."),
+		},
+		{
+			name: "should error with button without text",
+			args: args{request: domainSend.ButtonsRequest{
+				Phone:   "1728937129312@s.whatsapp.net",
+				Body:    "Do you want to continue?",
+				Buttons: []domainSend.Button{{ID: "yes", Text: "Yes"}, {ID: "no"}},
+			}},
+			err: pkgError.ValidationError("buttons[1]: text: cannot be blank."),
+		},
+		{
+			name: "should error with duplicate button ids",
+			args: args{request: domainSend.ButtonsRequest{
+				Phone:   "1728937129312@s.whatsapp.net",
+				Body:    "Do you want to continue?",
+				Buttons: []domainSend.Button{{ID: "yes", Text: "Yes"}, {ID: "yes", Text: "Sure"}},
+			}},
+			err: pkgError.ValidationError("button ids should be unique"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendButtons(context.Background(), tt.args.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateSendList(t *testing.T) {
+	rows := func(n int) []domainSend.ListRow {
+		result := make([]domainSend.ListRow, n)
+		for i := range result {
+			result[i] = domainSend.ListRow{ID: fmt.Sprintf("row-%d", i), Title: fmt.Sprintf("Row %d", i)}
+		}
+		return result
+	}
+
+	type args struct {
+		request domainSend.ListRequest
+	}
+	tests := []struct {
+		name string
+		args args
+		err  any
+	}{
+		{
+			name: "should success with one section",
+			args: args{request: domainSend.ListRequest{
+				Phone:      "1728937129312@s.whatsapp.net",
+				Title:      "Menu",
+				ButtonText: "Open menu",
+				Sections:   []domainSend.ListSection{{Rows: rows(3)}},
+			}},
+			err: nil,
+		},
+		{
+			name: "should error with empty sections",
+			args: args{request: domainSend.ListRequest{
+				Phone:      "1728937129312@s.whatsapp.net",
+				Title:      "Menu",
+				ButtonText: "Open menu",
+			}},
+			err: pkgError.ValidationError("sections: cannot be blank."),
+		},
+		{
+			name: "should error with untitled section when there are many",
+			args: args{request: domainSend.ListRequest{
+				Phone:      "1728937129312@s.whatsapp.net",
+				Title:      "Menu",
+				ButtonText: "Open menu",
+				Sections: []domainSend.ListSection{
+					{Title: "Food", Rows: []domainSend.ListRow{{ID: "rice", Title: "Rice"}}},
+					{Rows: []domainSend.ListRow{{ID: "tea", Title: "Tea"}}},
+				},
+			}},
+			err: pkgError.ValidationError("sections[1]: title: cannot be blank."),
+		},
+		{
+			name: "should error with row without id",
+			args: args{request: domainSend.ListRequest{
+				Phone:      "1728937129312@s.whatsapp.net",
+				Title:      "Menu",
+				ButtonText: "Open menu",
+				Sections:   []domainSend.ListSection{{Rows: []domainSend.ListRow{{Title: "Rice"}}}},
+			}},
+			err: pkgError.ValidationError("sections[0].rows[0]: id: cannot be blank."),
+		},
+		{
+			name: "should error with too many rows over all sections",
+			args: args{request: domainSend.ListRequest{
+				Phone:      "1728937129312@s.whatsapp.net",
+				Title:      "Menu",
+				ButtonText: "Open menu",
+				Sections: []domainSend.ListSection{
+					{Title: "First", Rows: rows(6)},
+					{Title: "Second", Rows: []domainSend.ListRow{
+						{ID: "a", Title: "A"}, {ID: "b", Title: "B"}, {ID: "c", Title: "C"}, {ID: "d", Title: "D"}, {ID: "e", Title: "E"},
+					}},
+				},
+			}},
+			err: pkgError.ValidationError("a list can have at most 10 rows over all sections"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendList(context.Background(), tt.args.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateScheduleMessage(t *testing.T) {
+	message := domainSend.MessageRequest{Phone: "1728937129312@s.whatsapp.net", Message: "Hello this is testing"}
+	tests := []struct {
+		name    string
+		request domainSend.ScheduleRequest
+		err     any
+	}{
+		{
+			name:    "should success with send_at in the future",
+			request: domainSend.ScheduleRequest{MessageRequest: message, SendAt: time.Now().Add(time.Hour).Format(time.RFC3339)},
+			err:     nil,
+		},
+		{
+			name:    "should error without send_at",
+			request: domainSend.ScheduleRequest{MessageRequest: message},
+			err:     pkgError.ValidationError("send_at: cannot be blank."),
+		},
+		{
+			name:    "should error with send_at in the past",
+			request: domainSend.ScheduleRequest{MessageRequest: message, SendAt: time.Now().Add(-time.Minute).Format(time.RFC3339)},
+			err:     pkgError.ValidationError("send_at: must be in the future."),
+		},
+		{
+			name:    "should error with send_at not RFC3339",
+			request: domainSend.ScheduleRequest{MessageRequest: message, SendAt: "2030-01-01 10:00"},
+			err:     pkgError.ValidationError("send_at: must be an RFC3339 timestamp."),
+		},
+		{
+			name:    "should error with empty message",
+			request: domainSend.ScheduleRequest{MessageRequest: domainSend.MessageRequest{Phone: message.Phone}, SendAt: "2030-01-01T10:00:00Z"},
+			err:     pkgError.ValidationError("message: cannot be blank."),
+		},
+		{
+			name:    "should success with the text type",
+			request: domainSend.ScheduleRequest{MessageRequest: message, SendAt: "2030-01-01T10:00:00Z", Type: domainSend.ScheduleTypeText},
+			err:     nil,
+		},
+		{
+			name:    "should error with another type",
+			request: domainSend.ScheduleRequest{MessageRequest: domainSend.MessageRequest{Phone: message.Phone}, SendAt: "2030-01-01T10:00:00Z", Type: "image"},
+			err:     pkgError.ValidationError("type: only text messages can be scheduled."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateScheduleMessage(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateSendTemplateButtons(t *testing.T) {
+	quickReply := domainSend.TemplateButton{Type: domainSend.TemplateButtonQuickReply, Text: "Yes", ID: "yes"}
+	url := domainSend.TemplateButton{Type: domainSend.TemplateButtonURL, Text: "Visit Website", URL: "https://example.com"}
+	call := domainSend.TemplateButton{Type: domainSend.TemplateButtonCall, Text: "Call", PhoneNumber: "+6281234567890"}
+
+	tests := []struct {
+		name    string
+		buttons []domainSend.TemplateButton
+		err     any
+	}{
+		{
+			name:    "should success mixing quick reply and call to action buttons",
+			buttons: []domainSend.TemplateButton{quickReply, url, call},
+			err:     nil,
+		},
+		{
+			name:    "should error without buttons",
+			buttons: nil,
+			err:     pkgError.ValidationError("buttons: cannot be blank."),
+		},
+		{
+			name:    "should error with more than 3 buttons",
+			buttons: []domainSend.TemplateButton{quickReply, url, call, {Type: domainSend.TemplateButtonQuickReply, Text: "No", ID: "no"}},
+			err:     pkgError.ValidationError("buttons: the length must be between 1 and 3."),
+		},
+		{
+			name:    "should error with unknown type",
+			buttons: []domainSend.TemplateButton{{Type: "copy", Text: "Copy"}},
+			err:     pkgError.ValidationError("buttons[0]: type: must be a valid value."),
+		},
+		{
+			name:    "should error with url button without url",
+			buttons: []domainSend.TemplateButton{{Type: domainSend.TemplateButtonURL, Text: "Visit"}},
+			err:     pkgError.ValidationError("buttons[0]: url: cannot be blank."),
+		},
+		{
+			name:    "should error with invalid call number",
+			buttons: []domainSend.TemplateButton{{Type: domainSend.TemplateButtonCall, Text: "Call", PhoneNumber: "call me"}},
+			err:     pkgError.ValidationError("buttons[0]: phone_number: must be an international phone number like +6281234567890."),
+		},
+		{
+			name:    "should error with two call buttons",
+			buttons: []domainSend.TemplateButton{call, call},
+			err:     pkgError.ValidationError("at most 1 call button is allowed"),
+		},
+		{
+			name:    "should error with duplicate quick reply ids",
+			buttons: []domainSend.TemplateButton{quickReply, quickReply},
+			err:     pkgError.ValidationError("button ids should be unique"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSendTemplateButtons(context.Background(), domainSend.TemplateButtonsRequest{
+				Phone:   "1728937129312@s.whatsapp.net",
+				Body:    "Do you want to continue?",
+				Buttons: tt.buttons,
+			})
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package validations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/config"
+	domainStatus "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/status"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	"github.com/dustin/go-humanize"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+func ValidatePostStatus(ctx context.Context, request domainStatus.PostStatusRequest) error {
+	statusTypes := make([]any, 0, len(domainStatus.StatusTypes))
+	for _, statusType := range domainStatus.StatusTypes {
+		statusTypes = append(statusTypes, statusType)
+	}
+	isText := request.Type == domainStatus.StatusTypeText
+	isMedia := request.Type == domainStatus.StatusTypeImage || request.Type == domainStatus.StatusTypeVideo
+
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Type, validation.Required, validation.In(statusTypes...)),
+		validation.Field(&request.Text, validation.When(isText, validation.Required)),
+		validation.Field(&request.BackgroundColor,
+			validation.When(isMedia, validation.Empty.Error("is only supported for text statuses")),
+			validation.By(validateStatusColor),
+		),
+		validation.Field(&request.Media,
+			validation.When(isText, validation.Empty.Error("is not supported for text statuses")),
+			validation.When(isMedia, validation.Required),
+		),
+		validation.Field(&request.Recipients, validation.Each(validation.Required)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	switch request.Type {
+	case domainStatus.StatusTypeImage:
+		if mimeType := request.Media.Header.Get("Content-Type"); mimeType != "image/jpeg" && mimeType != "image/jpg" && mimeType != "image/png" {
+			return pkgError.ValidationError("your image is not allowed. please use jpg/jpeg/png")
+		}
+		if request.Media.Size > config.WhatsappSettingMaxImageSize {
+			return pkgError.ValidationError(fmt.Sprintf("max image upload is %s", humanize.Bytes(uint64(config.WhatsappSettingMaxImageSize))))
+		}
+	case domainStatus.StatusTypeVideo:
+		if mimeType := request.Media.Header.Get("Content-Type"); mimeType != "video/mp4" {
+			return pkgError.ValidationError("your video type is not allowed. please use mp4")
+		}
+		if request.Media.Size > config.WhatsappSettingMaxVideoSize {
+			return pkgError.ValidationError(fmt.Sprintf("max video upload is %s", humanize.Bytes(uint64(config.WhatsappSettingMaxVideoSize))))
+		}
+	}
+
+	return nil
+}
+
+func validateStatusColor(value any) error {
+	color, _ := value.(string)
+	if color == "" {
+		return nil
+	}
+	if _, err := whatsapp.StatusColorARGB(color); err != nil {
+		return validation.NewError("validation_status_color", "must be in the form of #RRGGBB or #AARRGGBB")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package validations
+
+import (
+	"context"
+	"mime/multipart"
+	"testing"
+
+	domainStatus "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/status"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePostStatus(t *testing.T) {
+	newMedia := func(contentType string) *multipart.FileHeader {
+		return &multipart.FileHeader{
+			Filename: "status",
+			Size:     100,
+			Header:   map[string][]string{"Content-Type": {contentType}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		request domainStatus.PostStatusRequest
+		err     any
+	}{
+		{
+			name:    "should success with text",
+			request: domainStatus.PostStatusRequest{Type: "text", Text: "hello", BackgroundColor: "#25D366"},
+			err:     nil,
+		},
+		{
+			name:    "should success with image and recipients",
+			request: domainStatus.PostStatusRequest{Type: "image", Media: newMedia("image/png"), Recipients: []string{"6289685028129@s.whatsapp.net"}},
+			err:     nil,
+		},
+		{
+			name:    "should success with video",
+			request: domainStatus.PostStatusRequest{Type: "video", Text: "caption", Media: newMedia("video/mp4")},
+			err:     nil,
+		},
+		{
+			name:    "should error with unknown type",
+			request: domainStatus.PostStatusRequest{Type: "audio"},
+			err:     pkgError.ValidationError("type: must be a valid value."),
+		},
+		{
+			name:    "should error with text status without text",
+			request: domainStatus.PostStatusRequest{Type: "text"},
+			err:     pkgError.ValidationError("text: cannot be blank."),
+		},
+		{
+			name:    "should error with invalid background color",
+			request: domainStatus.PostStatusRequest{Type: "text", Text: "hello", BackgroundColor: "green"},
+			err:     pkgError.ValidationError("background_color: must be in the form of #RRGGBB or #AARRGGBB."),
+		},
+		{
+			name:    "should error with background color on image",
+			request: domainStatus.PostStatusRequest{Type: "image", BackgroundColor: "#25D366", Media: newMedia("image/png")},
+			err:     pkgError.ValidationError("background_color: is only supported for text statuses."),
+		},
+		{
+			name:    "should error with image status without media",
+			request: domainStatus.PostStatusRequest{Type: "image"},
+			err:     pkgError.ValidationError("media: cannot be blank."),
+		},
+		{
+			name:    "should error with video as image",
+			request: domainStatus.PostStatusRequest{Type: "image", Media: newMedia("video/mp4")},
+			err:     pkgError.ValidationError("your image is not allowed. please use jpg/jpeg/png"),
+		},
+		{
+			name:    "should error with empty recipient",
+			request: domainStatus.PostStatusRequest{Type: "text", Text: "hello", Recipients: []string{""}},
+			err:     pkgError.ValidationError("recipients: (0: cannot be blank.)."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePostStatus(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
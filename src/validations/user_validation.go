@@ -18,6 +18,18 @@ func ValidateUserInfo(ctx context.Context, request domainUser.InfoRequest) error
 
 	return nil
 }
+func ValidateContactInfo(ctx context.Context, request domainUser.ContactInfoRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
 func ValidateUserAvatar(ctx context.Context, request domainUser.AvatarRequest) error {
 	err := validation.ValidateStructWithContext(ctx, &request,
 		validation.Field(&request.Phone, validation.Required),
@@ -31,3 +43,89 @@ func ValidateUserAvatar(ctx context.Context, request domainUser.AvatarRequest) e
 
 	return nil
 }
+
+func ValidateChangeAvatar(ctx context.Context, request domainUser.ChangeAvatarRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Avatar, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidatePresenceSubscription(ctx context.Context, request domainUser.PresenceSubscriptionRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateChangePushName(ctx context.Context, request domainUser.ChangePushNameRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.PushName, validation.Required, validation.RuneLength(1, domainUser.PushNameMaxLength)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateChangeStatus(ctx context.Context, request domainUser.ChangeStatusRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Status, validation.Required, validation.RuneLength(1, domainUser.StatusMaxLength)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateBusinessCatalog(ctx context.Context, request domainUser.BusinessCatalogRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.Limit, validation.Min(0), validation.Max(domainUser.BusinessCatalogMaxLimit)),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateBusinessProduct(ctx context.Context, request domainUser.BusinessProductRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.Phone, validation.Required),
+		validation.Field(&request.ProductID, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateJIDInfo(ctx context.Context, request domainUser.JIDInfoRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.JID, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
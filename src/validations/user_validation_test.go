@@ -5,6 +5,8 @@ import (
 	domainUser "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/user"
 	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
 	"github.com/stretchr/testify/assert"
+	"mime/multipart"
+	"strings"
 	"testing"
 )
 
@@ -45,6 +47,32 @@ func TestValidateUserAvatar(t *testing.T) {
 	}
 }
 
+func TestValidateContactInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		request domainUser.ContactInfoRequest
+		err     any
+	}{
+		{
+			name:    "should success",
+			request: domainUser.ContactInfoRequest{Phone: "1728937129312@s.whatsapp.net"},
+			err:     nil,
+		},
+		{
+			name:    "should error with empty phone",
+			request: domainUser.ContactInfoRequest{Phone: ""},
+			err:     pkgError.ValidationError("phone: cannot be blank."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateContactInfo(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
 func TestValidateUserInfo(t *testing.T) {
 	type args struct {
 		request domainUser.InfoRequest
@@ -77,3 +105,202 @@ func TestValidateUserInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateChangePushName(t *testing.T) {
+	tests := []struct {
+		name    string
+		request domainUser.ChangePushNameRequest
+		err     any
+	}{
+		{
+			name:    "should success",
+			request: domainUser.ChangePushNameRequest{PushName: "Acme Support"},
+			err:     nil,
+		},
+		{
+			name:    "should success with 25 multibyte characters",
+			request: domainUser.ChangePushNameRequest{PushName: "ÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄÄ"},
+			err:     nil,
+		},
+		{
+			name:    "should error with empty push name",
+			request: domainUser.ChangePushNameRequest{PushName: ""},
+			err:     pkgError.ValidationError("push_name: cannot be blank."),
+		},
+		{
+			name:    "should error with too long push name",
+			request: domainUser.ChangePushNameRequest{PushName: "Acme Customer Support Team"},
+			err:     pkgError.ValidationError("push_name: the length must be between 1 and 25."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateChangePushName(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateChangeStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		request domainUser.ChangeStatusRequest
+		err     any
+	}{
+		{
+			name:    "should success",
+			request: domainUser.ChangeStatusRequest{Status: "Available 9am to 5pm"},
+			err:     nil,
+		},
+		{
+			name:    "should error with empty status",
+			request: domainUser.ChangeStatusRequest{Status: ""},
+			err:     pkgError.ValidationError("status: cannot be blank."),
+		},
+		{
+			name:    "should error with too long status",
+			request: domainUser.ChangeStatusRequest{Status: strings.Repeat("a", 140)},
+			err:     pkgError.ValidationError("status: the length must be between 1 and 139."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateChangeStatus(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateChangeAvatar(t *testing.T) {
+	newAvatar := func(contentType string) *multipart.FileHeader {
+		return &multipart.FileHeader{
+			Filename: "avatar",
+			Size:     100,
+			Header:   map[string][]string{"Content-Type": {contentType}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		request domainUser.ChangeAvatarRequest
+		err     any
+	}{
+		{
+			name:    "should success with jpeg",
+			request: domainUser.ChangeAvatarRequest{Avatar: newAvatar("image/jpeg")},
+			err:     nil,
+		},
+		{
+			name:    "should success with png",
+			request: domainUser.ChangeAvatarRequest{Avatar: newAvatar("image/png")},
+			err:     nil,
+		},
+		{
+			name:    "should error without avatar",
+			request: domainUser.ChangeAvatarRequest{},
+			err:     pkgError.ValidationError("avatar: cannot be blank."),
+		},
+		{
+			name:    "should leave the format to the image decoder",
+			request: domainUser.ChangeAvatarRequest{Avatar: newAvatar("application/octet-stream")},
+			err:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateChangeAvatar(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateBusinessCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		request domainUser.BusinessCatalogRequest
+		err     any
+	}{
+		{
+			name:    "should success without limit",
+			request: domainUser.BusinessCatalogRequest{Phone: "6281234567890"},
+			err:     nil,
+		},
+		{
+			name:    "should success with limit and cursor",
+			request: domainUser.BusinessCatalogRequest{Phone: "6281234567890", Limit: 50, After: "cursor"},
+			err:     nil,
+		},
+		{
+			name:    "should error with empty phone",
+			request: domainUser.BusinessCatalogRequest{},
+			err:     pkgError.ValidationError("phone: cannot be blank."),
+		},
+		{
+			name:    "should error with limit over max",
+			request: domainUser.BusinessCatalogRequest{Phone: "6281234567890", Limit: 101},
+			err:     pkgError.ValidationError("limit: must be no greater than 100."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBusinessCatalog(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateBusinessProduct(t *testing.T) {
+	tests := []struct {
+		name    string
+		request domainUser.BusinessProductRequest
+		err     any
+	}{
+		{
+			name:    "should success",
+			request: domainUser.BusinessProductRequest{Phone: "6281234567890", ProductID: "7123456789"},
+			err:     nil,
+		},
+		{
+			name:    "should error without product id",
+			request: domainUser.BusinessProductRequest{Phone: "6281234567890"},
+			err:     pkgError.ValidationError("product_id: cannot be blank."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBusinessProduct(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestValidateJIDInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		request domainUser.JIDInfoRequest
+		err     any
+	}{
+		{
+			name:    "should success",
+			request: domainUser.JIDInfoRequest{JID: "120363025246125486@g.us"},
+			err:     nil,
+		},
+		{
+			name:    "should error with empty jid",
+			request: domainUser.JIDInfoRequest{},
+			err:     pkgError.ValidationError("jid: cannot be blank."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJIDInfo(context.Background(), tt.request)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
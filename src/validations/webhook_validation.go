@@ -0,0 +1,53 @@
+package validations
+
+import (
+	"context"
+
+	domainWebhook "github.com/aldinokemal/go-whatsapp-web-multidevice/domains/webhook"
+	pkgError "github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/error"
+	"github.com/aldinokemal/go-whatsapp-web-multidevice/pkg/whatsapp"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+func ValidateAddWebhook(ctx context.Context, request domainWebhook.AddWebhookRequest) error {
+	eventTypes := make([]any, 0, len(whatsapp.WebhookEventTypes))
+	for _, eventType := range whatsapp.WebhookEventTypes {
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.URL, validation.Required, is.URL),
+		validation.Field(&request.Events, validation.Each(validation.In(eventTypes...))),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateDeleteWebhook(ctx context.Context, request domainWebhook.DeleteWebhookRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.URL, validation.Required),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}
+
+func ValidateTestWebhook(ctx context.Context, request domainWebhook.TestWebhookRequest) error {
+	err := validation.ValidateStructWithContext(ctx, &request,
+		validation.Field(&request.URL, validation.Required, is.URL),
+	)
+
+	if err != nil {
+		return pkgError.ValidationError(err.Error())
+	}
+
+	return nil
+}